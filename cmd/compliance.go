@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	complianceProfiles   []string
+	complianceFormat     string
+	complianceOutputFile string
+	complianceMinScore   float64
+)
+
+// formatComplianceReport writes the score, the requirement results and the failures of a compliance check to the provided writer
+func formatComplianceReport(w io.Writer, inputFile string, report *sbom.ComplianceReport) {
+	fmt.Fprintf(w, "%s: %s: score %.1f\n", inputFile, report.Title, report.Score)
+	for _, result := range report.Requirements {
+		status := "ok"
+		if result.Failed > 0 {
+			status = fmt.Sprintf("%d of %d failed", result.Failed, result.Passed+result.Failed)
+		}
+		fmt.Fprintf(w, "  %-18s %-40s %s\n", result.ID, result.Field, status)
+	}
+	if len(report.Failures) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "Failing fields:")
+	for i := 0; i < len(report.Failures); {
+		// Failures of the same component are listed together
+		component := report.Failures[i].Component
+		var fields []string
+		for ; i < len(report.Failures) && report.Failures[i].Component == component; i++ {
+			fields = append(fields, report.Failures[i].Field)
+		}
+		if component == "" {
+			component = "SBOM"
+		}
+		fmt.Fprintf(w, "  - %s: %s\n", component, strings.Join(fields, ", "))
+	}
+}
+
+// sarifLog is the subset of the SARIF 2.1.0 format written by the compliance command
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// complianceSARIF converts compliance reports to a SARIF log with one run per profile
+func complianceSARIF(inputFile string, reports []*sbom.ComplianceReport) sarifLog {
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    make([]sarifRun, 0, len(reports)),
+	}
+	tool := sbom.SbomctlTool()
+	for _, report := range reports {
+		run := sarifRun{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           tool.Name,
+				Version:        tool.Version,
+				InformationURI: "https://github.com/j12934/sbomctl",
+				Rules:          make([]sarifRule, 0, len(report.Requirements)),
+			}},
+			Results: make([]sarifResult, 0, len(report.Failures)),
+		}
+		descriptions := make(map[string]string)
+		for _, result := range report.Requirements {
+			descriptions[result.ID] = result.Description
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               result.ID,
+				Name:             result.Field,
+				ShortDescription: sarifMessage{Text: result.Description},
+			})
+		}
+		for _, failure := range report.Failures {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: inputFile}}}
+			message := fmt.Sprintf("The SBOM fails %s: %s", failure.Requirement, descriptions[failure.Requirement])
+			if failure.Component != "" {
+				location.LogicalLocations = []sarifLogicalLocation{{Name: failure.Component, Kind: "object"}}
+				message = fmt.Sprintf("Component %s fails %s: %s", failure.Component, failure.Requirement, descriptions[failure.Requirement])
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    failure.Requirement,
+				Level:     "error",
+				Message:   sarifMessage{Text: message},
+				Locations: []sarifLocation{location},
+			})
+		}
+		log.Runs = append(log.Runs, run)
+	}
+	return log
+}
+
+// complianceCmd represents the compliance command
+var complianceCmd = &cobra.Command{
+	Use:   "compliance [sbom file]",
+	Short: "Score an SBOM file against the NTIA minimum elements and BSI TR-03183-2",
+	Long: `Check an SBOM file against the requirements of compliance profiles and score it
+by the percentage of passed checks. Requirements on components are checked
+for the metadata component and every component, including nested ones.
+
+The --profile option selects the profiles:
+  ntia  NTIA minimum elements: author and timestamp of the SBOM, the direct
+        dependencies of the metadata component, and supplier, name, version
+        and a unique identifier (package URL, CPE or SWID) of every component
+  bsi   BSI TR-03183-2: CycloneDX 1.5 or later, creator of the SBOM with email
+        or URL, timestamp, and of every component the creator with email or
+        URL, name, version, filename, dependencies, licenses, SHA-512 hash
+        and the bsi:component:filename, executable, archive and structured
+        properties
+
+The report lists every failing component with its failing fields, as text
+(default), as JSON, or as SARIF for code scanning tools (--format sarif), to
+stdout or with -o to a file. With --min-score the command fails if any
+profile scores lower, e.g. as a gate in CI pipelines.
+
+Example:
+  sbomctl compliance sbom.json
+  sbomctl compliance sbom.json --profile ntia,bsi --min-score 80
+  sbomctl compliance sbom.json --profile bsi --format sarif -o compliance.sarif`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		if complianceFormat != "text" && complianceFormat != "json" && complianceFormat != "sarif" {
+			return fmt.Errorf("unsupported format %q, must be one of: text, json, sarif", complianceFormat)
+		}
+		names := complianceProfiles
+		if len(names) == 0 {
+			names = []string{"ntia"}
+		}
+		profiles := make([]sbom.ComplianceProfile, 0, len(names))
+		for _, name := range names {
+			profile, err := sbom.ComplianceProfileByName(name)
+			if err != nil {
+				return err
+			}
+			profiles = append(profiles, profile)
+		}
+
+		bom, err := sbom.ReadSBOMFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		// A low score is not a usage error
+		cmd.SilenceUsage = true
+
+		reports := make([]*sbom.ComplianceReport, 0, len(profiles))
+		for _, profile := range profiles {
+			reports = append(reports, sbom.CheckCompliance(bom, profile))
+		}
+
+		var out bytes.Buffer
+		switch complianceFormat {
+		case "json":
+			if err := writeJSON(&out, reports); err != nil {
+				return err
+			}
+		case "sarif":
+			if err := writeJSON(&out, complianceSARIF(inputFile, reports)); err != nil {
+				return err
+			}
+		default:
+			for i, report := range reports {
+				if i > 0 {
+					fmt.Fprintln(&out)
+				}
+				formatComplianceReport(&out, inputFile, report)
+			}
+		}
+		if complianceOutputFile == "" {
+			if _, err := cmd.OutOrStdout().Write(out.Bytes()); err != nil {
+				return err
+			}
+		} else if err := os.WriteFile(complianceOutputFile, out.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+
+		for _, report := range reports {
+			if report.Score < complianceMinScore {
+				return fmt.Errorf("%s score %.1f is below the minimum score %.1f", report.Profile, report.Score, complianceMinScore)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(complianceCmd)
+
+	complianceCmd.Flags().StringSliceVar(&complianceProfiles, "profile", []string{"ntia"}, "Compliance profiles to check (ntia, bsi)")
+	complianceCmd.Flags().StringVar(&complianceFormat, "format", "text", "Output format (text, json, sarif)")
+	complianceCmd.Flags().StringVarP(&complianceOutputFile, "output", "o", "", "Output file (default stdout)")
+	complianceCmd.Flags().Float64Var(&complianceMinScore, "min-score", 0, "Fail if a profile scores lower than this percentage")
+}
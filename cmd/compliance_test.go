@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestComplianceCommand(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+
+	output, err := executeCommand("compliance", inputFile)
+	if err != nil {
+		t.Fatalf("compliance command failed: %v", err)
+	}
+	if !strings.Contains(output, "score 86.7") || !strings.Contains(output, "  - pkg:npm/example-lib-3@3.4.5: supplier\n") {
+		t.Errorf("Expected the NTIA score and failing supplier, got:\n%s", output)
+	}
+
+	output, err = executeCommand("compliance", inputFile, "--profile", "ntia,bsi", "--format", "json")
+	if err != nil {
+		t.Fatalf("compliance command failed: %v", err)
+	}
+	var reports []sbom.ComplianceReport
+	if err := json.Unmarshal([]byte(output), &reports); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v\nOutput: %s", err, output)
+	}
+	if len(reports) != 2 || reports[1].Profile != "bsi" || reports[1].Score != 45.5 {
+		t.Errorf("Unexpected reports: %+v", reports)
+	}
+
+	if _, err := executeCommand("compliance", inputFile, "--profile", "bsi", "--min-score", "50"); err == nil || !strings.Contains(err.Error(), "below the minimum score") {
+		t.Errorf("Expected compliance command to fail below the minimum score, got %v", err)
+	}
+	if _, err := executeCommand("compliance", inputFile, "--profile", "iso"); err == nil {
+		t.Error("Expected compliance command to fail for an unknown profile")
+	}
+}
+
+func TestComplianceCommandSARIF(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+
+	outputFile := filepath.Join(t.TempDir(), "compliance.sarif")
+	if _, err := executeCommand("compliance", inputFile, "--format", "sarif", "-o", outputFile); err != nil {
+		t.Fatalf("compliance command failed: %v", err)
+	}
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(output, &log); err != nil {
+		t.Fatalf("Failed to decode SARIF output: %v\nOutput: %s", err, output)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) != 7 {
+		t.Fatalf("Unexpected SARIF log: %+v", log)
+	}
+	if driver := log.Runs[0].Tool.Driver; driver.Name != sbom.SbomctlTool().Name || driver.Version != sbom.SbomctlTool().Version {
+		t.Errorf("Expected the sbomctl tool as driver, got %s %s", driver.Name, driver.Version)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 || results[0].RuleID != "NTIA-SUPPLIER" || results[0].Locations[0].LogicalLocations[0].Name != "pkg:npm/example-lib-3@3.4.5" {
+		t.Errorf("Unexpected SARIF results: %+v", results)
+	}
+	if results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != inputFile {
+		t.Errorf("Expected the SBOM file as location, got %+v", results[0].Locations[0])
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertOutputFile   string
+	convertSpecVersion  string
+	convertOutputFormat string
+	convertStrict       bool
+)
+
+// formatConversionLosses writes the fields lost during a conversion to the provided writer
+func formatConversionLosses(w io.Writer, losses []sbom.ConversionLoss) {
+	for _, loss := range losses {
+		fmt.Fprintf(w, "Lost: %s (%d)\n", loss.Path, loss.Count)
+	}
+}
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert [sbom file]",
+	Short: "Convert an SBOM file to another spec version or format",
+	Long: `Convert a CycloneDX SBOM file to another spec version (1.0 to 1.6) and/or
+another format (json, xml, proto, spdx-json). By default the spec version and
+format of the input file are kept. --to is a shorthand for --output-format.
+
+SPDX 2.3 JSON documents are accepted as input as well. Their packages,
+relationships, license expressions and checksums are converted to CycloneDX
+components, dependencies, licenses and hashes. Fields without a counterpart
+in the target format, like SPDX files or CycloneDX services, are reported as
+lost.
+
+The proto format covers metadata, components, services, external references,
+dependencies, vulnerabilities and properties. Converting an SBOM with
+compositions, annotations, formulation, declarations or definitions, or with
+component pedigree, evidence or release notes, to proto fails instead of
+dropping them.
+
+Converting to an older spec version drops the fields that do not exist in
+that version, for example metadata.tools.components is turned back into the
+legacy tools list. Every lost field is reported together with how often it
+was lost. With --strict the conversion fails instead.
+
+The converted SBOM is written to stdout unless --output is given.
+
+Example:
+  sbomctl convert sbom.cdx.json --spec-version 1.4 -o sbom-1.4.cdx.json
+  sbomctl convert legacy.cdx.xml --spec-version 1.6 --output-format json
+  sbomctl convert sbom.cdx.json --spec-version 1.4 --strict
+  sbomctl convert sbom.cdx.json --to spdx-json -o sbom.spdx.json
+  sbomctl convert supplier.spdx.json --to json -o supplier.cdx.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		inputFormat := sbom.DetectFileFormat(data)
+		var bom *cyclonedx.BOM
+		var importLosses []sbom.ConversionLoss
+		if inputFormat == sbom.FileFormatSPDXJSON {
+			bom, importLosses, err = sbom.DecodeSPDX(data)
+		} else {
+			bom, err = sbom.DecodeSBOM(data, inputFormat)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		specVersion := bom.SpecVersion
+		if convertSpecVersion != "" {
+			specVersion, err = sbom.ParseSpecVersion(convertSpecVersion)
+			if err != nil {
+				return err
+			}
+		}
+
+		outputFormat := inputFormat
+		if convertOutputFormat != "" {
+			outputFormat, err = sbom.ParseFileFormat(convertOutputFormat)
+			if err != nil {
+				return err
+			}
+		}
+
+		if outputFormat == sbom.FileFormatSPDXJSON && convertSpecVersion != "" {
+			return fmt.Errorf("--spec-version cannot be used with the %s format", outputFormat)
+		}
+
+		converted, losses, err := sbom.ConvertSBOM(bom, specVersion, outputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to convert SBOM file %s: %w", inputFile, err)
+		}
+		losses = append(importLosses, losses...)
+
+		formatConversionLosses(cmd.ErrOrStderr(), losses)
+		if convertStrict && len(losses) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("the conversion loses %d fields", len(losses))
+		}
+
+		if convertOutputFile == "" {
+			_, err = cmd.OutOrStdout().Write(converted)
+			return err
+		}
+		if err := os.WriteFile(convertOutputFile, converted, 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVarP(&convertOutputFile, "output", "o", "", "Output file for the converted SBOM (default stdout)")
+	convertCmd.Flags().StringVar(&convertSpecVersion, "spec-version", "", "CycloneDX spec version to convert to (1.0 to 1.6, default the input's version)")
+	convertCmd.Flags().StringVar(&convertOutputFormat, "output-format", "", "Format of the converted SBOM (json, xml, proto, spdx-json, default the input's format)")
+	// --to reads better for conversions, but --output-format matches merge
+	convertCmd.Flags().StringVar(&convertOutputFormat, "to", "", "Shorthand for --output-format")
+	convertCmd.MarkFlagsMutuallyExclusive("output-format", "to")
+	convertCmd.Flags().BoolVar(&convertStrict, "strict", false, "Fail if fields are lost during the conversion")
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestConvertCommand(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	outputFile := filepath.Join(t.TempDir(), "converted.json")
+
+	_, err := executeCommand(
+		"convert",
+		filepath.Join(testdataDir, "sbom3.xml"),
+		"--spec-version",
+		"1.4",
+		"--output-format",
+		"json",
+		"-o",
+		outputFile,
+	)
+	if err != nil {
+		t.Fatalf("convert command failed: %v", err)
+	}
+
+	convertedBom, err := sbom.ReadSBOMFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read converted SBOM: %v", err)
+	}
+	if convertedBom.SpecVersion != cyclonedx.SpecVersion1_4 {
+		t.Errorf("Expected spec version 1.4, got %s", convertedBom.SpecVersion)
+	}
+}
+
+func TestConvertCommand_Stdout(t *testing.T) {
+	output, err := executeCommand("convert", filepath.Join("..", "testdata", "sbom1.json"), "--output-format", "xml")
+	if err != nil {
+		t.Fatalf("convert command failed: %v", err)
+	}
+	if !strings.Contains(output, "<bom") || !strings.Contains(output, "example-lib-1") {
+		t.Errorf("Expected XML SBOM on stdout, got %q", output)
+	}
+}
+
+func TestConvertCommand_Strict(t *testing.T) {
+	_, err := executeCommand(
+		"convert",
+		filepath.Join("..", "testdata", "sbom3.xml"),
+		"--spec-version",
+		"1.4",
+		"-o",
+		filepath.Join(t.TempDir(), "converted.xml"),
+		"--strict",
+	)
+	if err == nil {
+		t.Error("Expected convert command to fail when fields are lost with --strict")
+	}
+}
+
+func TestFormatConversionLosses(t *testing.T) {
+	var buf bytes.Buffer
+	formatConversionLosses(&buf, []sbom.ConversionLoss{{Path: "metadata.tools.components", Count: 2}})
+
+	if got := buf.String(); got != "Lost: metadata.tools.components (2)\n" {
+		t.Errorf("Unexpected output: %q", got)
+	}
+}
+
+func TestConvertCommand_SPDX(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	spdxFile := filepath.Join(t.TempDir(), "sbom.spdx.json")
+
+	_, err := executeCommand("convert", filepath.Join(testdataDir, "sbom1.json"), "--to", "spdx-json", "-o", spdxFile)
+	if err != nil {
+		t.Fatalf("convert command failed: %v", err)
+	}
+
+	output, err := executeCommand("convert", spdxFile, "--to", "json")
+	if err != nil {
+		t.Fatalf("convert command failed: %v", err)
+	}
+	if !strings.Contains(output, `"bomFormat": "CycloneDX"`) || !strings.Contains(output, "pkg:npm/example-lib-1@1.2.3") {
+		t.Errorf("Expected CycloneDX SBOM converted back from SPDX, got %q", output)
+	}
+
+	_, err = executeCommand("convert", filepath.Join(testdataDir, "sbom1.json"), "--to", "spdx-json", "--spec-version", "1.4")
+	if err == nil {
+		t.Error("Expected convert command to reject --spec-version for SPDX output")
+	}
+}
+
+func TestConvertCommand_ToFlag(t *testing.T) {
+	output, err := executeCommand("convert", "--help")
+	if err != nil {
+		t.Fatalf("convert --help failed: %v", err)
+	}
+	if !strings.Contains(output, "--to string") {
+		t.Errorf("Expected --to in the help, got %q", output)
+	}
+
+	if _, err := executeCommand("convert", filepath.Join("..", "testdata", "sbom1.json"), "--to", "xml", "--output-format", "json"); err == nil {
+		t.Error("Expected convert command to reject --to together with --output-format")
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat   string
+	diffJSONFile string
+)
+
+// formatDiff writes a human readable representation of the diff to the provided writer
+func formatDiff(w io.Writer, diff *sbom.Diff, oldFile, newFile string) {
+	fmt.Fprintf(w, "Old:\t%s\n", oldFile)
+	fmt.Fprintf(w, "New:\t%s\n", newFile)
+
+	if diff.IsEmpty() {
+		fmt.Fprintln(w, "\nNo differences found")
+		return
+	}
+
+	// Print summary
+	fmt.Fprintln(w, "\nSummary:")
+	fmt.Fprintf(w, "  Added Components:\t%d\n", len(diff.Added))
+	fmt.Fprintf(w, "  Removed Components:\t%d\n", len(diff.Removed))
+	fmt.Fprintf(w, "  Version Changes:\t%d\n", len(diff.VersionChanges))
+	fmt.Fprintf(w, "  License Changes:\t%d\n", len(diff.LicenseChanges))
+	fmt.Fprintf(w, "  Added Dependencies:\t%d\n", len(diff.AddedDependencies))
+	fmt.Fprintf(w, "  Removed Dependencies:\t%d\n", len(diff.RemovedDependencies))
+
+	printComponents := func(title string, components []sbom.DiffComponent) {
+		if len(components) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s:\n", title)
+		fmt.Fprintln(w, "    Name\tVersion\tType\tPURL")
+		for _, comp := range components {
+			fmt.Fprintf(w, "    %s\t%s\t%s\t%s\n", sbom.DisplayName(comp.Group, comp.Name), comp.Version, comp.Type, comp.PackageURL)
+		}
+	}
+	printComponents("Added Components", diff.Added)
+	printComponents("Removed Components", diff.Removed)
+
+	if len(diff.VersionChanges) > 0 {
+		fmt.Fprintln(w, "\nVersion Changes:")
+		fmt.Fprintln(w, "    Name\tOld Version\tNew Version")
+		for _, change := range diff.VersionChanges {
+			fmt.Fprintf(w, "    %s\t%s\t%s\n", sbom.DisplayName(change.Group, change.Name), change.OldVersion, change.NewVersion)
+		}
+	}
+
+	if len(diff.LicenseChanges) > 0 {
+		fmt.Fprintln(w, "\nLicense Changes:")
+		fmt.Fprintln(w, "    Name\tVersion\tOld Licenses\tNew Licenses")
+		for _, change := range diff.LicenseChanges {
+			fmt.Fprintf(w, "    %s\t%s\t%s\t%s\n",
+				sbom.DisplayName(change.Group, change.Name),
+				change.Version,
+				joinLicenses(change.OldLicenses),
+				joinLicenses(change.NewLicenses))
+		}
+	}
+
+	if len(diff.AddedDependencies) > 0 || len(diff.RemovedDependencies) > 0 {
+		fmt.Fprintln(w, "\nDependency Changes:")
+		for _, edge := range diff.AddedDependencies {
+			fmt.Fprintf(w, "  + %s -> %s\n", edge.From, edge.To)
+		}
+		for _, edge := range diff.RemovedDependencies {
+			fmt.Fprintf(w, "  - %s -> %s\n", edge.From, edge.To)
+		}
+	}
+}
+
+// writeJSON writes v as indented JSON to the provided writer
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// joinLicenses joins licenses for display, using "-" if there are none
+func joinLicenses(licenses []string) string {
+	if len(licenses) == 0 {
+		return "-"
+	}
+	return strings.Join(licenses, ", ")
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [old sbom file] [new sbom file]",
+	Short: "Show the differences between two SBOM files",
+	Long: `Compare two CycloneDX SBOM files and show which components were added, removed
+or changed their version, which licenses changed and which dependency edges
+were added or removed.
+
+Components are matched by their package URL (ignoring the version), falling
+back to group and name for components without a package URL.
+
+Example:
+  sbomctl diff release-1.0.sbom.json release-1.1.sbom.json
+  sbomctl diff old.json new.json --format json
+  sbomctl diff old.json new.json --json-file diff.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldFile, newFile := args[0], args[1]
+
+		if diffFormat != "text" && diffFormat != "json" {
+			return fmt.Errorf("unsupported format %q, must be one of: text, json", diffFormat)
+		}
+
+		// Read both SBOM files
+		oldBom, err := sbom.ReadSBOMFile(oldFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", oldFile, err)
+		}
+		newBom, err := sbom.ReadSBOMFile(newFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", newFile, err)
+		}
+
+		diff := sbom.DiffSBOMs(oldBom, newBom)
+
+		// Write the machine readable diff if requested
+		if diffJSONFile != "" {
+			file, err := os.Create(diffJSONFile)
+			if err != nil {
+				return fmt.Errorf("failed to create JSON diff file: %w", err)
+			}
+			if err := writeJSON(file, diff); err != nil {
+				file.Close()
+				return fmt.Errorf("failed to write JSON diff: %w", err)
+			}
+			if err := file.Close(); err != nil {
+				return fmt.Errorf("failed to write JSON diff: %w", err)
+			}
+		}
+
+		if diffFormat == "json" {
+			return writeJSON(cmd.OutOrStdout(), diff)
+		}
+
+		// Create a tabwriter for formatted output
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		formatDiff(w, diff, oldFile, newFile)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format (text, json)")
+	diffCmd.Flags().StringVar(&diffJSONFile, "json-file", "", "Additionally write the diff as JSON to this file")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestDiffCommand(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	jsonFile := filepath.Join(t.TempDir(), "diff.json")
+
+	var outputBuffer bytes.Buffer
+	rootCmd.SetOut(&outputBuffer)
+	defer rootCmd.SetOut(nil)
+
+	rootCmd.SetArgs([]string{
+		"diff",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom2.json"),
+		"--json-file",
+		jsonFile,
+	})
+	defer func() { diffJSONFile = "" }()
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("diff command failed: %v", err)
+	}
+
+	output := outputBuffer.String()
+	expectedStrings := []string{
+		"Added Components:",
+		"example-lib-3",
+		"Removed Components:",
+		"example-lib-1",
+	}
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', but it did not.\nOutput: %s", expected, output)
+		}
+	}
+
+	// Check the machine readable diff
+	data, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("JSON diff file not created: %v", err)
+	}
+	var diff sbom.Diff
+	if err := json.Unmarshal(data, &diff); err != nil {
+		t.Fatalf("Failed to parse JSON diff: %v", err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Name != "example-lib-3" {
+		t.Errorf("Expected example-lib-3 to be added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "example-lib-1" {
+		t.Errorf("Expected example-lib-1 to be removed, got %+v", diff.Removed)
+	}
+}
+
+func TestDiffCommandJSON(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+
+	output, err := executeCommand("diff", filepath.Join(testdataDir, "sbom1.json"), filepath.Join(testdataDir, "sbom2.json"), "--format", "json")
+	if err != nil {
+		t.Fatalf("diff command failed: %v", err)
+	}
+	var diff sbom.Diff
+	if err := json.Unmarshal([]byte(output), &diff); err != nil {
+		t.Fatalf("Failed to parse JSON diff: %v\nOutput: %s", err, output)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Name != "example-lib-3" {
+		t.Errorf("Expected example-lib-3 to be added, got %+v", diff.Added)
+	}
+
+	if _, err := executeCommand("diff", filepath.Join(testdataDir, "sbom1.json"), filepath.Join(testdataDir, "sbom2.json"), "--format", "yaml"); err == nil {
+		t.Error("Expected diff command to fail for an unknown format")
+	}
+}
+
+func TestFormatDiffNoChanges(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	formatDiff(&outputBuffer, &sbom.Diff{}, "old.json", "new.json")
+
+	if !strings.Contains(outputBuffer.String(), "No differences found") {
+		t.Errorf("Expected output to report no differences.\nOutput: %s", outputBuffer.String())
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	enrichHashesDirs         []string
+	enrichHashesOutputFile   string
+	enrichHashesOutputFormat string
+)
+
+// formatHashEnrichReport writes the enriched, missing, unreadable and mismatched components to the provided writer
+func formatHashEnrichReport(w io.Writer, report *sbom.HashEnrichReport) {
+	fmt.Fprintf(w, "Computed hashes for %d components\n", len(report.Enriched))
+	if len(report.Missing) > 0 {
+		fmt.Fprintf(w, "%d components have no artifact in the directories:\n", len(report.Missing))
+		for _, component := range report.Missing {
+			fmt.Fprintf(w, "  - %s\n", component)
+		}
+	}
+	if len(report.Unreadable) > 0 {
+		fmt.Fprintf(w, "%d artifacts could not be hashed:\n", len(report.Unreadable))
+		for _, unreadable := range report.Unreadable {
+			fmt.Fprintf(w, "  - %s in %s: %s\n", unreadable.Component, unreadable.Artifact, unreadable.Error)
+		}
+	}
+	if len(report.Mismatched) > 0 {
+		fmt.Fprintf(w, "%d hashes differ from the artifacts and were kept:\n", len(report.Mismatched))
+		for _, mismatch := range report.Mismatched {
+			fmt.Fprintf(w, "  - %s %s: %s in SBOM, %s computed\n", mismatch.Component, mismatch.Algorithm, mismatch.Existing, mismatch.Computed)
+		}
+	}
+}
+
+// enrichCmd represents the enrich command
+var enrichCmd = &cobra.Command{
+	Use:   "enrich",
+	Short: "Add information to the components of an SBOM",
+}
+
+// enrichHashesCmd represents the enrich hashes command
+var enrichHashesCmd = &cobra.Command{
+	Use:   "hashes [sbom file]",
+	Short: "Add hashes of local artifacts to the components of an SBOM file",
+	Long: `Add the SHA-256, SHA-512 and BLAKE3 hashes of the components' artifacts to an
+SBOM file. The artifact of each component is located from its package URL
+in the directories given with --dir, which are searched in order:
+  npm       node_modules/<name> of the component's version, or a tarball
+            <name>-<version>.tgz as created by npm pack
+  maven     a Maven repository like ~/.m2 with
+            <group>/<artifact>/<version>/<artifact>-<version>.jar, using the
+            classifier and type qualifiers
+  golang    the Go module cache (go env GOMODCACHE) with
+            cache/download/<module>/@v/<version>.zip, or vendor/<module>
+  composer  vendor/<vendor>/<name>
+  cargo     vendor/<name>-<version> or vendor/<name>
+Each directory can also be the node_modules, vendor or repository directory
+itself.
+
+Directories like node_modules/<name> or vendor/<module> are hashed like Go's
+dirhash, from the sorted paths and hashes of their regular files, and the
+algorithms of these hashes are listed in the sbomctl:hashes:dirhash property
+of the component. Artifacts that cannot be read are listed on stderr and
+left without hashes.
+
+Hashes with an algorithm the component already has are not replaced. If
+they differ from the computed hash, they are listed on stderr together with
+the components whose artifacts were not found. Hashes of a directory are
+only compared with hashes listed in the sbomctl:hashes:dirhash property.
+
+The SBOM is written to stdout unless --output is given, in the input's
+format unless --output-format is given.
+
+Example:
+  sbomctl enrich hashes sbom.json --dir . -o sbom-hashes.json
+  sbomctl enrich hashes sbom.json --dir ~/.m2 --dir $(go env GOMODCACHE)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		if len(enrichHashesDirs) == 0 {
+			return fmt.Errorf("no directories given, use --dir")
+		}
+
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		inputFormat := sbom.DetectFileFormat(data)
+		bom, err := sbom.DecodeSBOM(data, inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		outputFormat := inputFormat
+		if enrichHashesOutputFormat != "" {
+			if outputFormat, err = sbom.ParseFileFormat(enrichHashesOutputFormat); err != nil {
+				return err
+			}
+		}
+
+		for _, dir := range enrichHashesDirs {
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+		}
+
+		cmd.SilenceUsage = true
+
+		report := sbom.EnrichHashes(bom, enrichHashesDirs)
+		encoded, err := sbom.EncodeSBOM(bom, outputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to encode SBOM: %w", err)
+		}
+
+		formatHashEnrichReport(cmd.ErrOrStderr(), report)
+
+		if enrichHashesOutputFile == "" {
+			_, err = cmd.OutOrStdout().Write(encoded)
+			return err
+		}
+		if err := os.WriteFile(enrichHashesOutputFile, encoded, 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(enrichCmd)
+	enrichCmd.AddCommand(enrichHashesCmd)
+
+	enrichHashesCmd.Flags().StringArrayVar(&enrichHashesDirs, "dir", nil, "Directory to search for artifacts (repeatable)")
+	enrichHashesCmd.Flags().StringVarP(&enrichHashesOutputFile, "output", "o", "", "Output file for the SBOM (default stdout)")
+	enrichHashesCmd.Flags().StringVar(&enrichHashesOutputFormat, "output-format", "", "Format of the SBOM (json, xml, proto, spdx-json, default the input's format)")
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestEnrichHashesCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "example-lib-1-1.2.3.tgz"), []byte("tgz"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lib := filepath.Join(dir, "node_modules", "example-lib-2")
+	if err := os.MkdirAll(lib, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(lib, "package.json"), []byte(`{"name": "example-lib-2", "version": "2.3.4"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "output.xml")
+	if _, err := executeCommand("enrich", "hashes", filepath.Join("..", "testdata", "sbom1.json"), "--dir", dir, "-o", outputFile, "--output-format", "xml"); err != nil {
+		t.Fatalf("enrich hashes command failed: %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bom, err := sbom.DecodeSBOM(data, sbom.FileFormatXML)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+
+	for _, c := range *bom.Components {
+		switch c.Name {
+		case "example-lib-1", "example-lib-2":
+			if c.Hashes == nil || len(*c.Hashes) != 3 || (*c.Hashes)[2].Algorithm != cyclonedx.HashAlgoBlake3 {
+				t.Errorf("Expected 3 hashes for %s, got %+v", c.Name, c.Hashes)
+			}
+		default:
+			if c.Hashes != nil {
+				t.Errorf("Expected no hashes for %s, got %+v", c.Name, *c.Hashes)
+			}
+		}
+	}
+
+	if _, err := executeCommand("enrich", "hashes", filepath.Join("..", "testdata", "sbom1.json")); err == nil {
+		t.Error("Expected enrich hashes command to fail without --dir")
+	}
+	if _, err := executeCommand("enrich", "hashes", filepath.Join("..", "testdata", "sbom1.json"), "--dir", filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected enrich hashes command to fail for a missing directory")
+	}
+}
+
+func TestFormatHashEnrichReport(t *testing.T) {
+	var buf bytes.Buffer
+	formatHashEnrichReport(&buf, &sbom.HashEnrichReport{
+		Enriched: []sbom.HashedComponent{{Component: "pkg:npm/a@1.0.0", Artifact: "a-1.0.0.tgz", Added: 3}},
+		Missing:  []string{"pkg:npm/b@1.0.0"},
+		Unreadable: []sbom.UnreadableArtifact{
+			{Component: "pkg:npm/c@1.0.0", Artifact: "node_modules/c", Error: "permission denied"},
+		},
+		Mismatched: []sbom.HashMismatch{
+			{Component: "pkg:npm/a@1.0.0", Algorithm: cyclonedx.HashAlgoSHA256, Existing: "aa", Computed: "bb"},
+		},
+	})
+
+	expected := "Computed hashes for 1 components\n1 components have no artifact in the directories:\n  - pkg:npm/b@1.0.0\n" +
+		"1 artifacts could not be hashed:\n  - pkg:npm/c@1.0.0 in node_modules/c: permission denied\n" +
+		"1 hashes differ from the artifacts and were kept:\n  - pkg:npm/a@1.0.0 SHA-256: aa in SBOM, bb computed\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
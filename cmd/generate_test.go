@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestGenerateGoBinaryCommand(t *testing.T) {
+	// The test binary is a Go executable with build info
+	executable, err := os.Executable()
+	if err != nil {
+		t.Skipf("Failed to locate test binary: %v", err)
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "binary.json")
+	if _, err := executeCommand("generate", "go-binary", executable, "-o", outputFile); err != nil {
+		t.Fatalf("generate go-binary command failed: %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bom, err := sbom.DecodeSBOM(data, sbom.FileFormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+
+	found := false
+	for _, c := range *bom.Components {
+		if strings.HasPrefix(c.PackageURL, "pkg:golang/github.com/spf13/cobra@") {
+			found = c.Hashes == nil && c.Properties != nil && (*c.Properties)[0].Name == "sbomctl:go:h1" && strings.HasPrefix((*c.Properties)[0].Value, "h1:")
+		}
+	}
+	if !found {
+		t.Errorf("Expected cobra with its checksum in the components, got %+v", *bom.Components)
+	}
+
+	// The SBOM can be merged with others
+	mergedFile := filepath.Join(t.TempDir(), "merged.json")
+	if _, err := executeCommand("merge", outputFile, filepath.Join("..", "testdata", "sbom2.json"), "-o", mergedFile); err != nil {
+		t.Fatalf("merge command failed: %v", err)
+	}
+	merged, err := sbom.ReadSBOMFile(mergedFile)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	if errs := sbom.CheckRefIntegrity(merged); len(errs) != 0 {
+		t.Errorf("Expected consistent refs in merged SBOM, got %v", errs)
+	}
+
+	if _, err := executeCommand("generate", "go-binary", filepath.Join("..", "testdata", "sbom1.json")); err == nil {
+		t.Error("Expected generate go-binary command to fail for a file that is no Go executable")
+	}
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFormat        string
+	graphTypes         []string
+	graphDepth         int
+	graphGroupBySource bool
+)
+
+// graphGroups splits the nodes by the input SBOM they came from. Nodes
+// without source come first, under an empty source.
+func graphGroups(export *sbom.GraphExport) (sources []string, nodes map[string][]sbom.GraphNode) {
+	nodes = make(map[string][]sbom.GraphNode)
+	sources = []string{""}
+	for _, node := range export.Nodes {
+		if _, ok := nodes[node.Source]; !ok && node.Source != "" {
+			sources = append(sources, node.Source)
+		}
+		nodes[node.Source] = append(nodes[node.Source], node)
+	}
+	return sources, nodes
+}
+
+// dotQuote quotes a string as a Graphviz ID
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// formatDOT writes the graph in the Graphviz DOT language to the provided writer
+func formatDOT(w io.Writer, export *sbom.GraphExport, groupBySource bool) {
+	fmt.Fprintln(w, "digraph sbom {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box];")
+
+	writeNodes := func(indent string, nodes []sbom.GraphNode) {
+		for _, node := range nodes {
+			fmt.Fprintf(w, "%s%s [label=%s];\n", indent, dotQuote(node.ID), dotQuote(node.Label))
+		}
+	}
+	if groupBySource {
+		sources, nodes := graphGroups(export)
+		writeNodes("  ", nodes[""])
+		for i, source := range sources[1:] {
+			fmt.Fprintf(w, "  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(w, "    label=%s;\n", dotQuote(source))
+			writeNodes("    ", nodes[source])
+			fmt.Fprintln(w, "  }")
+		}
+	} else {
+		writeNodes("  ", export.Nodes)
+	}
+
+	for _, edge := range export.Edges {
+		fmt.Fprintf(w, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// mermaidLabel escapes a string for use in a quoted Mermaid label
+func mermaidLabel(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s) + `"`
+}
+
+// formatMermaid writes the graph as Mermaid flowchart to the provided writer.
+// Mermaid IDs cannot contain most characters of bom-refs, so nodes are
+// numbered instead.
+func formatMermaid(w io.Writer, export *sbom.GraphExport, groupBySource bool) {
+	ids := make(map[string]string, len(export.Nodes))
+	for i, node := range export.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+	}
+
+	fmt.Fprintln(w, "graph LR")
+	writeNodes := func(indent string, nodes []sbom.GraphNode) {
+		for _, node := range nodes {
+			fmt.Fprintf(w, "%s%s[%s]\n", indent, ids[node.ID], mermaidLabel(node.Label))
+		}
+	}
+	if groupBySource {
+		sources, nodes := graphGroups(export)
+		writeNodes("  ", nodes[""])
+		for i, source := range sources[1:] {
+			fmt.Fprintf(w, "  subgraph s%d[%s]\n", i, mermaidLabel(source))
+			writeNodes("    ", nodes[source])
+			fmt.Fprintln(w, "  end")
+		}
+	} else {
+		writeNodes("  ", export.Nodes)
+	}
+
+	for _, edge := range export.Edges {
+		fmt.Fprintf(w, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+	}
+}
+
+// xmlEscape escapes a string for use in XML text and attribute values
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// formatGraphML writes the graph as GraphML to the provided writer. Nodes
+// carry their label, type, package URL and source as data. When grouped by
+// source, the nodes of each input are nested in a group node.
+func formatGraphML(w io.Writer, export *sbom.GraphExport, groupBySource bool) {
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(w, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, key := range []string{"label", "type", "purl", "source"} {
+		fmt.Fprintf(w, "  <key id=%q for=\"node\" attr.name=%q attr.type=\"string\"/>\n", key, key)
+	}
+	fmt.Fprintln(w, `  <graph id="sbom" edgedefault="directed">`)
+
+	writeNodes := func(indent string, nodes []sbom.GraphNode) {
+		for _, node := range nodes {
+			fmt.Fprintf(w, "%s<node id=\"%s\">\n", indent, xmlEscape(node.ID))
+			for _, data := range [][2]string{{"label", node.Label}, {"type", node.Type}, {"purl", node.PackageURL}, {"source", node.Source}} {
+				if data[1] != "" {
+					fmt.Fprintf(w, "%s  <data key=%q>%s</data>\n", indent, data[0], xmlEscape(data[1]))
+				}
+			}
+			fmt.Fprintf(w, "%s</node>\n", indent)
+		}
+	}
+	if groupBySource {
+		sources, nodes := graphGroups(export)
+		writeNodes("    ", nodes[""])
+		for _, source := range sources[1:] {
+			fmt.Fprintf(w, "    <node id=\"%s\">\n", xmlEscape(source))
+			fmt.Fprintf(w, "      <data key=\"label\">%s</data>\n", xmlEscape(source))
+			fmt.Fprintf(w, "      <graph id=\"%s:\" edgedefault=\"directed\">\n", xmlEscape(source))
+			writeNodes("        ", nodes[source])
+			fmt.Fprintln(w, "      </graph>")
+			fmt.Fprintln(w, "    </node>")
+		}
+	} else {
+		writeNodes("    ", export.Nodes)
+	}
+
+	for _, edge := range export.Edges {
+		fmt.Fprintf(w, "    <edge source=\"%s\" target=\"%s\"/>\n", xmlEscape(edge.From), xmlEscape(edge.To))
+	}
+	fmt.Fprintln(w, "  </graph>")
+	fmt.Fprintln(w, "</graphml>")
+}
+
+// graphCmd represents the graph command
+var graphCmd = &cobra.Command{
+	Use:   "graph [sbom file]",
+	Short: "Export the dependency graph of an SBOM file",
+	Long: `Export the component and dependency graph of an SBOM file to render it or load
+it into graph tools. The following formats are supported:
+  dot      Graphviz DOT (default)
+  mermaid  Mermaid flowchart, e.g. for Markdown docs
+  graphml  GraphML
+  json     a list of nodes and a list of edges
+
+Nodes are identified by bom-ref and labeled with the package URL of their
+component, falling back to name@version.
+
+--type only keeps components of the given types. Dependencies through
+components that are left out are replaced by dependencies on the nearest
+kept components behind them. --depth only keeps components up to the given
+distance from the root components, the same roots the tree command uses.
+
+For merged SBOMs, --group-by-source groups the components by the input SBOM
+they came from, based on the serial number prefix of their bom-refs. The
+JSON format always contains the source of each node.
+
+Example:
+  sbomctl graph sbom.json | dot -Tsvg > sbom.svg
+  sbomctl graph merged.json --format mermaid --group-by-source
+  sbomctl graph sbom.json --format graphml --type library,framework --depth 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		switch graphFormat {
+		case "dot", "mermaid", "graphml", "json":
+		default:
+			return fmt.Errorf("unsupported graph format %q, must be one of: dot, mermaid, graphml, json", graphFormat)
+		}
+		if graphDepth < 0 {
+			return fmt.Errorf("depth must not be negative, got %d", graphDepth)
+		}
+
+		filter := sbom.GraphFilter{Depth: graphDepth}
+		for _, t := range graphTypes {
+			filter.Types = append(filter.Types, cyclonedx.ComponentType(t))
+		}
+
+		bom, err := sbom.ReadSBOMFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		export := sbom.NewGraph(bom).Export(filter)
+
+		w := cmd.OutOrStdout()
+		switch graphFormat {
+		case "mermaid":
+			formatMermaid(w, export, graphGroupBySource)
+		case "graphml":
+			formatGraphML(w, export, graphGroupBySource)
+		case "json":
+			return writeJSON(w, export)
+		default:
+			formatDOT(w, export, graphGroupBySource)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Graph format (dot, mermaid, graphml, json)")
+	graphCmd.Flags().StringSliceVar(&graphTypes, "type", nil, "Only keep components of these types, e.g. library,framework")
+	graphCmd.Flags().IntVar(&graphDepth, "depth", 0, "Only keep components up to this distance from the roots, 0 for unlimited")
+	graphCmd.Flags().BoolVar(&graphGroupBySource, "group-by-source", false, "Group components by the input SBOM of a merged SBOM they came from")
+}
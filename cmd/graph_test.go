@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func graphTestExport() *sbom.GraphExport {
+	return &sbom.GraphExport{
+		Nodes: []sbom.GraphNode{
+			{ID: "merged", Label: "merged"},
+			{ID: "urn:uuid:1/app", Label: `app "quoted"`, Type: "application", Source: "urn:uuid:1"},
+			{ID: "urn:uuid:2/lib", Label: "pkg:npm/lib@1.0.0", Type: "library", PackageURL: "pkg:npm/lib@1.0.0", Source: "urn:uuid:2"},
+		},
+		Edges: []sbom.GraphEdge{
+			{From: "merged", To: "urn:uuid:1/app"},
+			{From: "urn:uuid:1/app", To: "urn:uuid:2/lib"},
+		},
+	}
+}
+
+func TestFormatDOT(t *testing.T) {
+	var buf bytes.Buffer
+	formatDOT(&buf, graphTestExport(), true)
+
+	expected := `digraph sbom {
+  rankdir=LR;
+  node [shape=box];
+  "merged" [label="merged"];
+  subgraph cluster_0 {
+    label="urn:uuid:1";
+    "urn:uuid:1/app" [label="app \"quoted\""];
+  }
+  subgraph cluster_1 {
+    label="urn:uuid:2";
+    "urn:uuid:2/lib" [label="pkg:npm/lib@1.0.0"];
+  }
+  "merged" -> "urn:uuid:1/app";
+  "urn:uuid:1/app" -> "urn:uuid:2/lib";
+}
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected DOT:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestFormatMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	formatMermaid(&buf, graphTestExport(), false)
+
+	expected := `graph LR
+  n0["merged"]
+  n1["app #quot;quoted#quot;"]
+  n2["pkg:npm/lib@1.0.0"]
+  n0 --> n1
+  n1 --> n2
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected Mermaid:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	formatMermaid(&buf, graphTestExport(), true)
+	if !strings.Contains(buf.String(), "  subgraph s1[\"urn:uuid:2\"]\n    n2[\"pkg:npm/lib@1.0.0\"]\n  end\n") {
+		t.Errorf("Expected a subgraph per source, got:\n%s", buf.String())
+	}
+}
+
+func TestFormatGraphML(t *testing.T) {
+	for _, groupBySource := range []bool{false, true} {
+		var buf bytes.Buffer
+		formatGraphML(&buf, graphTestExport(), groupBySource)
+
+		// The output has to be well-formed XML
+		decoder := xml.NewDecoder(&buf)
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if err != io.EOF {
+					t.Errorf("Invalid GraphML (group by source %v): %v", groupBySource, err)
+				}
+				break
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	formatGraphML(&buf, graphTestExport(), false)
+	for _, expected := range []string{
+		`<data key="label">app &#34;quoted&#34;</data>`,
+		`<data key="purl">pkg:npm/lib@1.0.0</data>`,
+		`<edge source="urn:uuid:1/app" target="urn:uuid:2/lib"/>`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected GraphML to contain %s, got:\n%s", expected, buf.String())
+		}
+	}
+}
+
+func TestGraphCommand(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+
+	output, err := executeCommand("graph", inputFile)
+	if err != nil {
+		t.Fatalf("graph command failed: %v", err)
+	}
+	if !strings.Contains(output, `"SPDXRef-Package-example-app" -> "SPDXRef-Package-example-lib-3";`) {
+		t.Errorf("Expected DOT output with the dependency of example-app, got:\n%s", output)
+	}
+
+	output, err = executeCommand("graph", inputFile, "--format", "json", "--type", "library")
+	if err != nil {
+		t.Fatalf("graph command failed: %v", err)
+	}
+	var export sbom.GraphExport
+	if err := json.Unmarshal([]byte(output), &export); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if len(export.Nodes) != 2 || len(export.Edges) != 0 {
+		t.Errorf("Expected the two libraries without edges, got %+v", export)
+	}
+
+	if _, err := executeCommand("graph", inputFile, "--format", "svg"); err == nil {
+		t.Error("Expected graph command to fail for an unsupported format")
+	}
+}
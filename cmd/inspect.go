@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"text/tabwriter"
@@ -11,109 +14,90 @@ import (
 	"github.com/CycloneDX/cyclonedx-go"
 	"github.com/j12934/sbomctl/pkg/sbom"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	inspectFormat      string
+	inspectOutputFile  string
+	inspectAll         bool
+	inspectTypes       []string
+	inspectPurlTypes   []string
+	inspectNameRegex   string
+	inspectScopes      []string
+	inspectHasLicense  bool
+	inspectMissingPurl bool
+	inspectSort        string
+	inspectColumns     []string
+	inspectLicenses    bool
+	inspectWhere       string
 )
 
 // formatSBOMInfo formats the SBOM information and writes it to the provided writer
 // This function is exported for testing purposes
 func formatSBOMInfo(w io.Writer, bom *cyclonedx.BOM, inputFile string) {
+	summary := sbom.SummarizeSBOM(bom, inputFile)
+
 	// Print basic information
-	fmt.Fprintf(w, "File:\t%s\n", inputFile)
-	fmt.Fprintf(w, "SBOM Format:\t%s\n", bom.BOMFormat)
-	fmt.Fprintf(w, "Spec Version:\t%s\n", bom.SpecVersion)
-	fmt.Fprintf(w, "Serial Number:\t%s\n", bom.SerialNumber)
-	fmt.Fprintf(w, "Version:\t%d\n", bom.Version)
+	fmt.Fprintf(w, "File:\t%s\n", summary.File)
+	fmt.Fprintf(w, "SBOM Format:\t%s\n", summary.BOMFormat)
+	fmt.Fprintf(w, "Spec Version:\t%s\n", summary.SpecVersion)
+	fmt.Fprintf(w, "Serial Number:\t%s\n", summary.SerialNumber)
+	fmt.Fprintf(w, "Version:\t%d\n", summary.Version)
 
 	// Print metadata if available
-	if bom.Metadata != nil {
+	if summary.Metadata != nil {
 		fmt.Fprintln(w, "\nMetadata:")
-		if bom.Metadata.Timestamp != "" {
-			fmt.Fprintf(w, "  Timestamp:\t%s\n", bom.Metadata.Timestamp)
+		if summary.Metadata.Timestamp != "" {
+			fmt.Fprintf(w, "  Timestamp:\t%s\n", summary.Metadata.Timestamp)
 		}
 
 		// Print tools information if available
-		if bom.Metadata.Tools != nil {
+		if len(summary.Metadata.Tools) > 0 {
 			fmt.Fprintln(w, "  Tools:")
-
-			// Handle deprecated Tools field (for backward compatibility)
-			if bom.Metadata.Tools.Tools != nil {
-				for _, tool := range *bom.Metadata.Tools.Tools {
-					fmt.Fprintf(w, "    - %s", tool.Name)
-					if tool.Version != "" {
-						fmt.Fprintf(w, " (v%s)", tool.Version)
-					}
-					if tool.Vendor != "" {
-						fmt.Fprintf(w, " by %s", tool.Vendor)
-					}
-					fmt.Fprintln(w)
+			for _, tool := range summary.Metadata.Tools {
+				fmt.Fprintf(w, "    - %s", tool.Name)
+				if tool.Version != "" {
+					fmt.Fprintf(w, " (v%s)", tool.Version)
 				}
-			}
-
-			// Handle new Components field
-			if bom.Metadata.Tools.Components != nil {
-				for _, component := range *bom.Metadata.Tools.Components {
-					fmt.Fprintf(w, "    - %s", component.Name)
-					if component.Version != "" {
-						fmt.Fprintf(w, " (v%s)", component.Version)
-					}
-					if component.Publisher != "" {
-						fmt.Fprintf(w, " by %s", component.Publisher)
-					}
-					fmt.Fprintln(w)
+				if tool.Vendor != "" {
+					fmt.Fprintf(w, " by %s", tool.Vendor)
 				}
+				fmt.Fprintln(w)
 			}
 		}
 	}
 
 	// Print component information
 	fmt.Fprintln(w, "\nComponents:")
-	if bom.Components != nil && len(*bom.Components) > 0 {
-		fmt.Fprintf(w, "  Total Components:\t%d\n", len(*bom.Components))
-
-		// Count component types
-		typeCount := make(map[string]int)
-		for _, comp := range *bom.Components {
-			typeCount[string(comp.Type)]++
-		}
+	if summary.Components.Total > 0 {
+		fmt.Fprintf(w, "  Total Components:\t%d\n", summary.Components.Total)
 
 		// Print component types
 		fmt.Fprintln(w, "  Component Types:")
-		types := make([]string, 0, len(typeCount))
-		for t := range typeCount {
+		types := make([]string, 0, len(summary.Components.Types))
+		for t := range summary.Components.Types {
 			types = append(types, t)
 		}
 		sort.Strings(types)
 		for _, t := range types {
-			fmt.Fprintf(w, "    - %s:\t%d\n", t, typeCount[t])
+			fmt.Fprintf(w, "    - %s:\t%d\n", t, summary.Components.Types[t])
 		}
 
-		// Print top components (limited to 10 for readability)
-		fmt.Fprintln(w, "\n  Top Components (max 10):")
+		// Print top components
+		fmt.Fprintf(w, "\n  Top Components (max %d):\n", sbom.SummaryTopComponents)
 		fmt.Fprintln(w, "    Name\tVersion\tType\tPURL")
-
-		// Sort components by name for consistent output
-		components := *bom.Components
-		sort.Slice(components, func(i, j int) bool {
-			return strings.ToLower(components[i].Name) < strings.ToLower(components[j].Name)
-		})
-
-		// Print up to 10 components
-		limit := 10
-		if len(components) < limit {
-			limit = len(components)
-		}
-
-		for i := 0; i < limit; i++ {
-			comp := components[i]
+		for _, comp := range summary.Components.Top {
 			fmt.Fprintf(w, "    %s\t%s\t%s\t%s\n",
 				comp.Name,
 				comp.Version,
-				string(comp.Type),
+				comp.Type,
 				comp.PackageURL)
 		}
 
 		// Indicate if there are more components
-		if len(components) > 10 {
-			fmt.Fprintf(w, "    ... and %d more components\n", len(components)-10)
+		if more := summary.Components.Total - len(summary.Components.Top); more > 0 {
+			fmt.Fprintf(w, "    ... and %d more components\n", more)
 		}
 	} else {
 		fmt.Fprintln(w, "  No components found")
@@ -121,25 +105,244 @@ func formatSBOMInfo(w io.Writer, bom *cyclonedx.BOM, inputFile string) {
 
 	// Print dependency information
 	fmt.Fprintln(w, "\nDependencies:")
-	if bom.Dependencies != nil && len(*bom.Dependencies) > 0 {
-		fmt.Fprintf(w, "  Total Dependencies:\t%d\n", len(*bom.Dependencies))
-
-		// Count dependencies with dependsOn
-		withDependsOn := 0
-		maxDependsOn := 0
-		for _, dep := range *bom.Dependencies {
-			if dep.Dependencies != nil && len(*dep.Dependencies) > 0 {
-				withDependsOn++
-				if len(*dep.Dependencies) > maxDependsOn {
-					maxDependsOn = len(*dep.Dependencies)
-				}
+	if summary.Dependencies.Total > 0 {
+		fmt.Fprintf(w, "  Total Dependencies:\t%d\n", summary.Dependencies.Total)
+		fmt.Fprintf(w, "  Dependencies with dependsOn:\t%d\n", summary.Dependencies.WithDependsOn)
+		fmt.Fprintf(w, "  Max dependsOn count:\t%d\n", summary.Dependencies.MaxDependsOn)
+	} else {
+		fmt.Fprintln(w, "  No dependencies found")
+	}
+
+	// Print vulnerability information
+	fmt.Fprintln(w, "\nVulnerabilities:")
+	vulns := summary.Vulnerabilities
+	if vulns.Total == 0 {
+		fmt.Fprintln(w, "  No vulnerabilities found")
+		return
+	}
+	fmt.Fprintf(w, "  Total Vulnerabilities:\t%d\n", vulns.Total)
+
+	fmt.Fprintln(w, "  By Severity:")
+	for _, severity := range []cyclonedx.Severity{cyclonedx.SeverityCritical, cyclonedx.SeverityHigh, cyclonedx.SeverityMedium, cyclonedx.SeverityLow, cyclonedx.SeverityInfo, cyclonedx.SeverityNone, cyclonedx.SeverityUnknown} {
+		if count := vulns.Severities[string(severity)]; count > 0 {
+			fmt.Fprintf(w, "    - %s:\t%d\n", severity, count)
+		}
+	}
+
+	fmt.Fprintln(w, "  By Analysis State:")
+	states := make([]string, 0, len(vulns.States))
+	for state := range vulns.States {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	for _, state := range states {
+		fmt.Fprintf(w, "    - %s:\t%d\n", state, vulns.States[state])
+	}
+
+	fmt.Fprintf(w, "\n  Most Affected Components (max %d):\n", sbom.SummaryTopComponents)
+	fmt.Fprintln(w, "    Component\tVulnerabilities\tHighest Severity")
+	for _, c := range vulns.TopAffected {
+		fmt.Fprintf(w, "    %s\t%d\t%s\n", c.Component, c.Vulnerabilities, c.HighestSeverity)
+	}
+
+	fmt.Fprintf(w, "\n  Fixes Available:\t%d\n", len(vulns.Fixes))
+	for _, fix := range vulns.Fixes {
+		fixText := fix.Recommendation
+		if len(fix.FixedVersions) > 0 {
+			fixText = "fixed in " + strings.Join(fix.FixedVersions, ", ")
+		}
+		fmt.Fprintf(w, "    - %s (%s):\t%s\n", fix.ID, fix.Severity, fixText)
+	}
+}
+
+// defaultComponentColumns are the columns of a component listing if none are selected
+var defaultComponentColumns = []string{"name", "version", "type", "purl"}
+
+// componentRows returns the given columns of each component, see sbom.ComponentColumns
+func componentRows(components []cyclonedx.Component, columns []string) ([]map[string]string, error) {
+	if len(columns) == 0 {
+		columns = defaultComponentColumns
+	}
+	rows := make([]map[string]string, 0, len(components))
+	for i := range components {
+		row := make(map[string]string, len(columns))
+		for _, column := range columns {
+			value, err := sbom.ComponentColumn(&components[i], column)
+			if err != nil {
+				return nil, err
 			}
+			row[column] = value
 		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
 
-		fmt.Fprintf(w, "  Dependencies with dependsOn:\t%d\n", withDependsOn)
-		fmt.Fprintf(w, "  Max dependsOn count:\t%d\n", maxDependsOn)
-	} else {
-		fmt.Fprintln(w, "  No dependencies found")
+// formatComponentList writes the given columns of each component as a table
+// to the provided writer
+func formatComponentList(w io.Writer, components []cyclonedx.Component, columns []string) error {
+	if len(columns) == 0 {
+		columns = defaultComponentColumns
+	}
+	rows, err := componentRows(components, columns)
+	if err != nil {
+		return err
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return nil
+}
+
+// formatLicenseReport writes the components grouped by license to the provided writer
+func formatLicenseReport(w io.Writer, report *sbom.LicenseReport) {
+	fmt.Fprintln(w, "Licenses:")
+	if len(report.Licenses) == 0 {
+		fmt.Fprintln(w, "  No licenses found")
+	}
+	for _, usage := range report.Licenses {
+		fmt.Fprintf(w, "  %s:\t%d\n", usage.License, len(usage.Components))
+		for _, component := range usage.Components {
+			fmt.Fprintf(w, "    - %s\n", component)
+		}
+	}
+
+	fmt.Fprintf(w, "\nComponents without license information:\t%d\n", len(report.Unlicensed))
+	for _, component := range report.Unlicensed {
+		fmt.Fprintf(w, "  - %s\n", component)
+	}
+
+	if len(report.Invalid) > 0 {
+		fmt.Fprintf(w, "\nInvalid license expressions:\t%d\n", len(report.Invalid))
+		for _, invalid := range report.Invalid {
+			fmt.Fprintf(w, "  - %s: %s\n", invalid.Component, invalid.Error)
+		}
+	}
+}
+
+// inspectComponentFilter returns the component filter given by the flags of
+// the inspect command
+func inspectComponentFilter(cmd *cobra.Command) (sbom.ComponentFilter, error) {
+	filter := sbom.ComponentFilter{
+		PurlTypes:   inspectPurlTypes,
+		MissingPurl: inspectMissingPurl,
+	}
+	for _, t := range inspectTypes {
+		filter.Types = append(filter.Types, cyclonedx.ComponentType(t))
+	}
+	for _, scope := range inspectScopes {
+		filter.Scopes = append(filter.Scopes, cyclonedx.Scope(scope))
+	}
+	if inspectNameRegex != "" {
+		re, err := regexp.Compile(inspectNameRegex)
+		if err != nil {
+			return filter, fmt.Errorf("invalid name regex: %w", err)
+		}
+		filter.NameRegex = re
+	}
+	if cmd.Flags().Changed("has-license") {
+		filter.HasLicense = &inspectHasLicense
+	}
+	if inspectWhere != "" {
+		query, err := sbom.CompileQuery(inspectWhere)
+		if err != nil {
+			return filter, err
+		}
+		filter.Where = query
+	}
+	return filter, nil
+}
+
+// writeYAML writes a value as YAML to the provided writer
+func writeYAML(w io.Writer, v any) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// writeInspection writes the summary, the component listing or the license
+// report of an SBOM in the selected format to the provided writer
+func writeInspection(w io.Writer, bom *cyclonedx.BOM, inputFile string, filter sbom.ComponentFilter, listing bool) error {
+	if inspectLicenses {
+		report := sbom.ReportLicenses(sbom.FilterComponents(bom, filter))
+		switch inspectFormat {
+		case "json":
+			return writeJSON(w, report)
+		case "yaml":
+			return writeYAML(w, report)
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		defer tw.Flush()
+		formatLicenseReport(tw, report)
+		return nil
+	}
+
+	if listing {
+		components := sbom.FilterComponents(bom, filter)
+		if err := sbom.SortComponents(components, inspectSort); err != nil {
+			return err
+		}
+		switch inspectFormat {
+		case "json":
+			return writeJSON(w, components)
+		case "yaml":
+			return writeComponentsYAML(w, components)
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		defer tw.Flush()
+		return formatComponentList(tw, components, inspectColumns)
+	}
+
+	switch inspectFormat {
+	case "json":
+		return writeJSON(w, sbom.SummarizeSBOM(bom, inputFile))
+	case "yaml":
+		return writeYAML(w, sbom.SummarizeSBOM(bom, inputFile))
+	}
+
+	// Create a tabwriter for formatted output
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	// Format and print the SBOM information
+	formatSBOMInfo(tw, bom, inputFile)
+	return nil
+}
+
+// writeComponentsYAML writes components as YAML with the keys and key order
+// of their JSON form, as they have no YAML tags
+func writeComponentsYAML(w io.Writer, components []cyclonedx.Component) error {
+	data, err := json.Marshal(components)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	clearYAMLStyle(&node)
+	return writeYAML(w, &node)
+}
+
+// clearYAMLStyle resets the flow and quoting style a node decoded from JSON
+// has, so it is written in block style
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
 	}
 }
 
@@ -149,31 +352,98 @@ var inspectCmd = &cobra.Command{
 	Short: "Inspect a SBOM file and show information about it",
 	Long: `Inspect a CycloneDX SBOM file and display useful information about it,
 such as the number of components, types of components, and other metadata.
-	
+Vulnerabilities are counted by the highest severity of their ratings and by
+analysis state, with the most affected components and the available fixes.
+CycloneDX JSON, XML and protobuf files as well as SPDX 2.3 JSON documents are
+supported, the format is detected from the file's content. SPDX documents are
+shown as converted to CycloneDX.
+
+With --format json or --format yaml the same information is printed in a
+machine-readable form, e.g. for dashboards. -o writes it to a file instead of
+stdout.
+
+With --all, or any of the filter, --sort or --columns flags, every component
+is listed instead, including nested components. The filters can be combined,
+a component has to match all of them:
+  --type          component types, e.g. library,framework
+  --purl-type     package URL types, e.g. npm,golang,maven
+  --name-regex    regular expression for group/name
+  --scope         scopes, components without scope count as required
+  --has-license   only components with license information, or without
+                  them with --has-license=false
+  --missing-purl  only components without package URL
+  --where         query expression, see the query command
+The listing is sorted by name, or by --sort version|type|purl. --columns
+selects the columns from: name, version, type, purl, group, supplier,
+licenses, hashes, bom-ref, scope. With --format json or yaml the components
+are written in full, like by query --format json, and --columns is ignored.
+
+--licenses shows a license report instead: the components grouped by the
+licenses in their SPDX license expressions, the components without license
+information, and the ones with invalid expressions. The filters apply to the
+report as well.
+
 Example:
-  sbomctl inspect sbom.json`,
+  sbomctl inspect sbom.json
+  sbomctl inspect sbom.cdx.xml
+  sbomctl inspect supplier.spdx.json
+  sbomctl inspect sbom.json --format json -o summary.json
+  sbomctl inspect sbom.json --all --purl-type golang --columns name,version,hashes
+  sbomctl inspect sbom.json --has-license=false --sort purl --format json
+  sbomctl inspect sbom.json --licenses --scope required`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get the input file from args
 		inputFile := args[0]
 
+		if inspectFormat != "text" && inspectFormat != "json" && inspectFormat != "yaml" {
+			return fmt.Errorf("unsupported format %q, must be one of: text, json, yaml", inspectFormat)
+		}
+
+		listing := inspectAll
+		for _, name := range []string{"type", "purl-type", "name-regex", "scope", "has-license", "missing-purl", "where", "sort", "columns"} {
+			listing = listing || cmd.Flags().Changed(name)
+		}
+		filter, err := inspectComponentFilter(cmd)
+		if err != nil {
+			return err
+		}
+
 		// Read the SBOM file
 		bom, err := sbom.ReadSBOMFile(inputFile)
 		if err != nil {
 			return fmt.Errorf("failed to read SBOM file: %w", err)
 		}
 
-		// Create a tabwriter for formatted output
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		defer w.Flush()
-
-		// Format and print the SBOM information
-		formatSBOMInfo(w, bom, inputFile)
-
+		var out bytes.Buffer
+		if err := writeInspection(&out, bom, inputFile, filter, listing); err != nil {
+			return err
+		}
+		if inspectOutputFile == "" {
+			_, err = cmd.OutOrStdout().Write(out.Bytes())
+			return err
+		}
+		if err := os.WriteFile(inspectOutputFile, out.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
 		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(inspectCmd)
+
+	inspectCmd.Flags().StringVar(&inspectFormat, "format", "text", "Output format (text, json, yaml)")
+	inspectCmd.Flags().StringVarP(&inspectOutputFile, "output", "o", "", "Output file (default stdout)")
+	inspectCmd.Flags().BoolVar(&inspectAll, "all", false, "List all components instead of a summary")
+	inspectCmd.Flags().StringSliceVar(&inspectTypes, "type", nil, "Only list components of these types")
+	inspectCmd.Flags().StringSliceVar(&inspectPurlTypes, "purl-type", nil, "Only list components with these package URL types, e.g. npm,golang")
+	inspectCmd.Flags().StringVar(&inspectNameRegex, "name-regex", "", "Only list components whose group/name matches this regular expression")
+	inspectCmd.Flags().StringSliceVar(&inspectScopes, "scope", nil, "Only list components with these scopes (required, optional, excluded)")
+	inspectCmd.Flags().BoolVar(&inspectHasLicense, "has-license", false, "Only list components with license information (=false for without)")
+	inspectCmd.Flags().BoolVar(&inspectMissingPurl, "missing-purl", false, "Only list components without package URL")
+	inspectCmd.Flags().StringVar(&inspectWhere, "where", "", "Only list components matching this query expression, see the query command")
+	inspectCmd.Flags().StringVar(&inspectSort, "sort", "name", "Sort the listed components by name, version, type or purl")
+	inspectCmd.Flags().BoolVar(&inspectLicenses, "licenses", false, "Show the components grouped by license instead of a summary")
+	inspectCmd.Flags().StringSliceVar(&inspectColumns, "columns", defaultComponentColumns, "Columns of the listing (name, version, type, purl, group, supplier, licenses, hashes, bom-ref, scope)")
 }
@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
 )
 
 func TestInspectCommand(t *testing.T) {
@@ -170,3 +172,199 @@ func TestInspectCommandNoComponents(t *testing.T) {
 		}
 	}
 }
+
+func TestInspectCommandXML(t *testing.T) {
+	output, err := executeCommand("inspect", filepath.Join("..", "testdata", "sbom3.xml"))
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+
+	for _, expected := range []string{"Spec Version:   1.5", "example-lib-4", "pkg:maven/org.example/example-lib-4@4.5.6"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', but it did not.\nOutput: %s", expected, output)
+		}
+	}
+}
+
+func TestInspectCommandMachineReadable(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom1.json")
+
+	output, err := executeCommand("inspect", inputFile, "--format", "json")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	var summary sbom.Summary
+	if err := json.Unmarshal([]byte(output), &summary); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v\nOutput: %s", err, output)
+	}
+	if summary.Components.Total != 2 || summary.Components.Top[0].Name != "example-lib-1" || summary.Dependencies.Total != 2 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	output, err = executeCommand("inspect", inputFile, "--format", "yaml")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	for _, expected := range []string{"specVersion: \"1.4\"", "total: 2", "- name: example-lib-1"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected YAML output to contain '%s', but it did not.\nOutput: %s", expected, output)
+		}
+	}
+
+	if _, err := executeCommand("inspect", inputFile, "--format", "xml"); err == nil {
+		t.Error("Expected inspect command to fail for an unknown output format")
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "summary.json")
+	output, err = executeCommand("inspect", inputFile, "--format", "json", "-o", outputFile)
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	if output != "" {
+		t.Errorf("Expected no output with -o, got:\n%s", output)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &summary); err != nil || summary.Components.Total != 2 {
+		t.Errorf("Expected the summary in the output file, got %v: %s", err, data)
+	}
+}
+
+func TestFormatComponentList(t *testing.T) {
+	components := []cyclonedx.Component{
+		{Name: "lodash", Version: "4.17.21", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}}},
+		{Name: "uuid", Version: "1.9.0"},
+	}
+
+	var buf bytes.Buffer
+	if err := formatComponentList(&buf, components, []string{"name", "licenses"}); err != nil {
+		t.Fatalf("formatComponentList failed: %v", err)
+	}
+	expected := "NAME\tLICENSES\nlodash\tMIT\nuuid\t\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected listing:\n%q\nexpected:\n%q", buf.String(), expected)
+	}
+
+	if err := formatComponentList(&buf, components, []string{"size"}); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+}
+
+func TestInspectCommandListing(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "merged.json")
+
+	output, err := executeCommand("inspect", inputFile, "--all", "--name-regex", "lib-2$", "--columns", "name,bom-ref")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[1], "example-lib-2") {
+		t.Errorf("Expected a header and both example-lib-2 components, got:\n%s", output)
+	}
+
+	output, err = executeCommand("inspect", inputFile, "--purl-type", "npm", "--sort", "version", "--format", "json")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	var components []cyclonedx.Component
+	if err := json.Unmarshal([]byte(output), &components); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v\nOutput: %s", err, output)
+	}
+	if len(components) == 0 || components[0].Name != "example-lib-1" || components[0].PackageURL == "" || components[len(components)-1].Name != "example-lib-3" {
+		t.Errorf("Unexpected components: %+v", components)
+	}
+	queryOutput, err := executeCommand("query", inputFile, "purl.type == npm", "--sort", "version", "--format", "json")
+	if err != nil {
+		t.Fatalf("query command failed: %v", err)
+	}
+	if output != queryOutput {
+		t.Errorf("Expected the same components as query --format json.\ninspect:\n%s\nquery:\n%s", output, queryOutput)
+	}
+
+	output, err = executeCommand("inspect", inputFile, "--name-regex", "lib-1$", "--format", "yaml")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	for _, expected := range []string{"- bom-ref: ", "  name: example-lib-1\n", "  purl: pkg:npm/example-lib-1@1.2.3\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected YAML output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	if _, err := executeCommand("inspect", inputFile, "--name-regex", "("); err == nil {
+		t.Error("Expected inspect command to fail for an invalid regex")
+	}
+}
+
+func TestInspectCommandLicenses(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+
+	output, err := executeCommand("inspect", inputFile, "--licenses")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	for _, expected := range []string{"Licenses:", "  MIT:  1", "    - pkg:npm/example-lib-3@3.4.5", "Components without license information:  0"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	output, err = executeCommand("inspect", inputFile, "--licenses", "--name-regex", "lib-5", "--format", "json")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	var report sbom.LicenseReport
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v\nOutput: %s", err, output)
+	}
+	if len(report.Licenses) != 1 || report.Licenses[0].Components[0] != "pkg:npm/example-lib-5@5.0.1" {
+		t.Errorf("Expected only the license of example-lib-5, got %+v", report)
+	}
+}
+
+func TestInspectCommandWhere(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+
+	output, err := executeCommand("inspect", inputFile, "--where", "len(dependents) > 0 and licenses == MIT", "--columns", "name")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	if output != "NAME\nexample-lib-3\n" {
+		t.Errorf("Unexpected listing:\n%s", output)
+	}
+
+	if _, err := executeCommand("inspect", inputFile, "--where", "name =="); err == nil {
+		t.Error("Expected inspect command to fail for an invalid query")
+	}
+}
+
+func TestInspectCommandVulnerabilities(t *testing.T) {
+	scannedFile := filepath.Join(t.TempDir(), "scanned.json")
+	if _, err := executeCommand("vuln", "scan", filepath.Join("..", "testdata", "sbom4.spdx.json"), "--db", filepath.Join("..", "testdata", "osv"), "--format", "bom", "--output-format", "json", "-o", scannedFile); err != nil {
+		t.Fatalf("vuln scan command failed: %v", err)
+	}
+
+	output, err := executeCommand("inspect", scannedFile)
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	for _, expected := range []string{"Total Vulnerabilities:  1", "- critical:", "- not_analyzed:", "pkg:npm/example-lib-3@3.4.5  1", "GHSA-xxxx-lib3-0001 (critical):  fixed in 3.4.6"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', but it did not.\nOutput: %s", expected, output)
+		}
+	}
+
+	output, err = executeCommand("inspect", scannedFile, "--format", "json")
+	if err != nil {
+		t.Fatalf("inspect command failed: %v", err)
+	}
+	var summary sbom.Summary
+	if err := json.Unmarshal([]byte(output), &summary); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v\nOutput: %s", err, output)
+	}
+	if summary.Vulnerabilities.Total != 1 || summary.Vulnerabilities.Severities["critical"] != 1 || len(summary.Vulnerabilities.Fixes) != 1 {
+		t.Errorf("Unexpected vulnerability summary: %+v", summary.Vulnerabilities)
+	}
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	licenseCheckPolicy     string
+	licenseCheckFormat     string
+	licenseCheckOutputFile string
+)
+
+// formatLicenseViolations writes the license check result of a file to the provided writer
+func formatLicenseViolations(w io.Writer, inputFile string, violations []sbom.LicenseViolation) {
+	if len(violations) == 0 {
+		fmt.Fprintf(w, "%s: all licenses comply with the policy\n", inputFile)
+		return
+	}
+
+	fmt.Fprintf(w, "%s: %d license violations\n", inputFile, len(violations))
+	for _, violation := range violations {
+		fmt.Fprintf(w, "  - %s", violation.Component)
+		if violation.License != "" {
+			fmt.Fprintf(w, " (%s)", violation.License)
+		}
+		fmt.Fprintf(w, ": %s\n", strings.Join(violation.Reasons, ", "))
+	}
+}
+
+// licenseCheckCmd represents the license-check command
+var licenseCheckCmd = &cobra.Command{
+	Use:   "license-check [sbom file]",
+	Short: "Check the licenses of an SBOM's components against a policy",
+	Long: `Check the licenses of all components of an SBOM file against a license policy
+and exit with a non-zero status if any component violates it.
+
+The policy is a YAML file with a list of allowed and a list of denied
+licenses. A license is allowed if it is not denied and, if there is an allow
+list, on it. Entries can name a license with an exception, which takes
+precedence over an entry for the license alone. Components without license
+information are violations unless allowUnlicensed is set:
+
+  allow:
+    - MIT
+    - Apache-2.0
+    - GPL-2.0-only WITH Classpath-exception-2.0
+  deny:
+    - GPL-2.0-only
+    - AGPL-3.0-only
+  allowUnlicensed: false
+
+The licenses of a component are read as SPDX license expressions. An OR
+expression complies if one of its licenses is allowed, an AND expression only
+if all of them are. Several licenses of a component all apply, as if joined
+with AND.
+
+The violations are printed as text, or as JSON with --format json, to stdout
+or with -o to a file.
+
+Example:
+  sbomctl license-check sbom.json --policy license-policy.yaml
+  sbomctl license-check sbom.json --policy license-policy.yaml --format json -o violations.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		if licenseCheckFormat != "text" && licenseCheckFormat != "json" {
+			return fmt.Errorf("unsupported format %q, must be one of: text, json", licenseCheckFormat)
+		}
+
+		policy, err := sbom.LoadLicensePolicy(licenseCheckPolicy)
+		if err != nil {
+			return fmt.Errorf("failed to load license policy %s: %w", licenseCheckPolicy, err)
+		}
+		bom, err := sbom.ReadSBOMFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		// Violations are not usage errors
+		cmd.SilenceUsage = true
+
+		violations := policy.CheckLicenses(sbom.FilterComponents(bom, sbom.ComponentFilter{}))
+		var out bytes.Buffer
+		if licenseCheckFormat == "json" {
+			if err := writeJSON(&out, violations); err != nil {
+				return err
+			}
+		} else {
+			formatLicenseViolations(&out, inputFile, violations)
+		}
+		if licenseCheckOutputFile == "" {
+			if _, err := cmd.OutOrStdout().Write(out.Bytes()); err != nil {
+				return err
+			}
+		} else if err := os.WriteFile(licenseCheckOutputFile, out.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+
+		if len(violations) > 0 {
+			return fmt.Errorf("%d components violate the license policy", len(violations))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(licenseCheckCmd)
+
+	licenseCheckCmd.Flags().StringVar(&licenseCheckPolicy, "policy", "", "License policy file (YAML)")
+	_ = licenseCheckCmd.MarkFlagRequired("policy")
+	licenseCheckCmd.Flags().StringVar(&licenseCheckFormat, "format", "text", "Output format (text, json)")
+	licenseCheckCmd.Flags().StringVarP(&licenseCheckOutputFile, "output", "o", "", "Output file (default stdout)")
+}
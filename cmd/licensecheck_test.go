@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestFormatLicenseViolations(t *testing.T) {
+	var buf bytes.Buffer
+	formatLicenseViolations(&buf, "sbom.json", []sbom.LicenseViolation{
+		{Component: "pkg:npm/a@1.0.0", License: "GPL-3.0-only OR EPL-2.0", Reasons: []string{"GPL-3.0-only is denied", "EPL-2.0 is not allowed"}},
+		{Component: "b@1.0.0", Reasons: []string{"no license information"}},
+	})
+
+	expected := `sbom.json: 2 license violations
+  - pkg:npm/a@1.0.0 (GPL-3.0-only OR EPL-2.0): GPL-3.0-only is denied, EPL-2.0 is not allowed
+  - b@1.0.0: no license information
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	formatLicenseViolations(&buf, "sbom.json", nil)
+	if buf.String() != "sbom.json: all licenses comply with the policy\n" {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+}
+
+func TestLicenseCheckCommand(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	writePolicy := func(content string) {
+		if err := os.WriteFile(policyFile, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write policy: %v", err)
+		}
+	}
+
+	// example-lib-3 is MIT OR Apache-2.0, example-lib-5 is proprietary
+	writePolicy("allow: [MIT]\n")
+	output, err := executeCommand("license-check", inputFile, "--policy", policyFile)
+	if err == nil {
+		t.Error("Expected license-check to fail for the proprietary license")
+	}
+	if !strings.Contains(output, "1 license violations") || !strings.Contains(output, "pkg:npm/example-lib-5@5.0.1") {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	writePolicy("deny: [Apache-2.0]\n")
+	output, err = executeCommand("license-check", inputFile, "--policy", policyFile, "--format", "json")
+	if err != nil {
+		t.Fatalf("Expected MIT to satisfy the policy, got %v\n%s", err, output)
+	}
+	var violations []sbom.LicenseViolation
+	if err := json.Unmarshal([]byte(output), &violations); err != nil || len(violations) != 0 {
+		t.Errorf("Expected an empty JSON list, got %s (%v)", output, err)
+	}
+
+	// The violations are written to the file before the command fails
+	writePolicy("allow: [MIT]\n")
+	outputFile := filepath.Join(t.TempDir(), "violations.json")
+	if _, err := executeCommand("license-check", inputFile, "--policy", policyFile, "--format", "json", "-o", outputFile); err == nil {
+		t.Error("Expected license-check to fail for the proprietary license")
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &violations); err != nil || len(violations) != 1 {
+		t.Errorf("Expected one violation in the output file, got %s (%v)", data, err)
+	}
+
+	if _, err := executeCommand("license-check", inputFile); err == nil {
+		t.Error("Expected license-check to fail without policy")
+	}
+}
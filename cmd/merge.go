@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"strings"
 
+	"github.com/CycloneDX/cyclonedx-go"
 	"github.com/j12934/sbomctl/pkg/sbom"
 	"github.com/spf13/cobra"
 )
@@ -11,27 +14,131 @@ var (
 	outputFile             string
 	mergedComponentName    string
 	mergedComponentVersion string
+	mergeStrict            bool
+	mergeRepair            bool
+	mergeStrategy          string
+	mergePrecedence        string
+	mergeReproducible      bool
+	mergeOutputFormat      string
+	mergeSpecVersion       string
 )
 
+// formatMergeReport writes the field conflicts, repairs, conversion losses and remaining integrity problems of a merge to the provided writer
+func formatMergeReport(w io.Writer, report *sbom.MergeReport) {
+	for _, conflict := range report.Conflicts {
+		fmt.Fprintf(w, "Conflict: %s %s: %s (chose %s)\n", conflict.Component, conflict.Field, strings.Join(conflict.Values, ", "), conflict.Chosen)
+	}
+	for _, repair := range report.Repairs {
+		fmt.Fprintf(w, "Repaired: %s\n", repair)
+	}
+	formatConversionLosses(w, report.ConversionLosses)
+	for _, integrityErr := range report.IntegrityErrors {
+		fmt.Fprintf(w, "Warning: %s\n", integrityErr.Error())
+	}
+}
+
 // mergeCmd represents the merge command
 var mergeCmd = &cobra.Command{
 	Use:   "merge [sbom files...]",
 	Short: "Merge multiple SBOM files into one",
 	Long: `Merge multiple CycloneDX SBOM files into a single SBOM file.
-	
+
+Inputs may be any mix of CycloneDX JSON, XML and protobuf files and SPDX 2.3
+JSON documents, the format of each file is detected from its content. The
+merged SBOM is written as JSON unless --output-format selects xml, proto or
+spdx-json. The merged SBOM uses CycloneDX 1.6 unless --spec-version selects
+another version, fields that do not exist in an older version are reported
+as lost. Writing proto fails if the merged SBOM has compositions,
+annotations, formulation, declarations or definitions, or components with
+pedigree, evidence or release notes, as the proto format does not cover them.
+
+Components, dependencies, tools, services, vulnerabilities, compositions,
+annotations, external references and formulation of all inputs are merged,
+with every bom-ref rewritten according to the merge strategy.
+
+Before the merged SBOM is written, its ref integrity is checked: every
+dependencies entry and dependsOn target must refer to an existing bom-ref.
+Problems are reported as warnings by default. With --strict the merge fails
+instead, with --repair dangling refs are rewritten or dropped.
+
+The --strategy option selects how components are merged:
+  prefix        prefix every bom-ref with the serial number of its input (default)
+  purl          merge components with identical package URLs into one component
+  hierarchical  nest each input's components under its metadata component
+
+Duplicate components are combined into one: hashes, licenses, external
+references and properties of all duplicates are kept. When duplicates
+disagree on a single-valued field such as the supplier or a hash of the same
+algorithm, --precedence decides which value wins:
+  first          the value of the input given first (default)
+  last           the value of the input given last
+  most-complete  the value of the duplicate with the most fields set
+Every disagreement is reported as a conflict.
+
+With --reproducible identical inputs result in byte-identical output: the
+serial number is derived from the inputs' content, components, dependencies
+and tools are sorted and the timestamp is taken from SOURCE_DATE_EPOCH.
+
 Example:
-  sbomctl merge sbom1.sbom.json sbom2.sbom.json -o merged.sbom.json`,
+  sbomctl merge sbom1.sbom.json sbom2.sbom.json -o merged.sbom.json
+  sbomctl merge sbom1.sbom.json sbom2.sbom.json --repair
+  sbomctl merge sbom1.sbom.json vendor.cdx.xml --output-format xml -o merged.cdx.xml
+  sbomctl merge sbom1.sbom.json sbom2.sbom.json --spec-version 1.4
+  sbomctl merge sbom1.sbom.json sbom2.sbom.json --strategy purl
+  sbomctl merge sbom1.sbom.json sbom2.sbom.json --strategy purl --precedence most-complete
+  SOURCE_DATE_EPOCH=$(git log -1 --format=%ct) sbomctl merge sbom1.sbom.json sbom2.sbom.json --reproducible`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get the input files from args
 		inputFiles := args
 
+		integrity := sbom.IntegrityModeWarn
+		if mergeStrict {
+			integrity = sbom.IntegrityModeStrict
+		} else if mergeRepair {
+			integrity = sbom.IntegrityModeRepair
+		}
+
+		strategy, err := sbom.MergeStrategyByName(mergeStrategy)
+		if err != nil {
+			return err
+		}
+
+		precedence, err := sbom.ParsePrecedence(mergePrecedence)
+		if err != nil {
+			return err
+		}
+
+		outputFormat, err := sbom.ParseFileFormat(mergeOutputFormat)
+		if err != nil {
+			return err
+		}
+
+		var specVersion cyclonedx.SpecVersion
+		if mergeSpecVersion != "" {
+			specVersion, err = sbom.ParseSpecVersion(mergeSpecVersion)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Merge the SBOM files
-		err := sbom.MergeSBOMs(inputFiles, outputFile, mergedComponentName, mergedComponentVersion)
+		report, err := sbom.MergeSBOMsWithOptions(inputFiles, outputFile, sbom.MergeOptions{
+			ComponentName:    mergedComponentName,
+			ComponentVersion: mergedComponentVersion,
+			Integrity:        integrity,
+			Strategy:         strategy,
+			Precedence:       precedence,
+			Reproducible:     mergeReproducible,
+			OutputFormat:     outputFormat,
+			SpecVersion:      specVersion,
+		})
 		if err != nil {
 			return fmt.Errorf("failed to merge SBOM files: %w", err)
 		}
 
+		formatMergeReport(cmd.ErrOrStderr(), report)
+
 		fmt.Printf("Successfully merged %d SBOM files into %s\n", len(inputFiles), outputFile)
 		return nil
 	},
@@ -44,4 +151,12 @@ func init() {
 	mergeCmd.Flags().StringVarP(&outputFile, "output", "o", "merged.sbom.json", "Output file for the merged SBOM")
 	mergeCmd.Flags().StringVar(&mergedComponentName, "merged-component-name", "merged-sbom", "Name for the component in the merged SBOM's metadata")
 	mergeCmd.Flags().StringVar(&mergedComponentVersion, "merged-component-version", "", "Version for the component in the merged SBOM's metadata")
+	mergeCmd.Flags().BoolVar(&mergeStrict, "strict", false, "Fail if the merged SBOM has dangling or duplicate refs")
+	mergeCmd.Flags().BoolVar(&mergeRepair, "repair", false, "Rewrite or drop dangling refs in the merged SBOM and report the changes")
+	mergeCmd.MarkFlagsMutuallyExclusive("strict", "repair")
+	mergeCmd.Flags().StringVar(&mergeStrategy, "strategy", "prefix", "Merge strategy (prefix, purl, hierarchical)")
+	mergeCmd.Flags().StringVar(&mergePrecedence, "precedence", "first", "Which value wins when duplicate components disagree (first, last, most-complete)")
+	mergeCmd.Flags().StringVar(&mergeOutputFormat, "output-format", "json", "Format of the merged SBOM (json, xml, proto, spdx-json)")
+	mergeCmd.Flags().StringVar(&mergeSpecVersion, "spec-version", "", "CycloneDX spec version of the merged SBOM (1.0 to 1.6, default 1.6)")
+	mergeCmd.Flags().BoolVar(&mergeReproducible, "reproducible", false, "Produce byte-identical output for identical inputs, using SOURCE_DATE_EPOCH as timestamp")
 }
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
 )
 
 func TestMergeCommand_Basic(t *testing.T) {
@@ -35,3 +39,258 @@ func TestMergeCommand_Basic(t *testing.T) {
 		t.Errorf("output file is empty")
 	}
 }
+
+func TestMergeCommand_StrictAndRepairAreExclusive(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	outputFile := filepath.Join(t.TempDir(), "merged.json")
+
+	_, err := executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom2.json"),
+		"-o",
+		outputFile,
+		"--strict",
+		"--repair",
+	)
+	if err == nil {
+		t.Fatal("Expected merge command to reject --strict together with --repair")
+	}
+}
+
+func TestMergeCommand_Strict(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	outputFile := filepath.Join(t.TempDir(), "merged.json")
+
+	// The test data has consistent refs, so the strict merge must succeed
+	_, err := executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom2.json"),
+		"-o",
+		outputFile,
+		"--strict",
+	)
+	if err != nil {
+		t.Fatalf("merge command failed: %v", err)
+	}
+}
+
+func TestMergeCommand_Strategy(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	outputFile := filepath.Join(t.TempDir(), "merged.json")
+
+	for _, strategy := range []string{"prefix", "purl", "hierarchical"} {
+		_, err := executeCommand(
+			"merge",
+			filepath.Join(testdataDir, "sbom1.json"),
+			filepath.Join(testdataDir, "sbom2.json"),
+			"-o",
+			outputFile,
+			"--strategy",
+			strategy,
+		)
+		if err != nil {
+			t.Errorf("merge command with strategy %s failed: %v", strategy, err)
+		}
+	}
+
+	_, err := executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom2.json"),
+		"-o",
+		outputFile,
+		"--strategy",
+		"unknown",
+	)
+	if err == nil {
+		t.Error("Expected merge command to fail for an unknown strategy")
+	}
+}
+
+func TestMergeCommand_Precedence(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	outputFile := filepath.Join(t.TempDir(), "merged.json")
+
+	_, err := executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom2.json"),
+		"-o",
+		outputFile,
+		"--precedence",
+		"most-complete",
+	)
+	if err != nil {
+		t.Fatalf("merge command with precedence most-complete failed: %v", err)
+	}
+
+	_, err = executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom2.json"),
+		"-o",
+		outputFile,
+		"--precedence",
+		"random",
+	)
+	if err == nil {
+		t.Error("Expected merge command to fail for an unknown precedence")
+	}
+}
+
+func TestFormatMergeReport(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	formatMergeReport(&outputBuffer, &sbom.MergeReport{
+		Conflicts: []sbom.FieldConflict{
+			{Component: "pkg:npm/lodash@4.17.21", Field: "hashes[SHA-256]", Values: []string{"aaaa", "bbbb"}, Chosen: "aaaa"},
+		},
+	})
+
+	expected := "Conflict: pkg:npm/lodash@4.17.21 hashes[SHA-256]: aaaa, bbbb (chose aaaa)\n"
+	if outputBuffer.String() != expected {
+		t.Errorf("Unexpected output.\nExpected: %q\nGot: %q", expected, outputBuffer.String())
+	}
+}
+
+func TestMergeCommand_Reproducible(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	testDir := t.TempDir()
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	var outputs [][]byte
+	for _, name := range []string{"first.json", "second.json"} {
+		outputFile := filepath.Join(testDir, name)
+		_, err := executeCommand(
+			"merge",
+			filepath.Join(testdataDir, "sbom1.json"),
+			filepath.Join(testdataDir, "sbom2.json"),
+			"-o",
+			outputFile,
+			"--reproducible",
+		)
+		if err != nil {
+			t.Fatalf("merge command failed: %v", err)
+		}
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("output file not created: %v", err)
+		}
+		outputs = append(outputs, data)
+	}
+
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Error("Expected reproducible merges to be byte-identical")
+	}
+}
+
+func TestMergeCommand_MixedFormats(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	outputFile := filepath.Join(t.TempDir(), "merged.xml")
+
+	_, err := executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom3.xml"),
+		"-o",
+		outputFile,
+		"--output-format",
+		"xml",
+	)
+	if err != nil {
+		t.Fatalf("merge command failed: %v", err)
+	}
+
+	mergedBom, err := sbom.ReadSBOMFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	if mergedBom.Components == nil || len(*mergedBom.Components) != 3 {
+		t.Errorf("Expected 3 components in the merged SBOM, got %+v", mergedBom.Components)
+	}
+
+	_, err = executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom3.xml"),
+		"-o",
+		outputFile,
+		"--output-format",
+		"yaml",
+	)
+	if err == nil {
+		t.Error("Expected merge command to fail for an unknown output format")
+	}
+}
+
+func TestMergeCommand_SpecVersion(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	outputFile := filepath.Join(t.TempDir(), "merged.json")
+
+	_, err := executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom2.json"),
+		"-o",
+		outputFile,
+		"--spec-version",
+		"1.4",
+	)
+	if err != nil {
+		t.Fatalf("merge command failed: %v", err)
+	}
+
+	mergedBom, err := sbom.ReadSBOMFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	if mergedBom.SpecVersion != cyclonedx.SpecVersion1_4 {
+		t.Errorf("Expected spec version 1.4, got %s", mergedBom.SpecVersion)
+	}
+
+	_, err = executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom2.json"),
+		"-o",
+		outputFile,
+		"--spec-version",
+		"1.7",
+	)
+	if err == nil {
+		t.Error("Expected merge command to fail for an unknown spec version")
+	}
+}
+
+func TestMergeCommand_SPDXInput(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	outputFile := filepath.Join(t.TempDir(), "merged.json")
+
+	_, err := executeCommand(
+		"merge",
+		filepath.Join(testdataDir, "sbom1.json"),
+		filepath.Join(testdataDir, "sbom4.spdx.json"),
+		"-o",
+		outputFile,
+		"--strategy",
+		"purl",
+	)
+	if err != nil {
+		t.Fatalf("merge command failed: %v", err)
+	}
+
+	mergedBom, err := sbom.ReadSBOMFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, component := range *mergedBom.Components {
+		names[component.Name] = true
+	}
+	for _, name := range []string{"example-lib-1", "example-lib-2", "example-app", "example-lib-3", "example-lib-5"} {
+		if !names[name] {
+			t.Errorf("Expected %s in the merged SBOM, got %+v", name, names)
+		}
+	}
+}
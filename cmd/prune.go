@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneScopes       []string
+	pruneTypes        []string
+	prunePurls        []string
+	pruneWhere        string
+	pruneUnreachable  bool
+	pruneSplice       bool
+	pruneOutputFile   string
+	pruneOutputFormat string
+)
+
+// formatPruneReport writes what was removed from an SBOM to the provided writer
+func formatPruneReport(w io.Writer, report *sbom.PruneReport) {
+	fmt.Fprintf(w, "Removed %d components\n", len(report.Removed))
+	for _, component := range report.Removed {
+		fmt.Fprintf(w, "  - %s\n", component)
+	}
+	if report.DroppedEdges > 0 {
+		fmt.Fprintf(w, "Dropped %d dependency edges\n", report.DroppedEdges)
+	}
+	if report.SplicedEdges > 0 {
+		fmt.Fprintf(w, "Spliced %d dependency edges through removed components\n", report.SplicedEdges)
+	}
+}
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:     "prune [sbom file]",
+	Aliases: []string{"filter"},
+	Short:   "Remove components from an SBOM file",
+	Long: `Remove components from an SBOM file, e.g. test and development components
+from an SBOM that is shipped to customers. A component is removed if it
+matches any of the given criteria:
+  --scope        scopes, e.g. excluded,optional
+  --type         component types, e.g. file,data
+  --purl         package URL glob patterns, * matches any characters
+                 including /, e.g. 'pkg:npm/@types/*'
+  --where        query expression, see the query command
+  --unreachable  components that cannot be reached from the metadata
+                 component through the dependencies once the other
+                 components are removed, e.g. what only dev dependencies
+                 pulled in
+Nested components are removed together with their parent.
+
+The dependencies stay consistent: entries of and edges to components that
+no longer exist are dropped. With --splice, dependents of a removed
+component depend on its dependencies instead, so transitive relationships
+are preserved. Vulnerabilities that only affected removed components are
+dropped as well.
+
+The pruned SBOM gets a new serial number and is written to stdout unless
+--output is given, in the input's format unless --output-format is given.
+The removed components are listed on stderr.
+
+Example:
+  sbomctl prune sbom.json --scope excluded,optional -o customer.cdx.json
+  sbomctl prune sbom.json --purl 'pkg:npm/@types/*' --purl 'pkg:maven/org.junit*' --splice
+  sbomctl prune sbom.json --where 'properties.cdx:npm:package:development == true' --unreachable`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		opts := sbom.PruneOptions{
+			PurlPatterns: prunePurls,
+			Unreachable:  pruneUnreachable,
+			Splice:       pruneSplice,
+		}
+		for _, scope := range pruneScopes {
+			switch s := cyclonedx.Scope(scope); s {
+			case cyclonedx.ScopeRequired, cyclonedx.ScopeOptional, cyclonedx.ScopeExcluded:
+				opts.Scopes = append(opts.Scopes, s)
+			default:
+				return fmt.Errorf("unknown scope %q, must be one of: required, optional, excluded", scope)
+			}
+		}
+		for _, t := range pruneTypes {
+			opts.Types = append(opts.Types, cyclonedx.ComponentType(t))
+		}
+		if pruneWhere != "" {
+			query, err := sbom.CompileQuery(pruneWhere)
+			if err != nil {
+				return err
+			}
+			opts.Where = query
+		}
+		if len(opts.Scopes) == 0 && len(opts.Types) == 0 && len(opts.PurlPatterns) == 0 && opts.Where == nil && !opts.Unreachable {
+			return fmt.Errorf("no components selected, use --scope, --type, --purl, --where or --unreachable")
+		}
+
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		inputFormat := sbom.DetectFileFormat(data)
+		bom, err := sbom.DecodeSBOM(data, inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		outputFormat := inputFormat
+		if pruneOutputFormat != "" {
+			if outputFormat, err = sbom.ParseFileFormat(pruneOutputFormat); err != nil {
+				return err
+			}
+		}
+
+		pruned, report, err := sbom.PruneBOM(bom, opts)
+		if err != nil {
+			return fmt.Errorf("failed to prune SBOM file %s: %w", inputFile, err)
+		}
+		encoded, err := sbom.EncodeSBOM(pruned, outputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to encode SBOM: %w", err)
+		}
+
+		formatPruneReport(cmd.ErrOrStderr(), report)
+
+		if pruneOutputFile == "" {
+			_, err = cmd.OutOrStdout().Write(encoded)
+			return err
+		}
+		if err := os.WriteFile(pruneOutputFile, encoded, 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().StringSliceVar(&pruneScopes, "scope", nil, "Remove components with these scopes (required, optional, excluded)")
+	pruneCmd.Flags().StringSliceVar(&pruneTypes, "type", nil, "Remove components of these types")
+	pruneCmd.Flags().StringArrayVar(&prunePurls, "purl", nil, "Remove components whose package URL matches this glob pattern (repeatable)")
+	pruneCmd.Flags().StringVar(&pruneWhere, "where", "", "Remove components matching this query expression, see the query command")
+	pruneCmd.Flags().BoolVar(&pruneUnreachable, "unreachable", false, "Remove components that cannot be reached from the metadata component")
+	pruneCmd.Flags().BoolVar(&pruneSplice, "splice", false, "Connect the dependents of removed components to their dependencies")
+	pruneCmd.Flags().StringVarP(&pruneOutputFile, "output", "o", "", "Output file for the pruned SBOM (default stdout)")
+	pruneCmd.Flags().StringVar(&pruneOutputFormat, "output-format", "", "Format of the pruned SBOM (json, xml, proto, spdx-json, default the input's format)")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestPruneCommand(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+	outputFile := filepath.Join(t.TempDir(), "pruned.json")
+
+	output, err := executeCommand("filter", inputFile, "--purl", "pkg:npm/example-lib-3*", "--splice", "--output-format", "json", "-o", outputFile)
+	if err != nil {
+		t.Fatalf("prune command failed: %v", err)
+	}
+	if output != "" {
+		t.Errorf("Expected no output on stdout with --output, got:\n%s", output)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	bom, err := sbom.DecodeSBOM(data, sbom.FileFormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if len(*bom.Components) != 1 || (*bom.Components)[0].Name != "example-lib-5" {
+		t.Errorf("Unexpected components: %+v", *bom.Components)
+	}
+	if errs := sbom.CheckRefIntegrity(bom); len(errs) != 0 {
+		t.Errorf("Expected consistent refs, got %v", errs)
+	}
+
+	if _, err := executeCommand("prune", inputFile); err == nil {
+		t.Error("Expected prune command to fail without criteria")
+	}
+	if _, err := executeCommand("prune", inputFile, "--scope", "dev"); err == nil {
+		t.Error("Expected prune command to fail for an unknown scope")
+	}
+}
+
+func TestFormatPruneReport(t *testing.T) {
+	var buf bytes.Buffer
+	formatPruneReport(&buf, &sbom.PruneReport{Removed: []string{"pkg:npm/mocha@10.0.0"}, DroppedEdges: 2})
+
+	expected := "Removed 1 components\n  - pkg:npm/mocha@10.0.0\nDropped 2 dependency edges\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
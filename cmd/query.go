@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryFormat       string
+	queryColumns      []string
+	querySort         string
+	queryOutputFile   string
+	queryOutputFormat string
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query [sbom file] [expression]",
+	Short: "Find the components of an SBOM file matching an expression",
+	Long: `Find the components of an SBOM file, including nested ones, that match a
+query expression, like all golang libraries without hashes and supplier:
+
+  purl.type == golang and type == library and empty(hashes) and empty(supplier)
+
+Comparisons have the form "field operator value":
+  ==, !=          equal, not equal
+  =~, !~          matches, does not match a regular expression
+  <, <=, >, >=    compare numbers and versions, 1.10.0 > 1.9.0
+  in [a, b]       equal to one of the values
+Values are bare words or quoted strings. Comparisons are combined with and,
+or, not and parentheses. empty(field) and has(field) test whether a field is
+set, len(field) compares the number of values of a field.
+
+Fields:
+  name, version, type, group, description, publisher, author, supplier, cpe,
+  bom-ref, scope, purl, purl.type, purl.namespace, purl.name
+  licenses            the licenses in the component's license expressions
+  hashes              the algorithms of the component's hashes
+  properties.<name>   the values of the properties with that name
+  dependencies        the package URLs (or name@version) of direct dependencies
+  dependents          the package URLs (or name@version) of direct dependents
+A field with several values matches a comparison if any value does, and !=
+or !~ if none does.
+
+The matches are printed as a table (default) with --columns like inspect,
+as JSON array of the CycloneDX components (--format json), or as a new SBOM
+with only the matching components (--format bom). The new SBOM keeps the
+metadata and drops the dependencies on the other components. It is written
+in the input's format unless --output-format is given.
+
+Example:
+  sbomctl query sbom.json 'purl.type == golang and empty(hashes)'
+  sbomctl query sbom.json 'licenses =~ "^GPL-" or empty(licenses)' --columns name,version,licenses
+  sbomctl query sbom.json 'len(dependents) > 5' --sort purl --format json
+  sbomctl query sbom.json 'scope != excluded' --format bom -o release.cdx.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		if queryFormat != "table" && queryFormat != "json" && queryFormat != "bom" {
+			return fmt.Errorf("unsupported format %q, must be one of: table, json, bom", queryFormat)
+		}
+		var outputFormat sbom.FileFormat
+		if queryOutputFormat != "" {
+			var err error
+			if outputFormat, err = sbom.ParseFileFormat(queryOutputFormat); err != nil {
+				return err
+			}
+		}
+
+		// Invalid expressions and SBOMs are not usage errors
+		cmd.SilenceUsage = true
+
+		query, err := sbom.CompileQuery(args[1])
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		inputFormat := sbom.DetectFileFormat(data)
+		bom, err := sbom.DecodeSBOM(data, inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		var out bytes.Buffer
+		switch queryFormat {
+		case "bom":
+			if outputFormat == "" {
+				outputFormat = inputFormat
+			}
+			g := sbom.NewGraph(bom)
+			subset := sbom.SubsetBOM(bom, func(c *cyclonedx.Component) bool { return query.Match(c, g) })
+			encoded, err := sbom.EncodeSBOM(subset, outputFormat)
+			if err != nil {
+				return fmt.Errorf("failed to encode SBOM: %w", err)
+			}
+			out.Write(encoded)
+		default:
+			components := sbom.FilterComponents(bom, sbom.ComponentFilter{Where: query})
+			if err := sbom.SortComponents(components, querySort); err != nil {
+				return err
+			}
+			if queryFormat == "json" {
+				if err := writeJSON(&out, components); err != nil {
+					return err
+				}
+				break
+			}
+			w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+			if err := formatComponentList(w, components, queryColumns); err != nil {
+				return err
+			}
+			w.Flush()
+		}
+
+		if queryOutputFile == "" {
+			_, err = cmd.OutOrStdout().Write(out.Bytes())
+			return err
+		}
+		if err := os.WriteFile(queryOutputFile, out.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringVar(&queryFormat, "format", "table", "Result format (table, json, bom)")
+	queryCmd.Flags().StringSliceVar(&queryColumns, "columns", defaultComponentColumns, "Columns of the table (name, version, type, purl, group, supplier, licenses, hashes, bom-ref, scope)")
+	queryCmd.Flags().StringVar(&querySort, "sort", "name", "Sort the matches by name, version, type or purl (table and json)")
+	queryCmd.Flags().StringVarP(&queryOutputFile, "output", "o", "", "Output file (default stdout)")
+	queryCmd.Flags().StringVar(&queryOutputFormat, "output-format", "", "Format of the SBOM written with --format bom (json, xml, proto, spdx-json, default the input's format)")
+}
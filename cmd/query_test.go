@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestQueryCommand(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "merged.json")
+
+	output, err := executeCommand("query", inputFile, "name == example-lib-2", "--columns", "name,bom-ref")
+	if err != nil {
+		t.Fatalf("query command failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[2], "example-lib-2") {
+		t.Errorf("Expected a header and both example-lib-2 components, got:\n%s", output)
+	}
+
+	output, err = executeCommand("query", inputFile, "version < 3", "--format", "json", "--sort", "version")
+	if err != nil {
+		t.Fatalf("query command failed: %v", err)
+	}
+	var components []cyclonedx.Component
+	if err := json.Unmarshal([]byte(output), &components); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v\nOutput: %s", err, output)
+	}
+	if len(components) != 3 || components[0].Name != "example-lib-1" || components[0].PackageURL == "" {
+		t.Errorf("Unexpected components: %+v", components)
+	}
+
+	output, err = executeCommand("query", inputFile, "version <")
+	if err == nil {
+		t.Error("Expected query command to fail for an invalid expression")
+	}
+	if strings.Contains(output, "Usage:") {
+		t.Errorf("Expected no usage for an invalid expression, got:\n%s", output)
+	}
+}
+
+func TestQueryCommandBOM(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "merged.json")
+	outputFile := filepath.Join(t.TempDir(), "subset.xml")
+
+	if _, err := executeCommand("query", inputFile, "name != example-lib-3", "--format", "bom", "--output-format", "xml", "-o", outputFile); err != nil {
+		t.Fatalf("query command failed: %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if sbom.DetectFileFormat(data) != sbom.FileFormatXML {
+		t.Errorf("Expected an XML SBOM, got:\n%s", data)
+	}
+	bom, err := sbom.DecodeSBOM(data, sbom.FileFormatXML)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if len(*bom.Components) != 3 {
+		t.Errorf("Expected 3 components, got %d", len(*bom.Components))
+	}
+	if errs := sbom.CheckRefIntegrity(bom); len(errs) > 0 {
+		t.Errorf("Expected no dangling refs, got %v", errs)
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// executeCommand runs the root command with the given args and returns its
+// standard output. The flags set on the command, by the args or by a test
+// that ran it directly, are reset before and after, so state does not leak
+// between tests.
+func executeCommand(args ...string) (string, error) {
+	if cmd, _, err := rootCmd.Find(args); err == nil {
+		resetChangedFlags(cmd)
+	}
+
+	var outputBuffer bytes.Buffer
+	rootCmd.SetOut(&outputBuffer)
+	rootCmd.SetErr(&bytes.Buffer{})
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+
+	rootCmd.SetArgs(args)
+	cmd, err := rootCmd.ExecuteC()
+	if cmd != nil {
+		resetChangedFlags(cmd)
+	}
+	return outputBuffer.String(), err
+}
+
+// resetChangedFlags resets the flags of a command that were set
+func resetChangedFlags(cmd *cobra.Command) {
+	var changed []*pflag.Flag
+	cmd.Flags().Visit(func(flag *pflag.Flag) { changed = append(changed, flag) })
+	for _, flag := range changed {
+		// Setting a slice flag again appends to it, so it is cleared
+		// instead, which the commands treat like its default
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			_ = sliceValue.Replace(nil)
+		} else {
+			_ = cmd.Flags().Set(flag.Name, flag.DefValue)
+		}
+		flag.Changed = false
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	splitBy           string
+	splitOutputDir    string
+	splitOutputFormat string
+)
+
+// unsafeFileNameChars matches the characters replaced in the names of split SBOM files
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// splitFileExtensions are the extensions of the split SBOM files by format
+var splitFileExtensions = map[sbom.FileFormat]string{
+	sbom.FileFormatJSON:     ".cdx.json",
+	sbom.FileFormatXML:      ".cdx.xml",
+	sbom.FileFormatProto:    ".cdx.pb",
+	sbom.FileFormatSPDXJSON: ".spdx.json",
+}
+
+// splitFileNames returns a unique file name for every part
+func splitFileNames(parts []sbom.SplitPart, format sbom.FileFormat) []string {
+	names := make([]string, 0, len(parts))
+	seen := make(map[string]int)
+	for i, part := range parts {
+		name := unsafeFileNameChars.ReplaceAllString(part.Name, "_")
+		if name == "" {
+			name = fmt.Sprintf("part-%d", i+1)
+		}
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, seen[name])
+		}
+		names = append(names, name+splitFileExtensions[format])
+	}
+	return names
+}
+
+// splitCmd represents the split command
+var splitCmd = &cobra.Command{
+	Use:   "split [merged sbom file]",
+	Short: "Split a merged SBOM file back into per-source SBOM files",
+	Long: `Split an SBOM file created by merge back into one SBOM file per source.
+
+The --by option selects how the components are divided:
+  serial     one SBOM per serial number the bom-refs were prefixed with by the
+             prefix and hierarchical merge strategies (default). Each SBOM gets
+             back its original serial number, and the component the merged
+             metadata component depends on becomes its metadata component.
+  component  one SBOM per top-level component no other component depends
+             on, which becomes its metadata component. Use this for SBOMs
+             merged with the purl strategy or not merged by sbomctl at all.
+Components shared by several sources, like the ones the purl strategy
+merged, are copied into every SBOM whose components depend on them. The
+nested components of the metadata component become top-level components
+again, as they were before a hierarchical merge.
+
+The serial number prefix is stripped from all bom-refs. Dependencies,
+vulnerabilities, services and the other sections only keep the entries that
+belong to the source. Components that end up in no SBOM are reported.
+
+The SBOM files are written to --output-dir, named after their metadata
+component or serial number, in the input's format unless --output-format is
+given.
+
+Example:
+  sbomctl split merged.cdx.json -d services/
+  sbomctl split merged.cdx.json --by component -d services/ --output-format xml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		mode, err := sbom.ParseSplitMode(splitBy)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		inputFormat := sbom.DetectFileFormat(data)
+		bom, err := sbom.DecodeSBOM(data, inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		outputFormat := inputFormat
+		if splitOutputFormat != "" {
+			if outputFormat, err = sbom.ParseFileFormat(splitOutputFormat); err != nil {
+				return err
+			}
+		}
+
+		cmd.SilenceUsage = true
+
+		parts, unassigned, err := sbom.SplitBOM(bom, mode)
+		if err != nil {
+			return fmt.Errorf("failed to split SBOM file %s: %w", inputFile, err)
+		}
+
+		if err := os.MkdirAll(splitOutputDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		for i, name := range splitFileNames(parts, outputFormat) {
+			encoded, err := sbom.EncodeSBOM(parts[i].BOM, outputFormat)
+			if err != nil {
+				return fmt.Errorf("failed to encode SBOM %s: %w", name, err)
+			}
+			path := filepath.Join(splitOutputDir, name)
+			if err := os.WriteFile(path, encoded, 0o644); err != nil {
+				return fmt.Errorf("failed to write output file: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+		}
+
+		for _, component := range unassigned {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s belongs to no source\n", component)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(splitCmd)
+
+	splitCmd.Flags().StringVar(&splitBy, "by", "serial", "Divide the components by serial number prefix or top-level component (serial, component)")
+	splitCmd.Flags().StringVarP(&splitOutputDir, "output-dir", "d", "", "Directory to write the split SBOM files to")
+	splitCmd.Flags().StringVar(&splitOutputFormat, "output-format", "", "Format of the split SBOM files (json, xml, proto, spdx-json, default the input's format)")
+	_ = splitCmd.MarkFlagRequired("output-dir")
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestSplitCommand(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "merged.json")
+	outputDir := filepath.Join(t.TempDir(), "split")
+
+	output, err := executeCommand("split", inputFile, "-d", outputDir, "--output-format", "xml")
+	if err != nil {
+		t.Fatalf("split command failed: %v", err)
+	}
+	paths := strings.Fields(output)
+	expected := []string{
+		filepath.Join(outputDir, "3e671687-395b-41f5-a30f-a58921a69b79.cdx.xml"),
+		filepath.Join(outputDir, "4f782798-486a-52e6-b40f-b59032a70b80.cdx.xml"),
+	}
+	if !slices.Equal(paths, expected) {
+		t.Fatalf("Expected %v, got:\n%s", expected, output)
+	}
+
+	data, err := os.ReadFile(paths[1])
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	bom, err := sbom.DecodeSBOM(data, sbom.FileFormatXML)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if bom.SerialNumber != "urn:uuid:4f782798-486a-52e6-b40f-b59032a70b80" || len(*bom.Components) != 2 || (*bom.Components)[0].BOMRef != "pkg:npm/example-lib-2@2.3.4" {
+		t.Errorf("Unexpected split SBOM: %+v", bom)
+	}
+
+	if _, err := executeCommand("split", inputFile, "-d", outputDir, "--by", "service"); err == nil {
+		t.Error("Expected split command to fail for an unknown mode")
+	}
+	if _, err := executeCommand("split", filepath.Join("..", "testdata", "sbom1.json"), "-d", outputDir); err == nil {
+		t.Error("Expected split command to fail for an SBOM without prefixed refs")
+	}
+}
+
+func TestSplitFileNames(t *testing.T) {
+	parts := []sbom.SplitPart{{Name: "@scope/app-1.0.0"}, {Name: "@scope/app-1.0.0"}, {Name: ""}}
+	names := splitFileNames(parts, sbom.FileFormatJSON)
+	expected := []string{"_scope_app-1.0.0.cdx.json", "_scope_app-1.0.0-2.cdx.json", "part-3.cdx.json"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
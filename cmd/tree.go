@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	treeDepth   int
+	treeReverse string
+)
+
+// formatTree writes the dependency tree below the given nodes to the provided
+// writer. With reverse set, the tree shows the dependents of the nodes
+// instead, i.e. every path from the roots to the nodes. Subtrees that were
+// already printed are marked with (*), refs that close a cycle with (cycle).
+func formatTree(w io.Writer, g *sbom.Graph, roots []string, reverse bool, depth int) {
+	children := g.DependsOn
+	if reverse {
+		children = g.Dependents
+	}
+
+	// walk prints the children of ref and reports whether it did, which it
+	// does not at the depth limit
+	expanded := make(map[string]bool)
+	var walk func(ref, prefix string, path []string) bool
+	walk = func(ref, prefix string, path []string) bool {
+		next := children(ref)
+		if depth > 0 && len(path) >= depth {
+			return false
+		}
+		path = append(path, ref)
+		for i, child := range next {
+			branch, indent := "├── ", "│   "
+			if i == len(next)-1 {
+				branch, indent = "└── ", "    "
+			}
+
+			switch {
+			case slices.Contains(path, child):
+				fmt.Fprintf(w, "%s%s%s (cycle)\n", prefix, branch, g.Label(child))
+			case expanded[child] && len(children(child)) > 0:
+				fmt.Fprintf(w, "%s%s%s (*)\n", prefix, branch, g.Label(child))
+			default:
+				fmt.Fprintf(w, "%s%s%s\n", prefix, branch, g.Label(child))
+				// A child cut off by the depth limit is printed in full if it occurs again
+				if walk(child, prefix+indent, path) {
+					expanded[child] = true
+				}
+			}
+		}
+		return true
+	}
+
+	for _, root := range roots {
+		fmt.Fprintln(w, g.Label(root))
+		expanded[root] = walk(root, "", nil)
+	}
+}
+
+// treeCmd represents the tree command
+var treeCmd = &cobra.Command{
+	Use:   "tree [sbom file]",
+	Short: "Show the dependency tree of an SBOM file",
+	Long: `Show the dependencies of an SBOM file as an indented tree, starting at the
+metadata component. Components that nothing depends on and that cannot be
+reached from the metadata component are shown as additional roots.
+
+Components are shown by their package URL, falling back to name@version.
+A subtree is only printed once, later occurrences are marked with (*).
+Dependencies that lead back to a component on the current path are marked
+with (cycle).
+
+With --reverse the tree is turned upside down: it starts at the given
+package and shows everything that depends on it, up to the roots, so every
+path that pulls the package in can be read from it. The package can be given
+as package URL, bom-ref or name@version.
+
+Example:
+  sbomctl tree sbom.json
+  sbomctl tree sbom.json --depth 2
+  sbomctl tree sbom.json --reverse pkg:npm/lodash@4.17.21`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		if treeDepth < 0 {
+			return fmt.Errorf("depth must not be negative, got %d", treeDepth)
+		}
+
+		bom, err := sbom.ReadSBOMFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		g := sbom.NewGraph(bom)
+
+		roots := g.Roots()
+		if treeReverse != "" {
+			roots = g.Find(treeReverse)
+			if len(roots) == 0 {
+				return fmt.Errorf("no component matches %q", treeReverse)
+			}
+		}
+
+		formatTree(cmd.OutOrStdout(), g, roots, treeReverse != "", treeDepth)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+
+	treeCmd.Flags().IntVar(&treeDepth, "depth", 0, "Maximum depth of the tree, 0 for unlimited")
+	treeCmd.Flags().StringVar(&treeReverse, "reverse", "", "Show what depends on this package (package URL, bom-ref or name@version)")
+}
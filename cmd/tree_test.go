@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func treeTestGraph() *sbom.Graph {
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{
+		Component: &cyclonedx.Component{BOMRef: "app", Name: "app", Version: "1.0.0"},
+	}
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "a", Name: "a", Version: "1.0.0"},
+		{BOMRef: "b", Name: "b", Version: "1.0.0"},
+		{BOMRef: "c", Name: "c", Version: "1.0.0"},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"a", "b"}},
+		{Ref: "a", Dependencies: &[]string{"c"}},
+		{Ref: "b", Dependencies: &[]string{"a"}},
+		{Ref: "c", Dependencies: &[]string{"a"}},
+	}
+	return sbom.NewGraph(bom)
+}
+
+func TestFormatTree(t *testing.T) {
+	g := treeTestGraph()
+
+	var buf bytes.Buffer
+	formatTree(&buf, g, g.Roots(), false, 0)
+
+	expected := `app@1.0.0
+├── a@1.0.0
+│   └── c@1.0.0
+│       └── a@1.0.0 (cycle)
+└── b@1.0.0
+    └── a@1.0.0 (*)
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected tree:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestFormatTreeDepth(t *testing.T) {
+	g := treeTestGraph()
+
+	var buf bytes.Buffer
+	formatTree(&buf, g, g.Roots(), false, 1)
+
+	expected := `app@1.0.0
+├── a@1.0.0
+└── b@1.0.0
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected tree:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestFormatTreeDepthExpandsLaterOccurrence(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}}
+	bom.Components = &[]cyclonedx.Component{{BOMRef: "a", Name: "a"}, {BOMRef: "b", Name: "b"}, {BOMRef: "c", Name: "c"}}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"a", "b"}},
+		{Ref: "a", Dependencies: &[]string{"b"}},
+		{Ref: "b", Dependencies: &[]string{"c"}},
+	}
+	g := sbom.NewGraph(bom)
+
+	var buf bytes.Buffer
+	formatTree(&buf, g, g.Roots(), false, 2)
+
+	// b is cut off below a, so its children are printed where it occurs again
+	expected := `app
+├── a
+│   └── b
+└── b
+    └── c
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected tree:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestFormatTreeReverse(t *testing.T) {
+	g := treeTestGraph()
+
+	var buf bytes.Buffer
+	formatTree(&buf, g, []string{"c"}, true, 0)
+
+	expected := `c@1.0.0
+└── a@1.0.0
+    ├── app@1.0.0
+    ├── b@1.0.0
+    │   └── app@1.0.0
+    └── c@1.0.0 (cycle)
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected tree:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestTreeCommand(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+
+	output, err := executeCommand("tree", inputFile)
+	if err != nil {
+		t.Fatalf("tree command failed: %v", err)
+	}
+	if !strings.HasPrefix(output, "pkg:generic/example-app@2.0.0\n├── pkg:npm/example-lib-3@3.4.5") {
+		t.Errorf("Unexpected tree:\n%s", output)
+	}
+
+	output, err = executeCommand("tree", inputFile, "--reverse", "example-lib-5@5.0.1")
+	if err != nil {
+		t.Fatalf("tree command failed: %v", err)
+	}
+	if output != "pkg:npm/example-lib-5@5.0.1\n└── pkg:generic/example-app@2.0.0\n" {
+		t.Errorf("Unexpected reverse tree:\n%s", output)
+	}
+
+	if _, err := executeCommand("tree", inputFile, "--reverse", "pkg:npm/unknown@1.0.0"); err == nil {
+		t.Error("Expected tree command to fail for an unknown package")
+	}
+}
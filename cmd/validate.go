@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+// formatValidationErrors writes the validation result of a file to the provided writer
+func formatValidationErrors(w io.Writer, inputFile string, validationErrors []sbom.ValidationError) {
+	if len(validationErrors) == 0 {
+		fmt.Fprintf(w, "%s: valid\n", inputFile)
+		return
+	}
+
+	noun := "errors"
+	if len(validationErrors) == 1 {
+		noun = "error"
+	}
+	fmt.Fprintf(w, "%s: %d %s\n", inputFile, len(validationErrors), noun)
+	for _, validationErr := range validationErrors {
+		fmt.Fprintf(w, "  - %s\n", validationErr.Error())
+	}
+}
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [sbom files...]",
+	Short: "Validate SBOM files against the CycloneDX schema",
+	Long: `Validate CycloneDX JSON SBOM files against the JSON schema of their specVersion
+(1.2 to 1.6). The schemas are embedded, so no network access is needed.
+CycloneDX XML and protobuf files are decoded and only get the checks below.
+
+On top of the schema, the following checks are performed:
+  - all bom-refs are unique
+  - every dependencies entry refers to an existing bom-ref
+  - every dependsOn target refers to an existing bom-ref
+
+Each error is reported with a JSON pointer to the offending value. The command
+exits with a non-zero status if any file is invalid.
+
+Example:
+  sbomctl validate sbom.json
+  sbomctl validate sbom1.json sbom2.json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Validation failures are not usage errors
+		cmd.SilenceUsage = true
+
+		invalidFiles := 0
+		for _, inputFile := range args {
+			validationErrors, err := sbom.ValidateSBOMFile(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to validate SBOM file %s: %w", inputFile, err)
+			}
+			if len(validationErrors) > 0 {
+				invalidFiles++
+			}
+			formatValidationErrors(cmd.OutOrStdout(), inputFile, validationErrors)
+		}
+
+		if invalidFiles > 0 {
+			return fmt.Errorf("%d of %d SBOM files are invalid", invalidFiles, len(args))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestValidateCommand(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+
+	var outputBuffer bytes.Buffer
+	rootCmd.SetOut(&outputBuffer)
+	defer rootCmd.SetOut(nil)
+
+	rootCmd.SetArgs([]string{"validate", filepath.Join(testdataDir, "merged.json")})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("validate command failed: %v", err)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "merged.json: valid") {
+		t.Errorf("Expected merged.json to be reported as valid.\nOutput: %s", outputBuffer.String())
+	}
+}
+
+func TestValidateCommandInvalid(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+
+	var outputBuffer bytes.Buffer
+	rootCmd.SetOut(&outputBuffer)
+	rootCmd.SetErr(&bytes.Buffer{})
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+
+	// sbom1.json declares spec version 1.4 but uses the 1.5 tools object
+	rootCmd.SetArgs([]string{"validate", filepath.Join(testdataDir, "sbom1.json")})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatal("Expected validate command to fail for an invalid SBOM")
+	}
+
+	if !strings.Contains(outputBuffer.String(), "/metadata/tools") {
+		t.Errorf("Expected output to point at /metadata/tools.\nOutput: %s", outputBuffer.String())
+	}
+}
+
+func TestFormatValidationErrors(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	formatValidationErrors(&outputBuffer, "sbom.json", []sbom.ValidationError{
+		{Pointer: "/dependencies/0/ref", Message: "dependency ref \"foo\" does not match any bom-ref"},
+	})
+
+	expected := "sbom.json: 1 error\n  - /dependencies/0/ref: dependency ref \"foo\" does not match any bom-ref\n"
+	if outputBuffer.String() != expected {
+		t.Errorf("Unexpected output.\nExpected: %q\nGot: %q", expected, outputBuffer.String())
+	}
+}
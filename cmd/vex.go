@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vexApplyFiles        []string
+	vexApplyOutputFile   string
+	vexApplyOutputFormat string
+)
+
+// formatVEXReport writes the applied and unmatched VEX statements to the provided writer
+func formatVEXReport(w io.Writer, report *sbom.VEXReport) {
+	fmt.Fprintf(w, "Applied %d statements to %d vulnerabilities\n", report.Applied, report.Updated)
+	if len(report.Unmatched) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%d statements did not match:\n", len(report.Unmatched))
+	for _, unmatched := range report.Unmatched {
+		fmt.Fprintf(w, "  - %s (%s): %s\n", unmatched.Vulnerability, strings.Join(unmatched.Products, ", "), unmatched.Reason)
+	}
+}
+
+// vexCmd represents the vex command
+var vexCmd = &cobra.Command{
+	Use:   "vex",
+	Short: "Work with VEX documents",
+}
+
+// vexApplyCmd represents the vex apply command
+var vexApplyCmd = &cobra.Command{
+	Use:   "apply [sbom file]",
+	Short: "Apply VEX documents to the vulnerabilities of an SBOM file",
+	Long: `Apply the statements of VEX (Vulnerability Exploitability eXchange) documents
+to the vulnerabilities of an SBOM file, e.g. to mark vulnerabilities found by
+"vuln scan" as not affecting the product. OpenVEX, CSAF VEX and CycloneDX
+VEX documents are supported, in the order given with --vex, so later
+statements override earlier ones.
+
+A statement applies to the SBOM's vulnerabilities with its vulnerability ID
+or one of its aliases, as ID or in the references, and to the affected
+components that match one of its products:
+  package URL  matches components with this package URL, ignoring the
+               version and qualifiers if the package URL has none
+  BOM-Link     matches the component the link refers to, also in SBOMs
+               merged with the prefix or hierarchical strategy
+  bom-ref      matches the component with this bom-ref, also if it was
+               prefixed with a serial number by merge
+A product matching the metadata component covers all components. For
+OpenVEX statements with subcomponents the subcomponents are matched.
+
+The analysis state, justification, response and detail of the matched
+vulnerabilities are updated. If a statement covers only some of the
+components a vulnerability affects, they are split off into a copy of the
+vulnerability with the new analysis.
+
+The SBOM is written to stdout unless --output is given, in the input's
+format unless --output-format is given. The statements that did not match
+are listed on stderr.
+
+Example:
+  sbomctl vex apply sbom.json --vex openvex.json
+  sbomctl vex apply merged.json --vex csaf.json --vex cyclonedx-vex.json -o sbom-vex.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		if len(vexApplyFiles) == 0 {
+			return fmt.Errorf("no VEX documents given, use --vex")
+		}
+
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		inputFormat := sbom.DetectFileFormat(data)
+		bom, err := sbom.DecodeSBOM(data, inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		outputFormat := inputFormat
+		if vexApplyOutputFormat != "" {
+			if outputFormat, err = sbom.ParseFileFormat(vexApplyOutputFormat); err != nil {
+				return err
+			}
+		}
+
+		cmd.SilenceUsage = true
+
+		var statements []sbom.VEXStatement
+		for _, vexFile := range vexApplyFiles {
+			data, err := os.ReadFile(vexFile)
+			if err != nil {
+				return fmt.Errorf("failed to read VEX file %s: %w", vexFile, err)
+			}
+			fileStatements, _, err := sbom.ParseVEX(data)
+			if err != nil {
+				return fmt.Errorf("failed to read VEX file %s: %w", vexFile, err)
+			}
+			statements = append(statements, fileStatements...)
+		}
+
+		report := sbom.ApplyVEX(bom, statements)
+		encoded, err := sbom.EncodeSBOM(bom, outputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to encode SBOM: %w", err)
+		}
+
+		formatVEXReport(cmd.ErrOrStderr(), report)
+
+		if vexApplyOutputFile == "" {
+			_, err = cmd.OutOrStdout().Write(encoded)
+			return err
+		}
+		if err := os.WriteFile(vexApplyOutputFile, encoded, 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vexCmd)
+	vexCmd.AddCommand(vexApplyCmd)
+
+	vexApplyCmd.Flags().StringArrayVar(&vexApplyFiles, "vex", nil, "OpenVEX, CSAF VEX or CycloneDX VEX document to apply (repeatable)")
+	vexApplyCmd.Flags().StringVarP(&vexApplyOutputFile, "output", "o", "", "Output file for the SBOM (default stdout)")
+	vexApplyCmd.Flags().StringVar(&vexApplyOutputFormat, "output-format", "", "Format of the SBOM (json, xml, proto, spdx-json, default the input's format)")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestVexApplyCommand(t *testing.T) {
+	scannedFile := filepath.Join(t.TempDir(), "scanned.json")
+	if _, err := executeCommand("vuln", "scan", filepath.Join("..", "testdata", "merged.json"), "--db", filepath.Join("..", "testdata", "osv"), "--format", "bom", "-o", scannedFile); err != nil {
+		t.Fatalf("vuln scan command failed: %v", err)
+	}
+
+	output, err := executeCommand("vex", "apply", scannedFile,
+		"--vex", filepath.Join("..", "testdata", "vex", "openvex.json"),
+		"--vex", filepath.Join("..", "testdata", "vex", "cyclonedx-vex.json"))
+	if err != nil {
+		t.Fatalf("vex apply command failed: %v", err)
+	}
+	bom, err := sbom.DecodeSBOM([]byte(output), sbom.FileFormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+
+	states := make(map[string]int)
+	for _, v := range *bom.Vulnerabilities {
+		state := "none"
+		if v.Analysis != nil {
+			state = string(v.Analysis.State)
+		}
+		states[v.ID+" "+state] += len(*v.Affects)
+	}
+	expected := map[string]int{
+		"GHSA-xxxx-lib3-0001 not_affected": 1,
+		// Only the example-lib-2 of the first merged SBOM is covered by the BOM-Link
+		"GHSA-xxxx-lib2-0003 not_affected": 1,
+		"GHSA-xxxx-lib2-0003 none":         1,
+	}
+	if len(states) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, states)
+	}
+	for key, count := range expected {
+		if states[key] != count {
+			t.Errorf("Expected %d affected components for %s, got %v", count, key, states)
+		}
+	}
+
+	if _, err := executeCommand("vex", "apply", scannedFile); err == nil {
+		t.Error("Expected vex apply command to fail without --vex")
+	}
+	if _, err := executeCommand("vex", "apply", scannedFile, "--vex", filepath.Join("..", "testdata", "sbom3.xml")); err != nil {
+		t.Errorf("Expected CycloneDX XML without vulnerabilities to apply nothing, got %v", err)
+	}
+	if _, err := executeCommand("vex", "apply", scannedFile, "--vex", filepath.Join("..", "testdata", "sbom4.spdx.json")); err == nil {
+		t.Error("Expected vex apply command to fail for an SPDX document")
+	}
+}
+
+func TestFormatVEXReport(t *testing.T) {
+	var buf bytes.Buffer
+	formatVEXReport(&buf, &sbom.VEXReport{Applied: 2, Updated: 3, Unmatched: []sbom.UnmatchedVEXStatement{
+		{Vulnerability: "CVE-2099-9999", Products: []string{"pkg:npm/a@1.0.0", "pkg:npm/b@1.0.0"}, Reason: "vulnerability not in SBOM"},
+	}})
+
+	expected := "Applied 2 statements to 3 vulnerabilities\n1 statements did not match:\n  - CVE-2099-9999 (pkg:npm/a@1.0.0, pkg:npm/b@1.0.0): vulnerability not in SBOM\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vulnScanDB           string
+	vulnScanFormat       string
+	vulnScanOutputFile   string
+	vulnScanOutputFormat string
+)
+
+// formatVulnScanReport writes the vulnerability findings as a table to the provided writer
+func formatVulnScanReport(w io.Writer, findings []sbom.VulnerabilityFinding) error {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No known vulnerabilities found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tVULNERABILITY\tSEVERITY\tFIXED IN\tSUMMARY")
+	ids, components := make(map[string]bool), make(map[string]bool)
+	for _, f := range findings {
+		// Merged SBOMs can contain the same package URL several times
+		ids[f.ID], components[f.BOMRef+" "+f.Component] = true, true
+		fixed := strings.Join(f.FixedVersions, ", ")
+		if fixed == "" {
+			fixed = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", f.Component, f.ID, f.Severity, fixed, f.Summary)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nFound %d vulnerabilities in %d components\n", len(ids), len(components))
+	return nil
+}
+
+// vulnCmd represents the vuln command
+var vulnCmd = &cobra.Command{
+	Use:   "vuln",
+	Short: "Work with the vulnerabilities of SBOM files",
+}
+
+// vulnScanCmd represents the vuln scan command
+var vulnScanCmd = &cobra.Command{
+	Use:   "scan [sbom file]",
+	Short: "Scan an SBOM file for known vulnerabilities in a local OSV database",
+	Long: `Scan the components of an SBOM file for known vulnerabilities in a local copy
+of the OSV database (https://osv.dev), without network access. The --db
+option is a zip export like https://osv-vulnerabilities.storage.googleapis.com/npm/all.zip,
+or a directory with OSV JSON files and zip exports.
+
+Components are matched by their package URL against the affected packages
+of the vulnerabilities, and their versions against the affected ranges and
+versions by the rules of the ecosystem: semantic versions (npm, crates.io,
+Go including pseudo-versions, NuGet, Hex, Pub), PEP 440 (PyPI), Maven and
+Debian versions. deb packages are matched by their upstream qualifier if
+present and by their distro release.
+
+The --format option selects the output:
+  text  a table of the findings (default)
+  json  the findings as JSON
+  bom   the SBOM with the findings added to its vulnerabilities, one per
+        vulnerability ID with the components it affects, in the input's
+        format unless --output-format is given. SPDX cannot hold
+        vulnerabilities, so SPDX input is written as CycloneDX JSON
+
+Example:
+  sbomctl vuln scan sbom.json --db osv/
+  sbomctl vuln scan sbom.json --db npm-all.zip --format json
+  sbomctl vuln scan sbom.json --db osv/ --format bom -o sbom-with-vulns.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile := args[0]
+
+		if vulnScanFormat != "text" && vulnScanFormat != "json" && vulnScanFormat != "bom" {
+			return fmt.Errorf("unsupported format %q, must be one of: text, json, bom", vulnScanFormat)
+		}
+
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		inputFormat := sbom.DetectFileFormat(data)
+		bom, err := sbom.DecodeSBOM(data, inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+
+		outputFormat := inputFormat
+		if vulnScanOutputFormat != "" {
+			if outputFormat, err = sbom.ParseFileFormat(vulnScanOutputFormat); err != nil {
+				return err
+			}
+		}
+		// SPDX cannot hold vulnerabilities, so SPDX input is written as CycloneDX JSON
+		if vulnScanFormat == "bom" && outputFormat == sbom.FileFormatSPDXJSON {
+			if vulnScanOutputFormat != "" {
+				return fmt.Errorf("output format %s cannot hold vulnerabilities, use a CycloneDX format with --format bom", outputFormat)
+			}
+			outputFormat = sbom.FileFormatJSON
+		}
+
+		cmd.SilenceUsage = true
+
+		db, err := sbom.LoadOSVDatabase(vulnScanDB)
+		if err != nil {
+			return err
+		}
+		findings := sbom.ScanVulnerabilities(bom, db)
+
+		out := cmd.OutOrStdout()
+		if vulnScanOutputFile != "" {
+			f, err := os.Create(vulnScanOutputFile)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		switch vulnScanFormat {
+		case "json":
+			return writeJSON(out, findings)
+		case "bom":
+			added := sbom.AddVulnerabilityFindings(bom, findings)
+			encoded, err := sbom.EncodeSBOM(bom, outputFormat)
+			if err != nil {
+				return fmt.Errorf("failed to encode SBOM: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Added %d vulnerabilities from %d findings\n", added, len(findings))
+			_, err = out.Write(encoded)
+			return err
+		default:
+			return formatVulnScanReport(out, findings)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vulnCmd)
+	vulnCmd.AddCommand(vulnScanCmd)
+
+	vulnScanCmd.Flags().StringVar(&vulnScanDB, "db", "", "OSV database, a zip export or a directory of OSV JSON files (required)")
+	vulnScanCmd.Flags().StringVar(&vulnScanFormat, "format", "text", "Output format (text, json, bom)")
+	vulnScanCmd.Flags().StringVarP(&vulnScanOutputFile, "output", "o", "", "Output file (default stdout)")
+	vulnScanCmd.Flags().StringVar(&vulnScanOutputFormat, "output-format", "", "Format of the SBOM with --format bom (json, xml, proto, default the input's format or json for SPDX input)")
+	_ = vulnScanCmd.MarkFlagRequired("db")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestVulnScanCommand(t *testing.T) {
+	inputFile := filepath.Join("..", "testdata", "sbom4.spdx.json")
+	db := filepath.Join("..", "testdata", "osv")
+
+	output, err := executeCommand("vuln", "scan", inputFile, "--db", db)
+	if err != nil {
+		t.Fatalf("vuln scan command failed: %v", err)
+	}
+	for _, expected := range []string{"pkg:npm/example-lib-3@3.4.5", "GHSA-xxxx-lib3-0001", "critical", "3.4.6", "Found 1 vulnerabilities in 1 components"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	output, err = executeCommand("vuln", "scan", inputFile, "--db", db, "--format", "json")
+	if err != nil {
+		t.Fatalf("vuln scan command failed: %v", err)
+	}
+	var findings []sbom.VulnerabilityFinding
+	if err := json.Unmarshal([]byte(output), &findings); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if len(findings) != 1 || findings[0].BOMRef != "SPDXRef-Package-example-lib-3" {
+		t.Errorf("Unexpected findings: %+v", findings)
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "scanned.json")
+	if _, err := executeCommand("vuln", "scan", inputFile, "--db", db, "--format", "bom", "--output-format", "json", "-o", outputFile); err != nil {
+		t.Fatalf("vuln scan command failed: %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	bom, err := sbom.DecodeSBOM(data, sbom.FileFormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if bom.Vulnerabilities == nil || len(*bom.Vulnerabilities) != 1 || (*(*bom.Vulnerabilities)[0].Affects)[0].Ref != "SPDXRef-Package-example-lib-3" {
+		t.Errorf("Unexpected vulnerabilities: %+v", bom.Vulnerabilities)
+	}
+
+	// SPDX cannot hold the findings, so SPDX input is written as CycloneDX JSON
+	output, err = executeCommand("vuln", "scan", inputFile, "--db", db, "--format", "bom")
+	if err != nil {
+		t.Fatalf("vuln scan command failed: %v", err)
+	}
+	if sbom.DetectFileFormat([]byte(output)) != sbom.FileFormatJSON {
+		t.Errorf("Expected CycloneDX JSON for SPDX input, got:\n%s", output)
+	}
+	if bom, err = sbom.DecodeSBOM([]byte(output), sbom.FileFormatJSON); err != nil || bom.Vulnerabilities == nil || len(*bom.Vulnerabilities) != 1 {
+		t.Errorf("Expected the findings in the SBOM, got %v", err)
+	}
+	if _, err := executeCommand("vuln", "scan", inputFile, "--db", db, "--format", "bom", "--output-format", "spdx-json"); err == nil {
+		t.Error("Expected vuln scan command to fail for SPDX output with --format bom")
+	}
+
+	if _, err := executeCommand("vuln", "scan", inputFile); err == nil {
+		t.Error("Expected vuln scan command to fail without --db")
+	}
+	if _, err := executeCommand("vuln", "scan", inputFile, "--db", db, "--format", "sarif"); err == nil {
+		t.Error("Expected vuln scan command to fail for an unsupported format")
+	}
+}
+
+func TestFormatVulnScanReport(t *testing.T) {
+	var buf strings.Builder
+	if err := formatVulnScanReport(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "No known vulnerabilities found\n" {
+		t.Errorf("Unexpected report: %q", buf.String())
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/j12934/sbomctl/pkg/sbom"
+	"github.com/spf13/cobra"
+)
+
+var whyMaxPaths int
+
+// formatWhy writes the shortest paths to each target to the provided writer.
+// Paths through a merged SBOM are annotated with the serial number of the
+// input SBOM they came from.
+func formatWhy(w io.Writer, g *sbom.Graph, targets []string, maxPaths int) (found bool) {
+	for i, target := range targets {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		// Ask for one more path than shown to learn whether there are more
+		limit := maxPaths
+		if limit > 0 {
+			limit++
+		}
+		paths := g.ShortestPaths(target, limit)
+		if len(paths) == 0 {
+			fmt.Fprintf(w, "%s is not reachable from the root components\n", g.Label(target))
+			continue
+		}
+		found = true
+
+		fmt.Fprintf(w, "%s", g.Label(target))
+		if source := sbom.SourceSerial(target); source != "" {
+			fmt.Fprintf(w, " (from %s)", source)
+		}
+		count := fmt.Sprintf("%d", len(paths))
+		if maxPaths > 0 && len(paths) > maxPaths {
+			paths = paths[:maxPaths]
+			count = fmt.Sprintf("more than %d", maxPaths)
+		}
+		noun := "paths"
+		if len(paths) == 1 {
+			noun = "path"
+		}
+		fmt.Fprintf(w, ": %s shortest %s of length %d\n", count, noun, len(paths[0])-1)
+
+		for _, path := range paths {
+			labels := make([]string, len(path))
+			source := ""
+			for j, ref := range path {
+				labels[j] = g.Label(ref)
+				if source == "" {
+					source = sbom.SourceSerial(ref)
+				}
+			}
+			fmt.Fprintf(w, "  %s", strings.Join(labels, " -> "))
+			if source != "" && source != sbom.SourceSerial(target) {
+				fmt.Fprintf(w, " (from %s)", source)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return found
+}
+
+// whyCmd represents the why command
+var whyCmd = &cobra.Command{
+	Use:   "why [sbom file] [package]",
+	Short: "Explain why a component is part of an SBOM",
+	Long: `Show every shortest dependency path from the root components of an SBOM to a
+component, e.g. to find the direct dependency that pulls in a vulnerable
+transitive package.
+
+The component can be given as package URL, bom-ref or name@version. If it
+matches several components, as it does for the same package in several
+inputs of a merged SBOM, the paths to each of them are shown. For merged
+SBOMs every path is annotated with the serial number of the input SBOM it
+came from.
+
+The roots are the same as for the tree command: the metadata component and
+all components that nothing depends on.
+
+Example:
+  sbomctl why sbom.json pkg:npm/debug@2.6.9
+  sbomctl why merged.json debug@2.6.9 --max-paths 5`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile, query := args[0], args[1]
+
+		if whyMaxPaths < 0 {
+			return fmt.Errorf("max-paths must not be negative, got %d", whyMaxPaths)
+		}
+
+		bom, err := sbom.ReadSBOMFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file %s: %w", inputFile, err)
+		}
+		g := sbom.NewGraph(bom)
+
+		targets := g.Find(query)
+		if len(targets) == 0 {
+			return fmt.Errorf("no component matches %q", query)
+		}
+
+		if !formatWhy(cmd.OutOrStdout(), g, targets, whyMaxPaths) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("no path from the root components to %q", query)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whyCmd)
+
+	whyCmd.Flags().IntVar(&whyMaxPaths, "max-paths", 20, "Maximum number of paths shown per component, 0 for all")
+}
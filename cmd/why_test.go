@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/j12934/sbomctl/pkg/sbom"
+)
+
+func TestFormatWhy(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app", Version: "1.0.0"}}
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "a", Name: "a", Version: "1.0.0"},
+		{BOMRef: "b", Name: "b", Version: "1.0.0"},
+		{BOMRef: "c", Name: "c", Version: "1.0.0"},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"a", "b"}},
+		{Ref: "a", Dependencies: &[]string{"c"}},
+		{Ref: "b", Dependencies: &[]string{"c"}},
+	}
+	g := sbom.NewGraph(bom)
+
+	var buf bytes.Buffer
+	if !formatWhy(&buf, g, []string{"c"}, 0) {
+		t.Fatal("Expected paths to be found")
+	}
+	expected := `c@1.0.0: 2 shortest paths of length 2
+  app@1.0.0 -> a@1.0.0 -> c@1.0.0
+  app@1.0.0 -> b@1.0.0 -> c@1.0.0
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	formatWhy(&buf, g, []string{"c"}, 1)
+	if !strings.HasPrefix(buf.String(), "c@1.0.0: more than 1 shortest path of length 2\n") {
+		t.Errorf("Expected the paths to be limited, got:\n%s", buf.String())
+	}
+}
+
+func TestWhyCommand_Merged(t *testing.T) {
+	testdataDir := filepath.Join("..", "testdata")
+	mergedFile := filepath.Join(t.TempDir(), "merged.json")
+
+	_, err := executeCommand("merge", filepath.Join(testdataDir, "sbom4.spdx.json"), filepath.Join(testdataDir, "sbom1.json"), "-o", mergedFile)
+	if err != nil {
+		t.Fatalf("merge command failed: %v", err)
+	}
+
+	output, err := executeCommand("why", mergedFile, "pkg:npm/example-lib-3@3.4.5")
+	if err != nil {
+		t.Fatalf("why command failed: %v", err)
+	}
+	for _, expected := range []string{
+		"pkg:npm/example-lib-3@3.4.5 (from urn:uuid:",
+		"merged-sbom -> pkg:generic/example-app@2.0.0 -> pkg:npm/example-lib-3@3.4.5",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', but it did not.\nOutput: %s", expected, output)
+		}
+	}
+
+	if _, err := executeCommand("why", mergedFile, "pkg:npm/unknown@1.0.0"); err == nil {
+		t.Error("Expected why command to fail for an unknown package")
+	}
+}
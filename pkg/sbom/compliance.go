@@ -0,0 +1,271 @@
+package sbom
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// ComplianceProfile is a set of requirements SBOMs are scored against
+type ComplianceProfile struct {
+	// Name selects the profile, like "ntia"
+	Name string
+	// Title is the name of the document defining the requirements
+	Title        string
+	Requirements []ComplianceRequirement
+}
+
+// ComplianceRequirement is a single requirement of a ComplianceProfile. It is
+// checked once for the SBOM itself or once for every component.
+type ComplianceRequirement struct {
+	ID string
+	// Field is the CycloneDX field the requirement is about
+	Field       string
+	Description string
+	// document checks the SBOM, component each component; only one is set
+	document  func(bom *cyclonedx.BOM) bool
+	component func(c *cyclonedx.Component, dependencyRefs map[string]bool) bool
+}
+
+// ComplianceReport is the result of checking an SBOM against a profile
+type ComplianceReport struct {
+	Profile string `json:"profile"`
+	Title   string `json:"title"`
+	// Score is the percentage of passed checks, rounded to one decimal.
+	// Component requirements are checked once per component.
+	Score        float64             `json:"score"`
+	Requirements []RequirementResult `json:"requirements"`
+	Failures     []ComplianceFailure `json:"failures"`
+}
+
+// RequirementResult counts the passed and failed checks of a requirement
+type RequirementResult struct {
+	ID          string `json:"id"`
+	Field       string `json:"field"`
+	Description string `json:"description"`
+	Passed      int    `json:"passed"`
+	Failed      int    `json:"failed"`
+}
+
+// ComplianceFailure is a failed check of a requirement
+type ComplianceFailure struct {
+	Requirement string `json:"requirement"`
+	Field       string `json:"field"`
+	// Component is the package URL or name@version of the failing component,
+	// empty for requirements on the SBOM itself
+	Component string `json:"component,omitempty"`
+}
+
+// complianceProfiles are the built-in profiles, selectable by name
+var complianceProfiles = []ComplianceProfile{
+	{
+		Name:  "ntia",
+		Title: "NTIA Minimum Elements for a Software Bill of Materials",
+		Requirements: []ComplianceRequirement{
+			{ID: "NTIA-AUTHOR", Field: "metadata.authors", Description: "The author of the SBOM data is known", document: hasSBOMAuthor},
+			{ID: "NTIA-TIMESTAMP", Field: "metadata.timestamp", Description: "The SBOM has a timestamp", document: hasTimestamp},
+			{ID: "NTIA-DEPENDENCIES", Field: "dependencies", Description: "The direct dependencies of the metadata component are listed", document: hasPrimaryDependencies},
+			{ID: "NTIA-SUPPLIER", Field: "supplier", Description: "The component has a supplier, manufacturer or publisher", component: hasSupplier},
+			{ID: "NTIA-NAME", Field: "name", Description: "The component has a name", component: hasName},
+			{ID: "NTIA-VERSION", Field: "version", Description: "The component has a version", component: hasVersion},
+			{ID: "NTIA-UNIQUE-ID", Field: "purl", Description: "The component has a package URL, CPE or SWID tag ID", component: hasUniqueID},
+		},
+	},
+	{
+		Name:  "bsi",
+		Title: "BSI TR-03183-2 Cyber Resilience Requirements, Part 2: Software Bill of Materials",
+		Requirements: []ComplianceRequirement{
+			{ID: "BSI-SPEC-VERSION", Field: "specVersion", Description: "The SBOM uses CycloneDX 1.5 or later", document: func(bom *cyclonedx.BOM) bool { return bom.SpecVersion >= cyclonedx.SpecVersion1_5 }},
+			{ID: "BSI-SBOM-CREATOR", Field: "metadata.authors", Description: "The creator of the SBOM has an email address or URL", document: hasReachableSBOMCreator},
+			{ID: "BSI-TIMESTAMP", Field: "metadata.timestamp", Description: "The SBOM has a timestamp", document: hasTimestamp},
+			{ID: "BSI-CREATOR", Field: "manufacturer", Description: "The creator of the component has an email address or URL", component: hasReachableCreator},
+			{ID: "BSI-NAME", Field: "name", Description: "The component has a name", component: hasName},
+			{ID: "BSI-VERSION", Field: "version", Description: "The component has a version", component: hasVersion},
+			{ID: "BSI-FILENAME", Field: "properties.bsi:component:filename", Description: "The component has the filename of its executable form", component: hasProperty("bsi:component:filename")},
+			{ID: "BSI-DEPENDENCIES", Field: "dependencies", Description: "The dependencies of the component are listed, possibly empty", component: hasDependencyEntry},
+			{ID: "BSI-LICENSES", Field: "licenses", Description: "The component has licenses and its license expressions are valid", component: hasValidLicense},
+			{ID: "BSI-HASH", Field: "hashes", Description: "The component has a SHA-512 hash of its executable form", component: hasHash(cyclonedx.HashAlgoSHA512)},
+			{ID: "BSI-EXECUTABLE", Field: "properties.bsi:component:executable", Description: "The component states whether it is executable", component: hasProperty("bsi:component:executable")},
+			{ID: "BSI-ARCHIVE", Field: "properties.bsi:component:archive", Description: "The component states whether it is an archive", component: hasProperty("bsi:component:archive")},
+			{ID: "BSI-STRUCTURED", Field: "properties.bsi:component:structured", Description: "The component states whether it is a structured file", component: hasProperty("bsi:component:structured")},
+		},
+	},
+}
+
+// ComplianceProfileByName returns the built-in profile with the given name
+func ComplianceProfileByName(name string) (ComplianceProfile, error) {
+	for _, profile := range complianceProfiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return ComplianceProfile{}, fmt.Errorf("unknown compliance profile %q, must be one of: %v", name, ComplianceProfileNames())
+}
+
+// ComplianceProfileNames returns the names of all built-in profiles
+func ComplianceProfileNames() []string {
+	names := make([]string, 0, len(complianceProfiles))
+	for _, profile := range complianceProfiles {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+// CheckCompliance checks an SBOM against the requirements of a profile. The
+// component requirements are checked for the metadata component and all
+// components, including nested ones.
+func CheckCompliance(bom *cyclonedx.BOM, profile ComplianceProfile) *ComplianceReport {
+	var components []*cyclonedx.Component
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		components = append(components, bom.Metadata.Component)
+		walkComponents(bom.Metadata.Component.Components, func(c *cyclonedx.Component) { components = append(components, c) })
+	}
+	walkComponents(bom.Components, func(c *cyclonedx.Component) { components = append(components, c) })
+
+	dependencyRefs := make(map[string]bool)
+	if bom.Dependencies != nil {
+		for _, dep := range *bom.Dependencies {
+			dependencyRefs[dep.Ref] = true
+		}
+	}
+
+	report := &ComplianceReport{
+		Profile:      profile.Name,
+		Title:        profile.Title,
+		Requirements: make([]RequirementResult, 0, len(profile.Requirements)),
+		Failures:     []ComplianceFailure{},
+	}
+	for _, requirement := range profile.Requirements {
+		result := RequirementResult{ID: requirement.ID, Field: requirement.Field, Description: requirement.Description}
+		if requirement.document != nil {
+			if requirement.document(bom) {
+				result.Passed++
+			} else {
+				result.Failed++
+				report.Failures = append(report.Failures, ComplianceFailure{Requirement: requirement.ID, Field: requirement.Field})
+			}
+		}
+		report.Requirements = append(report.Requirements, result)
+	}
+
+	// Failures are listed per component, so all fields of a component are together
+	for _, c := range components {
+		for i, requirement := range profile.Requirements {
+			if requirement.component == nil {
+				continue
+			}
+			if requirement.component(c, dependencyRefs) {
+				report.Requirements[i].Passed++
+				continue
+			}
+			report.Requirements[i].Failed++
+			report.Failures = append(report.Failures, ComplianceFailure{Requirement: requirement.ID, Field: requirement.Field, Component: componentLabel(c)})
+		}
+	}
+
+	passed, total := 0, 0
+	for _, result := range report.Requirements {
+		passed += result.Passed
+		total += result.Passed + result.Failed
+	}
+	report.Score = 100
+	if total > 0 {
+		report.Score = math.Round(float64(passed)/float64(total)*1000) / 10
+	}
+	return report
+}
+
+func hasTimestamp(bom *cyclonedx.BOM) bool {
+	return bom.Metadata != nil && bom.Metadata.Timestamp != ""
+}
+
+func hasSBOMAuthor(bom *cyclonedx.BOM) bool {
+	if bom.Metadata == nil {
+		return false
+	}
+	if bom.Metadata.Authors != nil && slices.ContainsFunc(*bom.Metadata.Authors, func(a cyclonedx.OrganizationalContact) bool {
+		return a.Name != "" || a.Email != ""
+	}) {
+		return true
+	}
+	return slices.ContainsFunc([]*cyclonedx.OrganizationalEntity{bom.Metadata.Manufacturer, bom.Metadata.Manufacture, bom.Metadata.Supplier}, func(e *cyclonedx.OrganizationalEntity) bool {
+		return e != nil && e.Name != ""
+	})
+}
+
+func hasReachableSBOMCreator(bom *cyclonedx.BOM) bool {
+	if bom.Metadata == nil {
+		return false
+	}
+	return hasContactEmail(bom.Metadata.Authors) ||
+		slices.ContainsFunc([]*cyclonedx.OrganizationalEntity{bom.Metadata.Manufacturer, bom.Metadata.Manufacture, bom.Metadata.Supplier}, isReachable)
+}
+
+func hasPrimaryDependencies(bom *cyclonedx.BOM) bool {
+	if bom.Metadata == nil || bom.Metadata.Component == nil || bom.Dependencies == nil {
+		return false
+	}
+	return slices.ContainsFunc(*bom.Dependencies, func(dep cyclonedx.Dependency) bool {
+		return dep.Ref == bom.Metadata.Component.BOMRef && dep.Dependencies != nil && len(*dep.Dependencies) > 0
+	})
+}
+
+func hasSupplier(c *cyclonedx.Component, _ map[string]bool) bool {
+	return supplierName(c) != "" || c.Manufacturer != nil && c.Manufacturer.Name != "" || c.Publisher != ""
+}
+
+func hasReachableCreator(c *cyclonedx.Component, _ map[string]bool) bool {
+	return isReachable(c.Manufacturer) || isReachable(c.Supplier) || hasContactEmail(c.Authors)
+}
+
+func hasName(c *cyclonedx.Component, _ map[string]bool) bool {
+	return c.Name != ""
+}
+
+func hasVersion(c *cyclonedx.Component, _ map[string]bool) bool {
+	return c.Version != ""
+}
+
+func hasUniqueID(c *cyclonedx.Component, _ map[string]bool) bool {
+	return c.PackageURL != "" || c.CPE != "" || c.SWID != nil && c.SWID.TagID != ""
+}
+
+func hasDependencyEntry(c *cyclonedx.Component, dependencyRefs map[string]bool) bool {
+	return c.BOMRef != "" && dependencyRefs[c.BOMRef]
+}
+
+func hasValidLicense(c *cyclonedx.Component, _ map[string]bool) bool {
+	expression, err := ComponentLicenseExpression(c)
+	return err == nil && expression != nil
+}
+
+func hasHash(algorithm cyclonedx.HashAlgorithm) func(*cyclonedx.Component, map[string]bool) bool {
+	return func(c *cyclonedx.Component, _ map[string]bool) bool {
+		return c.Hashes != nil && slices.ContainsFunc(*c.Hashes, func(h cyclonedx.Hash) bool {
+			return h.Algorithm == algorithm && h.Value != ""
+		})
+	}
+}
+
+func hasProperty(name string) func(*cyclonedx.Component, map[string]bool) bool {
+	return func(c *cyclonedx.Component, _ map[string]bool) bool {
+		return c.Properties != nil && slices.ContainsFunc(*c.Properties, func(p cyclonedx.Property) bool {
+			return p.Name == name && p.Value != ""
+		})
+	}
+}
+
+// isReachable reports whether an organization has a URL or a contact with email address
+func isReachable(e *cyclonedx.OrganizationalEntity) bool {
+	if e == nil {
+		return false
+	}
+	return e.URL != nil && slices.ContainsFunc(*e.URL, func(url string) bool { return url != "" }) || hasContactEmail(e.Contact)
+}
+
+// hasContactEmail reports whether any of the contacts has an email address
+func hasContactEmail(contacts *[]cyclonedx.OrganizationalContact) bool {
+	return contacts != nil && slices.ContainsFunc(*contacts, func(c cyclonedx.OrganizationalContact) bool { return c.Email != "" })
+}
@@ -0,0 +1,107 @@
+package sbom
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func complianceTestBOM() *cyclonedx.BOM {
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{
+		Timestamp: "2024-05-01T12:00:00Z",
+		Authors:   &[]cyclonedx.OrganizationalContact{{Name: "Build Team", Email: "build@example.com"}},
+		Component: &cyclonedx.Component{
+			BOMRef: "app", Name: "app", Version: "1.0.0", Type: cyclonedx.ComponentTypeApplication,
+			PackageURL: "pkg:generic/app@1.0.0",
+			Supplier:   &cyclonedx.OrganizationalEntity{Name: "Example Inc.", URL: &[]string{"https://example.com"}},
+			Licenses:   &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "Apache-2.0"}}},
+			Hashes:     &[]cyclonedx.Hash{{Algorithm: cyclonedx.HashAlgoSHA512, Value: "abc"}},
+			Properties: &[]cyclonedx.Property{
+				{Name: "bsi:component:filename", Value: "app"},
+				{Name: "bsi:component:executable", Value: "executable"},
+				{Name: "bsi:component:archive", Value: "no archive"},
+				{Name: "bsi:component:structured", Value: "unstructured"},
+			},
+		},
+	}
+	bom.Components = &[]cyclonedx.Component{
+		{
+			BOMRef: "lodash", Name: "lodash", Version: "4.17.21", Type: cyclonedx.ComponentTypeLibrary,
+			PackageURL: "pkg:npm/lodash@4.17.21", Publisher: "OpenJS Foundation",
+			Licenses: &cyclonedx.Licenses{{Expression: "MIT OR"}},
+		},
+		{BOMRef: "vendored", Name: "vendored", Type: cyclonedx.ComponentTypeLibrary},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"lodash", "vendored"}},
+		{Ref: "lodash", Dependencies: &[]string{}},
+	}
+	return bom
+}
+
+func TestCheckComplianceNTIA(t *testing.T) {
+	profile, err := ComplianceProfileByName("ntia")
+	if err != nil {
+		t.Fatalf("ComplianceProfileByName failed: %v", err)
+	}
+	report := CheckCompliance(complianceTestBOM(), profile)
+
+	// 3 SBOM checks pass, 4 checks of 3 components of which vendored fails 3
+	if report.Score != 80 {
+		t.Errorf("Expected score 80, got %v", report.Score)
+	}
+	expected := []ComplianceFailure{
+		{Requirement: "NTIA-SUPPLIER", Field: "supplier", Component: "vendored"},
+		{Requirement: "NTIA-VERSION", Field: "version", Component: "vendored"},
+		{Requirement: "NTIA-UNIQUE-ID", Field: "purl", Component: "vendored"},
+	}
+	if !slices.Equal(report.Failures, expected) {
+		t.Errorf("Expected failures %+v, got %+v", expected, report.Failures)
+	}
+	if result := report.Requirements[3]; result.ID != "NTIA-SUPPLIER" || result.Passed != 2 || result.Failed != 1 {
+		t.Errorf("Unexpected requirement result: %+v", result)
+	}
+
+	bom := complianceTestBOM()
+	bom.Metadata.Authors = nil
+	bom.Metadata.Timestamp = ""
+	report = CheckCompliance(bom, profile)
+	if len(report.Failures) != 5 || report.Failures[0].Requirement != "NTIA-AUTHOR" || report.Failures[0].Component != "" {
+		t.Errorf("Expected the SBOM author and timestamp to fail first, got %+v", report.Failures)
+	}
+}
+
+func TestCheckComplianceBSI(t *testing.T) {
+	profile, err := ComplianceProfileByName("bsi")
+	if err != nil {
+		t.Fatalf("ComplianceProfileByName failed: %v", err)
+	}
+	report := CheckCompliance(complianceTestBOM(), profile)
+
+	var appFailures, lodashFailures []string
+	for _, failure := range report.Failures {
+		switch failure.Component {
+		case "pkg:generic/app@1.0.0":
+			appFailures = append(appFailures, failure.Requirement)
+		case "pkg:npm/lodash@4.17.21":
+			lodashFailures = append(lodashFailures, failure.Requirement)
+		}
+	}
+	if len(appFailures) != 0 {
+		t.Errorf("Expected the metadata component to comply, got %v", appFailures)
+	}
+	// The publisher has no email or URL, and the license expression is invalid
+	expected := []string{"BSI-CREATOR", "BSI-FILENAME", "BSI-LICENSES", "BSI-HASH", "BSI-EXECUTABLE", "BSI-ARCHIVE", "BSI-STRUCTURED"}
+	if !slices.Equal(lodashFailures, expected) {
+		t.Errorf("Expected lodash to fail %v, got %v", expected, lodashFailures)
+	}
+	if report.Requirements[0].ID != "BSI-SPEC-VERSION" || report.Requirements[0].Passed != 1 {
+		t.Errorf("Expected CycloneDX 1.6 to comply, got %+v", report.Requirements[0])
+	}
+
+	if _, err := ComplianceProfileByName("iso"); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
@@ -0,0 +1,239 @@
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// ComponentFilter selects components of an SBOM. Empty fields match every
+// component, all set fields have to match.
+type ComponentFilter struct {
+	Types []cyclonedx.ComponentType
+	// PurlTypes are package URL types like npm, golang or maven
+	PurlTypes []string
+	NameRegex *regexp.Regexp
+	// Scopes of the components, a missing scope counts as required
+	Scopes []cyclonedx.Scope
+	// HasLicense only matches components with (true) or without (false)
+	// license information if set
+	HasLicense *bool
+	// MissingPurl only matches components without package URL
+	MissingPurl bool
+	// Where is a query the components have to match
+	Where *Query
+}
+
+// Match returns whether a component matches the filter. The dependencies
+// and dependents fields of the Where query are only known to
+// FilterComponents, they are empty here.
+func (f ComponentFilter) Match(c *cyclonedx.Component) bool {
+	return f.query().eval(c, nil)
+}
+
+// query returns the filter as a query node, so the filter flags of inspect
+// are evaluated like the expressions of the query command
+func (f ComponentFilter) query() queryNode {
+	var nodes queryAnd
+	if len(f.Types) > 0 {
+		nodes = append(nodes, queryIn("type", f.Types))
+	}
+	if len(f.PurlTypes) > 0 {
+		nodes = append(nodes, queryIn("purl.type", f.PurlTypes))
+	}
+	if f.NameRegex != nil {
+		displayName := func(c *cyclonedx.Component, _ *Graph) []string { return []string{DisplayName(c.Group, c.Name)} }
+		nodes = append(nodes, queryComparison{field: displayName, operator: "=~", regex: f.NameRegex})
+	}
+	if len(f.Scopes) > 0 {
+		nodes = append(nodes, queryIn("scope", f.Scopes))
+	}
+	if f.HasLicense != nil {
+		var node queryNode = queryEmpty{field: queryFields["licenses"]}
+		if *f.HasLicense {
+			node = queryNot{node}
+		}
+		nodes = append(nodes, node)
+	}
+	if f.MissingPurl {
+		nodes = append(nodes, queryEmpty{field: queryFields["purl"]})
+	}
+	if f.Where != nil {
+		nodes = append(nodes, f.Where.root)
+	}
+	return nodes
+}
+
+// FilterComponents returns all components of an SBOM that match the filter,
+// including nested components. The metadata component is not included.
+func FilterComponents(bom *cyclonedx.BOM, filter ComponentFilter) []cyclonedx.Component {
+	var g *Graph
+	if filter.Where != nil {
+		g = NewGraph(bom)
+	}
+	query := filter.query()
+	components := []cyclonedx.Component{}
+	walkComponents(bom.Components, func(c *cyclonedx.Component) {
+		if query.eval(c, g) {
+			components = append(components, *c)
+		}
+	})
+	return components
+}
+
+// ComponentSortKeys are the keys components can be sorted by
+var ComponentSortKeys = []string{"name", "version", "type", "purl"}
+
+// SortComponents sorts components by one of the ComponentSortKeys. Ties are
+// broken by name and version, versions are compared by their numeric parts.
+func SortComponents(components []cyclonedx.Component, key string) error {
+	var compare func(a, b *cyclonedx.Component) int
+	switch key {
+	case "name":
+		compare = func(a, b *cyclonedx.Component) int { return 0 }
+	case "version":
+		compare = func(a, b *cyclonedx.Component) int { return compareVersions(a.Version, b.Version) }
+	case "type":
+		compare = func(a, b *cyclonedx.Component) int { return strings.Compare(string(a.Type), string(b.Type)) }
+	case "purl":
+		compare = func(a, b *cyclonedx.Component) int { return strings.Compare(a.PackageURL, b.PackageURL) }
+	default:
+		return fmt.Errorf("unknown sort key %q, must be one of: %s", key, strings.Join(ComponentSortKeys, ", "))
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		a, b := &components[i], &components[j]
+		if c := compare(a, b); c != 0 {
+			return c < 0
+		}
+		if c := strings.Compare(strings.ToLower(DisplayName(a.Group, a.Name)), strings.ToLower(DisplayName(b.Group, b.Name))); c != 0 {
+			return c < 0
+		}
+		return compareVersions(a.Version, b.Version) < 0
+	})
+	return nil
+}
+
+// compareVersions compares two versions piece by piece, numbers by value and
+// everything else lexically, so 1.10.0 sorts after 1.9.0. A version followed
+// by a pre-release like -rc.1 sorts before the version itself.
+func compareVersions(a, b string) int {
+	pa, pb := versionPieces(a), versionPieces(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.ParseUint(pa[i], 10, 64)
+		nb, errB := strconv.ParseUint(pb[i], 10, 64)
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = compareUint(na, nb)
+		case errA == nil:
+			c = -1
+		case errB == nil:
+			c = 1
+		default:
+			c = strings.Compare(pa[i], pb[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(pa) > len(pb):
+		return -compareVersionRest(pa[len(pb)])
+	case len(pa) < len(pb):
+		return compareVersionRest(pb[len(pa)])
+	}
+	return 0
+}
+
+// compareVersionRest compares a version to a longer one with the given next
+// piece: more numbers make it newer, anything else marks a pre-release
+func compareVersionRest(next string) int {
+	if _, err := strconv.ParseUint(next, 10, 64); err == nil {
+		return -1
+	}
+	return 1
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// versionPieces splits a version into runs of digits and runs of other
+// characters, dropping the separators
+func versionPieces(version string) []string {
+	var pieces []string
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			pieces = append(pieces, version[start:end])
+			start = -1
+		}
+	}
+	isDigit := func(r byte) bool { return r >= '0' && r <= '9' }
+	for i := 0; i < len(version); i++ {
+		if isVersionSeparator(version[i]) {
+			flush(i)
+			continue
+		}
+		if start >= 0 && isDigit(version[i]) != isDigit(version[start]) {
+			flush(i)
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(version))
+	return pieces
+}
+
+func isVersionSeparator(r byte) bool {
+	return r == '.' || r == '-' || r == '+' || r == '_' || r == '~'
+}
+
+// ComponentColumns are the columns of a component listing
+var ComponentColumns = []string{"name", "version", "type", "purl", "group", "supplier", "licenses", "hashes", "bom-ref", "scope"}
+
+// ComponentColumn returns the value of a column of a component listing, see
+// ComponentColumns. Licenses and hashes are joined by ", ".
+func ComponentColumn(c *cyclonedx.Component, column string) (string, error) {
+	switch column {
+	case "name":
+		return c.Name, nil
+	case "version":
+		return c.Version, nil
+	case "type":
+		return string(c.Type), nil
+	case "purl":
+		return c.PackageURL, nil
+	case "group":
+		return c.Group, nil
+	case "supplier":
+		return supplierName(c), nil
+	case "licenses":
+		return strings.Join(componentLicenses(*c), ", "), nil
+	case "hashes":
+		var hashes []string
+		if c.Hashes != nil {
+			for _, hash := range *c.Hashes {
+				hashes = append(hashes, string(hash.Algorithm)+":"+hash.Value)
+			}
+		}
+		return strings.Join(hashes, ", "), nil
+	case "bom-ref":
+		return c.BOMRef, nil
+	case "scope":
+		return string(c.Scope), nil
+	}
+	return "", fmt.Errorf("unknown column %q, must be one of: %s", column, strings.Join(ComponentColumns, ", "))
+}
@@ -0,0 +1,137 @@
+package sbom
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func componentsTestBOM() *cyclonedx.BOM {
+	bom := cyclonedx.NewBOM()
+	bom.Components = &[]cyclonedx.Component{
+		{
+			BOMRef: "app", Name: "app", Version: "1.0.0", Type: cyclonedx.ComponentTypeApplication,
+			Components: &[]cyclonedx.Component{
+				{BOMRef: "plugin", Name: "plugin", Version: "0.1.0", Type: cyclonedx.ComponentTypeLibrary},
+			},
+		},
+		{
+			BOMRef: "lodash", Name: "lodash", Version: "4.17.21", Type: cyclonedx.ComponentTypeLibrary,
+			PackageURL: "pkg:npm/lodash@4.17.21",
+			Licenses:   &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}},
+		},
+		{
+			BOMRef: "testify", Group: "github.com/stretchr", Name: "testify", Version: "1.10.0", Type: cyclonedx.ComponentTypeLibrary,
+			PackageURL: "pkg:golang/github.com/stretchr/testify@1.10.0", Scope: cyclonedx.ScopeExcluded,
+			Hashes: &[]cyclonedx.Hash{{Algorithm: cyclonedx.HashAlgoSHA256, Value: "abc"}},
+		},
+		{
+			BOMRef: "uuid", Group: "github.com/google", Name: "uuid", Version: "1.9.0", Type: cyclonedx.ComponentTypeLibrary,
+			PackageURL: "pkg:golang/github.com/google/uuid@1.9.0",
+		},
+	}
+	return bom
+}
+
+func componentRefs(components []cyclonedx.Component) []string {
+	refs := []string{}
+	for _, c := range components {
+		refs = append(refs, c.BOMRef)
+	}
+	return refs
+}
+
+func TestFilterComponents(t *testing.T) {
+	hasLicense, noLicense := true, false
+
+	tests := []struct {
+		name     string
+		filter   ComponentFilter
+		expected []string
+	}{
+		{"empty filter with nested components", ComponentFilter{}, []string{"app", "plugin", "lodash", "testify", "uuid"}},
+		{"type", ComponentFilter{Types: []cyclonedx.ComponentType{cyclonedx.ComponentTypeApplication}}, []string{"app"}},
+		{"purl type", ComponentFilter{PurlTypes: []string{"golang"}}, []string{"testify", "uuid"}},
+		{"name regex on group and name", ComponentFilter{NameRegex: regexp.MustCompile(`^github\.com/google/`)}, []string{"uuid"}},
+		{"missing scope counts as required", ComponentFilter{Scopes: []cyclonedx.Scope{cyclonedx.ScopeRequired}}, []string{"app", "plugin", "lodash", "uuid"}},
+		{"has license", ComponentFilter{HasLicense: &hasLicense}, []string{"lodash"}},
+		{"has no license", ComponentFilter{HasLicense: &noLicense, PurlTypes: []string{"golang"}}, []string{"testify", "uuid"}},
+		{"missing purl", ComponentFilter{MissingPurl: true}, []string{"app", "plugin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := componentRefs(FilterComponents(componentsTestBOM(), tt.filter))
+			if !slices.Equal(refs, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, refs)
+			}
+		})
+	}
+}
+
+func TestSortComponents(t *testing.T) {
+	tests := map[string][]string{
+		"name":    {"app", "uuid", "testify", "lodash", "plugin"},
+		"version": {"plugin", "app", "uuid", "testify", "lodash"},
+		"type":    {"app", "uuid", "testify", "lodash", "plugin"},
+		"purl":    {"app", "plugin", "uuid", "testify", "lodash"},
+	}
+	for key, expected := range tests {
+		components := FilterComponents(componentsTestBOM(), ComponentFilter{})
+		if err := SortComponents(components, key); err != nil {
+			t.Fatalf("SortComponents(%q) failed: %v", key, err)
+		}
+		if refs := componentRefs(components); !slices.Equal(refs, expected) {
+			t.Errorf("SortComponents(%q): expected %v, got %v", key, expected, refs)
+		}
+	}
+
+	if err := SortComponents(nil, "size"); err == nil {
+		t.Error("Expected an error for an unknown sort key")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.9.0", "1.10.0", -1},
+		{"1.10.0", "1.10.0", 0},
+		{"2.0.0", "2.0.0-rc.1", 1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"v1.2", "v1.10", -1},
+	}
+	for _, tt := range tests {
+		if c := compareVersions(tt.a, tt.b); (c > 0) != (tt.expected > 0) || (c < 0) != (tt.expected < 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, expected sign of %d", tt.a, tt.b, c, tt.expected)
+		}
+	}
+}
+
+func TestComponentColumn(t *testing.T) {
+	components := *componentsTestBOM().Components
+
+	tests := []struct {
+		component cyclonedx.Component
+		column    string
+		expected  string
+	}{
+		{components[1], "licenses", "MIT"},
+		{components[2], "hashes", "SHA-256:abc"},
+		{components[2], "group", "github.com/stretchr"},
+		{components[2], "scope", "excluded"},
+		{components[3], "bom-ref", "uuid"},
+	}
+	for _, tt := range tests {
+		value, err := ComponentColumn(&tt.component, tt.column)
+		if err != nil || value != tt.expected {
+			t.Errorf("ComponentColumn(%s, %q) = %q, %v, expected %q", tt.component.BOMRef, tt.column, value, err, tt.expected)
+		}
+	}
+
+	if _, err := ComponentColumn(&components[0], "size"); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+}
@@ -0,0 +1,321 @@
+package sbom
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// Precedence decides which value wins when duplicate components disagree on a scalar field
+type Precedence string
+
+const (
+	// PrecedenceFirst prefers values of the component that was seen first
+	PrecedenceFirst Precedence = "first"
+	// PrecedenceLast prefers values of the component that was seen last
+	PrecedenceLast Precedence = "last"
+	// PrecedenceMostComplete prefers values of the component with the most fields set
+	PrecedenceMostComplete Precedence = "most-complete"
+)
+
+// ParsePrecedence validates the name of a precedence
+func ParsePrecedence(name string) (Precedence, error) {
+	switch p := Precedence(name); p {
+	case PrecedenceFirst, PrecedenceLast, PrecedenceMostComplete:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown precedence %q, must be one of: first, last, most-complete", name)
+}
+
+// FieldConflict describes a field on which duplicate components disagreed
+type FieldConflict struct {
+	// Component identifies the merged component by bom-ref, package URL or name
+	Component string `json:"component"`
+	// Field is the name of the field, e.g. "supplier" or "hashes[SHA-256]"
+	Field string `json:"field"`
+	// Values are the distinct values found in the inputs
+	Values []string `json:"values"`
+	// Chosen is the value that ended up in the merged component
+	Chosen string `json:"chosen"`
+}
+
+// CombineComponents merges duplicates of the same component into one.
+// Hashes, licenses, external references and properties are combined, scalar
+// fields are chosen by the precedence of the merge options. Disagreements are
+// recorded as conflicts in the merge report.
+func (mc *MergeContext) CombineComponents(components ...cyclonedx.Component) cyclonedx.Component {
+	combined, conflicts := combineComponents(components, mc.Options.Precedence)
+	if mc.Report != nil {
+		mc.Report.Conflicts = append(mc.Report.Conflicts, conflicts...)
+	}
+	return combined
+}
+
+// scalarField gives access to a single-valued field of a component
+type scalarField struct {
+	name string
+	get  func(*cyclonedx.Component) string
+	set  func(dst, src *cyclonedx.Component)
+}
+
+var scalarFields = []scalarField{
+	{"type", func(c *cyclonedx.Component) string { return string(c.Type) }, func(dst, src *cyclonedx.Component) { dst.Type = src.Type }},
+	{"mime-type", func(c *cyclonedx.Component) string { return c.MIMEType }, func(dst, src *cyclonedx.Component) { dst.MIMEType = src.MIMEType }},
+	{"supplier", supplierName, func(dst, src *cyclonedx.Component) { dst.Supplier = src.Supplier }},
+	{"author", func(c *cyclonedx.Component) string { return c.Author }, func(dst, src *cyclonedx.Component) { dst.Author = src.Author }},
+	{"publisher", func(c *cyclonedx.Component) string { return c.Publisher }, func(dst, src *cyclonedx.Component) { dst.Publisher = src.Publisher }},
+	{"group", func(c *cyclonedx.Component) string { return c.Group }, func(dst, src *cyclonedx.Component) { dst.Group = src.Group }},
+	{"name", func(c *cyclonedx.Component) string { return c.Name }, func(dst, src *cyclonedx.Component) { dst.Name = src.Name }},
+	{"version", func(c *cyclonedx.Component) string { return c.Version }, func(dst, src *cyclonedx.Component) { dst.Version = src.Version }},
+	{"description", func(c *cyclonedx.Component) string { return c.Description }, func(dst, src *cyclonedx.Component) { dst.Description = src.Description }},
+	{"scope", func(c *cyclonedx.Component) string { return string(c.Scope) }, func(dst, src *cyclonedx.Component) { dst.Scope = src.Scope }},
+	{"copyright", func(c *cyclonedx.Component) string { return c.Copyright }, func(dst, src *cyclonedx.Component) { dst.Copyright = src.Copyright }},
+	{"cpe", func(c *cyclonedx.Component) string { return c.CPE }, func(dst, src *cyclonedx.Component) { dst.CPE = src.CPE }},
+	{"purl", func(c *cyclonedx.Component) string { return c.PackageURL }, func(dst, src *cyclonedx.Component) { dst.PackageURL = src.PackageURL }},
+}
+
+func supplierName(c *cyclonedx.Component) string {
+	if c.Supplier == nil {
+		return ""
+	}
+	return c.Supplier.Name
+}
+
+// combineComponents merges the given components, see MergeContext.CombineComponents
+func combineComponents(components []cyclonedx.Component, precedence Precedence) (cyclonedx.Component, []FieldConflict) {
+	if len(components) == 0 {
+		return cyclonedx.Component{}, nil
+	}
+	if len(components) == 1 {
+		return components[0], nil
+	}
+
+	ordered := orderByPrecedence(components, precedence)
+	result := ordered[0]
+	id := result.BOMRef
+	if id == "" {
+		id = componentKey(result)
+	}
+
+	var conflicts []FieldConflict
+
+	// Scalar fields: the first non-empty value in precedence order wins
+	for _, field := range scalarFields {
+		var values []string
+		var chosen *cyclonedx.Component
+		for i := range ordered {
+			value := field.get(&ordered[i])
+			if value == "" {
+				continue
+			}
+			if chosen == nil {
+				chosen = &ordered[i]
+			}
+			if !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+		if chosen == nil {
+			continue
+		}
+		field.set(&result, chosen)
+		if len(values) > 1 {
+			conflicts = append(conflicts, FieldConflict{Component: id, Field: field.name, Values: values, Chosen: field.get(chosen)})
+		}
+	}
+
+	// Fields without a dedicated merge are taken from the first component that has them
+	for _, c := range ordered[1:] {
+		fillMissingFields(&result, c)
+	}
+
+	// Hashes: union, with precedence deciding between different values of the same algorithm
+	var hashes []cyclonedx.Hash
+	hashValues := make(map[cyclonedx.HashAlgorithm][]string)
+	for _, c := range ordered {
+		if c.Hashes == nil {
+			continue
+		}
+		for _, hash := range *c.Hashes {
+			if !slices.Contains(hashValues[hash.Algorithm], hash.Value) {
+				if len(hashValues[hash.Algorithm]) == 0 {
+					hashes = append(hashes, hash)
+				}
+				hashValues[hash.Algorithm] = append(hashValues[hash.Algorithm], hash.Value)
+			}
+		}
+	}
+	for _, hash := range hashes {
+		if values := hashValues[hash.Algorithm]; len(values) > 1 {
+			conflicts = append(conflicts, FieldConflict{
+				Component: id,
+				Field:     fmt.Sprintf("hashes[%s]", hash.Algorithm),
+				Values:    values,
+				Chosen:    hash.Value,
+			})
+		}
+	}
+	result.Hashes = sliceOrNil(hashes)
+
+	// Licenses, external references and properties: union
+	var licenses cyclonedx.Licenses
+	var externalRefs []cyclonedx.ExternalReference
+	var properties []cyclonedx.Property
+	seen := make(map[string]bool)
+	for _, c := range ordered {
+		if c.Licenses != nil {
+			for _, license := range *c.Licenses {
+				key := "license:" + licenseKey(license)
+				if !seen[key] {
+					seen[key] = true
+					licenses = append(licenses, license)
+				}
+			}
+		}
+		if c.ExternalReferences != nil {
+			for _, ref := range *c.ExternalReferences {
+				key := "externalReference:" + string(ref.Type) + "|" + ref.URL
+				if !seen[key] {
+					seen[key] = true
+					externalRefs = append(externalRefs, ref)
+				}
+			}
+		}
+		if c.Properties != nil {
+			for _, property := range *c.Properties {
+				key := "property:" + property.Name + "=" + property.Value
+				if !seen[key] {
+					seen[key] = true
+					properties = append(properties, property)
+				}
+			}
+		}
+	}
+	result.Licenses = nil
+	if len(licenses) > 0 {
+		result.Licenses = &licenses
+	}
+	result.ExternalReferences = sliceOrNil(externalRefs)
+	result.Properties = sliceOrNil(properties)
+
+	return result, conflicts
+}
+
+// orderByPrecedence returns the components in the order in which their values are preferred
+func orderByPrecedence(components []cyclonedx.Component, precedence Precedence) []cyclonedx.Component {
+	ordered := make([]cyclonedx.Component, len(components))
+	copy(ordered, components)
+
+	switch precedence {
+	case PrecedenceLast:
+		for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	case PrecedenceMostComplete:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return completeness(ordered[i]) > completeness(ordered[j])
+		})
+	}
+
+	return ordered
+}
+
+// completeness counts the fields that are set on a component
+func completeness(c cyclonedx.Component) int {
+	score := 0
+	for _, field := range scalarFields {
+		if field.get(&c) != "" {
+			score++
+		}
+	}
+	for _, set := range []bool{
+		c.Hashes != nil && len(*c.Hashes) > 0,
+		c.Licenses != nil && len(*c.Licenses) > 0,
+		c.ExternalReferences != nil && len(*c.ExternalReferences) > 0,
+		c.Properties != nil && len(*c.Properties) > 0,
+		c.Manufacturer != nil,
+		c.Authors != nil,
+		c.SWID != nil,
+		c.Pedigree != nil,
+		c.Evidence != nil,
+	} {
+		if set {
+			score++
+		}
+	}
+	return score
+}
+
+// fillMissingFields copies the fields without a dedicated merge from src to
+// dst, if dst does not have them
+func fillMissingFields(dst *cyclonedx.Component, src cyclonedx.Component) {
+	if dst.Manufacturer == nil {
+		dst.Manufacturer = src.Manufacturer
+	}
+	if dst.Authors == nil {
+		dst.Authors = src.Authors
+	}
+	if dst.OmniborID == nil {
+		dst.OmniborID = src.OmniborID
+	}
+	if dst.SWHID == nil {
+		dst.SWHID = src.SWHID
+	}
+	if dst.SWID == nil {
+		dst.SWID = src.SWID
+	}
+	if dst.Modified == nil {
+		dst.Modified = src.Modified
+	}
+	if dst.Pedigree == nil {
+		dst.Pedigree = src.Pedigree
+	}
+	if dst.Components == nil {
+		dst.Components = src.Components
+	}
+	if dst.Evidence == nil {
+		dst.Evidence = src.Evidence
+	}
+	if dst.ReleaseNotes == nil {
+		dst.ReleaseNotes = src.ReleaseNotes
+	}
+	if dst.ModelCard == nil {
+		dst.ModelCard = src.ModelCard
+	}
+	if dst.Data == nil {
+		dst.Data = src.Data
+	}
+	if dst.CryptoProperties == nil {
+		dst.CryptoProperties = src.CryptoProperties
+	}
+}
+
+// licenseKey returns a string identifying a license choice: its expression,
+// or the ID, name, URL or text of its license, whichever is set first
+func licenseKey(license cyclonedx.LicenseChoice) string {
+	switch {
+	case license.Expression != "":
+		return license.Expression
+	case license.License == nil:
+		return ""
+	case license.License.ID != "":
+		return license.License.ID
+	case license.License.Name != "":
+		return license.License.Name
+	case license.License.URL != "":
+		return license.License.URL
+	case license.License.Text != nil:
+		return license.License.Text.Content
+	}
+	return ""
+}
+
+// sliceOrNil returns a pointer to the slice, or nil if it is empty
+func sliceOrNil[T any](s []T) *[]T {
+	if len(s) == 0 {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,184 @@
+package sbom
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func conflictTestComponents() []cyclonedx.Component {
+	return []cyclonedx.Component{
+		{
+			BOMRef:     "pkg:npm/lodash@4.17.21",
+			Name:       "lodash",
+			Version:    "4.17.21",
+			PackageURL: "pkg:npm/lodash@4.17.21",
+			Hashes: &[]cyclonedx.Hash{
+				{Algorithm: cyclonedx.HashAlgoSHA256, Value: "aaaa"},
+			},
+			Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}},
+		},
+		{
+			BOMRef:      "pkg:npm/lodash@4.17.21",
+			Name:        "lodash",
+			Version:     "4.17.21",
+			PackageURL:  "pkg:npm/lodash@4.17.21",
+			Description: "Lodash modular utilities.",
+			Supplier:    &cyclonedx.OrganizationalEntity{Name: "OpenJS Foundation"},
+			Hashes: &[]cyclonedx.Hash{
+				{Algorithm: cyclonedx.HashAlgoSHA256, Value: "bbbb"},
+				{Algorithm: cyclonedx.HashAlgoSHA512, Value: "cccc"},
+			},
+			Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}},
+			ExternalReferences: &[]cyclonedx.ExternalReference{
+				{Type: cyclonedx.ERTypeWebsite, URL: "https://lodash.com/"},
+			},
+			Properties: &[]cyclonedx.Property{{Name: "cdx:npm:package:path", Value: "node_modules/lodash"}},
+		},
+	}
+}
+
+func TestCombineComponentsUnion(t *testing.T) {
+	combined, conflicts := combineComponents(conflictTestComponents(), PrecedenceFirst)
+
+	if combined.Description != "Lodash modular utilities." {
+		t.Errorf("Expected description of the second component to be kept, got %q", combined.Description)
+	}
+	if combined.Supplier == nil || combined.Supplier.Name != "OpenJS Foundation" {
+		t.Errorf("Expected supplier of the second component to be kept, got %+v", combined.Supplier)
+	}
+	if combined.Hashes == nil || len(*combined.Hashes) != 2 {
+		t.Fatalf("Expected one hash per algorithm, got %+v", combined.Hashes)
+	}
+	if (*combined.Hashes)[0].Value != "aaaa" {
+		t.Errorf("Expected SHA-256 of the first component to win, got %s", (*combined.Hashes)[0].Value)
+	}
+	if combined.Licenses == nil || len(*combined.Licenses) != 1 {
+		t.Errorf("Expected duplicate licenses to be merged, got %+v", combined.Licenses)
+	}
+	if combined.ExternalReferences == nil || len(*combined.ExternalReferences) != 1 {
+		t.Errorf("Expected external references to be kept, got %+v", combined.ExternalReferences)
+	}
+	if combined.Properties == nil || len(*combined.Properties) != 1 {
+		t.Errorf("Expected properties to be kept, got %+v", combined.Properties)
+	}
+
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %+v", conflicts)
+	}
+	conflict := conflicts[0]
+	if conflict.Field != "hashes[SHA-256]" || conflict.Chosen != "aaaa" || len(conflict.Values) != 2 {
+		t.Errorf("Unexpected conflict: %+v", conflict)
+	}
+}
+
+func TestCombineComponentsLicensesWithoutID(t *testing.T) {
+	components := []cyclonedx.Component{
+		{Name: "foo", Licenses: &cyclonedx.Licenses{
+			{License: &cyclonedx.License{URL: "https://example.com/license-a"}},
+			{License: &cyclonedx.License{Text: &cyclonedx.AttachedText{Content: "License A"}}},
+		}},
+		{Name: "foo", Licenses: &cyclonedx.Licenses{
+			{License: &cyclonedx.License{URL: "https://example.com/license-a"}},
+			{License: &cyclonedx.License{URL: "https://example.com/license-b"}},
+			{License: &cyclonedx.License{Text: &cyclonedx.AttachedText{Content: "License B"}}},
+		}},
+	}
+	combined, _ := combineComponents(components, PrecedenceFirst)
+
+	var keys []string
+	for _, license := range *combined.Licenses {
+		keys = append(keys, licenseKey(license))
+	}
+	expected := []string{"https://example.com/license-a", "License A", "https://example.com/license-b", "License B"}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("Expected licenses %v, got %v", expected, keys)
+	}
+}
+
+func TestCombineComponentsPrecedence(t *testing.T) {
+	components := []cyclonedx.Component{
+		{Name: "foo", Version: "1.0.0", Publisher: "first"},
+		{Name: "foo", Version: "1.0.0", Publisher: "second", Description: "more complete", CPE: "cpe:2.3:a:foo:foo:1.0.0:*:*:*:*:*:*:*"},
+		{Name: "foo", Version: "1.0.0", Publisher: "third"},
+	}
+
+	tests := []struct {
+		precedence Precedence
+		expected   string
+	}{
+		{PrecedenceFirst, "first"},
+		{PrecedenceLast, "third"},
+		{PrecedenceMostComplete, "second"},
+	}
+
+	for _, tt := range tests {
+		combined, conflicts := combineComponents(components, tt.precedence)
+		if combined.Publisher != tt.expected {
+			t.Errorf("Precedence %s: expected publisher %q, got %q", tt.precedence, tt.expected, combined.Publisher)
+		}
+		if len(conflicts) != 1 || conflicts[0].Field != "publisher" || len(conflicts[0].Values) != 3 {
+			t.Errorf("Precedence %s: expected a publisher conflict with 3 values, got %+v", tt.precedence, conflicts)
+		}
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	if _, err := ParsePrecedence("most-complete"); err != nil {
+		t.Errorf("Expected most-complete to be valid: %v", err)
+	}
+	if _, err := ParsePrecedence("random"); err == nil {
+		t.Error("Expected error for unknown precedence")
+	}
+}
+
+func TestMergeSBOMsReportsConflicts(t *testing.T) {
+	testDir := t.TempDir()
+	sbom1Path := filepath.Join(testDir, "sbom1.json")
+	sbom2Path := filepath.Join(testDir, "sbom2.json")
+	outputPath := filepath.Join(testDir, "merged.json")
+
+	components := conflictTestComponents()
+	// The inputs' bom-refs differ from the package URL the components are merged to
+	components[0].BOMRef, components[1].BOMRef = "lodash-a", "lodash-b"
+	sbom1 := cyclonedx.NewBOM()
+	sbom1.SerialNumber = "urn:uuid:serial-1"
+	sbom1.Components = &[]cyclonedx.Component{components[0]}
+	sbom2 := cyclonedx.NewBOM()
+	sbom2.SerialNumber = "urn:uuid:serial-2"
+	sbom2.Components = &[]cyclonedx.Component{components[1]}
+
+	if err := WriteSBOMFile(sbom1, sbom1Path); err != nil {
+		t.Fatalf("Failed to write test SBOM 1: %v", err)
+	}
+	if err := WriteSBOMFile(sbom2, sbom2Path); err != nil {
+		t.Fatalf("Failed to write test SBOM 2: %v", err)
+	}
+
+	report, err := MergeSBOMsWithOptions([]string{sbom1Path, sbom2Path}, outputPath, MergeOptions{
+		Strategy:   PurlStrategy{},
+		Precedence: PrecedenceLast,
+	})
+	if err != nil {
+		t.Fatalf("Failed to merge SBOMs: %v", err)
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0].Chosen != "bbbb" {
+		t.Errorf("Expected SHA-256 conflict resolved to the last value, got %+v", report.Conflicts)
+	}
+
+	mergedBom, err := ReadSBOMFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	if len(*mergedBom.Components) != 1 {
+		t.Fatalf("Expected a single merged lodash component, got %d", len(*mergedBom.Components))
+	}
+	if (*mergedBom.Components)[0].Supplier == nil {
+		t.Error("Expected supplier of the second SBOM to survive the merge")
+	}
+	if ref := (*mergedBom.Components)[0].BOMRef; len(report.Conflicts) == 1 && report.Conflicts[0].Component != ref {
+		t.Errorf("Expected conflict to be reported for the merged component %s, got %s", ref, report.Conflicts[0].Component)
+	}
+}
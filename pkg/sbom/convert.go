@@ -0,0 +1,191 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// ConversionLoss describes a field that did not survive the conversion to
+// another spec version
+type ConversionLoss struct {
+	// Path is the JSON path of the field, with [] standing for any array
+	// index. Lost SPDX relationships are reported by type, like
+	// relationships[GENERATED_FROM].
+	Path string `json:"path"`
+	// Count is how often the field was lost
+	Count int `json:"count"`
+}
+
+// ParseSpecVersion parses a CycloneDX spec version like "1.6"
+func ParseSpecVersion(s string) (cyclonedx.SpecVersion, error) {
+	for sv := cyclonedx.SpecVersion1_0; sv <= cyclonedx.SpecVersion1_6; sv++ {
+		if sv.String() == s {
+			return sv, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown spec version %q, must be one of 1.0 to 1.6", s)
+}
+
+// ConvertSBOM converts a BOM to the given spec version and encodes it in the
+// given format. It returns the encoded BOM and the fields that were lost,
+// which happens when converting to an older spec version or to SPDX. The
+// spec version is ignored for SPDX.
+func ConvertSBOM(bom *cyclonedx.BOM, specVersion cyclonedx.SpecVersion, format FileFormat) ([]byte, []ConversionLoss, error) {
+	bom = withoutEmptyTools(bom)
+	if format == FileFormatSPDXJSON {
+		return EncodeSPDX(bom)
+	}
+	converted, err := convertBOM(bom, specVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	losses, err := conversionLosses(bom, converted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := EncodeSBOM(converted, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, losses, nil
+}
+
+// convertBOM returns a copy of the BOM that adheres to the given spec
+// version. The conversion of cyclonedx-go is only available while encoding,
+// so the BOM is encoded and decoded again. JSON does not exist before
+// CycloneDX 1.2, XML is used for these versions.
+func convertBOM(bom *cyclonedx.BOM, specVersion cyclonedx.SpecVersion) (*cyclonedx.BOM, error) {
+	format, bomFormat := FileFormatJSON, cyclonedx.BOMFileFormatJSON
+	if specVersion < cyclonedx.SpecVersion1_2 {
+		format, bomFormat = FileFormatXML, cyclonedx.BOMFileFormatXML
+	}
+
+	var buf bytes.Buffer
+	if err := cyclonedx.NewBOMEncoder(&buf, bomFormat).EncodeVersion(bom, specVersion); err != nil {
+		return nil, fmt.Errorf("failed to convert BOM to spec version %s: %w", specVersion, err)
+	}
+	return DecodeSBOM(buf.Bytes(), format)
+}
+
+// withoutEmptyTools returns a shallow copy of the BOM without its tools if
+// they are empty. A tools object without tools, components and services is
+// left behind by decoding a tools object with unknown content, and cannot be
+// encoded again.
+func withoutEmptyTools(bom *cyclonedx.BOM) *cyclonedx.BOM {
+	if bom.Metadata == nil || bom.Metadata.Tools == nil {
+		return bom
+	}
+	tools := bom.Metadata.Tools
+	if tools.Tools != nil || tools.Components != nil || tools.Services != nil {
+		return bom
+	}
+	bomCopy, metadata := *bom, *bom.Metadata
+	metadata.Tools = nil
+	bomCopy.Metadata = &metadata
+	return &bomCopy
+}
+
+// conversionLosses compares the JSON representations of a BOM before and
+// after a conversion and returns the fields that are missing or changed
+func conversionLosses(before, after *cyclonedx.BOM) ([]ConversionLoss, error) {
+	beforeTree, err := jsonTree(before)
+	if err != nil {
+		return nil, err
+	}
+	afterTree, err := jsonTree(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// These always change with the spec version
+	for _, tree := range []map[string]any{beforeTree, afterTree} {
+		delete(tree, "specVersion")
+		delete(tree, "$schema")
+	}
+
+	counts := make(map[string]int)
+	collectLosses("", beforeTree, afterTree, counts)
+	return sortedLosses(counts), nil
+}
+
+// sortedLosses turns the counts of lost fields by path into losses sorted by path
+func sortedLosses(counts map[string]int) []ConversionLoss {
+	losses := make([]ConversionLoss, 0, len(counts))
+	for path, count := range counts {
+		losses = append(losses, ConversionLoss{Path: path, Count: count})
+	}
+	sort.Slice(losses, func(i, j int) bool {
+		return losses[i].Path < losses[j].Path
+	})
+	return losses
+}
+
+// jsonTree returns the generic JSON representation of a BOM
+func jsonTree(bom *cyclonedx.BOM) (map[string]any, error) {
+	data, err := json.Marshal(bom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode BOM: %w", err)
+	}
+	var tree map[string]any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to decode BOM: %w", err)
+	}
+	return tree, nil
+}
+
+// collectLosses counts the values of before that are missing or different in
+// after. Array elements are compared by index, missing empty arrays and
+// objects are not counted.
+func collectLosses(path string, before, after any, counts map[string]int) {
+	switch b := before.(type) {
+	case map[string]any:
+		a, _ := after.(map[string]any)
+		for key, value := range b {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			afterValue, ok := a[key]
+			if !ok {
+				if isEmptyJSON(value) {
+					continue
+				}
+				counts[keyPath]++
+				continue
+			}
+			collectLosses(keyPath, value, afterValue, counts)
+		}
+	case []any:
+		a, _ := after.([]any)
+		for i, value := range b {
+			if i >= len(a) {
+				counts[path+"[]"]++
+				continue
+			}
+			collectLosses(path+"[]", value, a[i], counts)
+		}
+	default:
+		if !reflect.DeepEqual(before, after) {
+			counts[path]++
+		}
+	}
+}
+
+// isEmptyJSON reports whether a JSON value is an empty array or object, which
+// may be dropped by an encoding without losing information
+func isEmptyJSON(value any) bool {
+	switch v := value.(type) {
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
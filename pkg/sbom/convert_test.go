@@ -0,0 +1,72 @@
+package sbom
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestParseSpecVersion(t *testing.T) {
+	specVersion, err := ParseSpecVersion("1.4")
+	if err != nil {
+		t.Fatalf("Failed to parse spec version: %v", err)
+	}
+	if specVersion != cyclonedx.SpecVersion1_4 {
+		t.Errorf("Expected spec version 1.4, got %s", specVersion)
+	}
+
+	if _, err := ParseSpecVersion("2.0"); err == nil {
+		t.Error("Expected error for an unknown spec version")
+	}
+}
+
+func TestConvertSBOMDowngrade(t *testing.T) {
+	bom, err := ReadSBOMFile(filepath.Join("..", "..", "testdata", "sbom3.xml"))
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+
+	data, losses, err := ConvertSBOM(bom, cyclonedx.SpecVersion1_4, FileFormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to convert SBOM: %v", err)
+	}
+
+	if len(losses) == 0 || losses[0].Path != "metadata.tools.components" || losses[0].Count != 1 {
+		t.Errorf("Expected metadata.tools.components to be reported as lost, got %+v", losses)
+	}
+
+	converted, err := DecodeSBOM(data, FileFormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to decode converted SBOM: %v", err)
+	}
+	if converted.SpecVersion != cyclonedx.SpecVersion1_4 {
+		t.Errorf("Expected spec version 1.4, got %s", converted.SpecVersion)
+	}
+	if converted.Metadata.Tools == nil || converted.Metadata.Tools.Tools == nil || len(*converted.Metadata.Tools.Tools) != 1 {
+		t.Errorf("Expected tool components to be converted to legacy tools, got %+v", converted.Metadata.Tools)
+	}
+}
+
+func TestConvertSBOMUpgrade(t *testing.T) {
+	bom, err := ReadSBOMFile(filepath.Join("..", "..", "testdata", "sbom1.json"))
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+
+	data, losses, err := ConvertSBOM(bom, cyclonedx.SpecVersion1_6, FileFormatXML)
+	if err != nil {
+		t.Fatalf("Failed to convert SBOM: %v", err)
+	}
+	if len(losses) != 0 {
+		t.Errorf("Expected no losses for an upgrade, got %+v", losses)
+	}
+
+	converted, err := DecodeSBOM(data, FileFormatXML)
+	if err != nil {
+		t.Fatalf("Failed to decode converted SBOM: %v", err)
+	}
+	if converted.SpecVersion != cyclonedx.SpecVersion1_6 || len(*converted.Components) != 2 {
+		t.Errorf("Expected a 1.6 SBOM with 2 components, got %s with %+v", converted.SpecVersion, converted.Components)
+	}
+}
@@ -0,0 +1,173 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// csafDocument is the subset of a CSAF 2.0 VEX document read by ParseVEX,
+// see https://docs.oasis-open.org/csaf/csaf/v2.0/csaf-v2.0.html
+type csafDocument struct {
+	Document struct {
+		Tracking struct {
+			CurrentReleaseDate string `json:"current_release_date"`
+		} `json:"tracking"`
+	} `json:"document"`
+	ProductTree struct {
+		Branches         []csafBranch          `json:"branches"`
+		FullProductNames []csafFullProductName `json:"full_product_names"`
+		Relationships    []struct {
+			ProductReference string              `json:"product_reference"`
+			FullProductName  csafFullProductName `json:"full_product_name"`
+		} `json:"relationships"`
+		ProductGroups []struct {
+			GroupID    string   `json:"group_id"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"product_groups"`
+	} `json:"product_tree"`
+	Vulnerabilities []csafVulnerability `json:"vulnerabilities"`
+}
+
+type csafBranch struct {
+	Branches []csafBranch         `json:"branches"`
+	Product  *csafFullProductName `json:"product"`
+}
+
+type csafFullProductName struct {
+	ProductID                   string `json:"product_id"`
+	ProductIdentificationHelper struct {
+		Purl string `json:"purl"`
+	} `json:"product_identification_helper"`
+}
+
+type csafVulnerability struct {
+	CVE string `json:"cve"`
+	IDs []struct {
+		Text string `json:"text"`
+	} `json:"ids"`
+	ProductStatus map[string][]string `json:"product_status"`
+	Flags         []csafProductNote   `json:"flags"`
+	Threats       []csafProductNote   `json:"threats"`
+	Remediations  []csafProductNote   `json:"remediations"`
+}
+
+// csafProductNote is a flag, threat or remediation about products
+type csafProductNote struct {
+	Label      string   `json:"label"`
+	Category   string   `json:"category"`
+	Details    string   `json:"details"`
+	ProductIDs []string `json:"product_ids"`
+	GroupIDs   []string `json:"group_ids"`
+}
+
+// csafStates map the CSAF product status to CycloneDX, in the order they are read
+var csafStates = []struct {
+	status string
+	state  cyclonedx.ImpactAnalysisState
+}{
+	{"known_not_affected", cyclonedx.IASNotAffected},
+	{"known_affected", cyclonedx.IASExploitable},
+	{"fixed", cyclonedx.IASResolved},
+	{"first_fixed", cyclonedx.IASResolved},
+	{"under_investigation", cyclonedx.IASInTriage},
+}
+
+// csafResponses map the categories of CSAF remediations to CycloneDX
+var csafResponses = map[string]cyclonedx.ImpactAnalysisResponse{
+	"vendor_fix":     cyclonedx.IARUpdate,
+	"workaround":     cyclonedx.IARWorkaroundAvailable,
+	"mitigation":     cyclonedx.IARWorkaroundAvailable,
+	"no_fix_planned": cyclonedx.IARWillNotFix,
+	"none_available": cyclonedx.IARCanNotFix,
+}
+
+// parseCSAFVEX reads the statements of a CSAF VEX document, one per
+// vulnerability and product. Products are identified by the package URL of
+// their product identification helper, or of the component for products
+// that are a component of another product, else by their product ID.
+func parseCSAFVEX(data []byte) ([]VEXStatement, error) {
+	var doc csafDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse CSAF document: %w", err)
+	}
+
+	purls := make(map[string]string)
+	var walk func(branches []csafBranch)
+	walk = func(branches []csafBranch) {
+		for _, branch := range branches {
+			if branch.Product != nil {
+				purls[branch.Product.ProductID] = branch.Product.ProductIdentificationHelper.Purl
+			}
+			walk(branch.Branches)
+		}
+	}
+	walk(doc.ProductTree.Branches)
+	for _, product := range doc.ProductTree.FullProductNames {
+		purls[product.ProductID] = product.ProductIdentificationHelper.Purl
+	}
+	for _, relationship := range doc.ProductTree.Relationships {
+		purl := relationship.FullProductName.ProductIdentificationHelper.Purl
+		if purl == "" {
+			purl = purls[relationship.ProductReference]
+		}
+		purls[relationship.FullProductName.ProductID] = purl
+	}
+	groups := make(map[string][]string)
+	for _, group := range doc.ProductTree.ProductGroups {
+		groups[group.GroupID] = group.ProductIDs
+	}
+	// covers reports whether a flag, threat or remediation is about a product
+	covers := func(note csafProductNote, productID string) bool {
+		return slices.Contains(note.ProductIDs, productID) || slices.ContainsFunc(note.GroupIDs, func(group string) bool {
+			return slices.Contains(groups[group], productID)
+		})
+	}
+
+	statements := []VEXStatement{}
+	for _, v := range doc.Vulnerabilities {
+		var aliases []string
+		for _, id := range v.IDs {
+			aliases = append(aliases, id.Text)
+		}
+		id := v.CVE
+		if id == "" && len(aliases) > 0 {
+			id = aliases[0]
+		}
+
+		for _, status := range csafStates {
+			for _, productID := range v.ProductStatus[status.status] {
+				product := productID
+				if purl := purls[productID]; purl != "" {
+					product = purl
+				}
+				statement := VEXStatement{
+					Vulnerability: id,
+					Aliases:       aliases,
+					Products:      []string{product},
+					State:         status.state,
+					Timestamp:     doc.Document.Tracking.CurrentReleaseDate,
+				}
+				for _, flag := range v.Flags {
+					if covers(flag, productID) && vexJustifications[flag.Label] != "" {
+						statement.Justification = vexJustifications[flag.Label]
+					}
+				}
+				for _, threat := range v.Threats {
+					if threat.Category == "impact" && covers(threat, productID) {
+						statement.Detail = threat.Details
+					}
+				}
+				for _, remediation := range v.Remediations {
+					if response, ok := csafResponses[remediation.Category]; ok && covers(remediation, productID) && !slices.Contains(statement.Response, response) {
+						statement.Response = append(statement.Response, response)
+					}
+				}
+				statements = append(statements, statement)
+			}
+		}
+	}
+	return statements, nil
+}
@@ -0,0 +1,64 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestParseCSAFVEX(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "vex", "csaf.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, format, err := ParseVEX(data)
+	if err != nil {
+		t.Fatalf("Failed to parse VEX: %v", err)
+	}
+	if format != "CSAF" || len(statements) != 1 {
+		t.Fatalf("Expected 1 CSAF statement, got %s %+v", format, statements)
+	}
+	s := statements[0]
+	if s.Vulnerability != "CVE-2024-0001" || s.State != cyclonedx.IASExploitable || !slices.Equal(s.Products, []string{"pkg:npm/example-lib-3@3.4.5"}) ||
+		!slices.Equal(s.Response, []cyclonedx.ImpactAnalysisResponse{cyclonedx.IARUpdate}) || s.Detail != "Attackers can pollute object prototypes." {
+		t.Errorf("Unexpected statement: %+v", s)
+	}
+}
+
+func TestParseCSAFVEXRelationshipsAndGroups(t *testing.T) {
+	data := []byte(`{
+		"document": {"csaf_version": "2.0"},
+		"product_tree": {
+			"full_product_names": [
+				{"product_id": "APP", "name": "app"},
+				{"product_id": "LIB", "name": "lib", "product_identification_helper": {"purl": "pkg:maven/org.example/lib@1.0"}}
+			],
+			"relationships": [
+				{"category": "default_component_of", "product_reference": "LIB", "relates_to_product_reference": "APP", "full_product_name": {"product_id": "APP:LIB", "name": "lib in app"}}
+			],
+			"product_groups": [{"group_id": "G1", "product_ids": ["APP:LIB"]}]
+		},
+		"vulnerabilities": [{
+			"ids": [{"system_name": "GHSA", "text": "GHSA-aaaa-bbbb-cccc"}],
+			"product_status": {"known_not_affected": ["APP:LIB", "APP"]},
+			"flags": [{"label": "vulnerable_code_not_present", "group_ids": ["G1"]}]
+		}]
+	}`)
+	statements, _, err := ParseVEX(data)
+	if err != nil {
+		t.Fatalf("Failed to parse VEX: %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %+v", statements)
+	}
+	if s := statements[0]; s.Vulnerability != "GHSA-aaaa-bbbb-cccc" || !slices.Equal(s.Products, []string{"pkg:maven/org.example/lib@1.0"}) || s.Justification != cyclonedx.IAJCodeNotPresent {
+		t.Errorf("Unexpected statement for the component: %+v", s)
+	}
+	// Products without package URL are identified by their product ID
+	if s := statements[1]; !slices.Equal(s.Products, []string{"APP"}) || s.Justification != "" {
+		t.Errorf("Unexpected statement for the product: %+v", s)
+	}
+}
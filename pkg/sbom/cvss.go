@@ -0,0 +1,126 @@
+package sbom
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// cvss3Weights are the weights of the CVSS v3 base metric values
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// cvss3BaseScore calculates the base score of a CVSS v3.0 or v3.1 vector
+// like "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+func cvss3BaseScore(vector string) (float64, error) {
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || (parts[0] != "CVSS:3.0" && parts[0] != "CVSS:3.1") {
+		return 0, fmt.Errorf("invalid CVSS v3 vector %q", vector)
+	}
+	values := make(map[string]string)
+	for _, part := range parts[1:] {
+		metric, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, fmt.Errorf("invalid CVSS v3 vector %q", vector)
+		}
+		values[metric] = value
+	}
+
+	scope := values["S"]
+	if scope != "U" && scope != "C" {
+		return 0, fmt.Errorf("invalid CVSS v3 vector %q: missing scope", vector)
+	}
+	weights := make(map[string]float64)
+	for metric, metricWeights := range cvss3Weights {
+		weight, ok := metricWeights[values[metric]]
+		if !ok {
+			return 0, fmt.Errorf("invalid CVSS v3 vector %q: missing %s", vector, metric)
+		}
+		weights[metric] = weight
+	}
+	// Privileges weigh more if the scope changes
+	if scope == "C" && values["PR"] == "L" {
+		weights["PR"] = 0.68
+	} else if scope == "C" && values["PR"] == "H" {
+		weights["PR"] = 0.5
+	}
+
+	iss := 1 - (1-weights["C"])*(1-weights["I"])*(1-weights["A"])
+	impact := 6.42 * iss
+	if scope == "C" {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	exploitability := 8.22 * weights["AV"] * weights["AC"] * weights["PR"] * weights["UI"]
+	if impact <= 0 {
+		return 0, nil
+	}
+	if scope == "C" {
+		return cvssRoundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return cvssRoundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// cvssRoundUp rounds up to one decimal as defined in CVSS v3.1, avoiding
+// floating point errors
+func cvssRoundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return (math.Floor(float64(i)/10000) + 1) / 10
+}
+
+// cvssSeverity returns the qualitative severity rating of a CVSS score
+func cvssSeverity(score float64) cyclonedx.Severity {
+	switch {
+	case score >= 9:
+		return cyclonedx.SeverityCritical
+	case score >= 7:
+		return cyclonedx.SeverityHigh
+	case score >= 4:
+		return cyclonedx.SeverityMedium
+	case score > 0:
+		return cyclonedx.SeverityLow
+	}
+	return cyclonedx.SeverityNone
+}
+
+// severityOrder ranks severities from unknown to critical
+var severityOrder = []cyclonedx.Severity{
+	cyclonedx.SeverityUnknown,
+	cyclonedx.SeverityNone,
+	cyclonedx.SeverityInfo,
+	cyclonedx.SeverityLow,
+	cyclonedx.SeverityMedium,
+	cyclonedx.SeverityHigh,
+	cyclonedx.SeverityCritical,
+}
+
+// highestSeverity returns the highest severity of the ratings, or
+// SeverityUnknown if none has a severity
+func highestSeverity(ratings *[]cyclonedx.VulnerabilityRating) cyclonedx.Severity {
+	highest := 0
+	if ratings != nil {
+		for _, rating := range *ratings {
+			severity := rating.Severity
+			if severity == "" && rating.Score != nil {
+				severity = cvssSeverity(*rating.Score)
+			}
+			for i, s := range severityOrder {
+				if s == severity && i > highest {
+					highest = i
+				}
+			}
+		}
+	}
+	return severityOrder[highest]
+}
@@ -0,0 +1,54 @@
+package sbom
+
+import (
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestCVSS3BaseScore(t *testing.T) {
+	tests := []struct {
+		vector   string
+		expected float64
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", 5.5},
+		{"CVSS:3.0/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:H", 5.9},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", 6.4},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			score, err := cvss3BaseScore(tt.vector)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if score != tt.expected {
+				t.Errorf("Expected %.1f, got %.1f", tt.expected, score)
+			}
+		})
+	}
+
+	for _, vector := range []string{"", "CVSS:2.0/AV:N", "CVSS:3.1/AV:N/AC:L", "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"} {
+		if _, err := cvss3BaseScore(vector); err == nil {
+			t.Errorf("Expected error for %q", vector)
+		}
+	}
+}
+
+func TestHighestSeverity(t *testing.T) {
+	score := 7.5
+	ratings := []cyclonedx.VulnerabilityRating{
+		{Severity: cyclonedx.SeverityMedium},
+		{Score: &score},
+		{Severity: cyclonedx.SeverityLow},
+	}
+	if severity := highestSeverity(&ratings); severity != cyclonedx.SeverityHigh {
+		t.Errorf("Expected high from the score, got %s", severity)
+	}
+	if severity := highestSeverity(nil); severity != cyclonedx.SeverityUnknown {
+		t.Errorf("Expected unknown without ratings, got %s", severity)
+	}
+}
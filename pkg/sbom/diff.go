@@ -0,0 +1,317 @@
+package sbom
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+)
+
+// Diff describes the changes between two SBOMs
+type Diff struct {
+	Added               []DiffComponent  `json:"added"`
+	Removed             []DiffComponent  `json:"removed"`
+	VersionChanges      []VersionChange  `json:"versionChanges"`
+	LicenseChanges      []LicenseChange  `json:"licenseChanges"`
+	AddedDependencies   []DependencyEdge `json:"addedDependencies"`
+	RemovedDependencies []DependencyEdge `json:"removedDependencies"`
+}
+
+// DiffComponent is the part of a component that is shown in a diff
+type DiffComponent struct {
+	Name       string `json:"name"`
+	Group      string `json:"group,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Type       string `json:"type,omitempty"`
+	PackageURL string `json:"purl,omitempty"`
+}
+
+// VersionChange describes a component whose version changed between two SBOMs
+type VersionChange struct {
+	Name       string `json:"name"`
+	Group      string `json:"group,omitempty"`
+	OldVersion string `json:"oldVersion"`
+	NewVersion string `json:"newVersion"`
+	OldPURL    string `json:"oldPurl,omitempty"`
+	NewPURL    string `json:"newPurl,omitempty"`
+}
+
+// LicenseChange describes a component whose licenses changed between two SBOMs
+type LicenseChange struct {
+	Name        string   `json:"name"`
+	Group       string   `json:"group,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	OldLicenses []string `json:"oldLicenses"`
+	NewLicenses []string `json:"newLicenses"`
+}
+
+// DependencyEdge is a dependency between two components, identified by their
+// version-less identity so that version bumps do not show up as edge changes
+type DependencyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// IsEmpty reports whether the diff contains no changes
+func (d *Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.VersionChanges) == 0 &&
+		len(d.LicenseChanges) == 0 && len(d.AddedDependencies) == 0 && len(d.RemovedDependencies) == 0
+}
+
+// DiffSBOMs compares two SBOMs and returns the changes from oldBom to newBom.
+// Components are matched by their package URL without version, falling back
+// to group and name for components without a package URL.
+func DiffSBOMs(oldBom, newBom *cyclonedx.BOM) *Diff {
+	diff := &Diff{
+		Added:               []DiffComponent{},
+		Removed:             []DiffComponent{},
+		VersionChanges:      []VersionChange{},
+		LicenseChanges:      []LicenseChange{},
+		AddedDependencies:   []DependencyEdge{},
+		RemovedDependencies: []DependencyEdge{},
+	}
+
+	oldByIdentity := groupComponentsByIdentity(oldBom)
+	newByIdentity := groupComponentsByIdentity(newBom)
+
+	identities := make(map[string]bool)
+	for id := range oldByIdentity {
+		identities[id] = true
+	}
+	for id := range newByIdentity {
+		identities[id] = true
+	}
+
+	for id := range identities {
+		oldComps := oldByIdentity[id]
+		newComps := newByIdentity[id]
+
+		// Components present with the same version on both sides are unchanged,
+		// apart from possibly their licenses
+		var remainingOld, remainingNew []cyclonedx.Component
+		for _, oc := range oldComps {
+			if nc, ok := findComponentVersion(newComps, oc.Version); ok {
+				diff.addLicenseChange(oc, nc)
+			} else {
+				remainingOld = append(remainingOld, oc)
+			}
+		}
+		for _, nc := range newComps {
+			if _, ok := findComponentVersion(oldComps, nc.Version); !ok {
+				remainingNew = append(remainingNew, nc)
+			}
+		}
+
+		// A single version on each side is treated as a version bump, anything
+		// else is reported as additions and removals
+		if len(remainingOld) == 1 && len(remainingNew) == 1 {
+			oc, nc := remainingOld[0], remainingNew[0]
+			diff.VersionChanges = append(diff.VersionChanges, VersionChange{
+				Name:       nc.Name,
+				Group:      nc.Group,
+				OldVersion: oc.Version,
+				NewVersion: nc.Version,
+				OldPURL:    oc.PackageURL,
+				NewPURL:    nc.PackageURL,
+			})
+			diff.addLicenseChange(oc, nc)
+			continue
+		}
+		for _, oc := range remainingOld {
+			diff.Removed = append(diff.Removed, toDiffComponent(oc))
+		}
+		for _, nc := range remainingNew {
+			diff.Added = append(diff.Added, toDiffComponent(nc))
+		}
+	}
+
+	oldEdges := dependencyEdges(oldBom)
+	newEdges := dependencyEdges(newBom)
+	for edge := range newEdges {
+		if !oldEdges[edge] {
+			diff.AddedDependencies = append(diff.AddedDependencies, edge)
+		}
+	}
+	for edge := range oldEdges {
+		if !newEdges[edge] {
+			diff.RemovedDependencies = append(diff.RemovedDependencies, edge)
+		}
+	}
+
+	diff.sort()
+	return diff
+}
+
+// addLicenseChange records a license change if the licenses of the two components differ
+func (d *Diff) addLicenseChange(oldComp, newComp cyclonedx.Component) {
+	oldLicenses := componentLicenses(oldComp)
+	newLicenses := componentLicenses(newComp)
+	if slices.Equal(oldLicenses, newLicenses) {
+		return
+	}
+	d.LicenseChanges = append(d.LicenseChanges, LicenseChange{
+		Name:        newComp.Name,
+		Group:       newComp.Group,
+		Version:     newComp.Version,
+		OldLicenses: oldLicenses,
+		NewLicenses: newLicenses,
+	})
+}
+
+// sort orders all parts of the diff for stable output
+func (d *Diff) sort() {
+	sortDiffComponents(d.Added)
+	sortDiffComponents(d.Removed)
+	sort.Slice(d.VersionChanges, func(i, j int) bool {
+		a, b := d.VersionChanges[i], d.VersionChanges[j]
+		return DisplayName(a.Group, a.Name) < DisplayName(b.Group, b.Name)
+	})
+	sort.Slice(d.LicenseChanges, func(i, j int) bool {
+		a, b := d.LicenseChanges[i], d.LicenseChanges[j]
+		if DisplayName(a.Group, a.Name) != DisplayName(b.Group, b.Name) {
+			return DisplayName(a.Group, a.Name) < DisplayName(b.Group, b.Name)
+		}
+		return a.Version < b.Version
+	})
+	sortEdges(d.AddedDependencies)
+	sortEdges(d.RemovedDependencies)
+}
+
+func sortDiffComponents(components []DiffComponent) {
+	sort.Slice(components, func(i, j int) bool {
+		a, b := components[i], components[j]
+		if DisplayName(a.Group, a.Name) != DisplayName(b.Group, b.Name) {
+			return DisplayName(a.Group, a.Name) < DisplayName(b.Group, b.Name)
+		}
+		return a.Version < b.Version
+	})
+}
+
+func sortEdges(edges []DependencyEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
+
+// DisplayName joins group and name the way they are shown to users
+func DisplayName(group, name string) string {
+	if group == "" {
+		return name
+	}
+	return group + "/" + name
+}
+
+func toDiffComponent(c cyclonedx.Component) DiffComponent {
+	return DiffComponent{
+		Name:       c.Name,
+		Group:      c.Group,
+		Version:    c.Version,
+		Type:       string(c.Type),
+		PackageURL: c.PackageURL,
+	}
+}
+
+func findComponentVersion(components []cyclonedx.Component, version string) (cyclonedx.Component, bool) {
+	for _, c := range components {
+		if c.Version == version {
+			return c, true
+		}
+	}
+	return cyclonedx.Component{}, false
+}
+
+// groupComponentsByIdentity indexes all components of a BOM, including nested
+// ones, by their version-less identity
+func groupComponentsByIdentity(bom *cyclonedx.BOM) map[string][]cyclonedx.Component {
+	result := make(map[string][]cyclonedx.Component)
+	walkComponents(bom.Components, func(c *cyclonedx.Component) {
+		id := componentIdentity(*c)
+		// Skip exact duplicates, which are common in merged SBOMs
+		if _, ok := findComponentVersion(result[id], c.Version); ok {
+			return
+		}
+		result[id] = append(result[id], *c)
+	})
+	return result
+}
+
+// dependencyEdges returns the dependency graph of a BOM as a set of edges
+// between component identities. Refs that do not belong to a component are
+// used as they are.
+func dependencyEdges(bom *cyclonedx.BOM) map[DependencyEdge]bool {
+	identityByRef := make(map[string]string)
+	index := func(c *cyclonedx.Component) {
+		if c.BOMRef != "" {
+			identityByRef[c.BOMRef] = componentIdentity(*c)
+		}
+	}
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		index(bom.Metadata.Component)
+	}
+	walkComponents(bom.Components, index)
+
+	resolve := func(ref string) string {
+		if id, ok := identityByRef[ref]; ok {
+			return id
+		}
+		return ref
+	}
+
+	edges := make(map[DependencyEdge]bool)
+	if bom.Dependencies == nil {
+		return edges
+	}
+	for _, dep := range *bom.Dependencies {
+		if dep.Dependencies == nil {
+			continue
+		}
+		for _, target := range *dep.Dependencies {
+			edges[DependencyEdge{From: resolve(dep.Ref), To: resolve(target)}] = true
+		}
+	}
+	return edges
+}
+
+// walkComponents calls fn for every component in the list, descending into
+// nested components
+func walkComponents(components *[]cyclonedx.Component, fn func(*cyclonedx.Component)) {
+	if components == nil {
+		return
+	}
+	for i := range *components {
+		fn(&(*components)[i])
+		walkComponents((*components)[i].Components, fn)
+	}
+}
+
+// componentIdentity returns a version-less key that identifies a component
+// across SBOMs. The package URL without its version is preferred, group and
+// name are used for components without a (valid) package URL.
+func componentIdentity(c cyclonedx.Component) string {
+	if c.PackageURL != "" {
+		if purl, err := packageurl.FromString(c.PackageURL); err == nil {
+			purl.Version = ""
+			return purl.ToString()
+		}
+	}
+	return DisplayName(c.Group, c.Name)
+}
+
+// componentLicenses returns the sorted license IDs, names and expressions of a component
+func componentLicenses(c cyclonedx.Component) []string {
+	licenses := []string{}
+	if c.Licenses == nil {
+		return licenses
+	}
+	for _, choice := range *c.Licenses {
+		if key := licenseKey(choice); key != "" {
+			licenses = append(licenses, key)
+		}
+	}
+	sort.Strings(licenses)
+	return licenses
+}
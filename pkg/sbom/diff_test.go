@@ -0,0 +1,130 @@
+package sbom
+
+import (
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestDiffSBOMs(t *testing.T) {
+	oldBom := cyclonedx.NewBOM()
+	oldBom.Components = &[]cyclonedx.Component{
+		{
+			BOMRef:     "app",
+			Name:       "app",
+			Version:    "1.0.0",
+			Type:       cyclonedx.ComponentTypeApplication,
+			PackageURL: "pkg:npm/app@1.0.0",
+		},
+		{
+			BOMRef:     "lodash",
+			Name:       "lodash",
+			Version:    "4.17.20",
+			Type:       cyclonedx.ComponentTypeLibrary,
+			PackageURL: "pkg:npm/lodash@4.17.20",
+			Licenses:   &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}},
+		},
+		{
+			BOMRef:     "left-pad",
+			Name:       "left-pad",
+			Version:    "1.3.0",
+			Type:       cyclonedx.ComponentTypeLibrary,
+			PackageURL: "pkg:npm/left-pad@1.3.0",
+		},
+	}
+	oldBom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"lodash", "left-pad"}},
+	}
+
+	newBom := cyclonedx.NewBOM()
+	newBom.Components = &[]cyclonedx.Component{
+		{
+			BOMRef:     "pkg:npm/app@1.0.0",
+			Name:       "app",
+			Version:    "1.0.0",
+			Type:       cyclonedx.ComponentTypeApplication,
+			PackageURL: "pkg:npm/app@1.0.0",
+		},
+		{
+			BOMRef:     "pkg:npm/lodash@4.17.21",
+			Name:       "lodash",
+			Version:    "4.17.21",
+			Type:       cyclonedx.ComponentTypeLibrary,
+			PackageURL: "pkg:npm/lodash@4.17.21",
+			Licenses:   &cyclonedx.Licenses{{Expression: "MIT OR Apache-2.0"}},
+		},
+		{
+			BOMRef:  "internal-lib",
+			Name:    "internal-lib",
+			Group:   "acme",
+			Version: "0.1.0",
+			Type:    cyclonedx.ComponentTypeLibrary,
+		},
+	}
+	newBom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "pkg:npm/app@1.0.0", Dependencies: &[]string{"pkg:npm/lodash@4.17.21", "internal-lib"}},
+	}
+
+	diff := DiffSBOMs(oldBom, newBom)
+
+	if len(diff.Added) != 1 || diff.Added[0].Name != "internal-lib" || diff.Added[0].Group != "acme" {
+		t.Errorf("Expected acme/internal-lib to be added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "left-pad" {
+		t.Errorf("Expected left-pad to be removed, got %+v", diff.Removed)
+	}
+	if len(diff.VersionChanges) != 1 {
+		t.Fatalf("Expected 1 version change, got %+v", diff.VersionChanges)
+	}
+	change := diff.VersionChanges[0]
+	if change.Name != "lodash" || change.OldVersion != "4.17.20" || change.NewVersion != "4.17.21" {
+		t.Errorf("Unexpected version change: %+v", change)
+	}
+	if len(diff.LicenseChanges) != 1 || diff.LicenseChanges[0].NewLicenses[0] != "MIT OR Apache-2.0" {
+		t.Errorf("Expected lodash license change, got %+v", diff.LicenseChanges)
+	}
+
+	// Edges are compared by identity, so the app -> lodash edge must survive the version bump
+	expectedAdded := DependencyEdge{From: "pkg:npm/app", To: "acme/internal-lib"}
+	if len(diff.AddedDependencies) != 1 || diff.AddedDependencies[0] != expectedAdded {
+		t.Errorf("Expected added edge %+v, got %+v", expectedAdded, diff.AddedDependencies)
+	}
+	expectedRemoved := DependencyEdge{From: "pkg:npm/app", To: "pkg:npm/left-pad"}
+	if len(diff.RemovedDependencies) != 1 || diff.RemovedDependencies[0] != expectedRemoved {
+		t.Errorf("Expected removed edge %+v, got %+v", expectedRemoved, diff.RemovedDependencies)
+	}
+}
+
+func TestDiffSBOMsMultipleVersions(t *testing.T) {
+	oldBom := cyclonedx.NewBOM()
+	oldBom.Components = &[]cyclonedx.Component{
+		{Name: "lodash", Version: "3.10.1", PackageURL: "pkg:npm/lodash@3.10.1"},
+		{Name: "lodash", Version: "4.17.21", PackageURL: "pkg:npm/lodash@4.17.21"},
+	}
+
+	newBom := cyclonedx.NewBOM()
+	newBom.Components = &[]cyclonedx.Component{
+		{Name: "lodash", Version: "4.17.21", PackageURL: "pkg:npm/lodash@4.17.21"},
+	}
+
+	diff := DiffSBOMs(oldBom, newBom)
+
+	if len(diff.VersionChanges) != 0 {
+		t.Errorf("Expected no version changes, got %+v", diff.VersionChanges)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Version != "3.10.1" {
+		t.Errorf("Expected lodash 3.10.1 to be removed, got %+v", diff.Removed)
+	}
+}
+
+func TestDiffSBOMsIdentical(t *testing.T) {
+	bom, err := ReadSBOMFile("../../testdata/sbom1.json")
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+
+	diff := DiffSBOMs(bom, bom)
+	if !diff.IsEmpty() {
+		t.Errorf("Expected empty diff for identical SBOMs, got %+v", diff)
+	}
+}
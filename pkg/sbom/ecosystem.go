@@ -0,0 +1,411 @@
+package sbom
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionComparators compare the versions of an OSV ecosystem. Ecosystems
+// that are not listed are compared with compareVersions.
+var versionComparators = map[string]func(a, b string) int{
+	"npm":       compareSemver,
+	"crates.io": compareSemver,
+	"Go":        compareSemver,
+	"NuGet":     compareSemver,
+	"Hex":       compareSemver,
+	"Pub":       compareSemver,
+	"PyPI":      comparePEP440,
+	"Maven":     compareMaven,
+	"Debian":    compareDebian,
+	"Ubuntu":    compareDebian,
+}
+
+// compareEcosystemVersions compares two versions by the rules of an OSV
+// ecosystem like "PyPI" or "Debian:12"
+func compareEcosystemVersions(ecosystem, a, b string) int {
+	ecosystem, _, _ = strings.Cut(ecosystem, ":")
+	if compare, ok := versionComparators[ecosystem]; ok {
+		return compare(a, b)
+	}
+	return compareVersions(a, b)
+}
+
+// semverPattern matches semantic versions, leniently with a "v" prefix and
+// without minor or patch version
+var semverPattern = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// compareSemver compares semantic versions. Build metadata is ignored, so Go
+// versions with +incompatible compare like their base version, and Go
+// pseudo-versions sort like the pre-releases they are. Versions that are not
+// semantic versions are compared with compareVersions.
+func compareSemver(a, b string) int {
+	ma, mb := semverPattern.FindStringSubmatch(a), semverPattern.FindStringSubmatch(b)
+	if ma == nil || mb == nil {
+		return compareVersions(a, b)
+	}
+	for i := 1; i <= 3; i++ {
+		if c := compareNumeric(ma[i], mb[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A version without pre-release is greater than one with
+	switch preA, preB := ma[4], mb[4]; {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	default:
+		return comparePrerelease(strings.Split(preA, "."), strings.Split(preB, "."))
+	}
+}
+
+// comparePrerelease compares the dot-separated identifiers of semver
+// pre-releases: numeric identifiers numerically and lower than alphanumeric
+// ones, which are compared in ASCII order
+func comparePrerelease(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		numA, numB := isDigits(a[i]), isDigits(b[i])
+		var c int
+		switch {
+		case numA && numB:
+			c = compareNumeric(a[i], b[i])
+		case numA:
+			c = -1
+		case numB:
+			c = 1
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
+
+// pep440Pattern matches PEP 440 versions, see the appendix of PEP 440
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// pep440Version is a parsed PEP 440 version. The pre-release, post-release
+// and dev-release numbers are -1 if absent.
+type pep440Version struct {
+	epoch   string
+	release []string
+	// phase is the pre-release phase (0 alpha, 1 beta, 2 rc), -1 if absent
+	phase, pre, post, dev int
+	local                 string
+}
+
+func parsePEP440(version string) (pep440Version, bool) {
+	m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(version)))
+	if m == nil {
+		return pep440Version{}, false
+	}
+	v := pep440Version{epoch: m[1], release: strings.Split(m[2], "."), phase: -1, pre: -1, post: -1, dev: -1, local: m[10]}
+	if m[3] != "" {
+		switch m[3] {
+		case "a", "alpha":
+			v.phase = 0
+		case "b", "beta":
+			v.phase = 1
+		default:
+			v.phase = 2
+		}
+		v.pre = atoiOrZero(m[4])
+	}
+	switch {
+	case m[5] != "":
+		v.post = atoiOrZero(m[5])
+	case m[6] != "":
+		v.post = atoiOrZero(m[7])
+	}
+	if m[8] != "" {
+		v.dev = atoiOrZero(m[9])
+	}
+	return v, true
+}
+
+// comparePEP440 compares Python package versions by PEP 440: epoch, release,
+// then dev releases before pre-releases before the release before
+// post-releases. Versions that are not valid PEP 440 versions are compared
+// with compareVersions.
+func comparePEP440(a, b string) int {
+	va, okA := parsePEP440(a)
+	vb, okB := parsePEP440(b)
+	if !okA || !okB {
+		return compareVersions(a, b)
+	}
+	if c := compareNumeric(va.epoch, vb.epoch); c != 0 {
+		return c
+	}
+	// Trailing zeros of the release do not matter, 1.0 == 1.0.0
+	for i := 0; i < len(va.release) || i < len(vb.release); i++ {
+		var ra, rb string
+		if i < len(va.release) {
+			ra = va.release[i]
+		}
+		if i < len(vb.release) {
+			rb = vb.release[i]
+		}
+		if c := compareNumeric(ra, rb); c != 0 {
+			return c
+		}
+	}
+	for _, key := range [][2][2]int{
+		{va.preKey(), vb.preKey()},
+		{{va.post, 0}, {vb.post, 0}},
+		{va.devKey(), vb.devKey()},
+	} {
+		if c := compareKey(key[0], key[1]); c != 0 {
+			return c
+		}
+	}
+	// A local version sorts after the same version without one
+	return strings.Compare(va.local, vb.local)
+}
+
+// preKey orders the pre-release: dev releases of the release itself first,
+// then the pre-releases by phase and number, then everything else
+func (v pep440Version) preKey() [2]int {
+	switch {
+	case v.phase >= 0:
+		return [2]int{v.phase, v.pre}
+	case v.post < 0 && v.dev >= 0:
+		return [2]int{-1, 0}
+	}
+	return [2]int{3, 0}
+}
+
+// devKey orders dev releases before everything else
+func (v pep440Version) devKey() [2]int {
+	if v.dev < 0 {
+		return [2]int{1, 0}
+	}
+	return [2]int{0, v.dev}
+}
+
+func compareKey(a, b [2]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// mavenQualifiers are the well-known qualifiers of Maven versions in
+// ascending order, the empty qualifier is the release
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+// mavenQualifierAliases map qualifiers to their well-known names
+var mavenQualifierAliases = map[string]string{
+	"a": "alpha", "b": "beta", "m": "milestone", "cr": "rc",
+	"ga": "", "final": "", "release": "",
+}
+
+// mavenItem is a number or a qualifier of a Maven version
+type mavenItem struct {
+	number    string
+	qualifier string
+	isNumber  bool
+}
+
+// parseMaven splits a Maven version into numbers and qualifiers at dots,
+// hyphens and transitions between digits and letters, dropping trailing
+// zeros and release qualifiers
+func parseMaven(version string) []mavenItem {
+	var items []mavenItem
+	var current strings.Builder
+	flush := func() {
+		token := current.String()
+		current.Reset()
+		if isDigits(token) && token != "" {
+			items = append(items, mavenItem{number: strings.TrimLeft(token, "0"), isNumber: true})
+			return
+		}
+		if alias, ok := mavenQualifierAliases[token]; ok {
+			token = alias
+		}
+		items = append(items, mavenItem{qualifier: token})
+	}
+	version = strings.ToLower(version)
+	for i, r := range version {
+		switch {
+		case r == '.' || r == '-' || r == '_':
+			flush()
+			continue
+		case i > 0 && current.Len() > 0 && isDigit(byte(r)) != isDigit(version[i-1]):
+			flush()
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	for len(items) > 0 {
+		last := items[len(items)-1]
+		if last.isNumber && last.number != "" || !last.isNumber && last.qualifier != "" {
+			break
+		}
+		items = items[:len(items)-1]
+	}
+	return items
+}
+
+// compareMaven compares Maven versions like Maven's ComparableVersion:
+// numbers numerically, numbers after qualifiers, well-known qualifiers in
+// the order alpha, beta, milestone, rc, snapshot, release, sp and other
+// qualifiers after them alphabetically. 1.0 == 1 == 1-ga.
+func compareMaven(a, b string) int {
+	ia, ib := parseMaven(a), parseMaven(b)
+	for i := 0; i < len(ia) || i < len(ib); i++ {
+		// Missing items compare like 0 or the release
+		x, y := mavenItem{isNumber: true}, mavenItem{isNumber: true}
+		if i < len(ia) {
+			x = ia[i]
+		} else if i < len(ib) && !ib[i].isNumber {
+			x = mavenItem{}
+		}
+		if i < len(ib) {
+			y = ib[i]
+		} else if !x.isNumber {
+			y = mavenItem{}
+		}
+
+		var c int
+		switch {
+		case x.isNumber && y.isNumber:
+			c = compareNumeric(x.number, y.number)
+		case x.isNumber:
+			c = 1
+		case y.isNumber:
+			c = -1
+		default:
+			c = strings.Compare(mavenQualifierKey(x.qualifier), mavenQualifierKey(y.qualifier))
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// mavenQualifierKey returns a key that sorts qualifiers like Maven does
+func mavenQualifierKey(qualifier string) string {
+	for i, known := range mavenQualifiers {
+		if qualifier == known {
+			return strconv.Itoa(i)
+		}
+	}
+	return strconv.Itoa(len(mavenQualifiers)) + "-" + qualifier
+}
+
+// compareDebian compares Debian package versions like dpkg: the epoch
+// numerically, then the upstream version and the revision, in which ~ sorts
+// before anything, even the end of the version
+func compareDebian(a, b string) int {
+	epochA, upstreamA, revisionA := splitDebian(a)
+	epochB, upstreamB, revisionB := splitDebian(b)
+	if c := compareNumeric(epochA, epochB); c != 0 {
+		return c
+	}
+	if c := compareDebianPart(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareDebianPart(revisionA, revisionB)
+}
+
+// splitDebian splits a Debian version into epoch, upstream version and revision
+func splitDebian(version string) (string, string, string) {
+	epoch := ""
+	if before, after, ok := strings.Cut(version, ":"); ok && isDigits(before) {
+		epoch, version = before, after
+	}
+	revision := ""
+	if i := strings.LastIndex(version, "-"); i >= 0 {
+		version, revision = version[:i], version[i+1:]
+	}
+	return epoch, version, revision
+}
+
+// compareDebianPart compares an upstream version or revision by alternately
+// comparing non-digit parts character by character and digit parts numerically
+func compareDebianPart(a, b string) int {
+	for a != "" || b != "" {
+		var textA, textB string
+		textA, a = splitPrefix(a, func(c byte) bool { return !isDigit(c) })
+		textB, b = splitPrefix(b, func(c byte) bool { return !isDigit(c) })
+		for i := 0; i < len(textA) || i < len(textB); i++ {
+			if c := compareUint(uint64(debianOrder(textA, i)+256), uint64(debianOrder(textB, i)+256)); c != 0 {
+				return c
+			}
+		}
+
+		var numA, numB string
+		numA, a = splitPrefix(a, isDigit)
+		numB, b = splitPrefix(b, isDigit)
+		if c := compareNumeric(numA, numB); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// debianOrder returns the sort weight of the character at i: ~ before the
+// end of the string before letters before everything else
+func debianOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	switch c := s[i]; {
+	case c == '~':
+		return -1
+	case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z':
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+// splitPrefix splits s after the longest prefix of characters matching fn
+func splitPrefix(s string, fn func(byte) bool) (string, string) {
+	i := 0
+	for i < len(s) && fn(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareNumeric compares strings of digits of any length numerically, an
+// empty string counts as 0
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return compareUint(uint64(len(a)), uint64(len(b)))
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isDigits(s string) bool {
+	_, rest := splitPrefix(s, isDigit)
+	return s != "" && rest == ""
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
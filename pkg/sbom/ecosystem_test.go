@@ -0,0 +1,64 @@
+package sbom
+
+import "testing"
+
+func TestCompareEcosystemVersions(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		a, b      string
+		expected  int
+	}{
+		// Semantic versions
+		{"npm", "1.2.3", "1.2.3", 0},
+		{"npm", "1.2.3", "1.10.0", -1},
+		{"npm", "1.0.0-alpha", "1.0.0", -1},
+		{"npm", "1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"npm", "1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"npm", "1.0.0-rc.1", "1.0.0-beta", 1},
+		{"npm", "1.0.0+build.1", "1.0.0+build.2", 0},
+		{"crates.io", "0.10.0", "0.9.9", 1},
+		// Go versions and pseudo-versions
+		{"Go", "v1.2.3", "1.2.3", 0},
+		{"Go", "v0.0.0-20240101120000-abcdef123456", "v0.0.0-20240201120000-123456abcdef", -1},
+		{"Go", "v1.2.4-0.20240101120000-abcdef123456", "v1.2.4", -1},
+		{"Go", "v1.2.4-0.20240101120000-abcdef123456", "v1.2.3", 1},
+		{"Go", "v2.0.0+incompatible", "v2.0.0", 0},
+		// PEP 440
+		{"PyPI", "1.0", "1.0.0", 0},
+		{"PyPI", "1.0.dev1", "1.0a1", -1},
+		{"PyPI", "1.0a1", "1.0b1", -1},
+		{"PyPI", "1.0rc1", "1.0", -1},
+		{"PyPI", "1.0", "1.0.post1", -1},
+		{"PyPI", "1.0.post1.dev1", "1.0.post1", -1},
+		{"PyPI", "1!0.5", "2.0", 1},
+		{"PyPI", "2.0.0-RC1", "2.0.0rc1", 0},
+		{"PyPI", "1.0+local", "1.0", 1},
+		// Maven
+		{"Maven", "1.0", "1.0.0", 0},
+		{"Maven", "1.0-alpha-1", "1.0-beta-1", -1},
+		{"Maven", "1.0-rc1", "1.0", -1},
+		{"Maven", "1.0-SNAPSHOT", "1.0", -1},
+		{"Maven", "1.0", "1.0-sp1", -1},
+		{"Maven", "2.12.7.1", "2.12.7", 1},
+		{"Maven", "1.0-M1", "1.0-RC1", -1},
+		// Debian
+		{"Debian:12", "1.2.3-1", "1.2.3-2", -1},
+		{"Debian:12", "1:1.0-1", "2.0-1", 1},
+		{"Debian:12", "1.0~rc1-1", "1.0-1", -1},
+		{"Ubuntu:22.04:LTS", "1.2.3-1ubuntu1", "1.2.3-1", 1},
+		{"Debian", "7.88.1-10+deb12u5", "7.88.1-10+deb12u12", -1},
+		// Ecosystems without comparator
+		{"RubyGems", "1.2.10", "1.2.9", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ecosystem+" "+tt.a+" "+tt.b, func(t *testing.T) {
+			if got := compareEcosystemVersions(tt.ecosystem, tt.a, tt.b); got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+			if got := compareEcosystemVersions(tt.ecosystem, tt.b, tt.a); got != -tt.expected {
+				t.Errorf("Expected %d for reversed arguments, got %d", -tt.expected, got)
+			}
+		})
+	}
+}
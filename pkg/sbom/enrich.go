@@ -0,0 +1,380 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"lukechampine.com/blake3"
+)
+
+// enrichHashAlgorithms are the hash algorithms EnrichHashes computes
+var enrichHashAlgorithms = []struct {
+	algorithm cyclonedx.HashAlgorithm
+	new       func() hash.Hash
+}{
+	{cyclonedx.HashAlgoSHA256, sha256.New},
+	{cyclonedx.HashAlgoSHA512, sha512.New},
+	{cyclonedx.HashAlgoBlake3, func() hash.Hash { return blake3.New(32, nil) }},
+}
+
+// HashEnrichReport is the result of adding the hashes of local artifacts to
+// the components of an SBOM
+type HashEnrichReport struct {
+	Enriched []HashedComponent `json:"enriched"`
+	// Missing are the components whose artifact was not found, including
+	// those without a package URL of a supported type
+	Missing    []string             `json:"missing"`
+	Unreadable []UnreadableArtifact `json:"unreadable"`
+	Mismatched []HashMismatch       `json:"mismatched"`
+}
+
+// HashedComponent is a component whose hashes were computed from an artifact
+type HashedComponent struct {
+	Component string `json:"component"`
+	Artifact  string `json:"artifact"`
+	// Added is the number of hashes the component did not have yet
+	Added int `json:"added"`
+}
+
+// UnreadableArtifact is an artifact that was found but could not be hashed
+type UnreadableArtifact struct {
+	Component string `json:"component"`
+	Artifact  string `json:"artifact"`
+	Error     string `json:"error"`
+}
+
+// HashMismatch is a hash of a component that differs from the hash computed
+// from its artifact. The component keeps its hash.
+type HashMismatch struct {
+	Component string                  `json:"component"`
+	Algorithm cyclonedx.HashAlgorithm `json:"algorithm"`
+	Existing  string                  `json:"existing"`
+	Computed  string                  `json:"computed"`
+}
+
+// EnrichHashes locates the artifact of every component from its package URL
+// in the given directories and adds its SHA-256, SHA-512 and BLAKE3 hashes
+// to the component. The directories are searched in order for the layouts of
+// npm (node_modules and packed tarballs), Maven repositories (~/.m2), the Go
+// module cache and vendor directories of Go, Composer and Cargo.
+//
+// Artifacts that are directories, like installed npm packages and vendored
+// modules, are hashed like Go's dirhash: the hash of the lines
+// "<hash of file>  <slash-separated path>\n" of their regular files, sorted by
+// path, with paths relative to the directory. The algorithms of such hashes
+// are listed in the sbomctl:hashes:dirhash property of the component, and
+// they are only compared with hashes computed the same way.
+func EnrichHashes(bom *cyclonedx.BOM, dirs []string) *HashEnrichReport {
+	var components []*cyclonedx.Component
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		components = append(components, bom.Metadata.Component)
+		walkComponents(bom.Metadata.Component.Components, func(c *cyclonedx.Component) { components = append(components, c) })
+	}
+	walkComponents(bom.Components, func(c *cyclonedx.Component) { components = append(components, c) })
+
+	report := &HashEnrichReport{Enriched: []HashedComponent{}, Missing: []string{}, Unreadable: []UnreadableArtifact{}, Mismatched: []HashMismatch{}}
+	for _, c := range components {
+		artifact, isDir := findArtifact(c.PackageURL, dirs)
+		if artifact == "" {
+			report.Missing = append(report.Missing, componentLabel(c))
+			continue
+		}
+		hashes, err := hashArtifact(artifact, isDir)
+		if err != nil {
+			report.Unreadable = append(report.Unreadable, UnreadableArtifact{Component: componentLabel(c), Artifact: artifact, Error: err.Error()})
+			continue
+		}
+
+		dirhashed := dirhashAlgorithms(c)
+		var added []string
+		hashed := HashedComponent{Component: componentLabel(c), Artifact: artifact}
+		for _, computed := range hashes {
+			existing := -1
+			if c.Hashes != nil {
+				for i, h := range *c.Hashes {
+					if h.Algorithm == computed.Algorithm {
+						existing = i
+						break
+					}
+				}
+			}
+			switch {
+			case existing < 0:
+				c.Hashes = appendTo(c.Hashes, computed)
+				hashed.Added++
+				if isDir {
+					added = append(added, string(computed.Algorithm))
+				}
+			case isDir != slices.Contains(dirhashed, string(computed.Algorithm)):
+				// The hash is of another kind of artifact, like the tarball
+				// of a package that was found installed
+			case !strings.EqualFold((*c.Hashes)[existing].Value, computed.Value):
+				report.Mismatched = append(report.Mismatched, HashMismatch{
+					Component: componentLabel(c),
+					Algorithm: computed.Algorithm,
+					Existing:  (*c.Hashes)[existing].Value,
+					Computed:  computed.Value,
+				})
+			}
+		}
+		if len(added) > 0 {
+			setProperty(c, dirhashProperty, strings.Join(append(dirhashed, added...), ","))
+		}
+		report.Enriched = append(report.Enriched, hashed)
+	}
+	return report
+}
+
+// dirhashProperty lists the algorithms of the hashes of a component that
+// EnrichHashes computed from a directory
+const dirhashProperty = "sbomctl:hashes:dirhash"
+
+// dirhashAlgorithms returns the algorithms listed in the dirhash property of
+// a component
+func dirhashAlgorithms(c *cyclonedx.Component) []string {
+	if c.Properties != nil {
+		for _, p := range *c.Properties {
+			if p.Name == dirhashProperty && p.Value != "" {
+				return strings.Split(p.Value, ",")
+			}
+		}
+	}
+	return nil
+}
+
+// setProperty sets the value of a property of a component, adding it if it
+// does not exist yet
+func setProperty(c *cyclonedx.Component, name, value string) {
+	if c.Properties != nil {
+		for i := range *c.Properties {
+			if (*c.Properties)[i].Name == name {
+				(*c.Properties)[i].Value = value
+				return
+			}
+		}
+	}
+	c.Properties = appendTo(c.Properties, cyclonedx.Property{Name: name, Value: value})
+}
+
+// findArtifact returns the path of the first artifact of a package URL found
+// in the directories and whether it is a directory, or an empty string
+func findArtifact(purl string, dirs []string) (string, bool) {
+	parsed, err := packageurl.FromString(purl)
+	if err != nil || parsed.Name == "" {
+		return "", false
+	}
+	for _, dir := range dirs {
+		for _, candidate := range artifactCandidates(parsed, purl) {
+			p := filepath.Join(dir, filepath.FromSlash(candidate))
+			info, err := os.Stat(p)
+			if err != nil {
+				continue
+			}
+			if info.IsDir() && !artifactVersionMatches(p, parsed) {
+				continue
+			}
+			return p, info.IsDir()
+		}
+	}
+	return "", false
+}
+
+// artifactCandidates returns the slash-separated paths, relative to a
+// searched directory, at which the artifact of a package URL can be. The
+// directory can be the project, like the one containing node_modules, or the
+// node_modules, vendor or repository directory itself.
+func artifactCandidates(purl packageurl.PackageURL, raw string) []string {
+	name := purl.Name
+	if purl.Namespace != "" {
+		name = purl.Namespace + "/" + purl.Name
+	}
+	qualifiers := purl.Qualifiers.Map()
+
+	switch purl.Type {
+	case packageurl.TypeNPM:
+		// npm pack names tarballs of scoped packages like scope-name-1.0.0.tgz
+		tarball := strings.TrimPrefix(strings.ReplaceAll(name, "/", "-"), "@") + "-" + purl.Version + ".tgz"
+		return []string{tarball, "node_modules/" + name, name}
+	case packageurl.TypeMaven:
+		if purl.Namespace == "" || purl.Version == "" {
+			return nil
+		}
+		extension := qualifiers["type"]
+		if extension == "" {
+			extension = "jar"
+		}
+		file := purl.Name + "-" + purl.Version
+		if classifier := qualifiers["classifier"]; classifier != "" {
+			file += "-" + classifier
+		}
+		p := path.Join(strings.ReplaceAll(purl.Namespace, ".", "/"), purl.Name, purl.Version, file+"."+extension)
+		return []string{"repository/" + p, p}
+	case packageurl.TypeGolang:
+		// packageurl lowercases the namespace, but module paths are case-sensitive
+		name = goModulePath(raw, name)
+		if purl.Version == "" {
+			return []string{"vendor/" + name, name}
+		}
+		escaped := escapeModulePath(name)
+		download := "cache/download/" + escaped + "/@v/" + escapeModulePath(purl.Version) + ".zip"
+		return []string{download, "pkg/mod/" + download, "vendor/" + name, name}
+	case packageurl.TypeComposer:
+		return []string{"vendor/" + name, name}
+	case packageurl.TypeCargo:
+		return []string{
+			"vendor/" + purl.Name + "-" + purl.Version, purl.Name + "-" + purl.Version,
+			"vendor/" + purl.Name, purl.Name,
+		}
+	}
+	return nil
+}
+
+// artifactVersionMatches reports whether a directory is the version of the
+// package URL, as far as it records its version
+func artifactVersionMatches(dir string, purl packageurl.PackageURL) bool {
+	if purl.Type != packageurl.TypeNPM || purl.Version == "" {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return false
+	}
+	var manifest struct {
+		Version string `json:"version"`
+	}
+	return json.Unmarshal(data, &manifest) == nil && manifest.Version == purl.Version
+}
+
+// goModulePath returns the module path of a golang package URL in its
+// original case, or fallback if it cannot be determined
+func goModulePath(raw, fallback string) string {
+	p, ok := strings.CutPrefix(raw, "pkg:golang/")
+	if !ok {
+		return fallback
+	}
+	if i := strings.IndexAny(p, "@?#"); i >= 0 {
+		p = p[:i]
+	}
+	p, err := url.PathUnescape(p)
+	if err != nil || !strings.EqualFold(p, fallback) {
+		return fallback
+	}
+	return p
+}
+
+// escapeModulePath escapes a Go module path or version for the module cache,
+// in which upper-case letters are written as "!" and the lower-case letter
+func escapeModulePath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// hashArtifact computes the hashes of a file, or of a directory from the
+// sorted hashes and paths of its regular files
+func hashArtifact(p string, isDir bool) ([]cyclonedx.Hash, error) {
+	if !isDir {
+		sums, err := hashFile(p)
+		if err != nil {
+			return nil, err
+		}
+		return toHashes(sums), nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(p, file)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// WalkDir visits "a/b" before "a.txt", the lines are sorted by path
+	sort.Strings(files)
+
+	dirHashers := newHashers()
+	for _, file := range files {
+		if strings.Contains(file, "\n") {
+			return nil, fmt.Errorf("file name %q contains a newline", file)
+		}
+		sums, err := hashFile(filepath.Join(p, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		for i, h := range dirHashers {
+			fmt.Fprintf(h, "%x  %s\n", sums[i], file)
+		}
+	}
+	sums := make([][]byte, len(dirHashers))
+	for i, h := range dirHashers {
+		sums[i] = h.Sum(nil)
+	}
+	return toHashes(sums), nil
+}
+
+// hashFile computes the sums of a file with every algorithm of enrichHashAlgorithms
+func hashFile(p string) ([][]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashers := newHashers()
+	writers := make([]io.Writer, len(hashers))
+	for i, h := range hashers {
+		writers[i] = h
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return nil, err
+	}
+	sums := make([][]byte, len(hashers))
+	for i, h := range hashers {
+		sums[i] = h.Sum(nil)
+	}
+	return sums, nil
+}
+
+func newHashers() []hash.Hash {
+	hashers := make([]hash.Hash, len(enrichHashAlgorithms))
+	for i, a := range enrichHashAlgorithms {
+		hashers[i] = a.new()
+	}
+	return hashers
+}
+
+func toHashes(sums [][]byte) []cyclonedx.Hash {
+	hashes := make([]cyclonedx.Hash, len(sums))
+	for i, sum := range sums {
+		hashes[i] = cyclonedx.Hash{Algorithm: enrichHashAlgorithms[i].algorithm, Value: hex.EncodeToString(sum)}
+	}
+	return hashes
+}
@@ -0,0 +1,173 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// writeArtifact writes a file below the directory, creating its parents
+func writeArtifact(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestEnrichHashes(t *testing.T) {
+	project := t.TempDir()
+	writeArtifact(t, project, "node_modules/left-pad/package.json", `{"name": "left-pad", "version": "1.3.0"}`)
+	writeArtifact(t, project, "node_modules/left-pad/index.js", "module.exports = leftPad;\n")
+	// The packed tarball is found before the unpacked directory
+	writeArtifact(t, project, "left-pad-1.3.0.tgz", "tgz")
+	writeArtifact(t, project, "node_modules/@types/node/package.json", `{"name": "@types/node", "version": "20.0.0"}`)
+	writeArtifact(t, project, "vendor/github.com/pkg/errors/errors.go", "package errors\n")
+	writeArtifact(t, project, "vendor/monolog/monolog/composer.json", "{}")
+	writeArtifact(t, project, "vendor/monolog/monolog/src/Logger.php", "<?php\n")
+	writeArtifact(t, project, "vendor/rand/src/lib.rs", "")
+	writeArtifact(t, project, "vendor/rand/bad\nname.rs", "")
+
+	m2 := t.TempDir()
+	writeArtifact(t, m2, "repository/org/apache/commons/commons-lang3/3.12.0/commons-lang3-3.12.0.jar", "jar")
+	writeArtifact(t, m2, "repository/org/example/app/1.0/app-1.0-sources.zip", "sources")
+
+	modCache := t.TempDir()
+	writeArtifact(t, modCache, "cache/download/github.com/!burnt!sushi/toml/@v/v1.3.2.zip", "zip")
+
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{Name: "app", Version: "1.0", PackageURL: "pkg:maven/org.example/app@1.0?classifier=sources&type=zip"}}
+	bom.Components = &[]cyclonedx.Component{
+		{Name: "left-pad", Version: "1.3.0", PackageURL: "pkg:npm/left-pad@1.3.0"},
+		// node_modules contains another version
+		{Name: "@types/node", Version: "18.0.0", PackageURL: "pkg:npm/%40types/node@18.0.0"},
+		{Name: "commons-lang3", Version: "3.12.0", PackageURL: "pkg:maven/org.apache.commons/commons-lang3@3.12.0", Hashes: &[]cyclonedx.Hash{
+			{Algorithm: cyclonedx.HashAlgoSHA256, Value: sha256Hex("jar")},
+			{Algorithm: cyclonedx.HashAlgoSHA512, Value: "0000"},
+		}},
+		{Name: "toml", Version: "v1.3.2", PackageURL: "pkg:golang/github.com/BurntSushi/toml@v1.3.2"},
+		{Name: "errors", Version: "v0.9.1", PackageURL: "pkg:golang/github.com/pkg/errors@v0.9.1", Components: &[]cyclonedx.Component{
+			{Name: "monolog", Version: "3.0.0", PackageURL: "pkg:composer/monolog/monolog@3.0.0", Hashes: &[]cyclonedx.Hash{
+				// Not computed from a directory, so it is not compared
+				{Algorithm: cyclonedx.HashAlgoSHA256, Value: "0000"},
+			}},
+		}},
+		{Name: "rand", Version: "0.8.5", PackageURL: "pkg:cargo/rand@0.8.5"},
+		{Name: "no-purl"},
+	}
+
+	report := EnrichHashes(bom, []string{project, m2, modCache})
+
+	var enriched []string
+	for _, e := range report.Enriched {
+		rel := e.Artifact
+		for _, dir := range []string{project, m2, modCache} {
+			if r, err := filepath.Rel(dir, e.Artifact); err == nil && !filepath.IsAbs(r) && r[0] != '.' {
+				rel = filepath.ToSlash(r)
+			}
+		}
+		enriched = append(enriched, fmt.Sprintf("%s %s %d", e.Component, rel, e.Added))
+	}
+	expected := []string{
+		"pkg:maven/org.example/app@1.0?classifier=sources&type=zip repository/org/example/app/1.0/app-1.0-sources.zip 3",
+		"pkg:npm/left-pad@1.3.0 left-pad-1.3.0.tgz 3",
+		"pkg:maven/org.apache.commons/commons-lang3@3.12.0 repository/org/apache/commons/commons-lang3/3.12.0/commons-lang3-3.12.0.jar 1",
+		"pkg:golang/github.com/BurntSushi/toml@v1.3.2 cache/download/github.com/!burnt!sushi/toml/@v/v1.3.2.zip 3",
+		"pkg:golang/github.com/pkg/errors@v0.9.1 vendor/github.com/pkg/errors 3",
+		"pkg:composer/monolog/monolog@3.0.0 vendor/monolog/monolog 2",
+	}
+	if !slices.Equal(enriched, expected) {
+		t.Errorf("Expected enriched components:\n%v\nGot:\n%v", expected, enriched)
+	}
+	if len(report.Unreadable) != 1 || report.Unreadable[0].Component != "pkg:cargo/rand@0.8.5" || report.Unreadable[0].Artifact != filepath.Join(project, "vendor", "rand") {
+		t.Errorf("Expected the rand directory to be unreadable, got %+v", report.Unreadable)
+	}
+	if rand := (*bom.Components)[5]; rand.Hashes != nil || rand.Properties != nil {
+		t.Errorf("Expected no hashes for an unreadable artifact, got %+v", rand)
+	}
+
+	errors := (*bom.Components)[4]
+	expectedDirhash := sha256Hex(sha256Hex("package errors\n") + "  errors.go\n")
+	if errors.Hashes == nil || (*errors.Hashes)[0].Value != expectedDirhash {
+		t.Errorf("Expected the SHA-256 dirhash %s, got %+v", expectedDirhash, errors.Hashes)
+	}
+	if got := dirhashAlgorithms(&errors); !slices.Equal(got, []string{"SHA-256", "SHA-512", "BLAKE3"}) {
+		t.Errorf("Expected the dirhash property to list all algorithms, got %v", got)
+	}
+	monolog := (*errors.Components)[0]
+	if got := dirhashAlgorithms(&monolog); !slices.Equal(got, []string{"SHA-512", "BLAKE3"}) {
+		t.Errorf("Expected the dirhash property to list the added algorithms, got %v", got)
+	}
+	if !slices.Equal(report.Missing, []string{"pkg:npm/%40types/node@18.0.0", "no-purl"}) {
+		t.Errorf("Unexpected missing components: %v", report.Missing)
+	}
+	if len(report.Mismatched) != 1 || report.Mismatched[0].Algorithm != cyclonedx.HashAlgoSHA512 || report.Mismatched[0].Existing != "0000" {
+		t.Errorf("Expected a SHA-512 mismatch, got %+v", report.Mismatched)
+	}
+
+	hashes := *(*bom.Components)[2].Hashes
+	if len(hashes) != 3 || hashes[1].Value != "0000" || hashes[2].Algorithm != cyclonedx.HashAlgoBlake3 || len(hashes[2].Value) != 64 {
+		t.Errorf("Expected the existing hashes to be kept and BLAKE3 to be added, got %+v", hashes)
+	}
+	toml := (*bom.Components)[3].Hashes
+	if toml == nil || (*toml)[0].Value != sha256Hex("zip") {
+		t.Errorf("Expected the SHA-256 of the module zip, got %+v", toml)
+	}
+}
+
+func TestEnrichHashesDirectoryMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writeArtifact(t, dir, "vendor/monolog/monolog/composer.json", "{}")
+
+	bom := cyclonedx.NewBOM()
+	bom.Components = &[]cyclonedx.Component{{Name: "monolog", PackageURL: "pkg:composer/monolog/monolog@3.0.0"}}
+	EnrichHashes(bom, []string{dir})
+	if report := EnrichHashes(bom, []string{dir}); len(report.Mismatched) != 0 {
+		t.Fatalf("Expected the hashes of an unchanged directory to match, got %+v", report.Mismatched)
+	}
+
+	writeArtifact(t, dir, "vendor/monolog/monolog/composer.json", `{"name": "monolog/monolog"}`)
+	report := EnrichHashes(bom, []string{dir})
+	if len(report.Mismatched) != 3 {
+		t.Errorf("Expected 3 mismatches for a changed directory, got %+v", report.Mismatched)
+	}
+}
+
+func TestHashArtifactDirectory(t *testing.T) {
+	dir := t.TempDir()
+	// WalkDir visits a/b before a.txt
+	writeArtifact(t, dir, "a/b", "b")
+	writeArtifact(t, dir, "a.txt", "a")
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	hashes, err := hashArtifact(dir, true)
+	if err != nil {
+		t.Fatalf("Failed to hash directory: %v", err)
+	}
+	expected := sha256Hex(sha256Hex("a") + "  a.txt\n" + sha256Hex("b") + "  a/b\n")
+	if len(hashes) != 3 || hashes[0].Algorithm != cyclonedx.HashAlgoSHA256 || hashes[0].Value != expected {
+		t.Errorf("Expected the SHA-256 dirhash %s, got %+v", expected, hashes)
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	if got := escapeModulePath("github.com/Azure/azure-sdk-for-go"); got != "github.com/!azure/azure-sdk-for-go" {
+		t.Errorf("Unexpected escaped path %s", got)
+	}
+}
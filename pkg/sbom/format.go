@@ -0,0 +1,140 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"unicode"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// FileFormat is the serialization format of a CycloneDX SBOM
+type FileFormat string
+
+const (
+	// FileFormatJSON is the CycloneDX JSON format
+	FileFormatJSON FileFormat = "json"
+	// FileFormatXML is the CycloneDX XML format
+	FileFormatXML FileFormat = "xml"
+	// FileFormatProto is the CycloneDX protocol buffers format
+	FileFormatProto FileFormat = "proto"
+	// FileFormatSPDXJSON is the SPDX 2.3 JSON format
+	FileFormatSPDXJSON FileFormat = "spdx-json"
+)
+
+// ParseFileFormat validates the name of a file format
+func ParseFileFormat(name string) (FileFormat, error) {
+	switch f := FileFormat(name); f {
+	case FileFormatJSON, FileFormatXML, FileFormatProto, FileFormatSPDXJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown file format %q, must be one of: json, xml, proto, spdx-json", name)
+}
+
+// DetectFileFormat detects the format of an SBOM by its content: JSON
+// documents start with '{' and XML documents with '<', ignoring whitespace
+// and a byte order mark. JSON documents with an spdxVersion are SPDX.
+// Anything else is treated as protocol buffers.
+func DetectFileFormat(data []byte) FileFormat {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimLeftFunc(data, unicode.IsSpace)
+	switch {
+	case bytes.HasPrefix(data, []byte("{")):
+		var doc struct {
+			SPDXVersion string `json:"spdxVersion"`
+		}
+		if json.Unmarshal(data, &doc) == nil && doc.SPDXVersion != "" {
+			return FileFormatSPDXJSON
+		}
+		return FileFormatJSON
+	case bytes.HasPrefix(data, []byte("<")):
+		return FileFormatXML
+	}
+	return FileFormatProto
+}
+
+// DecodeSBOM decodes an SBOM in the given format. SPDX documents are
+// converted to CycloneDX, use DecodeSPDX to learn what was lost.
+func DecodeSBOM(data []byte, format FileFormat) (*cyclonedx.BOM, error) {
+	bom := &cyclonedx.BOM{}
+	switch format {
+	case FileFormatJSON:
+		if err := cyclonedx.NewBOMDecoder(bytes.NewReader(data), cyclonedx.BOMFileFormatJSON).Decode(bom); err != nil {
+			return nil, fmt.Errorf("failed to decode BOM: %w", err)
+		}
+	case FileFormatXML:
+		if err := cyclonedx.NewBOMDecoder(bytes.NewReader(data), cyclonedx.BOMFileFormatXML).Decode(bom); err != nil {
+			return nil, fmt.Errorf("failed to decode BOM: %w", err)
+		}
+		// bomFormat only exists in JSON, but is required there
+		bom.BOMFormat = cyclonedx.BOMFormat
+	case FileFormatProto:
+		if err := unmarshalProto(data, bom); err != nil {
+			return nil, fmt.Errorf("failed to decode BOM: %w", err)
+		}
+	case FileFormatSPDXJSON:
+		spdxBom, _, err := DecodeSPDX(data)
+		if err != nil {
+			return nil, err
+		}
+		return spdxBom, nil
+	default:
+		return nil, fmt.Errorf("unknown file format %q", format)
+	}
+	return bom, nil
+}
+
+// EncodeSBOM encodes an SBOM in the given format. Use ConvertSBOM to learn
+// what was lost when encoding as SPDX.
+func EncodeSBOM(bom *cyclonedx.BOM, format FileFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FileFormatJSON, "":
+		encoder := cyclonedx.NewBOMEncoder(&buf, cyclonedx.BOMFileFormatJSON)
+		encoder.SetPretty(true)
+		if err := encoder.Encode(bom); err != nil {
+			return nil, fmt.Errorf("failed to encode BOM: %w", err)
+		}
+	case FileFormatXML:
+		encoder := cyclonedx.NewBOMEncoder(&buf, cyclonedx.BOMFileFormatXML)
+		encoder.SetPretty(true)
+		// Encoding for the BOM's own version sets the XML namespace, which
+		// is missing in BOMs decoded from JSON or protobuf
+		var err error
+		if bom.SpecVersion != 0 {
+			err = encoder.EncodeVersion(bom, bom.SpecVersion)
+		} else {
+			err = encoder.Encode(bom)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode BOM: %w", err)
+		}
+	case FileFormatProto:
+		data, err := marshalProto(bom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode BOM: %w", err)
+		}
+		return data, nil
+	case FileFormatSPDXJSON:
+		data, _, err := EncodeSPDX(bom)
+		return data, err
+	default:
+		return nil, fmt.Errorf("unknown file format %q", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteSBOMFileFormat writes a CycloneDX BOM to a file in the given format
+func WriteSBOMFileFormat(bom *cyclonedx.BOM, filename string, format FileFormat) error {
+	// Encode first, so a failed encoding does not leave a truncated file behind
+	data, err := EncodeSBOM(bom, format)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
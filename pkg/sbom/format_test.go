@@ -0,0 +1,113 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestDetectFileFormat(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected FileFormat
+	}{
+		{`{"bomFormat": "CycloneDX"}`, FileFormatJSON},
+		{`{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT"}`, FileFormatSPDXJSON},
+		{"\xef\xbb\xbf\n  {}", FileFormatJSON},
+		{`<?xml version="1.0"?><bom/>`, FileFormatXML},
+		{"\n<bom/>", FileFormatXML},
+		{"\x0a\x031.6", FileFormatProto},
+	}
+
+	for _, tt := range tests {
+		if format := DetectFileFormat([]byte(tt.data)); format != tt.expected {
+			t.Errorf("DetectFileFormat(%q) = %s, expected %s", tt.data, format, tt.expected)
+		}
+	}
+}
+
+func TestReadSBOMFileXML(t *testing.T) {
+	bom, err := ReadSBOMFile(filepath.Join("..", "..", "testdata", "sbom3.xml"))
+	if err != nil {
+		t.Fatalf("Failed to read XML SBOM: %v", err)
+	}
+
+	if bom.BOMFormat != "CycloneDX" {
+		t.Errorf("Expected bomFormat to be set for XML input, got %q", bom.BOMFormat)
+	}
+	if bom.Components == nil || len(*bom.Components) != 1 || (*bom.Components)[0].Name != "example-lib-4" {
+		t.Fatalf("Expected example-lib-4 component, got %+v", bom.Components)
+	}
+	if hashes := (*bom.Components)[0].Hashes; hashes == nil || len(*hashes) != 1 {
+		t.Errorf("Expected component hash to be read, got %+v", hashes)
+	}
+}
+
+func TestWriteSBOMFileFormatXMLRoundTrip(t *testing.T) {
+	bom, err := ReadSBOMFile(filepath.Join("..", "..", "testdata", "sbom1.json"))
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "sbom.xml")
+	if err := WriteSBOMFileFormat(bom, outputPath, FileFormatXML); err != nil {
+		t.Fatalf("Failed to write XML SBOM: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read XML SBOM: %v", err)
+	}
+	if !strings.Contains(string(data), "<bom xmlns=") {
+		t.Errorf("Expected XML output, got %s", data)
+	}
+
+	roundTripped, err := ReadSBOMFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read XML SBOM back: %v", err)
+	}
+	if len(*roundTripped.Components) != len(*bom.Components) || roundTripped.SerialNumber != bom.SerialNumber {
+		t.Errorf("Expected XML round trip to keep components and serial number, got %+v", roundTripped)
+	}
+}
+
+func TestMergeSBOMsMixedFormats(t *testing.T) {
+	testdataDir := filepath.Join("..", "..", "testdata")
+	outputPath := filepath.Join(t.TempDir(), "merged.xml")
+
+	_, err := MergeSBOMsWithOptions(
+		[]string{filepath.Join(testdataDir, "sbom1.json"), filepath.Join(testdataDir, "sbom3.xml")},
+		outputPath,
+		MergeOptions{OutputFormat: FileFormatXML},
+	)
+	if err != nil {
+		t.Fatalf("Failed to merge JSON and XML SBOMs: %v", err)
+	}
+
+	mergedBom, err := ReadSBOMFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+
+	names := make(map[string]bool)
+	walkComponents(mergedBom.Components, func(c *cyclonedx.Component) {
+		names[c.Name] = true
+	})
+	for _, name := range []string{"example-lib-1", "example-lib-2", "example-lib-4"} {
+		if !names[name] {
+			t.Errorf("Expected merged SBOM to contain %s", name)
+		}
+	}
+}
+
+func TestParseFileFormat(t *testing.T) {
+	if _, err := ParseFileFormat("proto"); err != nil {
+		t.Errorf("Expected proto to be valid: %v", err)
+	}
+	if _, err := ParseFileFormat("yaml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
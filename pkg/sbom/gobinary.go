@@ -0,0 +1,145 @@
+package sbom
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+	"github.com/package-url/packageurl-go"
+)
+
+// GenerateGoBinarySBOM reads the build info embedded in a Go executable and
+// returns an SBOM of the modules linked into it
+func GenerateGoBinarySBOM(path string) (*cyclonedx.BOM, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build info: %w", err)
+	}
+	timestamp, err := sourceDateEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	bom := goBuildInfoBOM(info, filepath.Base(path))
+	if timestamp != nil {
+		bom.Metadata.Timestamp = timestamp.Format(time.RFC3339)
+	}
+	return bom, nil
+}
+
+// goBuildInfoBOM returns an SBOM of the build info of a Go executable. The
+// main module is the metadata component, with the Go version and build
+// settings as properties, or the executable's name if it was not built in
+// module mode. The components are the dependencies and the Go standard
+// library as pkg:golang/stdlib, like in the Go vulnerability database. The
+// serial number is derived from the build info, so the SBOM of an executable
+// is always the same.
+func goBuildInfoBOM(info *debug.BuildInfo, name string) *cyclonedx.BOM {
+	bom := cyclonedx.NewBOM()
+	bom.SerialNumber = "urn:uuid:" + uuid.NewSHA1(reproducibleNamespace, []byte(info.String())).String()
+	bom.Version = 1
+
+	main := goModuleComponent(&info.Main, cyclonedx.ComponentTypeApplication)
+	if info.Main.Path == "" {
+		if info.Path != "" {
+			name = info.Path
+		}
+		main.Name, main.BOMRef, main.PackageURL = name, name, ""
+	}
+	properties := []cyclonedx.Property{{Name: "sbomctl:go:version", Value: info.GoVersion}}
+	for _, setting := range info.Settings {
+		properties = append(properties, cyclonedx.Property{Name: "sbomctl:go:build:" + setting.Key, Value: setting.Value})
+	}
+	if main.Properties != nil {
+		properties = append(properties, *main.Properties...)
+	}
+	main.Properties = &properties
+
+	bom.Metadata = &cyclonedx.Metadata{
+		Tools:     &cyclonedx.ToolsChoice{Components: &[]cyclonedx.Component{SbomctlTool()}},
+		Component: &main,
+	}
+
+	components := []cyclonedx.Component{}
+	// Development builds of Go have versions like "devel go1.23-3c8f9b7"
+	if version, ok := strings.CutPrefix(info.GoVersion, "go"); ok {
+		version, _, _ = strings.Cut(version, " ")
+		purl := packageurl.NewPackageURL(packageurl.TypeGolang, "", "stdlib", version, nil, "").ToString()
+		components = append(components, cyclonedx.Component{
+			BOMRef:     purl,
+			Type:       cyclonedx.ComponentTypeLibrary,
+			Name:       "stdlib",
+			Version:    version,
+			PackageURL: purl,
+		})
+	}
+	for _, dep := range info.Deps {
+		components = append(components, goModuleComponent(dep, cyclonedx.ComponentTypeLibrary))
+	}
+	bom.Components = &components
+
+	// The build info does not record which module requires which, so the
+	// main module depends on all of them
+	dependsOn := make([]string, len(components))
+	for i, c := range components {
+		dependsOn[i] = c.BOMRef
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{{Ref: main.BOMRef, Dependencies: sliceOrNil(dependsOn)}}
+	return bom
+}
+
+// goModuleComponent returns the component of a module of the build info. A
+// module replaced by another module version is the replacement, as that is
+// what was linked, and one replaced by a local directory has no checksum.
+func goModuleComponent(module *debug.Module, componentType cyclonedx.ComponentType) cyclonedx.Component {
+	var properties []cyclonedx.Property
+	if module.Replace != nil {
+		// Replacements by local directories have the version "(devel)"
+		if module.Replace.Version == "" || module.Replace.Version == "(devel)" {
+			properties = append(properties, cyclonedx.Property{Name: "sbomctl:go:replaced-by", Value: module.Replace.Path})
+			module = &debug.Module{Path: module.Path, Version: module.Version}
+		} else {
+			properties = append(properties, cyclonedx.Property{Name: "sbomctl:go:replaces", Value: module.Path + "@" + module.Version})
+			module = module.Replace
+		}
+	}
+
+	// Main modules built from their source tree have the version "(devel)"
+	version := module.Version
+	if version == "(devel)" {
+		version = ""
+	}
+	purl := goModulePURL(module.Path, version)
+	c := cyclonedx.Component{
+		BOMRef:     purl,
+		Type:       componentType,
+		Name:       module.Path,
+		Version:    version,
+		PackageURL: purl,
+	}
+
+	// The go.sum checksum is a hash over the file hashes and names of the
+	// module's files, https://go.dev/ref/mod#go-sum-files, not the hash of
+	// an artifact, so it is kept as it is instead of in the hashes
+	if module.Sum != "" {
+		properties = append(properties, cyclonedx.Property{Name: "sbomctl:go:h1", Value: module.Sum})
+	}
+	c.Properties = sliceOrNil(properties)
+	return c
+}
+
+// goModulePURL returns the package URL of a Go module. The module path is
+// split into namespace and name by hand, as packageurl would lowercase it.
+func goModulePURL(path, version string) string {
+	namespace, name := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		namespace, name = path[:i], path[i+1:]
+	}
+	purl := packageurl.NewPackageURL(packageurl.TypeGolang, namespace, name, version, nil, "")
+	return purl.ToString()
+}
@@ -0,0 +1,106 @@
+package sbom
+
+import (
+	"runtime/debug"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+const goBuildInfo = `path	github.com/example/app/cmd/app
+mod	github.com/example/app	v1.2.0	h1:QRIq4UdBVsFmbTxGZUXv9dl7mf0cG7p4ZzONh5b/ttI=
+dep	github.com/BurntSushi/toml	v1.3.2	h1:o7IhLm0Msx3BaB+n3Ag7L8EVlByGKpq14C8yWiaP8+s=
+dep	github.com/pkg/errors	v0.9.1
+=>	github.com/example/errors	v0.9.2	h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4=
+dep	golang.org/x/net	v0.20.0
+=>	../net	(devel)	
+build	-ldflags="-s -w"
+build	GOOS=linux
+build	vcs.revision=0123456789abcdef
+`
+
+func TestGoBuildInfoBOM(t *testing.T) {
+	info, err := debug.ParseBuildInfo(goBuildInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ParseBuildInfo leaves the Go version to buildinfo.ReadFile
+	info.GoVersion = "go1.22.1 X:loopvar"
+	bom := goBuildInfoBOM(info, "app")
+
+	main := bom.Metadata.Component
+	if main.PackageURL != "pkg:golang/github.com/example/app@v1.2.0" || main.BOMRef != main.PackageURL || main.Type != cyclonedx.ComponentTypeApplication {
+		t.Errorf("Unexpected metadata component: %+v", main)
+	}
+	if main.Hashes != nil {
+		t.Errorf("Expected the h1: checksum not to be a hash, got %+v", main.Hashes)
+	}
+	properties := make([]string, 0, len(*main.Properties))
+	for _, p := range *main.Properties {
+		properties = append(properties, p.Name+"="+p.Value)
+	}
+	expectedProperties := []string{
+		"sbomctl:go:version=go1.22.1 X:loopvar",
+		"sbomctl:go:build:-ldflags=-s -w",
+		"sbomctl:go:build:GOOS=linux",
+		"sbomctl:go:build:vcs.revision=0123456789abcdef",
+		"sbomctl:go:h1=h1:QRIq4UdBVsFmbTxGZUXv9dl7mf0cG7p4ZzONh5b/ttI=",
+	}
+	if !slices.Equal(properties, expectedProperties) {
+		t.Errorf("Expected properties %v, got %v", expectedProperties, properties)
+	}
+
+	var purls []string
+	for _, c := range *bom.Components {
+		purls = append(purls, c.PackageURL)
+	}
+	expectedPURLs := []string{
+		"pkg:golang/stdlib@1.22.1",
+		// The module path keeps its case
+		"pkg:golang/github.com/BurntSushi/toml@v1.3.2",
+		"pkg:golang/github.com/example/errors@v0.9.2",
+		"pkg:golang/golang.org/x/net@v0.20.0",
+	}
+	if !slices.Equal(purls, expectedPURLs) {
+		t.Errorf("Expected components %v, got %v", expectedPURLs, purls)
+	}
+
+	replaced := (*bom.Components)[2]
+	expectedReplaced := []cyclonedx.Property{
+		{Name: "sbomctl:go:replaces", Value: "github.com/pkg/errors@v0.9.1"},
+		{Name: "sbomctl:go:h1", Value: "h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4="},
+	}
+	if replaced.Properties == nil || !slices.Equal(*replaced.Properties, expectedReplaced) {
+		t.Errorf("Expected the replacement with its checksum, got %+v", replaced)
+	}
+	local := (*bom.Components)[3]
+	if len(*local.Properties) != 1 || (*local.Properties)[0] != (cyclonedx.Property{Name: "sbomctl:go:replaced-by", Value: "../net"}) {
+		t.Errorf("Expected the locally replaced module without checksum, got %+v", local)
+	}
+
+	if deps := *bom.Dependencies; len(deps) != 1 || deps[0].Ref != main.BOMRef || len(*deps[0].Dependencies) != 4 {
+		t.Errorf("Expected the main module to depend on all components, got %+v", deps)
+	}
+	if errs := CheckRefIntegrity(bom); len(errs) != 0 {
+		t.Errorf("Expected consistent refs, got %v", errs)
+	}
+	if other := goBuildInfoBOM(info, "app"); other.SerialNumber != bom.SerialNumber {
+		t.Errorf("Expected the same serial number for the same build info, got %s and %s", bom.SerialNumber, other.SerialNumber)
+	}
+}
+
+func TestGoBuildInfoBOMWithoutModule(t *testing.T) {
+	info, err := debug.ParseBuildInfo("path\tcommand-line-arguments\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.GoVersion = "devel go1.23-3c8f9b7"
+	bom := goBuildInfoBOM(info, "app")
+	if main := bom.Metadata.Component; main.Name != "command-line-arguments" || main.PackageURL != "" {
+		t.Errorf("Expected the package path as name, got %+v", main)
+	}
+	if len(*bom.Components) != 0 {
+		t.Errorf("Expected no stdlib component for a development version of Go, got %+v", *bom.Components)
+	}
+}
@@ -0,0 +1,343 @@
+package sbom
+
+import (
+	"slices"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// Graph is the dependency graph of a BOM. Nodes are identified by bom-ref,
+// edges are the dependsOn entries of the BOM's dependencies.
+type Graph struct {
+	refs       []string
+	components map[string]*cyclonedx.Component
+	dependsOn  map[string][]string
+	dependents map[string][]string
+	roots      []string
+}
+
+// NewGraph builds the dependency graph of a BOM. Refs that are used in the
+// dependencies but do not belong to a component are nodes as well, so
+// dangling refs show up instead of silently disappearing.
+func NewGraph(bom *cyclonedx.BOM) *Graph {
+	g := &Graph{
+		components: make(map[string]*cyclonedx.Component),
+		dependsOn:  make(map[string][]string),
+		dependents: make(map[string][]string),
+	}
+
+	addComponent := func(c *cyclonedx.Component) {
+		if c.BOMRef == "" || g.components[c.BOMRef] != nil {
+			return
+		}
+		g.components[c.BOMRef] = c
+		g.addNode(c.BOMRef)
+	}
+	var metadataRef string
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		metadataRef = bom.Metadata.Component.BOMRef
+		addComponent(bom.Metadata.Component)
+		walkComponents(bom.Metadata.Component.Components, addComponent)
+	}
+	walkComponents(bom.Components, addComponent)
+
+	if bom.Dependencies != nil {
+		for _, dep := range *bom.Dependencies {
+			g.addNode(dep.Ref)
+			if dep.Dependencies == nil {
+				continue
+			}
+			for _, target := range *dep.Dependencies {
+				g.addNode(target)
+				if !slices.Contains(g.dependsOn[dep.Ref], target) {
+					g.dependsOn[dep.Ref] = append(g.dependsOn[dep.Ref], target)
+					g.dependents[target] = append(g.dependents[target], dep.Ref)
+				}
+			}
+		}
+	}
+
+	// The graph starts at the metadata component. Every node that nothing
+	// depends on and that cannot be reached from there is a root as well.
+	reachable := make(map[string]bool)
+	if metadataRef != "" && len(g.dependsOn[metadataRef]) > 0 {
+		g.roots = []string{metadataRef}
+		g.reach(metadataRef, reachable)
+	}
+	for _, ref := range g.refs {
+		if !reachable[ref] && len(g.dependents[ref]) == 0 {
+			g.roots = append(g.roots, ref)
+		}
+	}
+	// A graph that only consists of cycles has no such node
+	if len(g.roots) == 0 && len(g.refs) > 0 {
+		g.roots = g.refs[:1]
+	}
+
+	return g
+}
+
+// reach marks all nodes reachable from ref
+func (g *Graph) reach(ref string, reachable map[string]bool) {
+	if reachable[ref] {
+		return
+	}
+	reachable[ref] = true
+	for _, target := range g.dependsOn[ref] {
+		g.reach(target, reachable)
+	}
+}
+
+// addNode adds a node, keeping the order in which nodes were first seen
+func (g *Graph) addNode(ref string) {
+	if ref == "" {
+		return
+	}
+	if _, ok := g.dependsOn[ref]; ok {
+		return
+	}
+	g.dependsOn[ref] = nil
+	g.refs = append(g.refs, ref)
+}
+
+// Refs returns the bom-refs of all nodes, in the order of the BOM
+func (g *Graph) Refs() []string {
+	return g.refs
+}
+
+// Roots returns the nodes the graph starts from: the metadata component and
+// all nodes nothing depends on that cannot be reached from it
+func (g *Graph) Roots() []string {
+	return g.roots
+}
+
+// Component returns the component of a node, or nil for dangling refs
+func (g *Graph) Component(ref string) *cyclonedx.Component {
+	return g.components[ref]
+}
+
+// DependsOn returns the direct dependencies of a node
+func (g *Graph) DependsOn(ref string) []string {
+	return g.dependsOn[ref]
+}
+
+// Dependents returns the nodes that directly depend on a node
+func (g *Graph) Dependents(ref string) []string {
+	return g.dependents[ref]
+}
+
+// Label returns a human readable name of a node: the package URL of its
+// component, or name@version, or the bom-ref itself
+func (g *Graph) Label(ref string) string {
+	c := g.components[ref]
+	if c == nil || c.PackageURL == "" && c.Name == "" {
+		return ref
+	}
+	return componentLabel(c)
+}
+
+// Find returns the nodes matching a query, which can be a bom-ref, a package
+// URL or name@version. The serial number prefix of merged SBOMs is ignored,
+// so a query can match a node of every input.
+func (g *Graph) Find(query string) []string {
+	var matches []string
+	for _, ref := range g.refs {
+		if ref == query || stripSerialPrefix(ref) == query {
+			matches = append(matches, ref)
+			continue
+		}
+		c := g.components[ref]
+		if c == nil {
+			continue
+		}
+		if c.PackageURL != "" && purlKey(c.PackageURL) == purlKey(query) ||
+			c.Name+"@"+c.Version == query || DisplayName(c.Group, c.Name)+"@"+c.Version == query {
+			matches = append(matches, ref)
+		}
+	}
+	return matches
+}
+
+// ShortestPaths returns every shortest path from a root to the target, with
+// the root first and the target last. At most limit paths are returned, all
+// of them if limit is zero. It returns nil if the target cannot be reached.
+func (g *Graph) ShortestPaths(target string, limit int) [][]string {
+	// Breadth-first search from all roots at once, remembering every
+	// predecessor on a shortest path
+	dist := make(map[string]int)
+	preds := make(map[string][]string)
+	queue := slices.Clone(g.roots)
+	for _, root := range g.roots {
+		dist[root] = 0
+	}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		if ref == target {
+			break
+		}
+		for _, next := range g.dependsOn[ref] {
+			d, seen := dist[next]
+			switch {
+			case !seen:
+				dist[next] = dist[ref] + 1
+				preds[next] = []string{ref}
+				queue = append(queue, next)
+			case d == dist[ref]+1:
+				preds[next] = append(preds[next], ref)
+			}
+		}
+	}
+	if _, ok := dist[target]; !ok {
+		return nil
+	}
+
+	var paths [][]string
+	var walk func(ref string, suffix []string)
+	walk = func(ref string, suffix []string) {
+		if limit > 0 && len(paths) >= limit {
+			return
+		}
+		suffix = append([]string{ref}, suffix...)
+		if dist[ref] == 0 {
+			paths = append(paths, suffix)
+			return
+		}
+		for _, pred := range preds[ref] {
+			walk(pred, suffix)
+		}
+	}
+	walk(target, nil)
+	return paths
+}
+
+// SourceSerial returns the serial number of the input SBOM a node of a
+// merged SBOM came from, taken from the prefix MergeSBOMs adds to bom-refs.
+// It returns an empty string for refs without prefix.
+func SourceSerial(ref string) string {
+	stripped := stripSerialPrefix(ref)
+	if stripped == ref {
+		return ""
+	}
+	return ref[:len(ref)-len(stripped)-1]
+}
+
+// GraphNode is a node of an exported dependency graph
+type GraphNode struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	Type       string `json:"type,omitempty"`
+	PackageURL string `json:"purl,omitempty"`
+	// Source is the serial number of the input SBOM of a merged SBOM the node came from
+	Source string `json:"source,omitempty"`
+}
+
+// GraphEdge is a dependency between two nodes of an exported dependency graph
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GraphExport is a dependency graph as a plain list of nodes and edges
+type GraphExport struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphFilter selects the nodes of an exported dependency graph
+type GraphFilter struct {
+	// Types are the component types to keep, all types are kept if empty
+	Types []cyclonedx.ComponentType
+	// Depth is the maximum distance of a node from the roots, unlimited if zero
+	Depth int
+}
+
+// Export returns the nodes and edges of the graph that match the filter.
+// Edges through nodes that are filtered out by type are replaced by edges to
+// the nearest matching nodes behind them, so the graph stays connected.
+// Nodes beyond the depth are cut off together with their edges.
+func (g *Graph) Export(filter GraphFilter) *GraphExport {
+	// Nodes beyond the depth end the graph, nodes of other types are
+	// bridged by edges
+	inDepth := make(map[string]bool)
+	keep := make(map[string]bool)
+	var dist map[string]int
+	if filter.Depth > 0 {
+		dist = g.distances()
+	}
+	for _, ref := range g.refs {
+		if d, ok := dist[ref]; filter.Depth > 0 && (!ok || d > filter.Depth) {
+			continue
+		}
+		inDepth[ref] = true
+		if len(filter.Types) > 0 {
+			c := g.components[ref]
+			if c == nil || !slices.Contains(filter.Types, c.Type) {
+				continue
+			}
+		}
+		keep[ref] = true
+	}
+
+	export := &GraphExport{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, ref := range g.refs {
+		if !keep[ref] {
+			continue
+		}
+		node := GraphNode{ID: ref, Label: g.Label(ref), Source: SourceSerial(ref)}
+		if c := g.components[ref]; c != nil {
+			node.Type = string(c.Type)
+			node.PackageURL = c.PackageURL
+		}
+		export.Nodes = append(export.Nodes, node)
+
+		for _, target := range g.keptTargets(ref, keep, inDepth) {
+			export.Edges = append(export.Edges, GraphEdge{From: ref, To: target})
+		}
+	}
+	return export
+}
+
+// keptTargets returns the kept nodes ref depends on, looking through nodes
+// within the depth that are not kept
+func (g *Graph) keptTargets(ref string, keep, inDepth map[string]bool) []string {
+	var targets []string
+	visited := map[string]bool{ref: true}
+	var walk func(string)
+	walk = func(ref string) {
+		for _, next := range g.dependsOn[ref] {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			if keep[next] {
+				targets = append(targets, next)
+			} else if inDepth[next] {
+				walk(next)
+			}
+		}
+	}
+	walk(ref)
+	return targets
+}
+
+// distances returns the length of the shortest path from a root to every
+// reachable node
+func (g *Graph) distances() map[string]int {
+	dist := make(map[string]int)
+	queue := slices.Clone(g.roots)
+	for _, root := range g.roots {
+		dist[root] = 0
+	}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		for _, next := range g.dependsOn[ref] {
+			if _, seen := dist[next]; !seen {
+				dist[next] = dist[ref] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return dist
+}
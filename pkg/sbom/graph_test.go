@@ -0,0 +1,187 @@
+package sbom
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// graphTestBOM returns a BOM whose graph has a root, a shared dependency, a
+// cycle, an orphan and a dangling ref
+func graphTestBOM() *cyclonedx.BOM {
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{
+		Component: &cyclonedx.Component{BOMRef: "app", Name: "app", Version: "1.0.0"},
+	}
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "a", Name: "a", Version: "1.0.0", PackageURL: "pkg:npm/a@1.0.0"},
+		{BOMRef: "b", Name: "b", Version: "2.0.0", PackageURL: "pkg:npm/b@2.0.0"},
+		{BOMRef: "c", Group: "example", Name: "c", Version: "3.0.0"},
+		{BOMRef: "orphan", Name: "orphan"},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"a", "b"}},
+		{Ref: "a", Dependencies: &[]string{"c"}},
+		{Ref: "b", Dependencies: &[]string{"c", "missing"}},
+		{Ref: "c", Dependencies: &[]string{"a"}},
+	}
+	return bom
+}
+
+func TestNewGraph(t *testing.T) {
+	g := NewGraph(graphTestBOM())
+
+	if roots := g.Roots(); !slices.Equal(roots, []string{"app", "orphan"}) {
+		t.Errorf("Expected app and orphan as roots, got %v", roots)
+	}
+	if dependsOn := g.DependsOn("b"); !slices.Equal(dependsOn, []string{"c", "missing"}) {
+		t.Errorf("Expected dependencies of b to keep their order, got %v", dependsOn)
+	}
+	if dependents := g.Dependents("c"); !slices.Equal(dependents, []string{"a", "b"}) {
+		t.Errorf("Expected a and b to depend on c, got %v", dependents)
+	}
+	if g.Component("missing") != nil || !slices.Contains(g.Refs(), "missing") {
+		t.Error("Expected the dangling ref to be a node without component")
+	}
+}
+
+func TestGraphLabel(t *testing.T) {
+	g := NewGraph(graphTestBOM())
+
+	tests := map[string]string{
+		"a":       "pkg:npm/a@1.0.0",
+		"c":       "example/c@3.0.0",
+		"orphan":  "orphan",
+		"missing": "missing",
+	}
+	for ref, expected := range tests {
+		if label := g.Label(ref); label != expected {
+			t.Errorf("Label(%q) = %q, expected %q", ref, label, expected)
+		}
+	}
+}
+
+func TestGraphFind(t *testing.T) {
+	bom := graphTestBOM()
+	(*bom.Components)[3].BOMRef = "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79/orphan"
+	g := NewGraph(bom)
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"pkg:npm/b@2.0.0", []string{"b"}},
+		{"example/c@3.0.0", []string{"c"}},
+		{"c@3.0.0", []string{"c"}},
+		{"orphan", []string{"urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79/orphan"}},
+		{"pkg:npm/unknown@1.0.0", nil},
+	}
+	for _, tt := range tests {
+		if matches := g.Find(tt.query); !slices.Equal(matches, tt.expected) {
+			t.Errorf("Find(%q) = %v, expected %v", tt.query, matches, tt.expected)
+		}
+	}
+}
+
+func TestGraphShortestPaths(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"a", "b", "d"}},
+		{Ref: "a", Dependencies: &[]string{"c"}},
+		{Ref: "b", Dependencies: &[]string{"c"}},
+		{Ref: "d", Dependencies: &[]string{"e"}},
+		{Ref: "e", Dependencies: &[]string{"c"}},
+		{Ref: "unreachable", Dependencies: &[]string{"unreachable-dep"}},
+		{Ref: "unreachable-dep", Dependencies: &[]string{"unreachable"}},
+	}
+	g := NewGraph(bom)
+
+	paths := g.ShortestPaths("c", 0)
+	if len(paths) != 2 || !slices.Equal(paths[0], []string{"app", "a", "c"}) || !slices.Equal(paths[1], []string{"app", "b", "c"}) {
+		t.Errorf("Expected the two shortest paths via a and b, got %v", paths)
+	}
+	if paths := g.ShortestPaths("c", 1); len(paths) != 1 {
+		t.Errorf("Expected the number of paths to be limited, got %v", paths)
+	}
+	if paths := g.ShortestPaths("app", 0); len(paths) != 1 || !slices.Equal(paths[0], []string{"app"}) {
+		t.Errorf("Expected the path to a root to be the root itself, got %v", paths)
+	}
+	if paths := g.ShortestPaths("unreachable-dep", 0); paths != nil {
+		t.Errorf("Expected no path to a component only reachable through a cycle, got %v", paths)
+	}
+}
+
+func TestSourceSerial(t *testing.T) {
+	tests := map[string]string{
+		"urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79/pkg:npm/a@1.0.0": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+		"pkg:npm/a@1.0.0": "",
+		"urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79": "",
+	}
+	for ref, expected := range tests {
+		if serial := SourceSerial(ref); serial != expected {
+			t.Errorf("SourceSerial(%q) = %q, expected %q", ref, serial, expected)
+		}
+	}
+}
+
+func TestGraphExport(t *testing.T) {
+	bom := graphTestBOM()
+	for i := range *bom.Components {
+		(*bom.Components)[i].Type = cyclonedx.ComponentTypeLibrary
+	}
+	(*bom.Components)[0].Type = cyclonedx.ComponentTypeFramework
+	g := NewGraph(bom)
+
+	nodeIDs := func(export *GraphExport) []string {
+		var ids []string
+		for _, node := range export.Nodes {
+			ids = append(ids, node.ID)
+		}
+		return ids
+	}
+
+	export := g.Export(GraphFilter{})
+	if len(export.Nodes) != len(g.Refs()) || len(export.Edges) != 6 {
+		t.Errorf("Expected the unfiltered export to contain the whole graph, got %d nodes and %d edges", len(export.Nodes), len(export.Edges))
+	}
+	if node := export.Nodes[1]; node.Label != "pkg:npm/a@1.0.0" || node.Type != "framework" || node.PackageURL != "pkg:npm/a@1.0.0" {
+		t.Errorf("Unexpected node for a: %+v", node)
+	}
+
+	// b reaches c directly, a is left out and only leads back to c
+	export = g.Export(GraphFilter{Types: []cyclonedx.ComponentType{cyclonedx.ComponentTypeLibrary}})
+	if ids := nodeIDs(export); !slices.Equal(ids, []string{"b", "c", "orphan"}) {
+		t.Errorf("Expected only libraries, got %v", ids)
+	}
+	if !slices.Equal(export.Edges, []GraphEdge{{From: "b", To: "c"}}) {
+		t.Errorf("Unexpected edges: %v", export.Edges)
+	}
+
+	export = g.Export(GraphFilter{Depth: 1})
+	if ids := nodeIDs(export); !slices.Equal(ids, []string{"app", "a", "b", "orphan"}) {
+		t.Errorf("Expected the nodes up to depth 1, got %v", ids)
+	}
+	if !slices.Equal(export.Edges, []GraphEdge{{From: "app", To: "a"}, {From: "app", To: "b"}}) {
+		t.Errorf("Unexpected edges: %v", export.Edges)
+	}
+}
+
+func TestGraphExportBridgesFilteredNodes(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "app", Name: "app", Type: cyclonedx.ComponentTypeApplication},
+		{BOMRef: "runtime", Name: "runtime", Type: cyclonedx.ComponentTypeFramework},
+		{BOMRef: "lib", Name: "lib", Type: cyclonedx.ComponentTypeLibrary},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"runtime"}},
+		{Ref: "runtime", Dependencies: &[]string{"lib"}},
+	}
+
+	export := NewGraph(bom).Export(GraphFilter{Types: []cyclonedx.ComponentType{cyclonedx.ComponentTypeApplication, cyclonedx.ComponentTypeLibrary}})
+	if !slices.Equal(export.Edges, []GraphEdge{{From: "app", To: "lib"}}) {
+		t.Errorf("Expected app to depend on lib through the left out runtime, got %v", export.Edges)
+	}
+}
@@ -0,0 +1,161 @@
+package sbom
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// IntegrityMode controls how ref integrity problems of a merged SBOM are handled
+type IntegrityMode string
+
+const (
+	// IntegrityModeWarn reports integrity problems but writes the SBOM anyway
+	IntegrityModeWarn IntegrityMode = "warn"
+	// IntegrityModeStrict fails the merge if there are integrity problems
+	IntegrityModeStrict IntegrityMode = "strict"
+	// IntegrityModeRepair drops or rewrites dangling refs before writing the SBOM
+	IntegrityModeRepair IntegrityMode = "repair"
+)
+
+// checkMergedIntegrity checks the ref integrity of a merged SBOM and handles
+// problems according to the given mode. Remaining problems are recorded in
+// the report.
+func checkMergedIntegrity(bom *cyclonedx.BOM, mode IntegrityMode, report *MergeReport) error {
+	if mode == IntegrityModeRepair {
+		report.Repairs = append(report.Repairs, RepairRefIntegrity(bom)...)
+	}
+
+	report.IntegrityErrors = CheckRefIntegrity(bom)
+	if mode == IntegrityModeStrict && len(report.IntegrityErrors) > 0 {
+		messages := make([]string, 0, len(report.IntegrityErrors))
+		for _, integrityErr := range report.IntegrityErrors {
+			messages = append(messages, integrityErr.Error())
+		}
+		return fmt.Errorf("merged SBOM has %d ref integrity errors:\n  - %s",
+			len(report.IntegrityErrors), strings.Join(messages, "\n  - "))
+	}
+
+	return nil
+}
+
+// RepairRefIntegrity fixes dangling refs in the dependency graph of a BOM.
+// A dangling ref is rewritten if it unambiguously matches a bom-ref that only
+// differs by its serial number prefix, or the package URL of a component.
+// Otherwise the edge, or the whole dependencies entry, is dropped. It returns
+// a description of every change made.
+func RepairRefIntegrity(bom *cyclonedx.BOM) []string {
+	if bom.Dependencies == nil {
+		return nil
+	}
+
+	resolve := newRefResolver(bom)
+	var repairs []string
+
+	dependencies := make([]cyclonedx.Dependency, 0, len(*bom.Dependencies))
+	for _, dep := range *bom.Dependencies {
+		ref, ok := resolve(dep.Ref)
+		if !ok {
+			repairs = append(repairs, fmt.Sprintf("dropped dependencies entry for unknown ref %q", dep.Ref))
+			continue
+		}
+		if ref != dep.Ref {
+			repairs = append(repairs, fmt.Sprintf("rewrote dependency ref %q to %q", dep.Ref, ref))
+		}
+
+		newDep := cyclonedx.Dependency{Ref: ref}
+		if dep.Dependencies != nil {
+			dependsOn := make([]string, 0, len(*dep.Dependencies))
+			for _, target := range *dep.Dependencies {
+				resolved, ok := resolve(target)
+				if !ok {
+					repairs = append(repairs, fmt.Sprintf("dropped dependency %q -> %q, target does not exist", ref, target))
+					continue
+				}
+				if resolved != target {
+					repairs = append(repairs, fmt.Sprintf("rewrote dependency %q -> %q to %q", ref, target, resolved))
+				}
+				dependsOn = append(dependsOn, resolved)
+			}
+			newDep.Dependencies = &dependsOn
+		}
+		dependencies = append(dependencies, newDep)
+	}
+
+	// Rewriting can make entries and edges collide
+	bom.Dependencies = deduplicateDependencies(&dependencies)
+
+	return repairs
+}
+
+// newRefResolver returns a function that maps a ref to an existing bom-ref of
+// the BOM, if there is a unique match
+func newRefResolver(bom *cyclonedx.BOM) func(string) (string, bool) {
+	known := make(map[string]bool)
+	candidates := make(map[string][]string)
+	addCandidate := func(key, ref string) {
+		for _, existing := range candidates[key] {
+			if existing == ref {
+				return
+			}
+		}
+		candidates[key] = append(candidates[key], ref)
+	}
+
+	index := func(c *cyclonedx.Component) {
+		if c.BOMRef == "" {
+			return
+		}
+		known[c.BOMRef] = true
+		addCandidate(stripSerialPrefix(c.BOMRef), c.BOMRef)
+		if c.PackageURL != "" {
+			addCandidate(c.PackageURL, c.BOMRef)
+		}
+	}
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		index(bom.Metadata.Component)
+		walkComponents(bom.Metadata.Component.Components, index)
+	}
+	walkComponents(bom.Components, index)
+	walkServices(bom.Services, func(s *cyclonedx.Service) {
+		if s.BOMRef != "" {
+			known[s.BOMRef] = true
+			addCandidate(stripSerialPrefix(s.BOMRef), s.BOMRef)
+		}
+	})
+
+	return func(ref string) (string, bool) {
+		if known[ref] {
+			return ref, true
+		}
+		for _, key := range []string{ref, stripSerialPrefix(ref)} {
+			if matches := candidates[key]; len(matches) == 1 {
+				return matches[0], true
+			}
+		}
+		return "", false
+	}
+}
+
+// stripSerialPrefix removes the "urn:uuid:.../" prefix that MergeSBOMs adds to bom-refs
+func stripSerialPrefix(ref string) string {
+	if !strings.HasPrefix(ref, "urn:uuid:") {
+		return ref
+	}
+	if i := strings.Index(ref, "/"); i >= 0 {
+		return ref[i+1:]
+	}
+	return ref
+}
+
+// walkServices calls fn for every service in the list, descending into nested services
+func walkServices(services *[]cyclonedx.Service, fn func(*cyclonedx.Service)) {
+	if services == nil {
+		return
+	}
+	for i := range *services {
+		fn(&(*services)[i])
+		walkServices((*services)[i].Services, fn)
+	}
+}
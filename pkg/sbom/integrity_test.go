@@ -0,0 +1,130 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// writeDanglingTestSBOMs writes two SBOMs where the first one depends on a
+// component that only the second one defines, and on one that does not exist
+func writeDanglingTestSBOMs(t *testing.T, testDir string) (string, string) {
+	sbom1Path := filepath.Join(testDir, "sbom1.json")
+	sbom2Path := filepath.Join(testDir, "sbom2.json")
+
+	sbom1 := cyclonedx.NewBOM()
+	sbom1.SerialNumber = "urn:uuid:serial-1"
+	sbom1.Components = &[]cyclonedx.Component{
+		{BOMRef: "pkg:npm/foo@1.0.0", Name: "foo", Version: "1.0.0", Type: cyclonedx.ComponentTypeLibrary},
+	}
+	sbom1.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "pkg:npm/foo@1.0.0", Dependencies: &[]string{"pkg:npm/baz@3.0.0", "pkg:npm/ghost@1.0.0"}},
+	}
+
+	sbom2 := cyclonedx.NewBOM()
+	sbom2.SerialNumber = "urn:uuid:serial-2"
+	sbom2.Components = &[]cyclonedx.Component{
+		{BOMRef: "pkg:npm/baz@3.0.0", Name: "baz", Version: "3.0.0", Type: cyclonedx.ComponentTypeLibrary},
+	}
+
+	if err := WriteSBOMFile(sbom1, sbom1Path); err != nil {
+		t.Fatalf("Failed to write test SBOM 1: %v", err)
+	}
+	if err := WriteSBOMFile(sbom2, sbom2Path); err != nil {
+		t.Fatalf("Failed to write test SBOM 2: %v", err)
+	}
+	return sbom1Path, sbom2Path
+}
+
+func TestMergeSBOMsWithOptionsWarnsAboutDanglingRefs(t *testing.T) {
+	testDir := t.TempDir()
+	sbom1Path, sbom2Path := writeDanglingTestSBOMs(t, testDir)
+	outputPath := filepath.Join(testDir, "merged.json")
+
+	report, err := MergeSBOMsWithOptions([]string{sbom1Path, sbom2Path}, outputPath, MergeOptions{})
+	if err != nil {
+		t.Fatalf("Failed to merge SBOMs: %v", err)
+	}
+	if len(report.IntegrityErrors) != 2 {
+		t.Errorf("Expected 2 integrity errors, got %v", report.IntegrityErrors)
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		t.Errorf("Expected merged SBOM to be written: %v", err)
+	}
+}
+
+func TestMergeSBOMsWithOptionsStrict(t *testing.T) {
+	testDir := t.TempDir()
+	sbom1Path, sbom2Path := writeDanglingTestSBOMs(t, testDir)
+	outputPath := filepath.Join(testDir, "merged.json")
+
+	_, err := MergeSBOMsWithOptions([]string{sbom1Path, sbom2Path}, outputPath, MergeOptions{
+		Integrity: IntegrityModeStrict,
+	})
+	if err == nil {
+		t.Fatal("Expected strict merge to fail")
+	}
+	if !strings.Contains(err.Error(), "urn:uuid:serial-1/pkg:npm/ghost@1.0.0") {
+		t.Errorf("Expected error to mention the dangling ref, got: %v", err)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("Expected no merged SBOM to be written in strict mode")
+	}
+}
+
+func TestMergeSBOMsWithOptionsRepair(t *testing.T) {
+	testDir := t.TempDir()
+	sbom1Path, sbom2Path := writeDanglingTestSBOMs(t, testDir)
+	outputPath := filepath.Join(testDir, "merged.json")
+
+	report, err := MergeSBOMsWithOptions([]string{sbom1Path, sbom2Path}, outputPath, MergeOptions{
+		Integrity: IntegrityModeRepair,
+	})
+	if err != nil {
+		t.Fatalf("Failed to merge SBOMs: %v", err)
+	}
+	if len(report.IntegrityErrors) != 0 {
+		t.Errorf("Expected no integrity errors after repair, got %v", report.IntegrityErrors)
+	}
+	if len(report.Repairs) != 2 {
+		t.Errorf("Expected 2 repairs, got %v", report.Repairs)
+	}
+
+	mergedBom, err := ReadSBOMFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	for _, dep := range *mergedBom.Dependencies {
+		if dep.Ref != "urn:uuid:serial-1/pkg:npm/foo@1.0.0" {
+			continue
+		}
+		if dep.Dependencies == nil || len(*dep.Dependencies) != 1 || (*dep.Dependencies)[0] != "urn:uuid:serial-2/pkg:npm/baz@3.0.0" {
+			t.Errorf("Expected foo to depend on the baz of SBOM 2, got %v", dep.Dependencies)
+		}
+		return
+	}
+	t.Error("Dependencies of foo not found in merged SBOM")
+}
+
+func TestRepairRefIntegrityDropsOrphanEntries(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "a", Name: "a"},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "a"},
+		{Ref: "orphan", Dependencies: &[]string{"a"}},
+	}
+
+	repairs := RepairRefIntegrity(bom)
+
+	if len(repairs) != 1 {
+		t.Errorf("Expected 1 repair, got %v", repairs)
+	}
+	if len(*bom.Dependencies) != 1 || (*bom.Dependencies)[0].Ref != "a" {
+		t.Errorf("Expected only the dependencies entry of a to remain, got %v", *bom.Dependencies)
+	}
+}
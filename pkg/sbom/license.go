@@ -0,0 +1,294 @@
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// LicenseExpression is a parsed SPDX license expression. It is either a
+// single license, optionally with an exception, or the AND or OR of several
+// expressions.
+type LicenseExpression struct {
+	// Operator is AND or OR, empty for a single license
+	Operator string
+	Operands []*LicenseExpression
+	// License is the license ID or LicenseRef of a single license. Licenses
+	// of components that only have a name use the name instead.
+	License string
+	// Exception is the exception of a single license given with WITH
+	Exception string
+}
+
+// licenseIDPattern matches SPDX license and exception IDs, LicenseRefs and
+// DocumentRefs, with an optional + for "or later"
+var licenseIDPattern = regexp.MustCompile(`^[A-Za-z0-9.\-]+(:[A-Za-z0-9.\-]+)?\+?$`)
+
+// ParseLicenseExpression parses an SPDX license expression like
+// "(MIT OR Apache-2.0) AND GPL-2.0-only WITH Classpath-exception-2.0". WITH
+// binds stronger than AND, which binds stronger than OR. Only the syntax is
+// checked, not whether the IDs are on the SPDX license list.
+func ParseLicenseExpression(expression string) (*LicenseExpression, error) {
+	p := &licenseParser{tokens: tokenizeLicenseExpression(expression)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in license expression %q", p.tokens[p.pos], expression)
+	}
+	return e, nil
+}
+
+// tokenizeLicenseExpression splits an expression at whitespace and parentheses
+func tokenizeLicenseExpression(expression string) []string {
+	expression = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expression)
+	return strings.Fields(expression)
+}
+
+// licenseParser is a recursive descent parser for license expressions
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+// accept consumes the next token if it is the given operator or parenthesis.
+// Operators are matched case-insensitively.
+func (p *licenseParser) accept(token string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], token) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *licenseParser) parseOr() (*LicenseExpression, error) {
+	return p.parseCompound("OR", p.parseAnd)
+}
+
+func (p *licenseParser) parseAnd() (*LicenseExpression, error) {
+	return p.parseCompound("AND", p.parseWith)
+}
+
+// parseCompound parses operands joined by the operator, flattening nested
+// expressions with the same operator
+func (p *licenseParser) parseCompound(operator string, parseOperand func() (*LicenseExpression, error)) (*LicenseExpression, error) {
+	e, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(operator) {
+		operand, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if e.Operator != operator {
+			e = &LicenseExpression{Operator: operator, Operands: []*LicenseExpression{e}}
+		}
+		if operand.Operator == operator {
+			e.Operands = append(e.Operands, operand.Operands...)
+		} else {
+			e.Operands = append(e.Operands, operand)
+		}
+	}
+	return e, nil
+}
+
+func (p *licenseParser) parseWith() (*LicenseExpression, error) {
+	if p.accept("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing closing parenthesis in license expression")
+		}
+		return e, nil
+	}
+
+	license, err := p.parseID("license")
+	if err != nil {
+		return nil, err
+	}
+	e := &LicenseExpression{License: license}
+	if p.accept("WITH") {
+		if e.Exception, err = p.parseID("exception"); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// parseID consumes a license or exception ID
+func (p *licenseParser) parseID(kind string) (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", fmt.Errorf("missing %s at the end of license expression", kind)
+	}
+	token := p.tokens[p.pos]
+	for _, keyword := range []string{"AND", "OR", "WITH", "(", ")"} {
+		if strings.EqualFold(token, keyword) {
+			return "", fmt.Errorf("expected %s but got %q in license expression", kind, token)
+		}
+	}
+	if !licenseIDPattern.MatchString(token) {
+		return "", fmt.Errorf("invalid %s ID %q in license expression", kind, token)
+	}
+	p.pos++
+	return token, nil
+}
+
+// String returns the expression in SPDX syntax, with parentheses only where
+// they are needed
+func (e *LicenseExpression) String() string {
+	if e.Operator == "" {
+		if e.Exception != "" {
+			return e.License + " WITH " + e.Exception
+		}
+		return e.License
+	}
+	parts := make([]string, len(e.Operands))
+	for i, operand := range e.Operands {
+		parts[i] = operand.String()
+		// AND binds stronger than OR, so only OR inside AND needs parentheses
+		if e.Operator == "AND" && operand.Operator == "OR" {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+e.Operator+" ")
+}
+
+// Licenses returns the single licenses of the expression, including their
+// exceptions, in the order they appear
+func (e *LicenseExpression) Licenses() []string {
+	if e.Operator == "" {
+		return []string{e.String()}
+	}
+	var licenses []string
+	for _, operand := range e.Operands {
+		licenses = append(licenses, operand.Licenses()...)
+	}
+	return licenses
+}
+
+// ComponentLicenseExpression returns the licenses of a component as one
+// expression. Several licenses of a component all apply, so they are joined
+// with AND. Licenses that only have a name are taken as they are. It returns
+// nil if the component has no license information.
+func ComponentLicenseExpression(c *cyclonedx.Component) (*LicenseExpression, error) {
+	if c.Licenses == nil {
+		return nil, nil
+	}
+	var operands []*LicenseExpression
+	for _, choice := range *c.Licenses {
+		var operand *LicenseExpression
+		switch {
+		case choice.Expression != "":
+			var err error
+			if operand, err = ParseLicenseExpression(choice.Expression); err != nil {
+				return nil, err
+			}
+		case choice.License != nil && choice.License.ID != "":
+			operand = &LicenseExpression{License: choice.License.ID}
+		case choice.License != nil && choice.License.Name != "":
+			operand = &LicenseExpression{License: choice.License.Name}
+		default:
+			continue
+		}
+		operands = append(operands, operand)
+	}
+	switch len(operands) {
+	case 0:
+		return nil, nil
+	case 1:
+		return operands[0], nil
+	}
+	e := &LicenseExpression{Operator: "AND"}
+	for _, operand := range operands {
+		if operand.Operator == "AND" {
+			e.Operands = append(e.Operands, operand.Operands...)
+		} else {
+			e.Operands = append(e.Operands, operand)
+		}
+	}
+	return e, nil
+}
+
+// LicenseReport groups components by license
+type LicenseReport struct {
+	Licenses []LicenseUsage `json:"licenses" yaml:"licenses"`
+	// Unlicensed are the components without license information
+	Unlicensed []string `json:"unlicensed" yaml:"unlicensed"`
+	// Invalid are the components whose license expression cannot be parsed
+	Invalid []InvalidLicense `json:"invalid,omitempty" yaml:"invalid,omitempty"`
+}
+
+// LicenseUsage lists the components under a license. Components with an
+// expression like "MIT OR Apache-2.0" are listed under every license in it.
+type LicenseUsage struct {
+	License    string   `json:"license" yaml:"license"`
+	Components []string `json:"components" yaml:"components"`
+}
+
+// InvalidLicense is a component with a license expression that cannot be parsed
+type InvalidLicense struct {
+	Component string `json:"component" yaml:"component"`
+	Error     string `json:"error" yaml:"error"`
+}
+
+// ReportLicenses groups the components by license. Licenses are sorted by
+// the number of their components, most used first. Components are
+// identified by package URL or name@version.
+func ReportLicenses(components []cyclonedx.Component) *LicenseReport {
+	report := &LicenseReport{Licenses: []LicenseUsage{}, Unlicensed: []string{}}
+	byLicense := make(map[string][]string)
+	for i := range components {
+		label := componentLabel(&components[i])
+		e, err := ComponentLicenseExpression(&components[i])
+		switch {
+		case err != nil:
+			report.Invalid = append(report.Invalid, InvalidLicense{Component: label, Error: err.Error()})
+		case e == nil:
+			report.Unlicensed = append(report.Unlicensed, label)
+		default:
+			seen := make(map[string]bool)
+			for _, license := range e.Licenses() {
+				if !seen[license] {
+					seen[license] = true
+					byLicense[license] = append(byLicense[license], label)
+				}
+			}
+		}
+	}
+
+	for license, labels := range byLicense {
+		report.Licenses = append(report.Licenses, LicenseUsage{License: license, Components: labels})
+	}
+	sort.Slice(report.Licenses, func(i, j int) bool {
+		a, b := report.Licenses[i], report.Licenses[j]
+		if len(a.Components) != len(b.Components) {
+			return len(a.Components) > len(b.Components)
+		}
+		return a.License < b.License
+	})
+	return report
+}
+
+// componentLabel returns a human readable name of a component: its package
+// URL, or name@version, or its bom-ref
+func componentLabel(c *cyclonedx.Component) string {
+	switch {
+	case c.PackageURL != "":
+		return c.PackageURL
+	case c.Name == "":
+		return c.BOMRef
+	case c.Version == "":
+		return DisplayName(c.Group, c.Name)
+	}
+	return DisplayName(c.Group, c.Name) + "@" + c.Version
+}
@@ -0,0 +1,102 @@
+package sbom
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestParseLicenseExpression(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+		licenses   []string
+	}{
+		{"MIT", "MIT", []string{"MIT"}},
+		{"MIT OR Apache-2.0", "MIT OR Apache-2.0", []string{"MIT", "Apache-2.0"}},
+		{"mit or apache-2.0 and BSD-3-Clause", "mit OR apache-2.0 AND BSD-3-Clause", []string{"mit", "apache-2.0", "BSD-3-Clause"}},
+		{"(MIT OR Apache-2.0) AND (BSD-2-Clause OR ISC)", "(MIT OR Apache-2.0) AND (BSD-2-Clause OR ISC)", []string{"MIT", "Apache-2.0", "BSD-2-Clause", "ISC"}},
+		{"((MIT))", "MIT", []string{"MIT"}},
+		{"A AND (B AND C)", "A AND B AND C", []string{"A", "B", "C"}},
+		{"GPL-2.0-only WITH Classpath-exception-2.0 OR MIT", "GPL-2.0-only WITH Classpath-exception-2.0 OR MIT", []string{"GPL-2.0-only WITH Classpath-exception-2.0", "MIT"}},
+		{"GPL-2.0+ AND DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2", "GPL-2.0+ AND DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2", []string{"GPL-2.0+", "DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2"}},
+	}
+	for _, tt := range tests {
+		e, err := ParseLicenseExpression(tt.expression)
+		if err != nil {
+			t.Errorf("ParseLicenseExpression(%q) failed: %v", tt.expression, err)
+			continue
+		}
+		if e.String() != tt.expected {
+			t.Errorf("ParseLicenseExpression(%q) = %q, expected %q", tt.expression, e.String(), tt.expected)
+		}
+		if licenses := e.Licenses(); !slices.Equal(licenses, tt.licenses) {
+			t.Errorf("Licenses of %q = %v, expected %v", tt.expression, licenses, tt.licenses)
+		}
+	}
+
+	// AND binds stronger than OR
+	e, _ := ParseLicenseExpression("A OR B AND C")
+	if e.Operator != "OR" || len(e.Operands) != 2 || e.Operands[1].Operator != "AND" {
+		t.Errorf("Expected A OR (B AND C), got %+v", e)
+	}
+
+	for _, invalid := range []string{"", "MIT OR", "(MIT", "MIT)", "MIT Apache-2.0", "WITH Classpath-exception-2.0", "MIT WITH", "Apache License 2.0", "MIT/X11"} {
+		if _, err := ParseLicenseExpression(invalid); err == nil {
+			t.Errorf("Expected ParseLicenseExpression(%q) to fail", invalid)
+		}
+	}
+}
+
+func TestComponentLicenseExpression(t *testing.T) {
+	c := &cyclonedx.Component{Licenses: &cyclonedx.Licenses{
+		{License: &cyclonedx.License{ID: "MIT"}},
+		{License: &cyclonedx.License{Name: "Custom License"}},
+		{Expression: "Apache-2.0 AND BSD-3-Clause"},
+	}}
+	e, err := ComponentLicenseExpression(c)
+	if err != nil {
+		t.Fatalf("ComponentLicenseExpression failed: %v", err)
+	}
+	if e.String() != "MIT AND Custom License AND Apache-2.0 AND BSD-3-Clause" {
+		t.Errorf("Unexpected expression %q", e.String())
+	}
+
+	if e, err := ComponentLicenseExpression(&cyclonedx.Component{}); e != nil || err != nil {
+		t.Errorf("Expected no expression for a component without licenses, got %v, %v", e, err)
+	}
+	if _, err := ComponentLicenseExpression(&cyclonedx.Component{Licenses: &cyclonedx.Licenses{{Expression: "MIT OR"}}}); err == nil {
+		t.Error("Expected an error for an invalid expression")
+	}
+}
+
+func TestReportLicenses(t *testing.T) {
+	components := []cyclonedx.Component{
+		{Name: "a", Version: "1.0.0", Licenses: &cyclonedx.Licenses{{Expression: "MIT OR Apache-2.0"}}},
+		{Name: "b", Version: "1.0.0", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}, {Expression: "MIT"}}},
+		{Name: "c", PackageURL: "pkg:npm/c@1.0.0"},
+		{Name: "d", Licenses: &cyclonedx.Licenses{{Expression: "MIT AND"}}},
+	}
+
+	report := ReportLicenses(components)
+
+	expected := []LicenseUsage{
+		{License: "MIT", Components: []string{"a@1.0.0", "b@1.0.0"}},
+		{License: "Apache-2.0", Components: []string{"a@1.0.0"}},
+	}
+	if len(report.Licenses) != len(expected) {
+		t.Fatalf("Expected %d licenses, got %+v", len(expected), report.Licenses)
+	}
+	for i := range expected {
+		if report.Licenses[i].License != expected[i].License || !slices.Equal(report.Licenses[i].Components, expected[i].Components) {
+			t.Errorf("Expected %+v, got %+v", expected[i], report.Licenses[i])
+		}
+	}
+	if !slices.Equal(report.Unlicensed, []string{"pkg:npm/c@1.0.0"}) {
+		t.Errorf("Unexpected unlicensed components: %v", report.Unlicensed)
+	}
+	if len(report.Invalid) != 1 || report.Invalid[0].Component != "d" {
+		t.Errorf("Unexpected invalid licenses: %+v", report.Invalid)
+	}
+}
@@ -0,0 +1,148 @@
+package sbom
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"gopkg.in/yaml.v3"
+)
+
+// LicensePolicy decides which licenses components may use. A license is
+// allowed if it is not denied and, if there is an allow list, on it.
+// Entries can name a license with an exception like
+// "GPL-2.0-only WITH Classpath-exception-2.0", which takes precedence over
+// an entry for the license alone. IDs are compared case-insensitively.
+type LicensePolicy struct {
+	Allow []string `json:"allow" yaml:"allow"`
+	Deny  []string `json:"deny" yaml:"deny"`
+	// AllowUnlicensed accepts components without license information, which
+	// are violations otherwise
+	AllowUnlicensed bool `json:"allowUnlicensed" yaml:"allowUnlicensed"`
+}
+
+// LicenseViolation is a component whose licenses do not satisfy a policy
+type LicenseViolation struct {
+	// Component is the package URL or name@version of the component
+	Component string `json:"component" yaml:"component"`
+	// License is the license expression of the component
+	License string `json:"license,omitempty" yaml:"license,omitempty"`
+	// Reasons are the licenses of the expression that are not allowed, or
+	// why the licenses could not be checked
+	Reasons []string `json:"reasons" yaml:"reasons"`
+}
+
+// LoadLicensePolicy reads a license policy from a YAML (or JSON) file like
+//
+//	allow: [MIT, Apache-2.0, BSD-3-Clause]
+//	deny: [GPL-3.0-only, AGPL-3.0-only]
+//	allowUnlicensed: false
+func LoadLicensePolicy(path string) (*LicensePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	policy := &LicensePolicy{}
+	if err := decoder.Decode(policy); err != nil {
+		return nil, fmt.Errorf("invalid license policy: %w", err)
+	}
+	for _, entry := range slices.Concat(policy.Allow, policy.Deny) {
+		e, err := ParseLicenseExpression(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid license policy entry %q: %w", entry, err)
+		}
+		if e.Operator != "" {
+			return nil, fmt.Errorf("invalid license policy entry %q: must be a single license", entry)
+		}
+	}
+	return policy, nil
+}
+
+// CheckLicenses returns the components whose licenses violate the policy.
+// An OR expression is satisfied if one of its licenses is allowed, an AND
+// expression only if all of them are.
+func (p *LicensePolicy) CheckLicenses(components []cyclonedx.Component) []LicenseViolation {
+	violations := []LicenseViolation{}
+	for i := range components {
+		label := componentLabel(&components[i])
+		e, err := ComponentLicenseExpression(&components[i])
+		switch {
+		case err != nil:
+			violations = append(violations, LicenseViolation{Component: label, Reasons: []string{err.Error()}})
+		case e == nil:
+			if !p.AllowUnlicensed {
+				violations = append(violations, LicenseViolation{Component: label, Reasons: []string{"no license information"}})
+			}
+		default:
+			if ok, reasons := p.evaluate(e); !ok {
+				violations = append(violations, LicenseViolation{Component: label, License: e.String(), Reasons: reasons})
+			}
+		}
+	}
+	return violations
+}
+
+// evaluate returns whether an expression satisfies the policy, and if not,
+// why its licenses are not allowed
+func (p *LicensePolicy) evaluate(e *LicenseExpression) (bool, []string) {
+	switch e.Operator {
+	case "":
+		return p.allows(e)
+	case "OR":
+		var reasons []string
+		for _, operand := range e.Operands {
+			ok, operandReasons := p.evaluate(operand)
+			if ok {
+				return true, nil
+			}
+			reasons = append(reasons, operandReasons...)
+		}
+		return false, reasons
+	}
+
+	ok := true
+	var reasons []string
+	for _, operand := range e.Operands {
+		operandOK, operandReasons := p.evaluate(operand)
+		ok = ok && operandOK
+		reasons = append(reasons, operandReasons...)
+	}
+	return ok, reasons
+}
+
+// allows checks a single license against the policy. An entry for the
+// license with its exception wins over an entry for the license alone.
+func (p *LicensePolicy) allows(e *LicenseExpression) (bool, []string) {
+	keys := []string{e.String()}
+	if e.Exception != "" {
+		keys = append(keys, e.License)
+	}
+	for _, key := range keys {
+		switch {
+		case containsFold(p.Deny, key):
+			return false, []string{fmt.Sprintf("%s is denied", e)}
+		case containsFold(p.Allow, key):
+			return true, nil
+		}
+	}
+	if len(p.Allow) > 0 {
+		return false, []string{fmt.Sprintf("%s is not allowed", e)}
+	}
+	return true, nil
+}
+
+// containsFold reports whether the list contains the value, ignoring case
+// and redundant whitespace
+func containsFold(list []string, value string) bool {
+	for _, entry := range list {
+		if strings.EqualFold(strings.Join(strings.Fields(entry), " "), value) {
+			return true
+		}
+	}
+	return false
+}
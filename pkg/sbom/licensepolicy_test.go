@@ -0,0 +1,83 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestLoadLicensePolicy(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write policy: %v", err)
+		}
+		return path
+	}
+
+	policy, err := LoadLicensePolicy(write("policy.yaml", "allow: [MIT, GPL-2.0-only WITH Classpath-exception-2.0]\ndeny:\n  - GPL-3.0-only\nallowUnlicensed: true\n"))
+	if err != nil {
+		t.Fatalf("LoadLicensePolicy failed: %v", err)
+	}
+	if len(policy.Allow) != 2 || !slices.Equal(policy.Deny, []string{"GPL-3.0-only"}) || !policy.AllowUnlicensed {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+
+	for name, content := range map[string]string{
+		"unknown-field.yaml": "allowed: [MIT]\n",
+		"expression.yaml":    "allow: [MIT OR Apache-2.0]\n",
+		"invalid-id.yaml":    "deny: [GPL 3]\n",
+	} {
+		if _, err := LoadLicensePolicy(write(name, content)); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestCheckLicenses(t *testing.T) {
+	policy := &LicensePolicy{
+		Allow: []string{"MIT", "Apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Deny:  []string{"gpl-2.0-only"},
+	}
+	component := func(name, expression string) cyclonedx.Component {
+		c := cyclonedx.Component{Name: name}
+		if expression != "" {
+			c.Licenses = &cyclonedx.Licenses{{Expression: expression}}
+		}
+		return c
+	}
+
+	tests := []struct {
+		component cyclonedx.Component
+		reasons   []string
+	}{
+		{component("allowed", "MIT"), nil},
+		{component("one-allowed-choice", "GPL-3.0-only OR Apache-2.0"), nil},
+		{component("exception", "GPL-2.0-only WITH Classpath-exception-2.0"), nil},
+		{component("denied", "GPL-2.0-only"), []string{"GPL-2.0-only is denied"}},
+		{component("not-allowed-choices", "GPL-3.0-only OR EPL-2.0"), []string{"GPL-3.0-only is not allowed", "EPL-2.0 is not allowed"}},
+		{component("and", "MIT AND (EPL-2.0 OR GPL-2.0-only)"), []string{"EPL-2.0 is not allowed", "GPL-2.0-only is denied"}},
+		{component("unlicensed", ""), []string{"no license information"}},
+		{component("invalid", "MIT AND"), []string{"missing license at the end of license expression"}},
+	}
+	for _, tt := range tests {
+		violations := policy.CheckLicenses([]cyclonedx.Component{tt.component})
+		switch {
+		case tt.reasons == nil && len(violations) > 0:
+			t.Errorf("%s: expected no violation, got %+v", tt.component.Name, violations)
+		case tt.reasons != nil && (len(violations) != 1 || !slices.Equal(violations[0].Reasons, tt.reasons)):
+			t.Errorf("%s: expected reasons %v, got %+v", tt.component.Name, tt.reasons, violations)
+		}
+	}
+
+	// Without an allow list, everything that is not denied is allowed
+	policy = &LicensePolicy{Deny: []string{"GPL-3.0-only"}, AllowUnlicensed: true}
+	violations := policy.CheckLicenses([]cyclonedx.Component{component("other", "EPL-2.0"), component("unlicensed", "")})
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %+v", violations)
+	}
+}
@@ -4,17 +4,83 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/CycloneDX/cyclonedx-go"
 )
 
+// MergeOptions configures how MergeSBOMsWithOptions merges SBOM files
+type MergeOptions struct {
+	// ComponentName is the name of the merged SBOM's metadata component
+	ComponentName string
+	// ComponentVersion is the version of the merged SBOM's metadata component
+	ComponentVersion string
+	// Integrity controls how ref integrity problems in the merged SBOM are handled
+	Integrity IntegrityMode
+	// Precedence decides which values win when duplicate components disagree
+	Precedence Precedence
+	// Strategy decides how components are merged, PrefixStrategy is used if nil
+	Strategy MergeStrategy
+	// SpecVersion is the spec version of the merged SBOM, the latest version is used if zero
+	SpecVersion cyclonedx.SpecVersion
+	// OutputFormat is the format of the merged SBOM, FileFormatJSON is used if empty
+	OutputFormat FileFormat
+	// Reproducible makes the output byte-identical for identical inputs: the
+	// serial number is derived from the inputs, the timestamp is taken from
+	// SOURCE_DATE_EPOCH and components, dependencies and tools are sorted
+	Reproducible bool
+}
+
+// MergeReport describes what happened during a merge besides the merge itself
+type MergeReport struct {
+	// IntegrityErrors lists the ref integrity problems of the written SBOM
+	IntegrityErrors []ValidationError
+	// Repairs lists the changes made to the merged SBOM in repair mode
+	Repairs []string
+	// Conflicts lists the fields on which merged duplicate components disagreed
+	Conflicts []FieldConflict
+	// ConversionLosses lists the fields lost by converting to an older spec version
+	ConversionLosses []ConversionLoss
+}
+
 // MergeSBOMs merges multiple SBOM files into a single SBOM file
 func MergeSBOMs(inputFiles []string, outputFile string, componentName string, componentVersion string) error {
+	_, err := MergeSBOMsWithOptions(inputFiles, outputFile, MergeOptions{
+		ComponentName:    componentName,
+		ComponentVersion: componentVersion,
+	})
+	return err
+}
+
+// MergeSBOMsWithOptions merges multiple SBOM files into a single SBOM file and
+// reports integrity problems and repairs of the merged result
+func MergeSBOMsWithOptions(inputFiles []string, outputFile string, opts MergeOptions) (*MergeReport, error) {
+	report := &MergeReport{}
+	componentName := opts.ComponentName
+	componentVersion := opts.ComponentVersion
+
+	// Use random IDs unless the output must be reproducible
+	serialID, refID := uuid.New(), uuid.New()
+	var timestamp *time.Time
+	if opts.Reproducible {
+		id, err := reproducibleID(inputFiles, opts)
+		if err != nil {
+			return report, err
+		}
+		serialID, refID = id, id
+
+		timestamp, err = sourceDateEpoch()
+		if err != nil {
+			return report, err
+		}
+	}
+
 	// Create a new BOM to hold the merged result
 	mergedBom := cyclonedx.NewBOM()
-	mergedBom.SerialNumber = "urn:uuid:" + uuid.New().String()
+	mergedBom.SerialNumber = "urn:uuid:" + serialID.String()
 	mergedBom.Version = 1
 
 	// Use provided component name or default
@@ -23,7 +89,7 @@ func MergeSBOMs(inputFiles []string, outputFile string, componentName string, co
 	}
 
 	// Generate a unique BOMRef for the merged SBOM
-	mergedBomRef := componentName + "-" + uuid.New().String()
+	mergedBomRef := componentName + "-" + refID.String()
 
 	// Create the component for the merged SBOM
 	mergedComponent := cyclonedx.Component{
@@ -37,47 +103,27 @@ func MergeSBOMs(inputFiles []string, outputFile string, componentName string, co
 		mergedComponent.Version = componentVersion
 	}
 
-	// Initialize metadata with sbomctl tool as a component
-	sbomctlToolComponent := cyclonedx.Component{
-		Name:      "sbomctl",
-		Version:   "0.1.0",
-		Publisher: "j12934",
-		Type:      cyclonedx.ComponentTypeApplication,
-	}
-
-	// Set metadata
+	// Set metadata with sbomctl tool as a component
 	mergedBom.Metadata = &cyclonedx.Metadata{
 		Tools: &cyclonedx.ToolsChoice{
-			Components: &[]cyclonedx.Component{sbomctlToolComponent},
+			Components: &[]cyclonedx.Component{SbomctlTool()},
 		},
 		Component: &mergedComponent,
 	}
+	if timestamp != nil {
+		mergedBom.Metadata.Timestamp = timestamp.Format(time.RFC3339)
+	}
 
 	// Initialize components slice
 	mergedBom.Components = &[]cyclonedx.Component{}
 
-	// Track components with metadata.component for dependencies
-	var metadataComponents []cyclonedx.Component
-
-	// Process each input file
+	// Read all input files first, the strategy needs to see all of them at once
+	inputs := make([]*cyclonedx.BOM, 0, len(inputFiles))
 	for _, file := range inputFiles {
 		// Read the SBOM file
 		bom, err := ReadSBOMFile(file)
 		if err != nil {
-			return fmt.Errorf("failed to read SBOM file %s: %w", file, err)
-		}
-
-		serial := ""
-		if bom.SerialNumber != "" {
-			serial = bom.SerialNumber
-		}
-
-		// Helper to prefix a ref with the serial number
-		prefixRef := func(ref string) string {
-			if ref == "" || serial == "" {
-				return ref
-			}
-			return serial + "/" + ref
+			return report, fmt.Errorf("failed to read SBOM file %s: %w", file, err)
 		}
 
 		// Extract tools directly from the JSON file if needed
@@ -92,48 +138,46 @@ func MergeSBOMs(inputFiles []string, outputFile string, componentName string, co
 			}
 		}
 
-		// Check if the SBOM has a metadata.component
-		if bom.Metadata != nil && bom.Metadata.Component != nil {
-			// Prefix the BOMRef
-			comp := *bom.Metadata.Component
-			comp.BOMRef = prefixRef(comp.BOMRef)
-			metadataComponents = append(metadataComponents, comp)
-			if mergedBom.Components == nil {
-				mergedBom.Components = &[]cyclonedx.Component{}
-			}
-			*mergedBom.Components = append(*mergedBom.Components, comp)
-		}
+		inputs = append(inputs, bom)
+	}
 
-		// Merge components, prefixing bom-ref
-		if bom.Components != nil {
-			if mergedBom.Components == nil {
-				mergedBom.Components = &[]cyclonedx.Component{}
-			}
-			for _, c := range *bom.Components {
-				c.BOMRef = prefixRef(c.BOMRef)
-				*mergedBom.Components = append(*mergedBom.Components, c)
-			}
+	// Let the strategy merge the components
+	strategy := opts.Strategy
+	if strategy == nil {
+		strategy = PrefixStrategy{}
+	}
+	mc := &MergeContext{
+		Merged:  mergedBom,
+		Inputs:  inputs,
+		Options: opts,
+		Report:  report,
+	}
+	refMappers, err := strategy.MergeComponents(mc)
+	if err != nil {
+		return report, fmt.Errorf("merge strategy %s failed: %w", strategy.Name(), err)
+	}
+	if len(refMappers) != len(inputs) {
+		return report, fmt.Errorf("merge strategy %s returned %d ref mappers for %d inputs", strategy.Name(), len(refMappers), len(inputs))
+	}
+
+	// Track refs of the inputs' metadata components for dependencies
+	var metadataComponentRefs []string
+
+	for i, bom := range inputs {
+		mapRef := refMappers[i]
+
+		if bom.Metadata != nil && bom.Metadata.Component != nil && bom.Metadata.Component.BOMRef != "" {
+			metadataComponentRefs = append(metadataComponentRefs, mapRef(bom.Metadata.Component.BOMRef))
 		}
 
-		// Merge dependencies, prefixing ref and dependsOn
+		// Merge dependencies, rewriting ref and dependsOn
 		if bom.Dependencies != nil {
-			if mergedBom.Dependencies == nil {
-				mergedBom.Dependencies = &[]cyclonedx.Dependency{}
-			}
-			for _, d := range *bom.Dependencies {
-				newDep := d
-				newDep.Ref = prefixRef(d.Ref)
-				if d.Dependencies != nil {
-					newDependsOn := make([]string, 0, len(*d.Dependencies))
-					for _, dep := range *d.Dependencies {
-						newDependsOn = append(newDependsOn, prefixRef(dep))
-					}
-					newDep.Dependencies = &newDependsOn
-				}
-				*mergedBom.Dependencies = append(*mergedBom.Dependencies, newDep)
-			}
+			mergedBom.Dependencies = appendTo(mergedBom.Dependencies, *mapDependencies(bom.Dependencies, mapRef)...)
 		}
 
+		// Merge services, vulnerabilities and the other sections referring to bom-refs
+		mergeSections(mergedBom, bom, mapRef)
+
 		// Merge tools if present
 		if bom.Metadata != nil && bom.Metadata.Tools != nil {
 			// Handle Components field (for tools)
@@ -156,19 +200,19 @@ func MergeSBOMs(inputFiles []string, outputFile string, componentName string, co
 		}
 	}
 
-	// Remove duplicate components
-	mergedBom.Components = deduplicateComponents(mergedBom.Components)
-
 	// Remove duplicate dependencies
 	mergedBom.Dependencies = deduplicateDependencies(mergedBom.Dependencies)
 
+	// Remove services, vulnerabilities and external references present in several inputs
+	deduplicateSections(mergedBom)
+
 	// Remove duplicate tool components
 	if mergedBom.Metadata != nil && mergedBom.Metadata.Tools != nil && mergedBom.Metadata.Tools.Components != nil {
 		mergedBom.Metadata.Tools.Components = deduplicateToolComponents(mergedBom.Metadata.Tools.Components)
 	}
 
 	// Create dependencies for metadata components
-	if len(metadataComponents) > 0 {
+	if len(metadataComponentRefs) > 0 {
 		if mergedBom.Dependencies == nil {
 			mergedBom.Dependencies = &[]cyclonedx.Dependency{}
 		}
@@ -176,134 +220,158 @@ func MergeSBOMs(inputFiles []string, outputFile string, componentName string, co
 			Ref:          mergedBomRef,
 			Dependencies: &[]string{},
 		}
-		for _, comp := range metadataComponents {
-			*mergedBomDependency.Dependencies = append(*mergedBomDependency.Dependencies, comp.BOMRef)
+		for _, ref := range metadataComponentRefs {
+			// Strategies may map several metadata components to the same ref
+			if !slices.Contains(*mergedBomDependency.Dependencies, ref) {
+				*mergedBomDependency.Dependencies = append(*mergedBomDependency.Dependencies, ref)
+			}
 		}
 		*mergedBom.Dependencies = append(*mergedBom.Dependencies, mergedBomDependency)
 	}
 
-	// Write the merged SBOM to the output file
-	return WriteSBOMFile(mergedBom, outputFile)
-}
-
-// ReadSBOMFile reads a CycloneDX SBOM file and returns the BOM object
-// This is an exported version of readSBOMFile for use by other packages
-func ReadSBOMFile(filename string) (*cyclonedx.BOM, error) {
-	// Open the file
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+	// Check ref integrity before anything is written
+	if err := checkMergedIntegrity(mergedBom, opts.Integrity, report); err != nil {
+		return report, err
 	}
-	defer file.Close()
 
-	// Create a new BOM decoder
-	decoder := cyclonedx.NewBOMDecoder(file, cyclonedx.BOMFileFormatJSON)
+	if opts.Reproducible {
+		sortBOM(mergedBom)
+	}
 
-	// Decode the BOM
-	bom := &cyclonedx.BOM{}
-	if err := decoder.Decode(bom); err != nil {
-		return nil, fmt.Errorf("failed to decode BOM: %w", err)
+	// Write the merged SBOM to the output file
+	outputFormat := opts.OutputFormat
+	if outputFormat == "" {
+		outputFormat = FileFormatJSON
+	}
+	specVersion := opts.SpecVersion
+	if specVersion == 0 {
+		specVersion = mergedBom.SpecVersion
+	}
+	// SPDX documents require a creation time, which is the Unix epoch in
+	// reproducible mode without SOURCE_DATE_EPOCH
+	if opts.Reproducible && outputFormat == FileFormatSPDXJSON && mergedBom.Metadata.Timestamp == "" {
+		mergedBom.Metadata.Timestamp = time.Unix(0, 0).UTC().Format(time.RFC3339)
+	}
+	if specVersion == mergedBom.SpecVersion && outputFormat != FileFormatSPDXJSON {
+		return report, WriteSBOMFileFormat(mergedBom, outputFile, outputFormat)
 	}
 
-	return bom, nil
+	data, losses, err := ConvertSBOM(mergedBom, specVersion, outputFormat)
+	if err != nil {
+		return report, err
+	}
+	report.ConversionLosses = losses
+	if err := os.WriteFile(outputFile, data, 0o644); err != nil {
+		return report, fmt.Errorf("failed to write output file: %w", err)
+	}
+	return report, nil
 }
 
-// WriteSBOMFile writes a CycloneDX BOM to a file
-// This is an exported version of writeSBOMFile for use by other packages
-func WriteSBOMFile(bom *cyclonedx.BOM, filename string) error {
-	// Create the output file
-	file, err := os.Create(filename)
+// ReadSBOMFile reads a CycloneDX SBOM file and returns the BOM object.
+// The format (JSON, XML or protobuf) is detected from the file's content.
+func ReadSBOMFile(filename string) (*cyclonedx.BOM, error) {
+	data, err := os.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
-	defer file.Close()
 
-	// Create a new BOM encoder
-	encoder := cyclonedx.NewBOMEncoder(file, cyclonedx.BOMFileFormatJSON)
-	encoder.SetPretty(true)
-
-	// Encode the BOM
-	if err := encoder.Encode(bom); err != nil {
-		return fmt.Errorf("failed to encode BOM: %w", err)
-	}
+	return DecodeSBOM(data, DetectFileFormat(data))
+}
 
-	return nil
+// WriteSBOMFile writes a CycloneDX BOM to a file in the JSON format
+func WriteSBOMFile(bom *cyclonedx.BOM, filename string) error {
+	return WriteSBOMFileFormat(bom, filename, FileFormatJSON)
 }
 
-// deduplicateComponents removes duplicate components from the BOM
-func deduplicateComponents(components *[]cyclonedx.Component) *[]cyclonedx.Component {
+// deduplicateComponents removes duplicate components from the BOM, using
+// combine to merge the fields of all duplicates into the first occurrence
+func deduplicateComponents(components *[]cyclonedx.Component, combine func(...cyclonedx.Component) cyclonedx.Component) *[]cyclonedx.Component {
 	if components == nil {
 		return nil
 	}
 
-	// Use a map to track unique components by their BOMRef
-	seen := make(map[string]bool)
-	var unique []cyclonedx.Component
-
+	// Group components by key, remembering the order of first occurrence
+	var keys []string
+	duplicates := make(map[string][]cyclonedx.Component)
 	for _, component := range *components {
-		key := component.BOMRef
-		if key == "" {
-			key = component.PackageURL
-			if key == "" {
-				key = component.Name
-				if component.Version != "" {
-					key += "@" + component.Version
-				}
-			}
-		}
-		if !seen[key] {
-			seen[key] = true
-			unique = append(unique, component)
+		key := componentKey(component)
+		if _, seen := duplicates[key]; !seen {
+			keys = append(keys, key)
 		}
+		duplicates[key] = append(duplicates[key], component)
+	}
+
+	unique := make([]cyclonedx.Component, 0, len(keys))
+	for _, key := range keys {
+		unique = append(unique, combine(duplicates[key]...))
 	}
 
 	return &unique
 }
 
+// componentKey returns the key by which duplicate components are detected:
+// the bom-ref, or the package URL or name and version if there is none
+func componentKey(component cyclonedx.Component) string {
+	key := component.BOMRef
+	if key == "" {
+		key = component.PackageURL
+		if key == "" {
+			key = component.Name
+			if component.Version != "" {
+				key += "@" + component.Version
+			}
+		}
+	}
+	return key
+}
+
 // deduplicateDependencies removes duplicate dependencies from the BOM
-// and merges their dependsOn lists
+// and merges their dependsOn lists. The order of first occurrence is kept.
 func deduplicateDependencies(dependencies *[]cyclonedx.Dependency) *[]cyclonedx.Dependency {
 	if dependencies == nil {
 		return nil
 	}
 
-	// Map to store unique dependencies by ref
-	depMap := make(map[string]*cyclonedx.Dependency)
+	// Index of every ref in the result
+	index := make(map[string]int)
+	result := make([]cyclonedx.Dependency, 0, len(*dependencies))
 
 	for _, dep := range *dependencies {
-		key := dep.Ref
-		if existing, exists := depMap[key]; !exists {
-			newDep := dep
-			depMap[key] = &newDep
-		} else {
-			if dep.Dependencies != nil && len(*dep.Dependencies) > 0 {
-				if existing.Dependencies == nil {
-					existing.Dependencies = &[]string{}
-				}
-				for _, d := range *dep.Dependencies {
-					found := false
-					for _, existingDep := range *existing.Dependencies {
-						if existingDep == d {
-							found = true
-							break
-						}
-					}
-					if !found {
-						*existing.Dependencies = append(*existing.Dependencies, d)
-					}
+		i, exists := index[dep.Ref]
+		if !exists {
+			index[dep.Ref] = len(result)
+			result = append(result, dep)
+			continue
+		}
+
+		if dep.Dependencies != nil && len(*dep.Dependencies) > 0 {
+			existing := &result[i]
+			dependsOn := make([]string, 0)
+			if existing.Dependencies != nil {
+				dependsOn = append(dependsOn, *existing.Dependencies...)
+			}
+			for _, d := range *dep.Dependencies {
+				if !slices.Contains(dependsOn, d) {
+					dependsOn = append(dependsOn, d)
 				}
 			}
+			existing.Dependencies = &dependsOn
 		}
 	}
 
-	var result []cyclonedx.Dependency
-	for _, dep := range depMap {
-		result = append(result, *dep)
-	}
-
 	return &result
 }
 
+// SbomctlTool returns the tool component of SBOMs and reports created by sbomctl
+func SbomctlTool() cyclonedx.Component {
+	return cyclonedx.Component{
+		Name:      "sbomctl",
+		Version:   "0.1.0",
+		Publisher: "j12934",
+		Type:      cyclonedx.ComponentTypeApplication,
+	}
+}
+
 // extractToolsFromJSON extracts tools directly from a JSON file
 func extractToolsFromJSON(filename string) ([]cyclonedx.Component, error) {
 	// Read the file
@@ -377,13 +445,15 @@ func extractToolsFromJSON(filename string) ([]cyclonedx.Component, error) {
 	return components, nil
 }
 
-// deduplicateToolComponents removes duplicate tool components from the BOM
+// deduplicateToolComponents removes duplicate tool components from the BOM.
+// The order of first occurrence is kept.
 func deduplicateToolComponents(components *[]cyclonedx.Component) *[]cyclonedx.Component {
 	if components == nil {
 		return nil
 	}
 
-	toolMap := make(map[string]cyclonedx.Component)
+	index := make(map[string]int)
+	var unique []cyclonedx.Component
 
 	for _, comp := range *components {
 		if comp.Type != cyclonedx.ComponentTypeApplication {
@@ -395,16 +465,14 @@ func deduplicateToolComponents(components *[]cyclonedx.Component) *[]cyclonedx.C
 			key += "@" + comp.Version
 		}
 
-		existing, exists := toolMap[key]
-		if !exists || (comp.Publisher != "" && existing.Publisher == "") {
-			toolMap[key] = comp
+		i, exists := index[key]
+		if !exists {
+			index[key] = len(unique)
+			unique = append(unique, comp)
+		} else if comp.Publisher != "" && unique[i].Publisher == "" {
+			unique[i] = comp
 		}
 	}
 
-	var unique []cyclonedx.Component
-	for _, comp := range toolMap {
-		unique = append(unique, comp)
-	}
-
 	return &unique
 }
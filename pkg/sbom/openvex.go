@@ -0,0 +1,136 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// openVEXDocument is the subset of an OpenVEX document read by ParseVEX, see
+// https://github.com/openvex/spec. Vulnerabilities and products are strings
+// in OpenVEX before 0.2.0 and objects since.
+type openVEXDocument struct {
+	Timestamp  string             `json:"timestamp"`
+	Statements []openVEXStatement `json:"statements"`
+}
+
+type openVEXStatement struct {
+	Vulnerability   json.RawMessage   `json:"vulnerability"`
+	Products        []json.RawMessage `json:"products"`
+	Subcomponents   []json.RawMessage `json:"subcomponents"`
+	Status          string            `json:"status"`
+	Justification   string            `json:"justification"`
+	ImpactStatement string            `json:"impact_statement"`
+	ActionStatement string            `json:"action_statement"`
+	Timestamp       string            `json:"timestamp"`
+}
+
+type openVEXVulnerability struct {
+	ID      string   `json:"@id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+type openVEXProduct struct {
+	ID          string `json:"@id"`
+	Identifiers struct {
+		Purl string `json:"purl"`
+	} `json:"identifiers"`
+	Subcomponents []openVEXProduct `json:"subcomponents"`
+}
+
+// openVEXStates map the OpenVEX status to CycloneDX
+var openVEXStates = map[string]cyclonedx.ImpactAnalysisState{
+	"not_affected":        cyclonedx.IASNotAffected,
+	"affected":            cyclonedx.IASExploitable,
+	"fixed":               cyclonedx.IASResolved,
+	"under_investigation": cyclonedx.IASInTriage,
+}
+
+// parseOpenVEX reads the statements of an OpenVEX document. Statements about
+// subcomponents of a product are about the subcomponents, which are the
+// components of the SBOM that contain the vulnerable code.
+func parseOpenVEX(data []byte) ([]VEXStatement, error) {
+	var doc openVEXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenVEX document: %w", err)
+	}
+
+	statements := make([]VEXStatement, 0, len(doc.Statements))
+	for i, s := range doc.Statements {
+		state, ok := openVEXStates[s.Status]
+		if !ok {
+			return nil, fmt.Errorf("failed to parse OpenVEX document: statement %d has unknown status %q", i, s.Status)
+		}
+		statement := VEXStatement{
+			Products:      []string{},
+			State:         state,
+			Justification: vexJustifications[s.Justification],
+			Detail:        s.ImpactStatement,
+			Timestamp:     s.Timestamp,
+		}
+		if statement.Detail == "" {
+			statement.Detail = s.ActionStatement
+		}
+		if statement.Timestamp == "" {
+			statement.Timestamp = doc.Timestamp
+		}
+
+		var vulnerability openVEXVulnerability
+		if err := unmarshalStringOr(s.Vulnerability, &vulnerability.Name, &vulnerability); err != nil {
+			return nil, fmt.Errorf("failed to parse OpenVEX document: statement %d: %w", i, err)
+		}
+		statement.Vulnerability = vulnerability.Name
+		if statement.Vulnerability == "" {
+			statement.Vulnerability = vulnerability.ID
+		}
+		statement.Aliases = vulnerability.Aliases
+
+		var products, subcomponents []string
+		for _, raw := range s.Products {
+			var product openVEXProduct
+			if err := unmarshalStringOr(raw, &product.ID, &product); err != nil {
+				return nil, fmt.Errorf("failed to parse OpenVEX document: statement %d: %w", i, err)
+			}
+			products = append(products, product.identifier())
+			for _, sub := range product.Subcomponents {
+				subcomponents = append(subcomponents, sub.identifier())
+			}
+		}
+		for _, raw := range s.Subcomponents {
+			var sub openVEXProduct
+			if err := unmarshalStringOr(raw, &sub.ID, &sub); err != nil {
+				return nil, fmt.Errorf("failed to parse OpenVEX document: statement %d: %w", i, err)
+			}
+			subcomponents = append(subcomponents, sub.identifier())
+		}
+		if len(subcomponents) > 0 {
+			statement.Products = subcomponents
+		} else if len(products) > 0 {
+			statement.Products = products
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// identifier returns the package URL of the product, or its ID, which is
+// usually a package URL as well
+func (p openVEXProduct) identifier() string {
+	if p.Identifiers.Purl != "" {
+		return p.Identifiers.Purl
+	}
+	return p.ID
+}
+
+// unmarshalStringOr unmarshals a JSON string into s and anything else into v
+func unmarshalStringOr(data json.RawMessage, s *string, v any) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if data[0] == '"' {
+		return json.Unmarshal(data, s)
+	}
+	return json.Unmarshal(data, v)
+}
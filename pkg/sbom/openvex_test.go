@@ -0,0 +1,62 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestParseOpenVEX(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "vex", "openvex.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, format, err := ParseVEX(data)
+	if err != nil {
+		t.Fatalf("Failed to parse VEX: %v", err)
+	}
+	if format != "OpenVEX" || len(statements) != 2 {
+		t.Fatalf("Expected 2 OpenVEX statements, got %s %+v", format, statements)
+	}
+
+	s := statements[0]
+	if s.Vulnerability != "CVE-2024-0001" || s.State != cyclonedx.IASNotAffected || s.Justification != cyclonedx.IAJCodeNotReachable ||
+		s.Detail != "example-app never passes untrusted input to example-lib-3." || s.Timestamp != "2024-06-01T12:00:00Z" {
+		t.Errorf("Unexpected statement: %+v", s)
+	}
+	// Subcomponents are matched instead of the product
+	if !slices.Equal(s.Products, []string{"pkg:npm/example-lib-3@3.4.5"}) {
+		t.Errorf("Expected the subcomponent as product, got %v", s.Products)
+	}
+	if statements[1].State != cyclonedx.IASInTriage {
+		t.Errorf("Expected in_triage, got %s", statements[1].State)
+	}
+}
+
+func TestParseOpenVEXLegacy(t *testing.T) {
+	// Before OpenVEX 0.2.0, vulnerabilities and products were strings
+	data := []byte(`{
+		"@context": "https://openvex.dev/ns",
+		"statements": [{
+			"vulnerability": "CVE-2023-1234",
+			"products": ["pkg:oci/app@sha256:abc"],
+			"subcomponents": ["pkg:golang/golang.org/x/net@v0.7.0"],
+			"status": "fixed"
+		}]
+	}`)
+	statements, _, err := ParseVEX(data)
+	if err != nil {
+		t.Fatalf("Failed to parse VEX: %v", err)
+	}
+	if len(statements) != 1 || statements[0].Vulnerability != "CVE-2023-1234" || statements[0].State != cyclonedx.IASResolved ||
+		!slices.Equal(statements[0].Products, []string{"pkg:golang/golang.org/x/net@v0.7.0"}) {
+		t.Errorf("Unexpected statements: %+v", statements)
+	}
+
+	if _, _, err := ParseVEX([]byte(`{"@context": "https://openvex.dev/ns", "statements": [{"vulnerability": "CVE-1", "status": "maybe"}]}`)); err == nil {
+		t.Error("Expected error for unknown status")
+	}
+}
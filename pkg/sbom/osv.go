@@ -0,0 +1,495 @@
+package sbom
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+)
+
+// osvEntry is a vulnerability in the OSV format, see https://ossf.github.io/osv-schema/
+type osvEntry struct {
+	ID               string         `json:"id"`
+	Modified         string         `json:"modified"`
+	Published        string         `json:"published"`
+	Withdrawn        string         `json:"withdrawn"`
+	Aliases          []string       `json:"aliases"`
+	Summary          string         `json:"summary"`
+	Details          string         `json:"details"`
+	Severity         []osvSeverity  `json:"severity"`
+	Affected         []osvAffected  `json:"affected"`
+	References       []osvReference `json:"references"`
+	DatabaseSpecific map[string]any `json:"database_specific"`
+}
+
+type osvSeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type osvAffected struct {
+	Package  osvPackage `json:"package"`
+	Ranges   []osvRange `json:"ranges"`
+	Versions []string   `json:"versions"`
+}
+
+type osvPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+	Limit        string `json:"limit"`
+}
+
+type osvReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// OSVDatabase is a local copy of OSV vulnerabilities, indexed by ecosystem
+// and package name
+type OSVDatabase struct {
+	entries map[string][]*osvEntry
+	count   int
+}
+
+// VulnerabilityFinding is a vulnerability of the OSV database that affects a
+// component
+type VulnerabilityFinding struct {
+	ID       string             `json:"id"`
+	Aliases  []string           `json:"aliases,omitempty"`
+	Summary  string             `json:"summary,omitempty"`
+	Severity cyclonedx.Severity `json:"severity"`
+	// Component is the package URL of the affected component
+	Component string `json:"component"`
+	BOMRef    string `json:"bomRef,omitempty"`
+	Version   string `json:"version"`
+	// FixedVersions are the versions after the component's version that fix the vulnerability
+	FixedVersions []string `json:"fixedVersions,omitempty"`
+
+	entry *osvEntry
+}
+
+// purlEcosystems maps package URL types to OSV ecosystems, the ecosystem of
+// deb packages depends on the namespace
+var purlEcosystems = map[string]string{
+	packageurl.TypeNPM:      "npm",
+	packageurl.TypePyPi:     "PyPI",
+	packageurl.TypeMaven:    "Maven",
+	packageurl.TypeGolang:   "Go",
+	packageurl.TypeCargo:    "crates.io",
+	packageurl.TypeGem:      "RubyGems",
+	packageurl.TypeNuget:    "NuGet",
+	packageurl.TypeComposer: "Packagist",
+	packageurl.TypeHex:      "Hex",
+	"pub":                   "Pub",
+	"apk":                   "Alpine",
+}
+
+// LoadOSVDatabase loads the OSV vulnerabilities from a zip file like the
+// all.zip exports of osv.dev, or from a directory containing JSON files and
+// zip files. Withdrawn vulnerabilities are skipped.
+func LoadOSVDatabase(path string) (*OSVDatabase, error) {
+	db := &OSVDatabase{entries: make(map[string][]*osvEntry)}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OSV database: %w", err)
+	}
+	if !info.IsDir() {
+		return db, db.loadZip(path)
+	}
+
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".json":
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return fmt.Errorf("failed to read OSV entry: %w", err)
+			}
+			return db.add(data, p)
+		case ".zip":
+			return db.loadZip(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// loadZip loads all JSON files of a zip file
+func (db *OSVDatabase) loadZip(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open OSV database %s: %w", path, err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if !strings.EqualFold(filepath.Ext(f.Name), ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read OSV entry %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read OSV entry %s: %w", f.Name, err)
+		}
+		if err := db.add(data, path+"/"+f.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add parses an OSV entry and indexes it by its affected packages
+func (db *OSVDatabase) add(data []byte, name string) error {
+	entry := &osvEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return fmt.Errorf("failed to parse OSV entry %s: %w", name, err)
+	}
+	if entry.Withdrawn != "" {
+		return nil
+	}
+	db.count++
+	for _, affected := range entry.Affected {
+		key := osvKey(affected.Package.Ecosystem, affected.Package.Name)
+		if !slices.Contains(db.entries[key], entry) {
+			db.entries[key] = append(db.entries[key], entry)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of vulnerabilities in the database
+func (db *OSVDatabase) Len() int {
+	return db.count
+}
+
+// pypiNameSeparators matches the separators that are equivalent in Python package names
+var pypiNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// osvKey returns the index key of a package, with the name normalized for
+// ecosystems in which names are case-insensitive
+func osvKey(ecosystem, name string) string {
+	ecosystem, _, _ = strings.Cut(ecosystem, ":")
+	switch ecosystem {
+	case "PyPI":
+		name = pypiNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	case "NuGet", "Packagist":
+		name = strings.ToLower(name)
+	}
+	return ecosystem + "/" + name
+}
+
+// osvPackageOf returns the OSV ecosystem and package name of a package URL
+// and its distribution release for OS packages, like "12" for "debian-12"
+func osvPackageOf(purl packageurl.PackageURL) (string, string, string, bool) {
+	qualifiers := purl.Qualifiers.Map()
+	_, release, _ := strings.Cut(qualifiers["distro"], "-")
+	name := purl.Name
+	switch purl.Type {
+	case packageurl.TypeDebian:
+		ecosystem := map[string]string{"debian": "Debian", "ubuntu": "Ubuntu"}[strings.ToLower(purl.Namespace)]
+		// Debian advisories are about source packages
+		if upstream, _, _ := strings.Cut(qualifiers["upstream"], "@"); upstream != "" {
+			name = upstream
+		}
+		return ecosystem, name, release, ecosystem != ""
+	case packageurl.TypeMaven:
+		name = purl.Namespace + ":" + purl.Name
+	case "apk":
+	default:
+		if purl.Namespace != "" {
+			name = purl.Namespace + "/" + purl.Name
+		}
+	}
+	ecosystem, ok := purlEcosystems[purl.Type]
+	return ecosystem, name, release, ok
+}
+
+// ScanVulnerabilities matches the package URLs of the metadata component and
+// all components against the OSV database. Versions are compared by the
+// rules of the package's ecosystem.
+func ScanVulnerabilities(bom *cyclonedx.BOM, db *OSVDatabase) []VulnerabilityFinding {
+	var components []*cyclonedx.Component
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		components = append(components, bom.Metadata.Component)
+		walkComponents(bom.Metadata.Component.Components, func(c *cyclonedx.Component) { components = append(components, c) })
+	}
+	walkComponents(bom.Components, func(c *cyclonedx.Component) { components = append(components, c) })
+
+	findings := []VulnerabilityFinding{}
+	for _, c := range components {
+		purl, err := packageurl.FromString(c.PackageURL)
+		if err != nil || purl.Version == "" {
+			continue
+		}
+		ecosystem, name, release, ok := osvPackageOf(purl)
+		if !ok {
+			continue
+		}
+
+		var componentFindings []VulnerabilityFinding
+		for _, entry := range db.entries[osvKey(ecosystem, name)] {
+			fixed, affected := entry.affects(ecosystem, name, release, purl.Version)
+			if !affected {
+				continue
+			}
+			ratings := osvRatings(entry)
+			componentFindings = append(componentFindings, VulnerabilityFinding{
+				ID:            entry.ID,
+				Aliases:       entry.Aliases,
+				Summary:       entry.Summary,
+				Severity:      highestSeverity(&ratings),
+				Component:     c.PackageURL,
+				BOMRef:        c.BOMRef,
+				Version:       purl.Version,
+				FixedVersions: fixed,
+				entry:         entry,
+			})
+		}
+		sort.Slice(componentFindings, func(i, j int) bool { return componentFindings[i].ID < componentFindings[j].ID })
+		findings = append(findings, componentFindings...)
+	}
+	return findings
+}
+
+// affects reports whether the entry affects a version of a package and
+// returns the later versions that fix it
+func (e *osvEntry) affects(ecosystem, name, release, version string) ([]string, bool) {
+	affected := false
+	var fixed []string
+	for _, a := range e.Affected {
+		if osvKey(a.Package.Ecosystem, a.Package.Name) != osvKey(ecosystem, name) {
+			continue
+		}
+		// OS advisories are per distribution release, like "Debian:12"
+		if _, advisoryRelease, ok := strings.Cut(a.Package.Ecosystem, ":"); ok && release != "" {
+			advisoryRelease, _, _ = strings.Cut(strings.TrimPrefix(advisoryRelease, "v"), ":")
+			if !sameRelease(release, advisoryRelease) {
+				continue
+			}
+		}
+
+		compare := func(a, b string) int { return compareEcosystemVersions(ecosystem, a, b) }
+		if slices.ContainsFunc(a.Versions, func(v string) bool { return compare(v, version) == 0 }) {
+			affected = true
+		}
+		for _, r := range a.Ranges {
+			// Git ranges need commits, which package URLs do not have
+			if r.Type == "GIT" {
+				continue
+			}
+			rangeCompare := compare
+			if r.Type == "SEMVER" {
+				rangeCompare = compareSemver
+			}
+			if r.affects(version, rangeCompare) {
+				affected = true
+				for _, event := range r.Events {
+					if event.Fixed != "" && rangeCompare(event.Fixed, version) > 0 && !slices.Contains(fixed, event.Fixed) {
+						fixed = append(fixed, event.Fixed)
+					}
+				}
+			}
+		}
+		if affected {
+			sort.SliceStable(fixed, func(i, j int) bool { return compare(fixed[i], fixed[j]) < 0 })
+		}
+	}
+	return fixed, affected
+}
+
+// affects evaluates the events of the range in version order: introduced
+// versions start and fixed, last affected and limit versions end an
+// affected interval
+func (r osvRange) affects(version string, compare func(a, b string) int) bool {
+	eventVersion := func(e osvEvent) string {
+		return e.Introduced + e.Fixed + e.LastAffected + e.Limit
+	}
+	events := slices.Clone(r.Events)
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := eventVersion(events[i]), eventVersion(events[j])
+		if a == "0" || b == "0" {
+			return a == "0" && b != "0"
+		}
+		return compare(a, b) < 0
+	})
+
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || compare(version, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if compare(version, e.Fixed) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if compare(version, e.LastAffected) > 0 {
+				affected = false
+			}
+		case e.Limit != "":
+			if compare(version, e.Limit) >= 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+// osvSeverities maps the severities of the GitHub advisory database to CycloneDX
+var osvSeverities = map[string]cyclonedx.Severity{
+	"LOW":      cyclonedx.SeverityLow,
+	"MODERATE": cyclonedx.SeverityMedium,
+	"MEDIUM":   cyclonedx.SeverityMedium,
+	"HIGH":     cyclonedx.SeverityHigh,
+	"CRITICAL": cyclonedx.SeverityCritical,
+}
+
+// osvRatings converts the severities of an OSV entry to CycloneDX ratings.
+// The scores of CVSS v3 vectors are calculated.
+// sameRelease reports whether two distribution releases are the same, one
+// of them possibly more specific, like "3.18.4" and "3.18" but not "3.1"
+func sameRelease(a, b string) bool {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasPrefix(a, b+".")
+}
+
+func osvRatings(entry *osvEntry) []cyclonedx.VulnerabilityRating {
+	var ratings []cyclonedx.VulnerabilityRating
+	for _, s := range entry.Severity {
+		rating := cyclonedx.VulnerabilityRating{Vector: s.Score}
+		switch s.Type {
+		case "CVSS_V3":
+			rating.Method = cyclonedx.ScoringMethodCVSSv3
+			if strings.HasPrefix(s.Score, "CVSS:3.1/") {
+				rating.Method = cyclonedx.ScoringMethodCVSSv31
+			}
+			if score, err := cvss3BaseScore(s.Score); err == nil {
+				rating.Score = &score
+				rating.Severity = cvssSeverity(score)
+			}
+		case "CVSS_V4":
+			rating.Method = cyclonedx.ScoringMethodCVSSv4
+		case "CVSS_V2":
+			rating.Method = cyclonedx.ScoringMethodCVSSv2
+		default:
+			continue
+		}
+		ratings = append(ratings, rating)
+	}
+	if severity, ok := entry.DatabaseSpecific["severity"].(string); ok && osvSeverities[strings.ToUpper(severity)] != "" {
+		ratings = append(ratings, cyclonedx.VulnerabilityRating{
+			Severity: osvSeverities[strings.ToUpper(severity)],
+			Method:   cyclonedx.ScoringMethodOther,
+		})
+	}
+	return ratings
+}
+
+// osvVulnerability converts an OSV entry to a CycloneDX vulnerability without affects
+func osvVulnerability(entry *osvEntry) cyclonedx.Vulnerability {
+	v := cyclonedx.Vulnerability{
+		ID:          entry.ID,
+		Source:      &cyclonedx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/" + entry.ID},
+		Ratings:     sliceOrNil(osvRatings(entry)),
+		Description: entry.Summary,
+		Detail:      entry.Details,
+		Published:   entry.Published,
+		Updated:     entry.Modified,
+	}
+	for _, alias := range entry.Aliases {
+		v.References = appendTo(v.References, cyclonedx.VulnerabilityReference{
+			ID:     alias,
+			Source: &cyclonedx.Source{Name: "OSV", URL: "https://osv.dev/vulnerability/" + alias},
+		})
+	}
+	for _, ref := range entry.References {
+		if ref.Type == "ADVISORY" {
+			v.Advisories = appendTo(v.Advisories, cyclonedx.Advisory{URL: ref.URL})
+		}
+	}
+	if cweIDs, ok := entry.DatabaseSpecific["cwe_ids"].([]any); ok {
+		for _, id := range cweIDs {
+			s, _ := id.(string)
+			if cwe, err := strconv.Atoi(strings.TrimPrefix(s, "CWE-")); err == nil {
+				v.CWEs = appendTo(v.CWEs, cwe)
+			}
+		}
+	}
+	return v
+}
+
+// AddVulnerabilityFindings adds the findings to the vulnerabilities of the
+// BOM, with one vulnerability per ID that affects all of its components. The
+// affected version of each component is recorded, and the versions fixing
+// it as unaffected. Vulnerabilities the BOM already has are extended, and
+// findings for components without bom-ref are skipped, as they cannot be
+// referred to. It returns the number of vulnerabilities added.
+func AddVulnerabilityFindings(bom *cyclonedx.BOM, findings []VulnerabilityFinding) int {
+	index := make(map[string]int)
+	if bom.Vulnerabilities != nil {
+		for i, v := range *bom.Vulnerabilities {
+			if _, ok := index[v.ID]; !ok {
+				index[v.ID] = i
+			}
+		}
+	}
+
+	added := 0
+	for _, finding := range findings {
+		if finding.BOMRef == "" || finding.entry == nil {
+			continue
+		}
+		i, ok := index[finding.ID]
+		if !ok {
+			bom.Vulnerabilities = appendTo(bom.Vulnerabilities, osvVulnerability(finding.entry))
+			i = len(*bom.Vulnerabilities) - 1
+			index[finding.ID] = i
+			added++
+		}
+
+		v := &(*bom.Vulnerabilities)[i]
+		if v.Affects != nil && slices.ContainsFunc(*v.Affects, func(a cyclonedx.Affects) bool { return a.Ref == finding.BOMRef }) {
+			continue
+		}
+		versions := []cyclonedx.AffectedVersions{{Version: finding.Version, Status: cyclonedx.VulnerabilityStatusAffected}}
+		for _, fixed := range finding.FixedVersions {
+			versions = append(versions, cyclonedx.AffectedVersions{Version: fixed, Status: cyclonedx.VulnerabilityStatusNotAffected})
+		}
+		v.Affects = appendTo(v.Affects, cyclonedx.Affects{Ref: finding.BOMRef, Range: &versions})
+	}
+	return added
+}
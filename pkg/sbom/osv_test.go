@@ -0,0 +1,208 @@
+package sbom
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+)
+
+func osvTestBOM() *cyclonedx.BOM {
+	bom := cyclonedx.NewBOM()
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "lib-1", Name: "example-lib-1", Version: "1.2.3", PackageURL: "pkg:npm/example-lib-1@1.2.3"},
+		{BOMRef: "lib-2", Name: "example-lib-2", Version: "2.3.4", PackageURL: "pkg:npm/example-lib-2@2.3.4"},
+		{BOMRef: "lib-3", Name: "example-lib-3", Version: "3.4.5", PackageURL: "pkg:npm/example-lib-3@3.4.5"},
+		{Name: "example-lib-3", Version: "3.4.6", PackageURL: "pkg:npm/example-lib-3@3.4.6"},
+	}
+	return bom
+}
+
+func findingIDs(findings []VulnerabilityFinding) []string {
+	ids := []string{}
+	for _, f := range findings {
+		ids = append(ids, f.Component+" "+f.ID)
+	}
+	return ids
+}
+
+func TestScanVulnerabilities(t *testing.T) {
+	db, err := LoadOSVDatabase(filepath.Join("..", "..", "testdata", "osv"))
+	if err != nil {
+		t.Fatalf("Failed to load OSV database: %v", err)
+	}
+	if db.Len() != 3 {
+		t.Errorf("Expected 3 vulnerabilities, got %d", db.Len())
+	}
+
+	findings := ScanVulnerabilities(osvTestBOM(), db)
+	expected := []string{"pkg:npm/example-lib-2@2.3.4 GHSA-xxxx-lib2-0003", "pkg:npm/example-lib-3@3.4.5 GHSA-xxxx-lib3-0001"}
+	if ids := findingIDs(findings); !slices.Equal(ids, expected) {
+		t.Fatalf("Expected findings %v, got %v", expected, ids)
+	}
+	if f := findings[0]; f.Severity != cyclonedx.SeverityMedium || len(f.FixedVersions) != 0 {
+		t.Errorf("Unexpected finding for last affected version: %+v", f)
+	}
+	if f := findings[1]; f.Severity != cyclonedx.SeverityCritical || !slices.Equal(f.FixedVersions, []string{"3.4.6"}) || f.BOMRef != "lib-3" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+}
+
+func TestLoadOSVDatabaseZip(t *testing.T) {
+	zipFile := filepath.Join(t.TempDir(), "all.zip")
+	f, err := os.Create(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for _, name := range []string{"GHSA-xxxx-lib3-0001.json", "GHSA-xxxx-lib1-0002.json"} {
+		data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "osv", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	db, err := LoadOSVDatabase(zipFile)
+	if err != nil {
+		t.Fatalf("Failed to load OSV database: %v", err)
+	}
+	if db.Len() != 2 {
+		t.Errorf("Expected 2 vulnerabilities, got %d", db.Len())
+	}
+
+	if _, err := LoadOSVDatabase(filepath.Join("..", "..", "testdata", "sbom1.json")); err == nil {
+		t.Error("Expected error for a file that is not a zip file")
+	}
+}
+
+func TestOSVRangeAffects(t *testing.T) {
+	r := osvRange{Type: "ECOSYSTEM", Events: []osvEvent{
+		{Fixed: "1.5"}, {Introduced: "0"}, {Introduced: "2.0"}, {LastAffected: "2.2"}, {Introduced: "3.0"}, {Limit: "3.1"},
+	}}
+	compare := func(a, b string) int { return compareEcosystemVersions("PyPI", a, b) }
+	for version, expected := range map[string]bool{
+		"0.1": true, "1.4.9": true, "1.5": false, "1.9": false, "2.0": true,
+		"2.2": true, "2.2.1": false, "2.2.post1": false, "3.0": true, "3.1": false,
+	} {
+		if got := r.affects(version, compare); got != expected {
+			t.Errorf("Expected %s affected %v, got %v", version, expected, got)
+		}
+	}
+}
+
+func TestOSVPackageOf(t *testing.T) {
+	tests := []struct {
+		purl      string
+		ecosystem string
+		name      string
+		release   string
+	}{
+		{"pkg:npm/%40babel/core@7.0.0", "npm", "@babel/core", ""},
+		{"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1", "Maven", "org.apache.logging.log4j:log4j-core", ""},
+		{"pkg:golang/golang.org/x/net@v0.1.0", "Go", "golang.org/x/net", ""},
+		{"pkg:pypi/Django@4.2", "PyPI", "django", ""},
+		{"pkg:deb/debian/libssl3@3.0.11-1~deb12u2?upstream=openssl&distro=debian-12", "Debian", "openssl", "12"},
+		{"pkg:apk/alpine/busybox@1.36.1-r5?distro=alpine-3.19.1", "Alpine", "busybox", "3.19.1"},
+	}
+	for _, tt := range tests {
+		purl, err := packageurl.FromString(tt.purl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ecosystem, name, release, ok := osvPackageOf(purl)
+		if !ok || ecosystem != tt.ecosystem || name != tt.name || release != tt.release {
+			t.Errorf("%s: expected %s %s %s, got %s %s %s", tt.purl, tt.ecosystem, tt.name, tt.release, ecosystem, name, release)
+		}
+	}
+
+	if osvKey("PyPI", "Foo_Bar.baz") != osvKey("PyPI:", "foo-bar-baz") {
+		t.Error("Expected normalized PyPI names to match")
+	}
+}
+
+func TestOSVAffectsRelease(t *testing.T) {
+	entry := &osvEntry{Affected: []osvAffected{
+		{Package: osvPackage{Ecosystem: "Alpine:v3.1", Name: "busybox"}, Versions: []string{"1.36.1-r5"}},
+		{Package: osvPackage{Ecosystem: "Debian:11", Name: "openssl"}, Versions: []string{"3.0.11-1"}},
+	}}
+	tests := []struct {
+		ecosystem, name, release string
+		affected                 bool
+	}{
+		{"Alpine", "busybox", "3.1", true},
+		{"Alpine", "busybox", "3.1.4", true},
+		{"Alpine", "busybox", "3.18", false},
+		{"Debian", "openssl", "11", true},
+		{"Debian", "openssl", "1", false},
+		{"Debian", "openssl", "12", false},
+	}
+	for _, tt := range tests {
+		version := "1.36.1-r5"
+		if tt.ecosystem == "Debian" {
+			version = "3.0.11-1"
+		}
+		if _, affected := entry.affects(tt.ecosystem, tt.name, tt.release, version); affected != tt.affected {
+			t.Errorf("%s %s release %s: expected affected %v, got %v", tt.ecosystem, tt.name, tt.release, tt.affected, affected)
+		}
+	}
+}
+
+func TestAddVulnerabilityFindings(t *testing.T) {
+	db, err := LoadOSVDatabase(filepath.Join("..", "..", "testdata", "osv"))
+	if err != nil {
+		t.Fatalf("Failed to load OSV database: %v", err)
+	}
+	bom := osvTestBOM()
+	bom.Vulnerabilities = &[]cyclonedx.Vulnerability{{ID: "GHSA-xxxx-lib2-0003"}}
+
+	findings := ScanVulnerabilities(bom, db)
+	if added := AddVulnerabilityFindings(bom, findings); added != 1 {
+		t.Errorf("Expected 1 vulnerability added, got %d", added)
+	}
+	// Adding again does not duplicate anything
+	AddVulnerabilityFindings(bom, findings)
+
+	vulns := *bom.Vulnerabilities
+	if len(vulns) != 2 {
+		t.Fatalf("Expected 2 vulnerabilities, got %d", len(vulns))
+	}
+	if vulns[0].Affects == nil || len(*vulns[0].Affects) != 1 || (*vulns[0].Affects)[0].Ref != "lib-2" {
+		t.Errorf("Expected existing vulnerability to be extended, got %+v", vulns[0].Affects)
+	}
+
+	v := vulns[1]
+	if v.ID != "GHSA-xxxx-lib3-0001" || v.Source == nil || v.Source.URL != "https://osv.dev/vulnerability/GHSA-xxxx-lib3-0001" {
+		t.Errorf("Unexpected vulnerability: %+v", v)
+	}
+	if v.Ratings == nil || len(*v.Ratings) != 2 || *(*v.Ratings)[0].Score != 9.8 || (*v.Ratings)[0].Method != cyclonedx.ScoringMethodCVSSv31 {
+		t.Errorf("Unexpected ratings: %+v", v.Ratings)
+	}
+	if v.References == nil || (*v.References)[0].ID != "CVE-2024-0001" || v.Advisories == nil || len(*v.Advisories) != 1 || v.CWEs == nil || (*v.CWEs)[0] != 1321 {
+		t.Errorf("Unexpected references, advisories or CWEs: %+v", v)
+	}
+	expectedAffects := []cyclonedx.Affects{{Ref: "lib-3", Range: &[]cyclonedx.AffectedVersions{
+		{Version: "3.4.5", Status: cyclonedx.VulnerabilityStatusAffected},
+		{Version: "3.4.6", Status: cyclonedx.VulnerabilityStatusNotAffected},
+	}}}
+	if v.Affects == nil || len(*v.Affects) != 1 || (*v.Affects)[0].Ref != "lib-3" || !slices.Equal(*(*v.Affects)[0].Range, *expectedAffects[0].Range) {
+		t.Errorf("Expected affects %+v, got %+v", expectedAffects, v.Affects)
+	}
+	if errs := CheckRefIntegrity(bom); len(errs) != 0 {
+		t.Errorf("Expected consistent refs, got %v", errs)
+	}
+}
@@ -0,0 +1,1404 @@
+package sbom
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The protobuf format is encoded and decoded by hand, following the field
+// numbers and enum values of bom-1.6.proto from the CycloneDX specification.
+// Metadata, components, services, external references, dependencies,
+// vulnerabilities and properties are supported, but not compositions,
+// annotations, formulation, declarations, definitions, and some fields of
+// components and services like pedigree, evidence and release notes.
+// Encoding fails if the BOM uses any of these, so no data is lost silently.
+// Unknown fields are skipped when decoding.
+
+// protoClassifications are the values of the Classification enum, by number
+var protoClassifications = []cyclonedx.ComponentType{
+	"",
+	cyclonedx.ComponentTypeApplication,
+	cyclonedx.ComponentTypeFramework,
+	cyclonedx.ComponentTypeLibrary,
+	cyclonedx.ComponentTypeOS,
+	cyclonedx.ComponentTypeDevice,
+	cyclonedx.ComponentTypeFile,
+	cyclonedx.ComponentTypeContainer,
+	cyclonedx.ComponentTypeFirmware,
+	cyclonedx.ComponentTypeDeviceDriver,
+	cyclonedx.ComponentTypePlatform,
+	cyclonedx.ComponentTypeMachineLearningModel,
+	cyclonedx.ComponentTypeData,
+	cyclonedx.ComponentTypeCryptographicAsset,
+}
+
+// protoScopes are the values of the Scope enum, by number
+var protoScopes = []cyclonedx.Scope{
+	"",
+	cyclonedx.ScopeRequired,
+	cyclonedx.ScopeOptional,
+	cyclonedx.ScopeExcluded,
+}
+
+// protoHashAlgorithms are the values of the HashAlg enum, by number
+var protoHashAlgorithms = []cyclonedx.HashAlgorithm{
+	"",
+	cyclonedx.HashAlgoMD5,
+	cyclonedx.HashAlgoSHA1,
+	cyclonedx.HashAlgoSHA256,
+	cyclonedx.HashAlgoSHA384,
+	cyclonedx.HashAlgoSHA512,
+	cyclonedx.HashAlgoSHA3_256,
+	cyclonedx.HashAlgoSHA3_384,
+	cyclonedx.HashAlgoSHA3_512,
+	cyclonedx.HashAlgoBlake2b_256,
+	cyclonedx.HashAlgoBlake2b_384,
+	cyclonedx.HashAlgoBlake2b_512,
+	cyclonedx.HashAlgoBlake3,
+}
+
+// protoExternalReferenceTypes are the values of the ExternalReferenceType
+// enum, by number. Only the types up to CycloneDX 1.4 are supported.
+var protoExternalReferenceTypes = []cyclonedx.ExternalReferenceType{
+	cyclonedx.ERTypeOther,
+	cyclonedx.ERTypeVCS,
+	cyclonedx.ERTypeIssueTracker,
+	cyclonedx.ERTypeWebsite,
+	cyclonedx.ERTypeAdvisories,
+	cyclonedx.ERTypeBOM,
+	cyclonedx.ERTypeMailingList,
+	cyclonedx.ERTypeSocial,
+	cyclonedx.ERTypeChat,
+	cyclonedx.ERTypeDocumentation,
+	cyclonedx.ERTypeSupport,
+	cyclonedx.ERTypeDistribution,
+	cyclonedx.ERTypeLicense,
+	cyclonedx.ERTypeBuildMeta,
+	cyclonedx.ERTypeBuildSystem,
+	cyclonedx.ERTypeReleaseNotes,
+}
+
+// protoDataFlows are the values of the DataFlowDirection enum, by number
+var protoDataFlows = []cyclonedx.DataFlow{
+	"",
+	cyclonedx.DataFlowInbound,
+	cyclonedx.DataFlowOutbound,
+	cyclonedx.DataFlowBidirectional,
+	cyclonedx.DataFlowUnknown,
+}
+
+// protoSeverities are the values of the Severity enum, by number
+var protoSeverities = []cyclonedx.Severity{
+	cyclonedx.SeverityUnknown,
+	cyclonedx.SeverityCritical,
+	cyclonedx.SeverityHigh,
+	cyclonedx.SeverityMedium,
+	cyclonedx.SeverityLow,
+	cyclonedx.SeverityInfo,
+	cyclonedx.SeverityNone,
+}
+
+// protoScoringMethods are the values of the ScoreMethod enum, by number
+var protoScoringMethods = []cyclonedx.ScoringMethod{
+	"",
+	cyclonedx.ScoringMethodCVSSv2,
+	cyclonedx.ScoringMethodCVSSv3,
+	cyclonedx.ScoringMethodCVSSv31,
+	cyclonedx.ScoringMethodOWASP,
+	cyclonedx.ScoringMethodOther,
+	cyclonedx.ScoringMethodCVSSv4,
+	cyclonedx.ScoringMethodSSVC,
+}
+
+// protoImpactAnalysisStates are the values of the ImpactAnalysisState enum, by number
+var protoImpactAnalysisStates = []cyclonedx.ImpactAnalysisState{
+	"",
+	cyclonedx.IASResolved,
+	cyclonedx.IASResolvedWithPedigree,
+	cyclonedx.IASExploitable,
+	cyclonedx.IASInTriage,
+	cyclonedx.IASFalsePositive,
+	cyclonedx.IASNotAffected,
+}
+
+// protoImpactAnalysisJustifications are the values of the
+// ImpactAnalysisJustification enum, by number
+var protoImpactAnalysisJustifications = []cyclonedx.ImpactAnalysisJustification{
+	"",
+	cyclonedx.IAJCodeNotPresent,
+	cyclonedx.IAJCodeNotReachable,
+	cyclonedx.IAJRequiresConfiguration,
+	cyclonedx.IAJRequiresDependency,
+	cyclonedx.IAJRequiresEnvironment,
+	cyclonedx.IAJProtectedByCompiler,
+	cyclonedx.IAJProtectedAtRuntime,
+	cyclonedx.IAJProtectedAtPerimeter,
+	cyclonedx.IAJProtectedByMitigatingControl,
+}
+
+// protoImpactAnalysisResponses are the values of the VulnerabilityResponse enum, by number
+var protoImpactAnalysisResponses = []cyclonedx.ImpactAnalysisResponse{
+	"",
+	cyclonedx.IARCanNotFix,
+	cyclonedx.IARWillNotFix,
+	cyclonedx.IARUpdate,
+	cyclonedx.IARRollback,
+	cyclonedx.IARWorkaroundAvailable,
+}
+
+// protoVulnerabilityStatuses are the values of the
+// VulnerabilityAffectedStatus enum, by number
+var protoVulnerabilityStatuses = []cyclonedx.VulnerabilityStatus{
+	cyclonedx.VulnerabilityStatusUnknown,
+	cyclonedx.VulnerabilityStatusAffected,
+	cyclonedx.VulnerabilityStatusNotAffected,
+}
+
+// marshalProto encodes a BOM in the CycloneDX protobuf format
+func marshalProto(bom *cyclonedx.BOM) ([]byte, error) {
+	e := &protoEncoder{}
+	data := e.bom(bom)
+	if e.err != nil {
+		return nil, e.err
+	}
+	if len(e.unsupported) > 0 {
+		return nil, fmt.Errorf("the protobuf format does not support %s, use json or xml instead", strings.Join(e.unsupported, ", "))
+	}
+	return data, nil
+}
+
+// protoEncoder encodes BOMs and collects the fields it cannot encode
+type protoEncoder struct {
+	unsupported []string
+	err         error
+}
+
+// unsupportedIf records a field that cannot be encoded, if it is set
+func (e *protoEncoder) unsupportedIf(set bool, path string) {
+	if set && !slices.Contains(e.unsupported, path) {
+		e.unsupported = append(e.unsupported, path)
+	}
+}
+
+// enum returns the number of an enum value, recording it as unsupported if it is unknown
+func enum[T comparable](e *protoEncoder, values []T, value T, path string) uint64 {
+	i := slices.Index(values, value)
+	if i < 0 {
+		e.unsupportedIf(true, fmt.Sprintf("%s %v", path, value))
+		return 0
+	}
+	return uint64(i)
+}
+
+func (e *protoEncoder) bom(bom *cyclonedx.BOM) []byte {
+	e.unsupportedIf(bom.Compositions != nil && len(*bom.Compositions) > 0, "compositions")
+	e.unsupportedIf(bom.Annotations != nil && len(*bom.Annotations) > 0, "annotations")
+	e.unsupportedIf(bom.Formulation != nil && len(*bom.Formulation) > 0, "formulation")
+	e.unsupportedIf(bom.Declarations != nil, "declarations")
+	e.unsupportedIf(bom.Definitions != nil, "definitions")
+
+	var b []byte
+	b = appendProtoString(b, 1, bom.SpecVersion.String())
+	b = appendProtoVarint(b, 2, uint64(bom.Version))
+	b = appendProtoString(b, 3, bom.SerialNumber)
+	if bom.Metadata != nil {
+		b = appendProtoMessage(b, 4, e.metadata(bom.Metadata))
+	}
+	if bom.Components != nil {
+		for i := range *bom.Components {
+			b = appendProtoMessage(b, 5, e.component(&(*bom.Components)[i], "components[]"))
+		}
+	}
+	if bom.Services != nil {
+		for i := range *bom.Services {
+			b = appendProtoMessage(b, 6, e.service(&(*bom.Services)[i], "services[]"))
+		}
+	}
+	if bom.ExternalReferences != nil {
+		for _, ref := range *bom.ExternalReferences {
+			b = appendProtoMessage(b, 7, e.externalReference(ref, "externalReferences[]"))
+		}
+	}
+	if bom.Dependencies != nil {
+		for _, dep := range *bom.Dependencies {
+			b = appendProtoMessage(b, 8, protoDependency(dep))
+		}
+	}
+	if bom.Vulnerabilities != nil {
+		for i := range *bom.Vulnerabilities {
+			b = appendProtoMessage(b, 10, e.vulnerability(&(*bom.Vulnerabilities)[i], "vulnerabilities[]"))
+		}
+	}
+	if bom.Properties != nil {
+		for _, property := range *bom.Properties {
+			b = appendProtoMessage(b, 12, protoProperty(property))
+		}
+	}
+	return b
+}
+
+func (e *protoEncoder) metadata(m *cyclonedx.Metadata) []byte {
+	var b []byte
+	b = e.appendTimestamp(b, 1, m.Timestamp, "metadata timestamp")
+	b = e.appendTools(b, 2, m.Tools, "metadata.tools")
+	if m.Authors != nil {
+		for _, author := range *m.Authors {
+			b = appendProtoMessage(b, 3, protoContact(author))
+		}
+	}
+	if m.Component != nil {
+		b = appendProtoMessage(b, 4, e.component(m.Component, "metadata.component"))
+	}
+	if m.Supplier != nil {
+		b = appendProtoMessage(b, 6, e.entity(m.Supplier, "metadata.supplier"))
+	}
+	b = e.appendLicenses(b, 7, m.Licenses, "metadata.licenses[]")
+	if m.Properties != nil {
+		for _, property := range *m.Properties {
+			b = appendProtoMessage(b, 8, protoProperty(property))
+		}
+	}
+	e.unsupportedIf(m.Lifecycles != nil, "metadata.lifecycles")
+	e.unsupportedIf(m.Manufacture != nil, "metadata.manufacture")
+	e.unsupportedIf(m.Manufacturer != nil, "metadata.manufacturer")
+	return b
+}
+
+func (e *protoEncoder) component(c *cyclonedx.Component, path string) []byte {
+	var b []byte
+	b = appendProtoVarint(b, 1, enum(e, protoClassifications, c.Type, path+".type"))
+	b = appendProtoString(b, 2, c.MIMEType)
+	b = appendProtoString(b, 3, c.BOMRef)
+	if c.Supplier != nil {
+		b = appendProtoMessage(b, 4, e.entity(c.Supplier, path+".supplier"))
+	}
+	b = appendProtoString(b, 5, c.Author)
+	b = appendProtoString(b, 6, c.Publisher)
+	b = appendProtoString(b, 7, c.Group)
+	b = appendProtoString(b, 8, c.Name)
+	b = appendProtoString(b, 9, c.Version)
+	b = appendProtoString(b, 10, c.Description)
+	b = appendProtoVarint(b, 11, enum(e, protoScopes, c.Scope, path+".scope"))
+	b = e.appendHashes(b, 12, c.Hashes)
+	b = e.appendLicenses(b, 13, c.Licenses, path+".licenses[]")
+	b = appendProtoString(b, 14, c.Copyright)
+	b = appendProtoString(b, 15, c.CPE)
+	b = appendProtoString(b, 16, c.PackageURL)
+	if c.Modified != nil {
+		b = appendProtoOptional(b, 18, protowire.EncodeBool(*c.Modified))
+	}
+	if c.ExternalReferences != nil {
+		for _, ref := range *c.ExternalReferences {
+			b = appendProtoMessage(b, 20, e.externalReference(ref, path+".externalReferences[]"))
+		}
+	}
+	if c.Components != nil {
+		for i := range *c.Components {
+			b = appendProtoMessage(b, 21, e.component(&(*c.Components)[i], path+".components[]"))
+		}
+	}
+	if c.Properties != nil {
+		for _, property := range *c.Properties {
+			b = appendProtoMessage(b, 22, protoProperty(property))
+		}
+	}
+	if c.Manufacturer != nil {
+		b = appendProtoMessage(b, 28, e.entity(c.Manufacturer, path+".manufacturer"))
+	}
+	if c.Authors != nil {
+		for _, author := range *c.Authors {
+			b = appendProtoMessage(b, 29, protoContact(author))
+		}
+	}
+	if c.OmniborID != nil {
+		for _, id := range *c.OmniborID {
+			b = appendProtoString(b, 30, id)
+		}
+	}
+	if c.SWHID != nil {
+		for _, id := range *c.SWHID {
+			b = appendProtoString(b, 31, id)
+		}
+	}
+
+	e.unsupportedIf(c.SWID != nil, path+".swid")
+	e.unsupportedIf(c.Pedigree != nil, path+".pedigree")
+	e.unsupportedIf(c.Evidence != nil, path+".evidence")
+	e.unsupportedIf(c.ReleaseNotes != nil, path+".releaseNotes")
+	e.unsupportedIf(c.ModelCard != nil, path+".modelCard")
+	e.unsupportedIf(c.Data != nil, path+".data")
+	e.unsupportedIf(c.CryptoProperties != nil, path+".cryptoProperties")
+	return b
+}
+
+func (e *protoEncoder) service(s *cyclonedx.Service, path string) []byte {
+	var b []byte
+	b = appendProtoString(b, 1, s.BOMRef)
+	if s.Provider != nil {
+		b = appendProtoMessage(b, 2, e.entity(s.Provider, path+".provider"))
+	}
+	b = appendProtoString(b, 3, s.Group)
+	b = appendProtoString(b, 4, s.Name)
+	b = appendProtoString(b, 5, s.Version)
+	b = appendProtoString(b, 6, s.Description)
+	if s.Endpoints != nil {
+		for _, endpoint := range *s.Endpoints {
+			b = appendProtoString(b, 7, endpoint)
+		}
+	}
+	if s.Authenticated != nil {
+		b = appendProtoOptional(b, 8, protowire.EncodeBool(*s.Authenticated))
+	}
+	if s.CrossesTrustBoundary != nil {
+		b = appendProtoOptional(b, 9, protowire.EncodeBool(*s.CrossesTrustBoundary))
+	}
+	if s.Data != nil {
+		for _, data := range *s.Data {
+			var d []byte
+			d = appendProtoVarint(d, 1, enum(e, protoDataFlows, data.Flow, path+".data[].flow"))
+			d = appendProtoString(d, 2, data.Classification)
+			b = appendProtoMessage(b, 10, d)
+		}
+	}
+	b = e.appendLicenses(b, 11, s.Licenses, path+".licenses[]")
+	if s.ExternalReferences != nil {
+		for _, ref := range *s.ExternalReferences {
+			b = appendProtoMessage(b, 12, e.externalReference(ref, path+".externalReferences[]"))
+		}
+	}
+	if s.Services != nil {
+		for i := range *s.Services {
+			b = appendProtoMessage(b, 13, e.service(&(*s.Services)[i], path+".services[]"))
+		}
+	}
+	if s.Properties != nil {
+		for _, property := range *s.Properties {
+			b = appendProtoMessage(b, 15, protoProperty(property))
+		}
+	}
+
+	e.unsupportedIf(s.ReleaseNotes != nil, path+".releaseNotes")
+	return b
+}
+
+func (e *protoEncoder) vulnerability(v *cyclonedx.Vulnerability, path string) []byte {
+	var b []byte
+	b = appendProtoString(b, 1, v.BOMRef)
+	b = appendProtoString(b, 2, v.ID)
+	if v.Source != nil {
+		b = appendProtoMessage(b, 3, protoSource(*v.Source))
+	}
+	if v.References != nil {
+		for _, ref := range *v.References {
+			var r []byte
+			r = appendProtoString(r, 1, ref.ID)
+			if ref.Source != nil {
+				r = appendProtoMessage(r, 2, protoSource(*ref.Source))
+			}
+			b = appendProtoMessage(b, 4, r)
+		}
+	}
+	if v.Ratings != nil {
+		for _, rating := range *v.Ratings {
+			var r []byte
+			if rating.Source != nil {
+				r = appendProtoMessage(r, 1, protoSource(*rating.Source))
+			}
+			if rating.Score != nil {
+				r = protowire.AppendTag(r, 2, protowire.Fixed64Type)
+				r = protowire.AppendFixed64(r, math.Float64bits(*rating.Score))
+			}
+			if rating.Severity != "" {
+				r = appendProtoOptional(r, 3, enum(e, protoSeverities, rating.Severity, path+".ratings[].severity"))
+			}
+			r = appendProtoVarint(r, 4, enum(e, protoScoringMethods, rating.Method, path+".ratings[].method"))
+			r = appendProtoString(r, 5, rating.Vector)
+			r = appendProtoString(r, 6, rating.Justification)
+			b = appendProtoMessage(b, 5, r)
+		}
+	}
+	if v.CWEs != nil && len(*v.CWEs) > 0 {
+		// Repeated scalars are packed in proto3
+		var cwes []byte
+		for _, cwe := range *v.CWEs {
+			cwes = protowire.AppendVarint(cwes, uint64(int32(cwe)))
+		}
+		b = appendProtoMessage(b, 6, cwes)
+	}
+	b = appendProtoString(b, 7, v.Description)
+	b = appendProtoString(b, 8, v.Detail)
+	b = appendProtoString(b, 9, v.Recommendation)
+	if v.Advisories != nil {
+		for _, advisory := range *v.Advisories {
+			var a []byte
+			a = appendProtoString(a, 1, advisory.Title)
+			a = appendProtoString(a, 2, advisory.URL)
+			b = appendProtoMessage(b, 10, a)
+		}
+	}
+	b = e.appendTimestamp(b, 11, v.Created, path+".created")
+	b = e.appendTimestamp(b, 12, v.Published, path+".published")
+	b = e.appendTimestamp(b, 13, v.Updated, path+".updated")
+	if v.Credits != nil {
+		var c []byte
+		if v.Credits.Organizations != nil {
+			for i := range *v.Credits.Organizations {
+				c = appendProtoMessage(c, 1, e.entity(&(*v.Credits.Organizations)[i], path+".credits.organizations[]"))
+			}
+		}
+		if v.Credits.Individuals != nil {
+			for _, individual := range *v.Credits.Individuals {
+				c = appendProtoMessage(c, 2, protoContact(individual))
+			}
+		}
+		b = appendProtoMessage(b, 14, c)
+	}
+	b = e.appendTools(b, 15, v.Tools, path+".tools")
+	if v.Analysis != nil {
+		analysis := v.Analysis
+		var a []byte
+		a = appendProtoVarint(a, 1, enum(e, protoImpactAnalysisStates, analysis.State, path+".analysis.state"))
+		a = appendProtoVarint(a, 2, enum(e, protoImpactAnalysisJustifications, analysis.Justification, path+".analysis.justification"))
+		if analysis.Response != nil && len(*analysis.Response) > 0 {
+			var responses []byte
+			for _, response := range *analysis.Response {
+				responses = protowire.AppendVarint(responses, enum(e, protoImpactAnalysisResponses, response, path+".analysis.response"))
+			}
+			a = appendProtoMessage(a, 3, responses)
+		}
+		a = appendProtoString(a, 4, analysis.Detail)
+		a = e.appendTimestamp(a, 5, analysis.FirstIssued, path+".analysis.firstIssued")
+		a = e.appendTimestamp(a, 6, analysis.LastUpdated, path+".analysis.lastUpdated")
+		b = appendProtoMessage(b, 16, a)
+	}
+	if v.Affects != nil {
+		for _, affects := range *v.Affects {
+			var a []byte
+			a = appendProtoString(a, 1, affects.Ref)
+			if affects.Range != nil {
+				for _, version := range *affects.Range {
+					var r []byte
+					r = appendProtoString(r, 1, version.Version)
+					r = appendProtoString(r, 2, version.Range)
+					if version.Status != "" {
+						r = appendProtoOptional(r, 3, enum(e, protoVulnerabilityStatuses, version.Status, path+".affects[].versions[].status"))
+					}
+					a = appendProtoMessage(a, 2, r)
+				}
+			}
+			b = appendProtoMessage(b, 17, a)
+		}
+	}
+	if v.Properties != nil {
+		for _, property := range *v.Properties {
+			b = appendProtoMessage(b, 18, protoProperty(property))
+		}
+	}
+	b = e.appendTimestamp(b, 19, v.Rejected, path+".rejected")
+	b = appendProtoString(b, 21, v.Workaround)
+
+	e.unsupportedIf(v.ProofOfConcept != nil, path+".proofOfConcept")
+	return b
+}
+
+// appendTools appends the tools of the metadata or a vulnerability
+func (e *protoEncoder) appendTools(b []byte, num protowire.Number, tools *cyclonedx.ToolsChoice, path string) []byte {
+	if tools == nil {
+		return b
+	}
+	// Deprecated tools are written as separate Tool messages, like in CycloneDX 1.4
+	if tools.Tools != nil {
+		for _, tool := range *tools.Tools {
+			var t []byte
+			t = appendProtoString(t, 1, tool.Vendor)
+			t = appendProtoString(t, 2, tool.Name)
+			t = appendProtoString(t, 3, tool.Version)
+			t = e.appendHashes(t, 4, tool.Hashes)
+			if tool.ExternalReferences != nil {
+				for _, ref := range *tool.ExternalReferences {
+					t = appendProtoMessage(t, 5, e.externalReference(ref, path+"[].externalReferences[]"))
+				}
+			}
+			b = appendProtoMessage(b, num, t)
+		}
+	}
+	hasComponents := tools.Components != nil && len(*tools.Components) > 0
+	hasServices := tools.Services != nil && len(*tools.Services) > 0
+	if hasComponents || hasServices {
+		var t []byte
+		if tools.Components != nil {
+			for i := range *tools.Components {
+				t = appendProtoMessage(t, 6, e.component(&(*tools.Components)[i], path+".components[]"))
+			}
+		}
+		if tools.Services != nil {
+			for i := range *tools.Services {
+				t = appendProtoMessage(t, 7, e.service(&(*tools.Services)[i], path+".services[]"))
+			}
+		}
+		b = appendProtoMessage(b, num, t)
+	}
+	return b
+}
+
+// appendTimestamp appends an RFC 3339 date as a Timestamp message, unless it is empty
+func (e *protoEncoder) appendTimestamp(b []byte, num protowire.Number, value, name string) []byte {
+	if value == "" {
+		return b
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		e.err = errors.Join(e.err, fmt.Errorf("invalid %s %q: %w", name, value, err))
+		return b
+	}
+	var ts []byte
+	ts = appendProtoVarint(ts, 1, uint64(t.Unix()))
+	ts = appendProtoVarint(ts, 2, uint64(t.Nanosecond()))
+	return appendProtoMessage(b, num, ts)
+}
+
+func (e *protoEncoder) entity(entity *cyclonedx.OrganizationalEntity, path string) []byte {
+	var b []byte
+	b = appendProtoString(b, 1, entity.Name)
+	if entity.URL != nil {
+		for _, url := range *entity.URL {
+			b = appendProtoString(b, 2, url)
+		}
+	}
+	if entity.Contact != nil {
+		for _, contact := range *entity.Contact {
+			b = appendProtoMessage(b, 3, protoContact(contact))
+		}
+	}
+	b = appendProtoString(b, 4, entity.BOMRef)
+	e.unsupportedIf(entity.Address != nil, path+".address")
+	return b
+}
+
+func (e *protoEncoder) appendLicenses(b []byte, num protowire.Number, licenses *cyclonedx.Licenses, path string) []byte {
+	if licenses == nil {
+		return b
+	}
+	for _, choice := range *licenses {
+		var lc []byte
+		if choice.License != nil {
+			license := choice.License
+			var l []byte
+			l = appendProtoString(l, 1, license.ID)
+			l = appendProtoString(l, 2, license.Name)
+			if license.Text != nil {
+				var text []byte
+				text = appendProtoString(text, 1, license.Text.ContentType)
+				text = appendProtoString(text, 2, license.Text.Encoding)
+				text = appendProtoString(text, 3, license.Text.Content)
+				l = appendProtoMessage(l, 3, text)
+			}
+			l = appendProtoString(l, 4, license.URL)
+			l = appendProtoString(l, 5, license.BOMRef)
+			e.unsupportedIf(license.Licensing != nil, path+".license.licensing")
+			e.unsupportedIf(license.Properties != nil, path+".license.properties")
+			e.unsupportedIf(license.Acknowledgement != "", path+".license.acknowledgement")
+			lc = appendProtoMessage(lc, 1, l)
+		}
+		lc = appendProtoString(lc, 2, choice.Expression)
+		b = appendProtoMessage(b, num, lc)
+	}
+	return b
+}
+
+func (e *protoEncoder) appendHashes(b []byte, num protowire.Number, hashes *[]cyclonedx.Hash) []byte {
+	if hashes == nil {
+		return b
+	}
+	for _, hash := range *hashes {
+		var h []byte
+		h = appendProtoVarint(h, 1, enum(e, protoHashAlgorithms, hash.Algorithm, "hash algorithm"))
+		h = appendProtoString(h, 2, hash.Value)
+		b = appendProtoMessage(b, num, h)
+	}
+	return b
+}
+
+func (e *protoEncoder) externalReference(ref cyclonedx.ExternalReference, path string) []byte {
+	var b []byte
+	b = appendProtoVarint(b, 1, enum(e, protoExternalReferenceTypes, ref.Type, path+".type"))
+	b = appendProtoString(b, 2, ref.URL)
+	b = appendProtoString(b, 3, ref.Comment)
+	return e.appendHashes(b, 4, ref.Hashes)
+}
+
+func protoContact(contact cyclonedx.OrganizationalContact) []byte {
+	var b []byte
+	b = appendProtoString(b, 1, contact.Name)
+	b = appendProtoString(b, 2, contact.Email)
+	b = appendProtoString(b, 3, contact.Phone)
+	return appendProtoString(b, 4, contact.BOMRef)
+}
+
+func protoSource(source cyclonedx.Source) []byte {
+	var b []byte
+	b = appendProtoString(b, 1, source.Name)
+	return appendProtoString(b, 2, source.URL)
+}
+
+func protoDependency(dep cyclonedx.Dependency) []byte {
+	var b []byte
+	b = appendProtoString(b, 1, dep.Ref)
+	if dep.Dependencies != nil {
+		for _, ref := range *dep.Dependencies {
+			b = appendProtoMessage(b, 2, appendProtoString(nil, 1, ref))
+		}
+	}
+	return b
+}
+
+func protoProperty(property cyclonedx.Property) []byte {
+	var b []byte
+	b = appendProtoString(b, 1, property.Name)
+	return appendProtoString(b, 2, property.Value)
+}
+
+// appendProtoString appends a string field, unless it is empty
+func appendProtoString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendProtoVarint appends a varint field, unless it is zero
+func appendProtoVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendProtoOptional appends a varint field that is set, even if it is zero
+func appendProtoOptional(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendProtoMessage appends an embedded message field, even if it is empty
+func appendProtoMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+// unmarshalProto decodes a BOM in the CycloneDX protobuf format
+func unmarshalProto(data []byte, bom *cyclonedx.BOM) error {
+	var specVersion string
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			specVersion = v.string()
+		case 2:
+			bom.Version = int(int32(v.varint))
+		case 3:
+			bom.SerialNumber = v.string()
+		case 4:
+			bom.Metadata = &cyclonedx.Metadata{}
+			return decodeProtoMetadata(v.bytes, bom.Metadata)
+		case 5:
+			c, err := decodeProtoComponent(v.bytes)
+			bom.Components = appendTo(bom.Components, c)
+			return err
+		case 6:
+			service, err := decodeProtoService(v.bytes)
+			bom.Services = appendTo(bom.Services, service)
+			return err
+		case 7:
+			ref, err := decodeProtoExternalReference(v.bytes)
+			bom.ExternalReferences = appendTo(bom.ExternalReferences, ref)
+			return err
+		case 8:
+			dep, err := decodeProtoDependency(v.bytes)
+			bom.Dependencies = appendTo(bom.Dependencies, dep)
+			return err
+		case 10:
+			vulnerability, err := decodeProtoVulnerability(v.bytes)
+			bom.Vulnerabilities = appendTo(bom.Vulnerabilities, vulnerability)
+			return err
+		case 12:
+			property, err := decodeProtoProperty(v.bytes)
+			bom.Properties = appendTo(bom.Properties, property)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Everything that is neither JSON nor XML ends up here, make sure it is a BOM
+	sv, err := ParseSpecVersion(specVersion)
+	if err != nil {
+		return fmt.Errorf("not a CycloneDX protobuf document: %w", err)
+	}
+	bom.SpecVersion = sv
+	bom.BOMFormat = cyclonedx.BOMFormat
+	return nil
+}
+
+func decodeProtoMetadata(data []byte, m *cyclonedx.Metadata) error {
+	return walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			var err error
+			m.Timestamp, err = decodeProtoTimestamp(v.bytes)
+			return err
+		case 2:
+			if m.Tools == nil {
+				m.Tools = &cyclonedx.ToolsChoice{}
+			}
+			return decodeProtoTool(v.bytes, m.Tools)
+		case 3:
+			contact, err := decodeProtoContact(v.bytes)
+			m.Authors = appendTo(m.Authors, contact)
+			return err
+		case 4:
+			c, err := decodeProtoComponent(v.bytes)
+			m.Component = &c
+			return err
+		case 6:
+			entity, err := decodeProtoEntity(v.bytes)
+			m.Supplier = &entity
+			return err
+		case 7:
+			license, err := decodeProtoLicenseChoice(v.bytes)
+			if m.Licenses == nil {
+				m.Licenses = &cyclonedx.Licenses{}
+			}
+			*m.Licenses = append(*m.Licenses, license)
+			return err
+		case 8:
+			property, err := decodeProtoProperty(v.bytes)
+			m.Properties = appendTo(m.Properties, property)
+			return err
+		}
+		return nil
+	})
+}
+
+// decodeProtoTool decodes a Tool message, which is either a deprecated tool
+// or a list of tool components
+func decodeProtoTool(data []byte, tools *cyclonedx.ToolsChoice) error {
+	var tool cyclonedx.Tool
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			tool.Vendor = v.string()
+		case 2:
+			tool.Name = v.string()
+		case 3:
+			tool.Version = v.string()
+		case 4:
+			hash, err := decodeProtoHash(v.bytes)
+			tool.Hashes = appendTo(tool.Hashes, hash)
+			return err
+		case 5:
+			ref, err := decodeProtoExternalReference(v.bytes)
+			tool.ExternalReferences = appendTo(tool.ExternalReferences, ref)
+			return err
+		case 6:
+			c, err := decodeProtoComponent(v.bytes)
+			tools.Components = appendTo(tools.Components, c)
+			return err
+		case 7:
+			service, err := decodeProtoService(v.bytes)
+			tools.Services = appendTo(tools.Services, service)
+			return err
+		}
+		return nil
+	})
+	if tool.Name != "" || tool.Vendor != "" || tool.Version != "" {
+		tools.Tools = appendTo(tools.Tools, tool)
+	}
+	return err
+}
+
+func decodeProtoComponent(data []byte) (cyclonedx.Component, error) {
+	var c cyclonedx.Component
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			c.Type = enumValue(protoClassifications, v.varint)
+		case 2:
+			c.MIMEType = v.string()
+		case 3:
+			c.BOMRef = v.string()
+		case 4:
+			entity, err := decodeProtoEntity(v.bytes)
+			c.Supplier = &entity
+			return err
+		case 5:
+			c.Author = v.string()
+		case 6:
+			c.Publisher = v.string()
+		case 7:
+			c.Group = v.string()
+		case 8:
+			c.Name = v.string()
+		case 9:
+			c.Version = v.string()
+		case 10:
+			c.Description = v.string()
+		case 11:
+			c.Scope = enumValue(protoScopes, v.varint)
+		case 12:
+			hash, err := decodeProtoHash(v.bytes)
+			c.Hashes = appendTo(c.Hashes, hash)
+			return err
+		case 13:
+			license, err := decodeProtoLicenseChoice(v.bytes)
+			if c.Licenses == nil {
+				c.Licenses = &cyclonedx.Licenses{}
+			}
+			*c.Licenses = append(*c.Licenses, license)
+			return err
+		case 14:
+			c.Copyright = v.string()
+		case 15:
+			c.CPE = v.string()
+		case 16:
+			c.PackageURL = v.string()
+		case 18:
+			modified := protowire.DecodeBool(v.varint)
+			c.Modified = &modified
+		case 20:
+			ref, err := decodeProtoExternalReference(v.bytes)
+			c.ExternalReferences = appendTo(c.ExternalReferences, ref)
+			return err
+		case 21:
+			child, err := decodeProtoComponent(v.bytes)
+			c.Components = appendTo(c.Components, child)
+			return err
+		case 22:
+			property, err := decodeProtoProperty(v.bytes)
+			c.Properties = appendTo(c.Properties, property)
+			return err
+		case 28:
+			entity, err := decodeProtoEntity(v.bytes)
+			c.Manufacturer = &entity
+			return err
+		case 29:
+			contact, err := decodeProtoContact(v.bytes)
+			c.Authors = appendTo(c.Authors, contact)
+			return err
+		case 30:
+			c.OmniborID = appendTo(c.OmniborID, v.string())
+		case 31:
+			c.SWHID = appendTo(c.SWHID, v.string())
+		}
+		return nil
+	})
+	return c, err
+}
+
+func decodeProtoService(data []byte) (cyclonedx.Service, error) {
+	var s cyclonedx.Service
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			s.BOMRef = v.string()
+		case 2:
+			entity, err := decodeProtoEntity(v.bytes)
+			s.Provider = &entity
+			return err
+		case 3:
+			s.Group = v.string()
+		case 4:
+			s.Name = v.string()
+		case 5:
+			s.Version = v.string()
+		case 6:
+			s.Description = v.string()
+		case 7:
+			s.Endpoints = appendTo(s.Endpoints, v.string())
+		case 8:
+			authenticated := protowire.DecodeBool(v.varint)
+			s.Authenticated = &authenticated
+		case 9:
+			crossesTrustBoundary := protowire.DecodeBool(v.varint)
+			s.CrossesTrustBoundary = &crossesTrustBoundary
+		case 10:
+			var data cyclonedx.DataClassification
+			err := walkProto(v.bytes, func(num protowire.Number, v protoValue) error {
+				switch num {
+				case 1:
+					data.Flow = enumValue(protoDataFlows, v.varint)
+				case 2:
+					data.Classification = v.string()
+				}
+				return nil
+			})
+			s.Data = appendTo(s.Data, data)
+			return err
+		case 11:
+			license, err := decodeProtoLicenseChoice(v.bytes)
+			if s.Licenses == nil {
+				s.Licenses = &cyclonedx.Licenses{}
+			}
+			*s.Licenses = append(*s.Licenses, license)
+			return err
+		case 12:
+			ref, err := decodeProtoExternalReference(v.bytes)
+			s.ExternalReferences = appendTo(s.ExternalReferences, ref)
+			return err
+		case 13:
+			child, err := decodeProtoService(v.bytes)
+			s.Services = appendTo(s.Services, child)
+			return err
+		case 15:
+			property, err := decodeProtoProperty(v.bytes)
+			s.Properties = appendTo(s.Properties, property)
+			return err
+		}
+		return nil
+	})
+	return s, err
+}
+
+func decodeProtoVulnerability(data []byte) (cyclonedx.Vulnerability, error) {
+	var vuln cyclonedx.Vulnerability
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		var err error
+		switch num {
+		case 1:
+			vuln.BOMRef = v.string()
+		case 2:
+			vuln.ID = v.string()
+		case 3:
+			source, err := decodeProtoSource(v.bytes)
+			vuln.Source = &source
+			return err
+		case 4:
+			var ref cyclonedx.VulnerabilityReference
+			err = walkProto(v.bytes, func(num protowire.Number, v protoValue) error {
+				switch num {
+				case 1:
+					ref.ID = v.string()
+				case 2:
+					source, err := decodeProtoSource(v.bytes)
+					ref.Source = &source
+					return err
+				}
+				return nil
+			})
+			vuln.References = appendTo(vuln.References, ref)
+		case 5:
+			rating, err := decodeProtoRating(v.bytes)
+			vuln.Ratings = appendTo(vuln.Ratings, rating)
+			return err
+		case 6:
+			cwes, err := v.varints()
+			for _, cwe := range cwes {
+				vuln.CWEs = appendTo(vuln.CWEs, int(int32(cwe)))
+			}
+			return err
+		case 7:
+			vuln.Description = v.string()
+		case 8:
+			vuln.Detail = v.string()
+		case 9:
+			vuln.Recommendation = v.string()
+		case 10:
+			var advisory cyclonedx.Advisory
+			err = walkProto(v.bytes, func(num protowire.Number, v protoValue) error {
+				switch num {
+				case 1:
+					advisory.Title = v.string()
+				case 2:
+					advisory.URL = v.string()
+				}
+				return nil
+			})
+			vuln.Advisories = appendTo(vuln.Advisories, advisory)
+		case 11:
+			vuln.Created, err = decodeProtoTimestamp(v.bytes)
+		case 12:
+			vuln.Published, err = decodeProtoTimestamp(v.bytes)
+		case 13:
+			vuln.Updated, err = decodeProtoTimestamp(v.bytes)
+		case 14:
+			if vuln.Credits == nil {
+				vuln.Credits = &cyclonedx.Credits{}
+			}
+			credits := vuln.Credits
+			err = walkProto(v.bytes, func(num protowire.Number, v protoValue) error {
+				switch num {
+				case 1:
+					entity, err := decodeProtoEntity(v.bytes)
+					credits.Organizations = appendTo(credits.Organizations, entity)
+					return err
+				case 2:
+					contact, err := decodeProtoContact(v.bytes)
+					credits.Individuals = appendTo(credits.Individuals, contact)
+					return err
+				}
+				return nil
+			})
+		case 15:
+			if vuln.Tools == nil {
+				vuln.Tools = &cyclonedx.ToolsChoice{}
+			}
+			err = decodeProtoTool(v.bytes, vuln.Tools)
+		case 16:
+			analysis, err := decodeProtoAnalysis(v.bytes)
+			vuln.Analysis = &analysis
+			return err
+		case 17:
+			affects, err := decodeProtoAffects(v.bytes)
+			vuln.Affects = appendTo(vuln.Affects, affects)
+			return err
+		case 18:
+			property, err := decodeProtoProperty(v.bytes)
+			vuln.Properties = appendTo(vuln.Properties, property)
+			return err
+		case 19:
+			vuln.Rejected, err = decodeProtoTimestamp(v.bytes)
+		case 21:
+			vuln.Workaround = v.string()
+		}
+		return err
+	})
+	return vuln, err
+}
+
+func decodeProtoRating(data []byte) (cyclonedx.VulnerabilityRating, error) {
+	var rating cyclonedx.VulnerabilityRating
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			source, err := decodeProtoSource(v.bytes)
+			rating.Source = &source
+			return err
+		case 2:
+			score := math.Float64frombits(v.fixed64)
+			rating.Score = &score
+		case 3:
+			rating.Severity = enumValue(protoSeverities, v.varint)
+		case 4:
+			rating.Method = enumValue(protoScoringMethods, v.varint)
+		case 5:
+			rating.Vector = v.string()
+		case 6:
+			rating.Justification = v.string()
+		}
+		return nil
+	})
+	return rating, err
+}
+
+func decodeProtoAnalysis(data []byte) (cyclonedx.VulnerabilityAnalysis, error) {
+	var analysis cyclonedx.VulnerabilityAnalysis
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		var err error
+		switch num {
+		case 1:
+			analysis.State = enumValue(protoImpactAnalysisStates, v.varint)
+		case 2:
+			analysis.Justification = enumValue(protoImpactAnalysisJustifications, v.varint)
+		case 3:
+			var responses []uint64
+			responses, err = v.varints()
+			for _, response := range responses {
+				analysis.Response = appendTo(analysis.Response, enumValue(protoImpactAnalysisResponses, response))
+			}
+		case 4:
+			analysis.Detail = v.string()
+		case 5:
+			analysis.FirstIssued, err = decodeProtoTimestamp(v.bytes)
+		case 6:
+			analysis.LastUpdated, err = decodeProtoTimestamp(v.bytes)
+		}
+		return err
+	})
+	return analysis, err
+}
+
+func decodeProtoAffects(data []byte) (cyclonedx.Affects, error) {
+	var affects cyclonedx.Affects
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			affects.Ref = v.string()
+		case 2:
+			var version cyclonedx.AffectedVersions
+			err := walkProto(v.bytes, func(num protowire.Number, v protoValue) error {
+				switch num {
+				case 1:
+					version.Version = v.string()
+				case 2:
+					version.Range = v.string()
+				case 3:
+					version.Status = enumValue(protoVulnerabilityStatuses, v.varint)
+				}
+				return nil
+			})
+			affects.Range = appendTo(affects.Range, version)
+			return err
+		}
+		return nil
+	})
+	return affects, err
+}
+
+func decodeProtoSource(data []byte) (cyclonedx.Source, error) {
+	var source cyclonedx.Source
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			source.Name = v.string()
+		case 2:
+			source.URL = v.string()
+		}
+		return nil
+	})
+	return source, err
+}
+
+// decodeProtoTimestamp decodes a Timestamp message as an RFC 3339 date
+func decodeProtoTimestamp(data []byte) (string, error) {
+	var seconds, nanos uint64
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			seconds = v.varint
+		case 2:
+			nanos = v.varint
+		}
+		return nil
+	})
+	return time.Unix(int64(seconds), int64(nanos)).UTC().Format(time.RFC3339Nano), err
+}
+
+func decodeProtoEntity(data []byte) (cyclonedx.OrganizationalEntity, error) {
+	var entity cyclonedx.OrganizationalEntity
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			entity.Name = v.string()
+		case 2:
+			entity.URL = appendTo(entity.URL, v.string())
+		case 3:
+			contact, err := decodeProtoContact(v.bytes)
+			entity.Contact = appendTo(entity.Contact, contact)
+			return err
+		case 4:
+			entity.BOMRef = v.string()
+		}
+		return nil
+	})
+	return entity, err
+}
+
+func decodeProtoContact(data []byte) (cyclonedx.OrganizationalContact, error) {
+	var contact cyclonedx.OrganizationalContact
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			contact.Name = v.string()
+		case 2:
+			contact.Email = v.string()
+		case 3:
+			contact.Phone = v.string()
+		case 4:
+			contact.BOMRef = v.string()
+		}
+		return nil
+	})
+	return contact, err
+}
+
+func decodeProtoLicenseChoice(data []byte) (cyclonedx.LicenseChoice, error) {
+	var choice cyclonedx.LicenseChoice
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			license := &cyclonedx.License{}
+			choice.License = license
+			return walkProto(v.bytes, func(num protowire.Number, v protoValue) error {
+				switch num {
+				case 1:
+					license.ID = v.string()
+				case 2:
+					license.Name = v.string()
+				case 3:
+					license.Text = &cyclonedx.AttachedText{}
+					return walkProto(v.bytes, func(num protowire.Number, v protoValue) error {
+						switch num {
+						case 1:
+							license.Text.ContentType = v.string()
+						case 2:
+							license.Text.Encoding = v.string()
+						case 3:
+							license.Text.Content = v.string()
+						}
+						return nil
+					})
+				case 4:
+					license.URL = v.string()
+				case 5:
+					license.BOMRef = v.string()
+				}
+				return nil
+			})
+		case 2:
+			choice.Expression = v.string()
+		}
+		return nil
+	})
+	return choice, err
+}
+
+func decodeProtoHash(data []byte) (cyclonedx.Hash, error) {
+	var hash cyclonedx.Hash
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			hash.Algorithm = enumValue(protoHashAlgorithms, v.varint)
+		case 2:
+			hash.Value = v.string()
+		}
+		return nil
+	})
+	return hash, err
+}
+
+func decodeProtoExternalReference(data []byte) (cyclonedx.ExternalReference, error) {
+	ref := cyclonedx.ExternalReference{Type: cyclonedx.ERTypeOther}
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			ref.Type = enumValue(protoExternalReferenceTypes, v.varint)
+			if ref.Type == "" {
+				ref.Type = cyclonedx.ERTypeOther
+			}
+		case 2:
+			ref.URL = v.string()
+		case 3:
+			ref.Comment = v.string()
+		case 4:
+			hash, err := decodeProtoHash(v.bytes)
+			ref.Hashes = appendTo(ref.Hashes, hash)
+			return err
+		}
+		return nil
+	})
+	return ref, err
+}
+
+func decodeProtoDependency(data []byte) (cyclonedx.Dependency, error) {
+	var dep cyclonedx.Dependency
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			dep.Ref = v.string()
+		case 2:
+			child, err := decodeProtoDependency(v.bytes)
+			dep.Dependencies = appendTo(dep.Dependencies, child.Ref)
+			return err
+		}
+		return nil
+	})
+	return dep, err
+}
+
+func decodeProtoProperty(data []byte) (cyclonedx.Property, error) {
+	var property cyclonedx.Property
+	err := walkProto(data, func(num protowire.Number, v protoValue) error {
+		switch num {
+		case 1:
+			property.Name = v.string()
+		case 2:
+			property.Value = v.string()
+		}
+		return nil
+	})
+	return property, err
+}
+
+// protoValue is the value of a field. Depending on the wire type varint,
+// fixed64 or bytes is set, fields with an unexpected wire type read as empty.
+type protoValue struct {
+	typ     protowire.Type
+	varint  uint64
+	fixed64 uint64
+	bytes   []byte
+}
+
+func (v protoValue) string() string {
+	return string(v.bytes)
+}
+
+// varints returns the values of a repeated varint field, which is either
+// packed into bytes or one of several varint fields
+func (v protoValue) varints() ([]uint64, error) {
+	if v.typ == protowire.VarintType {
+		return []uint64{v.varint}, nil
+	}
+	var values []uint64
+	for data := v.bytes; len(data) > 0; {
+		value, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return values, protowire.ParseError(n)
+		}
+		values = append(values, value)
+		data = data[n:]
+	}
+	return values, nil
+}
+
+// walkProto calls fn for every varint, fixed64 and length-delimited field of a message
+func walkProto(data []byte, fn func(num protowire.Number, v protoValue) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		v := protoValue{typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			v.varint, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			v.fixed64, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			v.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if typ == protowire.VarintType || typ == protowire.Fixed64Type || typ == protowire.BytesType {
+			if err := fn(num, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// enumValue returns the enum value with the given number, or the zero value if it is unknown
+func enumValue[T any](values []T, number uint64) T {
+	var zero T
+	if number >= uint64(len(values)) || number > math.MaxInt32 {
+		return zero
+	}
+	return values[number]
+}
@@ -0,0 +1,232 @@
+package sbom
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	modified := false
+	bom := cyclonedx.NewBOM()
+	bom.SerialNumber = "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"
+	bom.Version = 2
+	bom.Metadata = &cyclonedx.Metadata{
+		Timestamp: "2023-01-02T12:00:00Z",
+		Tools: &cyclonedx.ToolsChoice{
+			Components: &[]cyclonedx.Component{{Type: cyclonedx.ComponentTypeApplication, Name: "sbomctl", Version: "0.1.0"}},
+		},
+		Component: &cyclonedx.Component{BOMRef: "app", Type: cyclonedx.ComponentTypeApplication, Name: "app"},
+		Supplier:  &cyclonedx.OrganizationalEntity{Name: "ACME", URL: &[]string{"https://acme.example"}},
+	}
+	bom.Components = &[]cyclonedx.Component{
+		{
+			BOMRef:     "pkg:npm/lodash@4.17.21",
+			Type:       cyclonedx.ComponentTypeLibrary,
+			Name:       "lodash",
+			Version:    "4.17.21",
+			Scope:      cyclonedx.ScopeRequired,
+			PackageURL: "pkg:npm/lodash@4.17.21",
+			Modified:   &modified,
+			Hashes:     &[]cyclonedx.Hash{{Algorithm: cyclonedx.HashAlgoSHA256, Value: "abcd"}},
+			Licenses: &cyclonedx.Licenses{
+				{License: &cyclonedx.License{ID: "MIT"}},
+				{Expression: "MIT OR Apache-2.0"},
+			},
+			ExternalReferences: &[]cyclonedx.ExternalReference{{Type: cyclonedx.ERTypeWebsite, URL: "https://lodash.com/"}},
+			Properties:         &[]cyclonedx.Property{{Name: "foo", Value: "bar"}},
+			Components:         &[]cyclonedx.Component{{Type: cyclonedx.ComponentTypeFile, Name: "lodash.js"}},
+		},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"pkg:npm/lodash@4.17.21"}},
+	}
+
+	data, err := EncodeSBOM(bom, FileFormatProto)
+	if err != nil {
+		t.Fatalf("Failed to encode BOM: %v", err)
+	}
+	if format := DetectFileFormat(data); format != FileFormatProto {
+		t.Errorf("Expected encoded BOM to be detected as proto, got %s", format)
+	}
+
+	decoded, err := DecodeSBOM(data, FileFormatProto)
+	if err != nil {
+		t.Fatalf("Failed to decode BOM: %v", err)
+	}
+
+	// The JSON schema reference and XML namespace are not part of the protobuf format
+	decoded.JSONSchema = bom.JSONSchema
+	decoded.XMLNS = bom.XMLNS
+	if !reflect.DeepEqual(bom, decoded) {
+		t.Errorf("Expected protobuf round trip to keep the BOM.\nExpected: %+v\nGot: %+v", bom, decoded)
+	}
+}
+
+func TestProtoRoundTripServicesAndVulnerabilities(t *testing.T) {
+	authenticated := true
+	crossesTrustBoundary := false
+	score := 9.8
+	zero := 0.0
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{
+		Tools: &cyclonedx.ToolsChoice{
+			Services: &[]cyclonedx.Service{{Name: "scanner", Endpoints: &[]string{"https://scanner.example/api"}}},
+		},
+	}
+	bom.Services = &[]cyclonedx.Service{
+		{
+			BOMRef:               "api",
+			Provider:             &cyclonedx.OrganizationalEntity{Name: "ACME"},
+			Group:                "acme",
+			Name:                 "api",
+			Version:              "1.0",
+			Description:          "The API",
+			Endpoints:            &[]string{"https://api.example/v1", "https://api.example/v2"},
+			Authenticated:        &authenticated,
+			CrossesTrustBoundary: &crossesTrustBoundary,
+			Data: &[]cyclonedx.DataClassification{
+				{Flow: cyclonedx.DataFlowInbound, Classification: "PII"},
+				{Flow: cyclonedx.DataFlowBidirectional, Classification: "public"},
+			},
+			Licenses:           &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}},
+			ExternalReferences: &[]cyclonedx.ExternalReference{{Type: cyclonedx.ERTypeDocumentation, URL: "https://api.example/docs"}},
+			Properties:         &[]cyclonedx.Property{{Name: "foo", Value: "bar"}},
+			Services:           &[]cyclonedx.Service{{BOMRef: "api-auth", Name: "auth"}},
+		},
+	}
+	bom.Vulnerabilities = &[]cyclonedx.Vulnerability{
+		{
+			BOMRef: "vuln-1",
+			ID:     "CVE-2021-23337",
+			Source: &cyclonedx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/CVE-2021-23337"},
+			References: &[]cyclonedx.VulnerabilityReference{
+				{ID: "GHSA-35jh-r3h4-6jhm", Source: &cyclonedx.Source{Name: "GitHub"}},
+			},
+			Ratings: &[]cyclonedx.VulnerabilityRating{
+				{Source: &cyclonedx.Source{Name: "NVD"}, Score: &score, Severity: cyclonedx.SeverityCritical, Method: cyclonedx.ScoringMethodCVSSv31, Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+				// The first value of the Severity enum and a zero score are kept
+				{Score: &zero, Severity: cyclonedx.SeverityUnknown, Justification: "not scored"},
+			},
+			CWEs:           &[]int{77, 94},
+			Description:    "Command injection",
+			Detail:         "Via the template function",
+			Recommendation: "Upgrade to 4.17.21",
+			Workaround:     "Do not pass untrusted input",
+			Advisories:     &[]cyclonedx.Advisory{{Title: "GHSA", URL: "https://github.com/advisories/GHSA-35jh-r3h4-6jhm"}},
+			Created:        "2021-02-15T13:15:00Z",
+			Published:      "2021-02-15T13:15:12.5Z",
+			Updated:        "2022-09-13T21:25:00Z",
+			Rejected:       "2023-01-01T00:00:00Z",
+			Credits: &cyclonedx.Credits{
+				Organizations: &[]cyclonedx.OrganizationalEntity{{Name: "Snyk"}},
+				Individuals:   &[]cyclonedx.OrganizationalContact{{Name: "Jane Doe", Email: "jane@example.com"}},
+			},
+			Tools: &cyclonedx.ToolsChoice{
+				Components: &[]cyclonedx.Component{{Type: cyclonedx.ComponentTypeApplication, Name: "sbomctl"}},
+			},
+			Analysis: &cyclonedx.VulnerabilityAnalysis{
+				State:         cyclonedx.IASNotAffected,
+				Justification: cyclonedx.IAJCodeNotReachable,
+				Response:      &[]cyclonedx.ImpactAnalysisResponse{cyclonedx.IARWillNotFix, cyclonedx.IARUpdate},
+				Detail:        "The template function is not used",
+				FirstIssued:   "2023-01-02T12:00:00Z",
+				LastUpdated:   "2023-01-03T12:00:00Z",
+			},
+			Affects: &[]cyclonedx.Affects{
+				{Ref: "pkg:npm/lodash@4.17.20", Range: &[]cyclonedx.AffectedVersions{
+					{Version: "4.17.20", Status: cyclonedx.VulnerabilityStatusAffected},
+					{Range: "vers:npm/>=4.17.21", Status: cyclonedx.VulnerabilityStatusUnknown},
+				}},
+			},
+			Properties: &[]cyclonedx.Property{{Name: "foo", Value: "bar"}},
+		},
+	}
+
+	data, err := EncodeSBOM(bom, FileFormatProto)
+	if err != nil {
+		t.Fatalf("Failed to encode BOM: %v", err)
+	}
+	decoded, err := DecodeSBOM(data, FileFormatProto)
+	if err != nil {
+		t.Fatalf("Failed to decode BOM: %v", err)
+	}
+
+	decoded.JSONSchema = bom.JSONSchema
+	decoded.XMLNS = bom.XMLNS
+	if !reflect.DeepEqual(bom.Services, decoded.Services) {
+		t.Errorf("Expected protobuf round trip to keep the services.\nExpected: %+v\nGot: %+v", *bom.Services, decoded.Services)
+	}
+	if !reflect.DeepEqual(bom.Vulnerabilities, decoded.Vulnerabilities) {
+		t.Errorf("Expected protobuf round trip to keep the vulnerabilities.\nExpected: %+v\nGot: %+v", *bom.Vulnerabilities, decoded.Vulnerabilities)
+	}
+	if !reflect.DeepEqual(bom, decoded) {
+		t.Errorf("Expected protobuf round trip to keep the BOM.\nExpected: %+v\nGot: %+v", bom, decoded)
+	}
+}
+
+func TestDecodeProtoUnpackedCWEs(t *testing.T) {
+	var vuln []byte
+	vuln = appendProtoString(vuln, 2, "CVE-2021-23337")
+	vuln = appendProtoOptional(vuln, 6, 77)
+	vuln = appendProtoOptional(vuln, 6, 94)
+	var data []byte
+	data = appendProtoString(data, 1, "1.6")
+	data = appendProtoMessage(data, 10, vuln)
+
+	bom, err := DecodeSBOM(data, FileFormatProto)
+	if err != nil {
+		t.Fatalf("Failed to decode BOM: %v", err)
+	}
+	if bom.Vulnerabilities == nil || !reflect.DeepEqual((*bom.Vulnerabilities)[0].CWEs, &[]int{77, 94}) {
+		t.Errorf("Expected CWEs 77 and 94, got %+v", bom.Vulnerabilities)
+	}
+}
+
+func TestProtoUnsupportedFields(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Compositions = &[]cyclonedx.Composition{{Aggregate: cyclonedx.CompositionAggregateComplete}}
+	bom.Components = &[]cyclonedx.Component{{Name: "foo", Pedigree: &cyclonedx.Pedigree{}}}
+	bom.Vulnerabilities = &[]cyclonedx.Vulnerability{{ID: "CVE-2021-23337", ProofOfConcept: &cyclonedx.ProofOfConcept{}}}
+
+	_, err := EncodeSBOM(bom, FileFormatProto)
+	if err == nil {
+		t.Fatal("Expected error for a BOM with fields the protobuf format does not support")
+	}
+	for _, field := range []string{"compositions", "components[].pedigree", "vulnerabilities[].proofOfConcept"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected error to mention %s, got: %v", field, err)
+		}
+	}
+}
+
+func TestDecodeProtoRejectsGarbage(t *testing.T) {
+	if _, err := DecodeSBOM([]byte("not an SBOM"), FileFormatProto); err == nil {
+		t.Error("Expected error for data that is not a CycloneDX protobuf document")
+	}
+}
+
+func TestMergeSBOMsProtoOutput(t *testing.T) {
+	testdataDir := filepath.Join("..", "..", "testdata")
+	outputPath := filepath.Join(t.TempDir(), "merged.cdx.bin")
+
+	_, err := MergeSBOMsWithOptions(
+		[]string{filepath.Join(testdataDir, "sbom1.json"), filepath.Join(testdataDir, "sbom2.json")},
+		outputPath,
+		MergeOptions{OutputFormat: FileFormatProto},
+	)
+	if err != nil {
+		t.Fatalf("Failed to merge SBOMs: %v", err)
+	}
+
+	mergedBom, err := ReadSBOMFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read merged protobuf SBOM: %v", err)
+	}
+	if mergedBom.Components == nil || len(*mergedBom.Components) != 4 {
+		t.Errorf("Expected 4 components in the merged SBOM, got %+v", mergedBom.Components)
+	}
+}
@@ -0,0 +1,224 @@
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// PruneOptions select the components PruneBOM removes. A component is
+// removed if it matches any of the criteria.
+type PruneOptions struct {
+	// Scopes of the components to remove, a missing scope counts as required
+	Scopes []cyclonedx.Scope
+	Types  []cyclonedx.ComponentType
+	// PurlPatterns are glob patterns for package URLs, where * matches any
+	// number of characters including slashes and ? a single character, like
+	// "pkg:npm/@types/*"
+	PurlPatterns []string
+	// Where is a query for the components to remove
+	Where *Query
+	// Unreachable removes components that cannot be reached from the metadata
+	// component through the dependencies, without passing through components
+	// removed by the other criteria. Nested components are reachable if their
+	// parent is. Components without bom-ref are not part of the dependency
+	// graph and only removed by the other criteria.
+	Unreachable bool
+	// Splice connects the dependents of removed components to their
+	// dependencies, so transitive relationships are preserved
+	Splice bool
+}
+
+// PruneReport describes what PruneBOM removed
+type PruneReport struct {
+	// Removed are the package URLs or name@version of the removed components
+	Removed []string `json:"removed"`
+	// DroppedEdges is the number of dependency edges that were dropped
+	// because their source or target no longer exists
+	DroppedEdges int `json:"droppedEdges"`
+	// SplicedEdges is the number of edges added through removed components
+	SplicedEdges int `json:"splicedEdges"`
+}
+
+// PruneBOM returns a copy of the BOM without the components selected by the
+// options. Nested components are removed together with their parent. The
+// dependencies are kept consistent: entries of and edges to refs that do not
+// exist in the pruned BOM are dropped, or spliced through removed components
+// if requested. See SubsetBOM for how the other sections are handled.
+func PruneBOM(bom *cyclonedx.BOM, opts PruneOptions) (*cyclonedx.BOM, *PruneReport, error) {
+	query, err := opts.query()
+	if err != nil {
+		return nil, nil, err
+	}
+	g := NewGraph(bom)
+	matches := func(c *cyclonedx.Component) bool {
+		return query.eval(c, g)
+	}
+
+	unreachable := make(map[string]bool)
+	if opts.Unreachable {
+		if bom.Metadata == nil || bom.Metadata.Component == nil || bom.Metadata.Component.BOMRef == "" {
+			return nil, nil, fmt.Errorf("removing unreachable components needs a metadata component with bom-ref")
+		}
+		unreachable = unreachableRefs(bom, g, matches)
+	}
+
+	report := &PruneReport{Removed: []string{}}
+	keep := func(c *cyclonedx.Component) bool {
+		if matches(c) || unreachable[c.BOMRef] {
+			report.Removed = append(report.Removed, componentLabel(c))
+			walkComponents(c.Components, func(nested *cyclonedx.Component) {
+				report.Removed = append(report.Removed, componentLabel(nested))
+			})
+			return false
+		}
+		return true
+	}
+	pruned, removed := subsetBOM(bom, keep, false)
+
+	// Everything else that is not known in the pruned BOM is a dangling ref
+	known := make(map[string]bool)
+	index := func(c *cyclonedx.Component) { known[c.BOMRef] = true }
+	if pruned.Metadata != nil && pruned.Metadata.Component != nil {
+		index(pruned.Metadata.Component)
+		walkComponents(pruned.Metadata.Component.Components, index)
+	}
+	walkComponents(pruned.Components, index)
+	walkServices(pruned.Services, func(s *cyclonedx.Service) { known[s.BOMRef] = true })
+
+	through := make(map[string]bool)
+	if opts.Splice {
+		through = removed
+	}
+	if bom.Dependencies != nil {
+		dependencies := []cyclonedx.Dependency{}
+		seen := make(map[string]bool)
+		for _, dep := range *bom.Dependencies {
+			ref := dep.Ref
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			if !known[ref] {
+				report.DroppedEdges += len(g.DependsOn(ref))
+				continue
+			}
+
+			dependsOn := g.keptTargets(ref, known, through)
+			for _, target := range g.DependsOn(ref) {
+				if !known[target] {
+					report.DroppedEdges++
+				}
+			}
+			for _, target := range dependsOn {
+				if !slices.Contains(g.DependsOn(ref), target) {
+					report.SplicedEdges++
+				}
+			}
+			dependencies = append(dependencies, cyclonedx.Dependency{Ref: ref, Dependencies: &dependsOn})
+		}
+		pruned.Dependencies = &dependencies
+	}
+
+	return pruned, report, nil
+}
+
+// unreachableRefs returns the bom-refs of the components that cannot be
+// reached from the metadata component without passing through components
+// that are removed anyway
+func unreachableRefs(bom *cyclonedx.BOM, g *Graph, removed func(*cyclonedx.Component) bool) map[string]bool {
+	reachable := make(map[string]bool)
+	var reach func(ref string)
+	reach = func(ref string) {
+		if reachable[ref] {
+			return
+		}
+		c := g.Component(ref)
+		if c != nil && removed(c) {
+			return
+		}
+		reachable[ref] = true
+		for _, target := range g.DependsOn(ref) {
+			reach(target)
+		}
+		// The dependencies of nested components are part of their parent's
+		if c != nil {
+			walkComponents(c.Components, func(nested *cyclonedx.Component) {
+				if nested.BOMRef != "" {
+					reach(nested.BOMRef)
+				}
+			})
+		}
+	}
+	reach(bom.Metadata.Component.BOMRef)
+
+	unreachable := make(map[string]bool)
+	var mark func(components *[]cyclonedx.Component, parentReachable bool)
+	mark = func(components *[]cyclonedx.Component, parentReachable bool) {
+		if components == nil {
+			return
+		}
+		for i := range *components {
+			c := &(*components)[i]
+			isReachable := parentReachable || reachable[c.BOMRef]
+			if !isReachable && c.BOMRef != "" {
+				unreachable[c.BOMRef] = true
+			}
+			mark(c.Components, isReachable)
+		}
+	}
+	mark(bom.Components, false)
+	return unreachable
+}
+
+// query returns the criteria of the options as a query node, so they are
+// evaluated like the expressions of the query command
+func (opts PruneOptions) query() (queryNode, error) {
+	var nodes queryOr
+	if len(opts.Scopes) > 0 {
+		nodes = append(nodes, queryIn("scope", opts.Scopes))
+	}
+	if len(opts.Types) > 0 {
+		nodes = append(nodes, queryIn("type", opts.Types))
+	}
+	for _, pattern := range opts.PurlPatterns {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		// A pattern like * must not match components without package URL
+		nodes = append(nodes, queryAnd{
+			queryNot{queryEmpty{field: queryFields["purl"]}},
+			queryComparison{field: queryFields["purl"], operator: "=~", regex: re},
+		})
+	}
+	if opts.Where != nil {
+		nodes = append(nodes, opts.Where.root)
+	}
+	return nodes, nil
+}
+
+// globToRegexp compiles a glob pattern in which * matches any number of
+// characters and ? matches a single character
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty package URL pattern")
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,131 @@
+package sbom
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func pruneTestBOM() *cyclonedx.BOM {
+	bom := queryTestBOM()
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root", Name: "root"}}
+	*bom.Components = append(*bom.Components, cyclonedx.Component{
+		BOMRef: "mocha", Name: "mocha", Version: "10.0.0", Type: cyclonedx.ComponentTypeLibrary,
+		PackageURL: "pkg:npm/mocha@10.0.0",
+	})
+	*bom.Dependencies = append(*bom.Dependencies,
+		cyclonedx.Dependency{Ref: "root", Dependencies: &[]string{"app", "testify"}},
+		cyclonedx.Dependency{Ref: "testify", Dependencies: &[]string{"mocha"}},
+	)
+	return bom
+}
+
+func pruneTestDependencies(t *testing.T, bom *cyclonedx.BOM, expected map[string][]string) {
+	t.Helper()
+	if len(*bom.Dependencies) != len(expected) {
+		t.Errorf("Unexpected dependencies: %+v", *bom.Dependencies)
+	}
+	for _, dep := range *bom.Dependencies {
+		if deps, ok := expected[dep.Ref]; !ok || !slices.Equal(*dep.Dependencies, deps) {
+			t.Errorf("Unexpected dependencies of %s: %v", dep.Ref, *dep.Dependencies)
+		}
+	}
+}
+
+func TestPruneBOM(t *testing.T) {
+	bom := pruneTestBOM()
+
+	pruned, report, err := PruneBOM(bom, PruneOptions{
+		Scopes: []cyclonedx.Scope{cyclonedx.ScopeExcluded},
+		Types:  []cyclonedx.ComponentType{cyclonedx.ComponentTypeApplication},
+	})
+	if err != nil {
+		t.Fatalf("PruneBOM failed: %v", err)
+	}
+
+	// plugin is removed together with app
+	if refs := componentRefs(*pruned.Components); !slices.Equal(refs, []string{"lodash", "uuid", "mocha"}) {
+		t.Errorf("Unexpected components: %v", refs)
+	}
+	if !slices.Equal(report.Removed, []string{"app@1.0.0", "plugin@0.1.0", "pkg:golang/github.com/stretchr/testify@1.10.0"}) {
+		t.Errorf("Unexpected removed components: %v", report.Removed)
+	}
+	pruneTestDependencies(t, pruned, map[string][]string{
+		"uuid": {"lodash"},
+		"root": {},
+	})
+	if report.DroppedEdges != 5 || report.SplicedEdges != 0 {
+		t.Errorf("Expected 5 dropped and no spliced edges, got %+v", report)
+	}
+	if len(*bom.Components) != 5 || len(*bom.Dependencies) != 4 {
+		t.Error("Expected the original BOM to be unchanged")
+	}
+	if pruned.SerialNumber == bom.SerialNumber {
+		t.Error("Expected a new serial number")
+	}
+}
+
+func TestPruneBOMSplice(t *testing.T) {
+	where, err := CompileQuery("name == app")
+	if err != nil {
+		t.Fatalf("CompileQuery failed: %v", err)
+	}
+
+	pruned, report, err := PruneBOM(pruneTestBOM(), PruneOptions{Where: where, Splice: true})
+	if err != nil {
+		t.Fatalf("PruneBOM failed: %v", err)
+	}
+
+	pruneTestDependencies(t, pruned, map[string][]string{
+		"uuid":    {"lodash"},
+		"root":    {"lodash", "uuid", "testify"},
+		"testify": {"mocha"},
+	})
+	if report.DroppedEdges != 3 || report.SplicedEdges != 2 {
+		t.Errorf("Expected 3 dropped and 2 spliced edges, got %+v", report)
+	}
+}
+
+func TestPruneBOMPurlPatterns(t *testing.T) {
+	pruned, report, err := PruneBOM(pruneTestBOM(), PruneOptions{PurlPatterns: []string{"pkg:golang/github.com/*", "pkg:npm/mocha@1?.*"}})
+	if err != nil {
+		t.Fatalf("PruneBOM failed: %v", err)
+	}
+	if refs := componentRefs(*pruned.Components); !slices.Equal(refs, []string{"app", "lodash"}) {
+		t.Errorf("Unexpected components: %v", refs)
+	}
+	if len(report.Removed) != 3 {
+		t.Errorf("Unexpected removed components: %v", report.Removed)
+	}
+
+	if _, _, err := PruneBOM(pruneTestBOM(), PruneOptions{PurlPatterns: []string{""}}); err == nil {
+		t.Error("Expected an error for an empty pattern")
+	}
+}
+
+func TestPruneBOMUnreachable(t *testing.T) {
+	// mocha is only pulled in by the excluded testify
+	pruned, report, err := PruneBOM(pruneTestBOM(), PruneOptions{
+		Scopes:      []cyclonedx.Scope{cyclonedx.ScopeExcluded},
+		Unreachable: true,
+	})
+	if err != nil {
+		t.Fatalf("PruneBOM failed: %v", err)
+	}
+	if refs := componentRefs(*pruned.Components); !slices.Equal(refs, []string{"app", "lodash", "uuid"}) {
+		t.Errorf("Unexpected components: %v", refs)
+	}
+	if refs := componentRefs(*(*pruned.Components)[0].Components); !slices.Equal(refs, []string{"plugin"}) {
+		t.Errorf("Expected the nested plugin to be reachable through app, got %v", refs)
+	}
+	if !slices.Equal(report.Removed, []string{"pkg:golang/github.com/stretchr/testify@1.10.0", "pkg:npm/mocha@10.0.0"}) {
+		t.Errorf("Unexpected removed components: %v", report.Removed)
+	}
+
+	bom := pruneTestBOM()
+	bom.Metadata = nil
+	if _, _, err := PruneBOM(bom, PruneOptions{Unreachable: true}); err == nil {
+		t.Error("Expected an error without metadata component")
+	}
+}
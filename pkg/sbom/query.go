@@ -0,0 +1,539 @@
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+)
+
+// Query is a compiled filter expression over components, like
+//
+//	purl.type == golang and type == library and empty(hashes) and empty(supplier)
+//
+// Comparisons have the form "field operator value" with the operators ==,
+// !=, =~ and !~ (regular expressions), <, <=, > and >= (numbers and versions)
+// and "in [value, ...]". Values are quoted strings or bare words. They can
+// be combined with and, or, not and parentheses. empty(field) and has(field)
+// test whether a field has a value, len(field) compares the number of
+// values. Fields with several values, like licenses, match a comparison if
+// any of their values does, != and !~ if none does. See QueryFields for the
+// available fields.
+type Query struct {
+	expression string
+	root       queryNode
+}
+
+// queryField returns the values of a field of a component. The graph gives
+// access to the dependencies and is nil if they are not known.
+type queryField func(c *cyclonedx.Component, g *Graph) []string
+
+// queryFields are the fields of a query, except the properties.<name> fields
+var queryFields = map[string]queryField{
+	"name":        func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.Name} },
+	"version":     func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.Version} },
+	"type":        func(c *cyclonedx.Component, _ *Graph) []string { return []string{string(c.Type)} },
+	"group":       func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.Group} },
+	"description": func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.Description} },
+	"publisher":   func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.Publisher} },
+	"author":      func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.Author} },
+	"supplier":    func(c *cyclonedx.Component, _ *Graph) []string { return []string{supplierName(c)} },
+	"cpe":         func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.CPE} },
+	"bom-ref":     func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.BOMRef} },
+	"scope": func(c *cyclonedx.Component, _ *Graph) []string {
+		if c.Scope == "" {
+			return []string{string(cyclonedx.ScopeRequired)}
+		}
+		return []string{string(c.Scope)}
+	},
+	"purl":           func(c *cyclonedx.Component, _ *Graph) []string { return []string{c.PackageURL} },
+	"purl.type":      purlField(func(p packageurl.PackageURL) string { return p.Type }),
+	"purl.namespace": purlField(func(p packageurl.PackageURL) string { return p.Namespace }),
+	"purl.name":      purlField(func(p packageurl.PackageURL) string { return p.Name }),
+	"licenses": func(c *cyclonedx.Component, _ *Graph) []string {
+		e, err := ComponentLicenseExpression(c)
+		if err != nil || e == nil {
+			return nil
+		}
+		return e.Licenses()
+	},
+	"hashes": func(c *cyclonedx.Component, _ *Graph) []string {
+		var algorithms []string
+		if c.Hashes != nil {
+			for _, hash := range *c.Hashes {
+				algorithms = append(algorithms, string(hash.Algorithm))
+			}
+		}
+		return algorithms
+	},
+	"dependencies": func(c *cyclonedx.Component, g *Graph) []string {
+		if g == nil {
+			return nil
+		}
+		return graphLabels(g, g.DependsOn(c.BOMRef))
+	},
+	"dependents": func(c *cyclonedx.Component, g *Graph) []string {
+		if g == nil {
+			return nil
+		}
+		return graphLabels(g, g.Dependents(c.BOMRef))
+	},
+}
+
+// QueryFields returns the names of the fields a query can use
+func QueryFields() []string {
+	fields := make([]string, 0, len(queryFields)+1)
+	for name := range queryFields {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return append(fields, "properties.<name>")
+}
+
+// purlField returns a field of the parsed package URL of a component
+func purlField(get func(packageurl.PackageURL) string) queryField {
+	return func(c *cyclonedx.Component, _ *Graph) []string {
+		purl, err := packageurl.FromString(c.PackageURL)
+		if err != nil {
+			return []string{""}
+		}
+		return []string{get(purl)}
+	}
+}
+
+// propertyField returns the values of all properties with the given name
+func propertyField(name string) queryField {
+	return func(c *cyclonedx.Component, _ *Graph) []string {
+		var values []string
+		if c.Properties != nil {
+			for _, property := range *c.Properties {
+				if property.Name == name {
+					values = append(values, property.Value)
+				}
+			}
+		}
+		return values
+	}
+}
+
+// graphLabels returns the labels of graph nodes
+func graphLabels(g *Graph, refs []string) []string {
+	labels := make([]string, len(refs))
+	for i, ref := range refs {
+		labels[i] = g.Label(ref)
+	}
+	return labels
+}
+
+// queryNode is a node of a compiled query
+type queryNode interface {
+	eval(c *cyclonedx.Component, g *Graph) bool
+}
+
+type queryAnd []queryNode
+
+func (q queryAnd) eval(c *cyclonedx.Component, g *Graph) bool {
+	for _, node := range q {
+		if !node.eval(c, g) {
+			return false
+		}
+	}
+	return true
+}
+
+type queryOr []queryNode
+
+func (q queryOr) eval(c *cyclonedx.Component, g *Graph) bool {
+	for _, node := range q {
+		if node.eval(c, g) {
+			return true
+		}
+	}
+	return false
+}
+
+type queryNot struct{ node queryNode }
+
+func (q queryNot) eval(c *cyclonedx.Component, g *Graph) bool {
+	return !q.node.eval(c, g)
+}
+
+// queryEmpty is empty(field), has(field) is its negation
+type queryEmpty struct {
+	field queryField
+}
+
+func (q queryEmpty) eval(c *cyclonedx.Component, g *Graph) bool {
+	for _, value := range q.field(c, g) {
+		if value != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// queryIn returns a node matching components whose field has one of the
+// values, like "field in [values]"
+func queryIn[T ~string](field string, values []T) queryNode {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = string(value)
+	}
+	return queryComparison{field: queryFields[field], operator: "in", values: strs}
+}
+
+// queryComparison compares the values of a field, or their number if count
+// is set
+type queryComparison struct {
+	field    queryField
+	count    bool
+	operator string
+	values   []string
+	regex    *regexp.Regexp
+}
+
+func (q queryComparison) eval(c *cyclonedx.Component, g *Graph) bool {
+	values := q.field(c, g)
+	if q.count {
+		values = []string{strconv.Itoa(len(values))}
+	}
+
+	// != and !~ are true if no value is equal or matches
+	switch q.operator {
+	case "!=":
+		return !slices.ContainsFunc(values, func(v string) bool { return slices.Contains(q.values, v) })
+	case "!~":
+		return !slices.ContainsFunc(values, q.regex.MatchString)
+	}
+	return slices.ContainsFunc(values, func(v string) bool {
+		switch q.operator {
+		case "==", "in":
+			return slices.Contains(q.values, v)
+		case "=~":
+			return q.regex.MatchString(v)
+		}
+		if v == "" {
+			return false
+		}
+		c := compareVersions(v, q.values[0])
+		switch q.operator {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		}
+		return c >= 0
+	})
+}
+
+// CompileQuery parses a query expression, see Query for the syntax
+func CompileQuery(expression string) (*Query, error) {
+	tokens, err := tokenizeQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %s", p.tokens[p.pos])
+	}
+	return &Query{expression: expression, root: root}, nil
+}
+
+// String returns the expression the query was compiled from
+func (q *Query) String() string {
+	return q.expression
+}
+
+// Match returns whether a component matches the query. The graph of the
+// component's BOM is needed for the dependencies and dependents fields,
+// which are empty if it is nil.
+func (q *Query) Match(c *cyclonedx.Component, g *Graph) bool {
+	return q.root.eval(c, g)
+}
+
+// queryToken is a token of a query expression
+type queryToken struct {
+	text string
+	// quoted is set for string literals, which are never keywords
+	quoted bool
+	pos    int
+}
+
+func (t queryToken) String() string {
+	if t.quoted {
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q at position %d", t.text, t.pos+1)
+}
+
+// queryOperators are the comparison operators besides "in"
+var queryOperators = []string{"==", "!=", "=~", "!~", "<", "<=", ">", ">="}
+
+// tokenizeQuery splits a query into operators, parentheses, brackets,
+// commas, quoted strings and words
+func tokenizeQuery(expression string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("()[],", r):
+			tokens = append(tokens, queryToken{text: string(r), pos: i})
+			i++
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, queryToken{text: b.String(), quoted: true, pos: i})
+			i = j + 1
+		case strings.ContainsRune("=!<>", r):
+			j := i + 1
+			if j < len(runes) && strings.ContainsRune("=~", runes[j]) {
+				j++
+			}
+			operator := string(runes[i:j])
+			if !slices.Contains(queryOperators, operator) {
+				return nil, fmt.Errorf("unknown operator %q at position %d", operator, i+1)
+			}
+			tokens = append(tokens, queryToken{text: operator, pos: i})
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()[],\"'=!<>", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i:j]), pos: i})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// queryParser is a recursive descent parser for query expressions
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid query: "+format, args...)
+}
+
+// peek reports whether the next token is the given keyword or symbol
+func (p *queryParser) peek(text string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, text)
+}
+
+// accept consumes the next token if it is the given keyword or symbol
+func (p *queryParser) accept(text string) bool {
+	if p.peek(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// next consumes the next token, describing what was expected if there is none
+func (p *queryParser) next(expected string) (queryToken, error) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, p.errorf("expected %s at the end", expected)
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *queryParser) expect(text string) error {
+	if p.accept(text) {
+		return nil
+	}
+	if p.pos >= len(p.tokens) {
+		return p.errorf("expected %q at the end", text)
+	}
+	return p.errorf("expected %q but got %s", text, p.tokens[p.pos])
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := queryOr{node}
+	for p.accept("or") {
+		if node, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		or = append(or, node)
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	node, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	and := queryAnd{node}
+	for p.accept("and") {
+		if node, err = p.parseNot(); err != nil {
+			return nil, err
+		}
+		and = append(and, node)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.accept("not") {
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{node}, nil
+	}
+	if p.accept("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expect(")")
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses empty(field), has(field) or a comparison
+func (p *queryParser) parseComparison() (queryNode, error) {
+	for _, function := range []string{"empty", "has"} {
+		if p.peek(function) && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(" {
+			p.pos += 2
+			field, err := p.parseFieldCall()
+			if err != nil {
+				return nil, err
+			}
+			if function == "has" {
+				return queryNot{queryEmpty{field}}, nil
+			}
+			return queryEmpty{field}, nil
+		}
+	}
+
+	comparison := queryComparison{}
+	var err error
+	if p.peek("len") && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(" {
+		p.pos += 2
+		comparison.count = true
+		comparison.field, err = p.parseFieldCall()
+	} else {
+		comparison.field, err = p.parseField()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	operator, err := p.next("an operator")
+	if err != nil {
+		return nil, err
+	}
+	comparison.operator = strings.ToLower(operator.text)
+	switch {
+	case operator.quoted:
+		return nil, p.errorf("expected an operator but got %s", operator)
+	case comparison.operator == "in":
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			comparison.values = append(comparison.values, value)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return comparison, nil
+	case !slices.Contains(queryOperators, comparison.operator):
+		return nil, p.errorf("expected an operator but got %s", operator)
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	comparison.values = []string{value}
+	if comparison.operator == "=~" || comparison.operator == "!~" {
+		if comparison.regex, err = regexp.Compile(value); err != nil {
+			return nil, p.errorf("invalid regular expression %q: %v", value, err)
+		}
+	}
+	return comparison, nil
+}
+
+// parseFieldCall parses the "field)" part of a function call
+func (p *queryParser) parseFieldCall() (queryField, error) {
+	field, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	return field, p.expect(")")
+}
+
+func (p *queryParser) parseField() (queryField, error) {
+	token, err := p.next("a field")
+	if err != nil {
+		return nil, err
+	}
+	name := strings.ToLower(token.text)
+	if field, ok := queryFields[name]; ok && !token.quoted {
+		return field, nil
+	}
+	if property, ok := strings.CutPrefix(token.text, "properties."); ok && property != "" && !token.quoted {
+		return propertyField(property), nil
+	}
+	return nil, p.errorf("unknown field %s, must be one of: %s", token, strings.Join(QueryFields(), ", "))
+}
+
+func (p *queryParser) parseValue() (string, error) {
+	token, err := p.next("a value")
+	if err != nil {
+		return "", err
+	}
+	if !token.quoted && (strings.Contains("()[],", token.text) || slices.Contains(queryOperators, token.text)) {
+		return "", p.errorf("expected a value but got %s", token)
+	}
+	return token.text, nil
+}
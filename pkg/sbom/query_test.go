@@ -0,0 +1,99 @@
+package sbom
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func queryTestBOM() *cyclonedx.BOM {
+	bom := componentsTestBOM()
+	components := *bom.Components
+	components[1].Properties = &[]cyclonedx.Property{{Name: "cdx:npm:package:development", Value: "true"}}
+	components[2].Supplier = &cyclonedx.OrganizationalEntity{Name: "Stretchr"}
+	components[3].Licenses = &cyclonedx.Licenses{{Expression: "BSD-3-Clause OR MIT"}}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"lodash", "uuid"}},
+		{Ref: "uuid", Dependencies: &[]string{"lodash"}},
+	}
+	return bom
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   []string
+	}{
+		{`name == lodash`, []string{"lodash"}},
+		{`name != lodash and type == library`, []string{"plugin", "testify", "uuid"}},
+		{`purl.type == golang and empty(hashes) and empty(supplier)`, []string{"uuid"}},
+		{`has(hashes)`, []string{"testify"}},
+		{`group =~ "^github\\.com/"`, []string{"testify", "uuid"}},
+		{`name !~ '^(app|plugin)$'`, []string{"lodash", "testify", "uuid"}},
+		{`version >= 1.9.0 and version < 4`, []string{"testify", "uuid"}},
+		{`TYPE IN [application, framework]`, []string{"app"}},
+		{`scope == required and purl.namespace == github.com/google`, []string{"uuid"}},
+		{`licenses == MIT`, []string{"lodash", "uuid"}},
+		{`licenses != MIT`, []string{"app", "plugin", "testify"}},
+		{`properties.cdx:npm:package:development == "true"`, []string{"lodash"}},
+		{`dependencies == pkg:npm/lodash@4.17.21`, []string{"app", "uuid"}},
+		{`len(dependents) >= 2`, []string{"lodash"}},
+		{`not (type == library or name == app)`, nil},
+		{`name == app or name == lodash and version == 1.0.0`, []string{"app"}},
+	}
+	for _, tt := range tests {
+		query, err := CompileQuery(tt.expression)
+		if err != nil {
+			t.Errorf("CompileQuery(%q) failed: %v", tt.expression, err)
+			continue
+		}
+		refs := componentRefs(FilterComponents(queryTestBOM(), ComponentFilter{Where: query}))
+		if !slices.Equal(refs, tt.expected) && !(len(refs) == 0 && len(tt.expected) == 0) {
+			t.Errorf("%s: expected %v, got %v", tt.expression, tt.expected, refs)
+		}
+	}
+}
+
+func TestQueryWithoutGraph(t *testing.T) {
+	query, err := CompileQuery("empty(dependencies)")
+	if err != nil {
+		t.Fatalf("CompileQuery failed: %v", err)
+	}
+	c := (*queryTestBOM().Components)[0]
+	if !query.Match(&c, nil) {
+		t.Error("Expected the dependencies to be empty without a graph")
+	}
+	if query.String() != "empty(dependencies)" {
+		t.Errorf("Unexpected String() %q", query.String())
+	}
+}
+
+func TestCompileQueryErrors(t *testing.T) {
+	tests := map[string]string{
+		"":                        "empty query",
+		"name":                    "expected an operator at the end",
+		"name ==":                 "expected a value at the end",
+		"size > 1":                `unknown field "size"`,
+		"name = lodash":           `unknown operator "="`,
+		`name == "lodash`:         "unterminated string",
+		"name =~ (":               "expected a value",
+		"name =~ '('":             "invalid regular expression",
+		"name == ==":              `expected a value but got "=="`,
+		"type in [library, <]":    `expected a value but got "<"`,
+		"(name == lodash":         `expected ")" at the end`,
+		"name == lodash version":  `unexpected "version"`,
+		"type in [library":        `expected "]" at the end`,
+		"empty(name":              `expected ")" at the end`,
+		"name lodash":             `expected an operator but got "lodash"`,
+		"properties. == x":        `unknown field "properties."`,
+		"name == a and or b == c": `unknown field "or"`,
+	}
+	for expression, expected := range tests {
+		_, err := CompileQuery(expression)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("CompileQuery(%q): expected error containing %q, got %v", expression, expected, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+)
+
+// reproducibleNamespace is the UUIDv5 namespace of serial numbers derived by sbomctl
+var reproducibleNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/j12934/sbomctl"))
+
+// reproducibleID returns a UUIDv5 derived from the content of the input files
+// and the merge options, so identical merges get identical IDs
+func reproducibleID(inputFiles []string, opts MergeOptions) (uuid.UUID, error) {
+	h := sha256.New()
+	for _, file := range inputFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to read SBOM file %s: %w", file, err)
+		}
+		// Length-prefix every input, so moving bytes between inputs changes the ID
+		fmt.Fprintf(h, "%d\n", len(data))
+		h.Write(data)
+	}
+
+	strategy := "prefix"
+	if opts.Strategy != nil {
+		strategy = opts.Strategy.Name()
+	}
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%s\n%d\n%s\n", opts.ComponentName, opts.ComponentVersion, opts.Integrity, opts.Precedence, strategy, opts.SpecVersion, opts.OutputFormat)
+
+	return uuid.NewSHA1(reproducibleNamespace, h.Sum(nil)), nil
+}
+
+// sourceDateEpoch returns the time set in the SOURCE_DATE_EPOCH environment
+// variable, or nil if it is not set
+func sourceDateEpoch() (*time.Time, error) {
+	value, ok := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if !ok || value == "" {
+		return nil, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", value, err)
+	}
+	t := time.Unix(seconds, 0).UTC()
+	return &t, nil
+}
+
+// sortBOM sorts the components, dependencies and tools of a BOM, so its
+// serialization does not depend on the order of the inputs' content
+func sortBOM(bom *cyclonedx.BOM) {
+	sortComponents(bom.Components)
+
+	if bom.Dependencies != nil {
+		for _, dep := range *bom.Dependencies {
+			if dep.Dependencies != nil {
+				sort.Strings(*dep.Dependencies)
+			}
+		}
+		sort.SliceStable(*bom.Dependencies, func(i, j int) bool {
+			return (*bom.Dependencies)[i].Ref < (*bom.Dependencies)[j].Ref
+		})
+	}
+
+	if bom.Metadata != nil && bom.Metadata.Tools != nil {
+		sortComponents(bom.Metadata.Tools.Components)
+		if bom.Metadata.Tools.Services != nil {
+			sort.SliceStable(*bom.Metadata.Tools.Services, func(i, j int) bool {
+				a, b := (*bom.Metadata.Tools.Services)[i], (*bom.Metadata.Tools.Services)[j]
+				return a.Name+"@"+a.Version < b.Name+"@"+b.Version
+			})
+		}
+	}
+}
+
+// sortComponents sorts components and their nested components by bom-ref,
+// then by group, name and version
+func sortComponents(components *[]cyclonedx.Component) {
+	if components == nil {
+		return
+	}
+	for i := range *components {
+		sortComponents((*components)[i].Components)
+	}
+	sort.SliceStable(*components, func(i, j int) bool {
+		a, b := (*components)[i], (*components)[j]
+		if a.BOMRef != b.BOMRef {
+			return a.BOMRef < b.BOMRef
+		}
+		return strings.Join([]string{a.Group, a.Name, a.Version}, "\x00") <
+			strings.Join([]string{b.Group, b.Name, b.Version}, "\x00")
+	})
+}
@@ -0,0 +1,138 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func mergeReproducible(t *testing.T, inputFiles []string, outputPath string) []byte {
+	_, err := MergeSBOMsWithOptions(inputFiles, outputPath, MergeOptions{Reproducible: true})
+	if err != nil {
+		t.Fatalf("Failed to merge SBOMs: %v", err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	return data
+}
+
+func TestMergeSBOMsReproducible(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	testdataDir := filepath.Join("..", "..", "testdata")
+	inputFiles := []string{filepath.Join(testdataDir, "sbom1.json"), filepath.Join(testdataDir, "sbom2.json")}
+	testDir := t.TempDir()
+
+	first := mergeReproducible(t, inputFiles, filepath.Join(testDir, "first.json"))
+	second := mergeReproducible(t, inputFiles, filepath.Join(testDir, "second.json"))
+	if !bytes.Equal(first, second) {
+		t.Fatal("Expected reproducible merges of the same inputs to be byte-identical")
+	}
+
+	mergedBom, err := ReadSBOMFile(filepath.Join(testDir, "first.json"))
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	if mergedBom.Metadata.Timestamp != "2023-11-14T22:13:20Z" {
+		t.Errorf("Expected timestamp from SOURCE_DATE_EPOCH, got %q", mergedBom.Metadata.Timestamp)
+	}
+
+	// Different inputs must result in a different serial number
+	reversed := mergeReproducible(t, []string{inputFiles[1], inputFiles[0]}, filepath.Join(testDir, "reversed.json"))
+	reversedBom, err := ReadSBOMFile(filepath.Join(testDir, "reversed.json"))
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	if bytes.Equal(first, reversed) || reversedBom.SerialNumber == mergedBom.SerialNumber {
+		t.Error("Expected a different serial number for a different order of inputs")
+	}
+}
+
+func TestMergeSBOMsReproducibleSPDX(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+
+	testdataDir := filepath.Join("..", "..", "testdata")
+	inputFiles := []string{filepath.Join(testdataDir, "sbom1.json"), filepath.Join(testdataDir, "sbom2.json")}
+	testDir := t.TempDir()
+
+	merge := func(outputPath string) []byte {
+		_, err := MergeSBOMsWithOptions(inputFiles, outputPath, MergeOptions{Reproducible: true, OutputFormat: FileFormatSPDXJSON})
+		if err != nil {
+			t.Fatalf("Failed to merge SBOMs: %v", err)
+		}
+		data, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read merged SBOM: %v", err)
+		}
+		return data
+	}
+	first := merge(filepath.Join(testDir, "first.spdx.json"))
+	second := merge(filepath.Join(testDir, "second.spdx.json"))
+	if !bytes.Equal(first, second) {
+		t.Fatal("Expected reproducible SPDX merges of the same inputs to be byte-identical")
+	}
+
+	var doc SPDXDocument
+	if err := json.Unmarshal(first, &doc); err != nil {
+		t.Fatalf("Failed to decode merged SPDX document: %v", err)
+	}
+	if doc.CreationInfo.Created != "1970-01-01T00:00:00Z" {
+		t.Errorf("Expected the Unix epoch as creation time without SOURCE_DATE_EPOCH, got %q", doc.CreationInfo.Created)
+	}
+}
+
+func TestMergeSBOMsReproducibleInvalidEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+
+	testdataDir := filepath.Join("..", "..", "testdata")
+	inputFiles := []string{filepath.Join(testdataDir, "sbom1.json"), filepath.Join(testdataDir, "sbom2.json")}
+
+	_, err := MergeSBOMsWithOptions(inputFiles, filepath.Join(t.TempDir(), "merged.json"), MergeOptions{Reproducible: true})
+	if err == nil {
+		t.Error("Expected error for an invalid SOURCE_DATE_EPOCH")
+	}
+}
+
+func TestSortBOM(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "b", Name: "b", Components: &[]cyclonedx.Component{{BOMRef: "b/2"}, {BOMRef: "b/1"}}},
+		{BOMRef: "a", Name: "a"},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "b", Dependencies: &[]string{"b/2", "b/1"}},
+		{Ref: "a"},
+	}
+
+	sortBOM(bom)
+
+	if (*bom.Components)[0].BOMRef != "a" || (*(*bom.Components)[1].Components)[0].BOMRef != "b/1" {
+		t.Errorf("Expected components to be sorted recursively, got %+v", *bom.Components)
+	}
+	if (*bom.Dependencies)[0].Ref != "a" || (*(*bom.Dependencies)[1].Dependencies)[0] != "b/1" {
+		t.Errorf("Expected dependencies and dependsOn to be sorted, got %+v", *bom.Dependencies)
+	}
+}
+
+func TestDeduplicateDependenciesKeepsOrder(t *testing.T) {
+	dependencies := []cyclonedx.Dependency{
+		{Ref: "c", Dependencies: &[]string{"a"}},
+		{Ref: "a"},
+		{Ref: "c", Dependencies: &[]string{"b"}},
+		{Ref: "b"},
+	}
+
+	unique := *deduplicateDependencies(&dependencies)
+	if len(unique) != 3 || unique[0].Ref != "c" || unique[1].Ref != "a" || unique[2].Ref != "b" {
+		t.Fatalf("Expected order of first occurrence, got %+v", unique)
+	}
+	if len(*unique[0].Dependencies) != 2 {
+		t.Errorf("Expected dependsOn of duplicates to be merged, got %v", *unique[0].Dependencies)
+	}
+}
@@ -0,0 +1,288 @@
+package sbom
+
+import (
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// mergeSections adds the services, vulnerabilities, compositions,
+// annotations, external references and formulation of an input SBOM to the
+// merged SBOM, rewriting every bom-ref with mapRef
+func mergeSections(merged, bom *cyclonedx.BOM, mapRef RefMapper) {
+	if bom.Services != nil {
+		for _, s := range *bom.Services {
+			merged.Services = appendTo(merged.Services, copyServiceWithRefs(s, mapRef))
+		}
+	}
+
+	if bom.Vulnerabilities != nil {
+		for _, v := range *bom.Vulnerabilities {
+			v.BOMRef = mapRef(v.BOMRef)
+			if v.Affects != nil {
+				affects := make([]cyclonedx.Affects, 0, len(*v.Affects))
+				for _, affected := range *v.Affects {
+					affected.Ref = mapRef(affected.Ref)
+					affects = append(affects, affected)
+				}
+				v.Affects = &affects
+			}
+			merged.Vulnerabilities = appendTo(merged.Vulnerabilities, v)
+		}
+	}
+
+	if bom.Compositions != nil {
+		for _, c := range *bom.Compositions {
+			c.BOMRef = mapRef(c.BOMRef)
+			c.Assemblies = mapBOMReferences(c.Assemblies, mapRef)
+			c.Dependencies = mapBOMReferences(c.Dependencies, mapRef)
+			c.Vulnerabilities = mapBOMReferences(c.Vulnerabilities, mapRef)
+			merged.Compositions = appendTo(merged.Compositions, c)
+		}
+	}
+
+	if bom.Annotations != nil {
+		for _, a := range *bom.Annotations {
+			a.BOMRef = mapRef(a.BOMRef)
+			a.Subjects = mapBOMReferences(a.Subjects, mapRef)
+			if a.Annotator != nil {
+				annotator := *a.Annotator
+				if annotator.Component != nil {
+					component := copyComponentWithRefs(*annotator.Component, mapRef)
+					annotator.Component = &component
+				}
+				if annotator.Service != nil {
+					service := copyServiceWithRefs(*annotator.Service, mapRef)
+					annotator.Service = &service
+				}
+				a.Annotator = &annotator
+			}
+			merged.Annotations = appendTo(merged.Annotations, a)
+		}
+	}
+
+	if bom.ExternalReferences != nil {
+		merged.ExternalReferences = appendTo(merged.ExternalReferences, *bom.ExternalReferences...)
+	}
+
+	if bom.Formulation != nil {
+		for _, f := range *bom.Formulation {
+			merged.Formulation = appendTo(merged.Formulation, copyFormulaWithRefs(f, mapRef))
+		}
+	}
+}
+
+// deduplicateSections removes the entries of the merged sections that were
+// contributed by more than one input
+func deduplicateSections(bom *cyclonedx.BOM) {
+	bom.Services = deduplicateServices(bom.Services)
+	bom.Vulnerabilities = deduplicateVulnerabilities(bom.Vulnerabilities)
+
+	if bom.ExternalReferences != nil {
+		seen := make(map[string]bool)
+		var unique []cyclonedx.ExternalReference
+		for _, ref := range *bom.ExternalReferences {
+			key := string(ref.Type) + "|" + ref.URL
+			if !seen[key] {
+				seen[key] = true
+				unique = append(unique, ref)
+			}
+		}
+		bom.ExternalReferences = &unique
+	}
+}
+
+// deduplicateServices removes services with the same bom-ref, or the same
+// name and version if they have none, keeping the first occurrence
+func deduplicateServices(services *[]cyclonedx.Service) *[]cyclonedx.Service {
+	if services == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	unique := make([]cyclonedx.Service, 0, len(*services))
+	for _, s := range *services {
+		key := s.BOMRef
+		if key == "" {
+			key = DisplayName(s.Group, s.Name) + "@" + s.Version
+		}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, s)
+		}
+	}
+	return &unique
+}
+
+// deduplicateVulnerabilities merges vulnerabilities with the same bom-ref.
+// Vulnerabilities without bom-ref are only merged if they have the same ID,
+// source and analysis state, so differing VEX statements are kept apart. The
+// first occurrence is kept, with the affected refs of all duplicates.
+func deduplicateVulnerabilities(vulnerabilities *[]cyclonedx.Vulnerability) *[]cyclonedx.Vulnerability {
+	if vulnerabilities == nil {
+		return nil
+	}
+
+	index := make(map[string]int)
+	unique := make([]cyclonedx.Vulnerability, 0, len(*vulnerabilities))
+	for _, v := range *vulnerabilities {
+		key := v.BOMRef
+		if key == "" {
+			key = v.ID
+			if v.Source != nil {
+				key += "|" + v.Source.Name + "|" + v.Source.URL
+			}
+			if v.Analysis != nil {
+				key += "|" + string(v.Analysis.State)
+			}
+		}
+
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(unique)
+			unique = append(unique, v)
+			continue
+		}
+		if v.Affects == nil {
+			continue
+		}
+
+		existing := &unique[i]
+		affects := make([]cyclonedx.Affects, 0)
+		if existing.Affects != nil {
+			affects = append(affects, *existing.Affects...)
+		}
+		for _, affected := range *v.Affects {
+			found := false
+			for _, a := range affects {
+				if a.Ref == affected.Ref {
+					found = true
+					break
+				}
+			}
+			if !found {
+				affects = append(affects, affected)
+			}
+		}
+		existing.Affects = &affects
+	}
+	return &unique
+}
+
+// copyServiceWithRefs returns a copy of the service in which the bom-refs of
+// the service and all of its nested services are rewritten
+func copyServiceWithRefs(s cyclonedx.Service, mapRef RefMapper) cyclonedx.Service {
+	s.BOMRef = mapRef(s.BOMRef)
+	if s.Services != nil {
+		children := make([]cyclonedx.Service, 0, len(*s.Services))
+		for _, child := range *s.Services {
+			children = append(children, copyServiceWithRefs(child, mapRef))
+		}
+		s.Services = &children
+	}
+	return s
+}
+
+// copyFormulaWithRefs returns a copy of the formula in which the bom-refs of
+// the formula, its components, services, workflows and tasks, and the refs
+// between them are rewritten
+func copyFormulaWithRefs(f cyclonedx.Formula, mapRef RefMapper) cyclonedx.Formula {
+	f.BOMRef = mapRef(f.BOMRef)
+	if f.Components != nil {
+		components := make([]cyclonedx.Component, 0, len(*f.Components))
+		for _, c := range *f.Components {
+			components = append(components, copyComponentWithRefs(c, mapRef))
+		}
+		f.Components = &components
+	}
+	if f.Services != nil {
+		services := make([]cyclonedx.Service, 0, len(*f.Services))
+		for _, s := range *f.Services {
+			services = append(services, copyServiceWithRefs(s, mapRef))
+		}
+		f.Services = &services
+	}
+	if f.Workflows != nil {
+		workflows := make([]cyclonedx.Workflow, 0, len(*f.Workflows))
+		for _, w := range *f.Workflows {
+			w.BOMRef = mapRef(w.BOMRef)
+			w.ResourceReferences = mapResourceReferences(w.ResourceReferences, mapRef)
+			w.TaskDependencies = mapDependencies(w.TaskDependencies, mapRef)
+			w.RuntimeTopology = mapDependencies(w.RuntimeTopology, mapRef)
+			if w.Tasks != nil {
+				tasks := make([]cyclonedx.Task, 0, len(*w.Tasks))
+				for _, task := range *w.Tasks {
+					task.BOMRef = mapRef(task.BOMRef)
+					task.ResourceReferences = mapResourceReferences(task.ResourceReferences, mapRef)
+					task.RuntimeTopology = mapDependencies(task.RuntimeTopology, mapRef)
+					tasks = append(tasks, task)
+				}
+				w.Tasks = &tasks
+			}
+			workflows = append(workflows, w)
+		}
+		f.Workflows = &workflows
+	}
+	return f
+}
+
+// mapDependencies returns a copy of the dependencies with ref and dependsOn rewritten
+func mapDependencies(dependencies *[]cyclonedx.Dependency, mapRef RefMapper) *[]cyclonedx.Dependency {
+	if dependencies == nil {
+		return nil
+	}
+	mapped := make([]cyclonedx.Dependency, 0, len(*dependencies))
+	for _, d := range *dependencies {
+		newDep := cyclonedx.Dependency{Ref: mapRef(d.Ref)}
+		if d.Dependencies != nil {
+			dependsOn := make([]string, 0, len(*d.Dependencies))
+			for _, dep := range *d.Dependencies {
+				dependsOn = append(dependsOn, mapRef(dep))
+			}
+			newDep.Dependencies = &dependsOn
+		}
+		mapped = append(mapped, newDep)
+	}
+	return &mapped
+}
+
+// mapBOMReferences returns a copy of the references, rewritten with mapRef
+func mapBOMReferences(refs *[]cyclonedx.BOMReference, mapRef RefMapper) *[]cyclonedx.BOMReference {
+	if refs == nil {
+		return nil
+	}
+	mapped := make([]cyclonedx.BOMReference, 0, len(*refs))
+	for _, ref := range *refs {
+		mapped = append(mapped, cyclonedx.BOMReference(mapRef(string(ref))))
+	}
+	return &mapped
+}
+
+// mapResourceReferences returns a copy of the resource references with their
+// refs rewritten. External references are kept as they are.
+func mapResourceReferences(refs *[]cyclonedx.ResourceReferenceChoice, mapRef RefMapper) *[]cyclonedx.ResourceReferenceChoice {
+	if refs == nil {
+		return nil
+	}
+	mapped := make([]cyclonedx.ResourceReferenceChoice, 0, len(*refs))
+	for _, ref := range *refs {
+		ref.Ref = mapRef(ref.Ref)
+		mapped = append(mapped, ref)
+	}
+	return &mapped
+}
+
+// isBOMLink reports whether a ref points into another BOM (a CycloneDX
+// BOM-Link), which must not be rewritten during a merge
+func isBOMLink(ref string) bool {
+	return strings.HasPrefix(ref, "urn:cdx:")
+}
+
+// appendTo appends values to the slice behind s, allocating it if needed
+func appendTo[T any](s *[]T, values ...T) *[]T {
+	if s == nil {
+		s = &[]T{}
+	}
+	*s = append(*s, values...)
+	return s
+}
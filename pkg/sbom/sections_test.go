@@ -0,0 +1,180 @@
+package sbom
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// sectionsTestSBOM returns an SBOM of a scanner that found CVE-2021-23337 in lodash
+func sectionsTestSBOM(serial string) *cyclonedx.BOM {
+	bom := cyclonedx.NewBOM()
+	bom.SerialNumber = serial
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "lodash", Name: "lodash", Version: "4.17.20", PackageURL: "pkg:npm/lodash@4.17.20", Type: cyclonedx.ComponentTypeLibrary},
+	}
+	bom.Services = &[]cyclonedx.Service{
+		{BOMRef: "api", Name: "api", Services: &[]cyclonedx.Service{{BOMRef: "api-auth", Name: "auth"}}},
+	}
+	bom.Vulnerabilities = &[]cyclonedx.Vulnerability{
+		{
+			ID:      "CVE-2021-23337",
+			Source:  &cyclonedx.Source{Name: "NVD", URL: "https://nvd.nist.gov/"},
+			Affects: &[]cyclonedx.Affects{{Ref: "lodash"}},
+			Analysis: &cyclonedx.VulnerabilityAnalysis{
+				State: cyclonedx.IASNotAffected,
+			},
+		},
+	}
+	bom.Compositions = &[]cyclonedx.Composition{
+		{
+			Aggregate:       cyclonedx.CompositionAggregateComplete,
+			Assemblies:      &[]cyclonedx.BOMReference{"lodash", "urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1#lodash"},
+			Vulnerabilities: &[]cyclonedx.BOMReference{"api"},
+		},
+	}
+	bom.Annotations = &[]cyclonedx.Annotation{
+		{
+			Subjects:  &[]cyclonedx.BOMReference{"lodash"},
+			Annotator: &cyclonedx.Annotator{Organization: &cyclonedx.OrganizationalEntity{Name: "ACME"}},
+			Timestamp: "2024-01-01T00:00:00Z",
+			Text:      "Reviewed",
+		},
+	}
+	bom.ExternalReferences = &[]cyclonedx.ExternalReference{
+		{Type: cyclonedx.ERTypeVCS, URL: "https://github.com/example/app"},
+	}
+	bom.Formulation = &[]cyclonedx.Formula{
+		{
+			BOMRef: "build",
+			Workflows: &[]cyclonedx.Workflow{
+				{
+					BOMRef:             "ci",
+					UID:                "ci",
+					ResourceReferences: &[]cyclonedx.ResourceReferenceChoice{{Ref: "lodash"}},
+					Tasks:              &[]cyclonedx.Task{{BOMRef: "ci-test", UID: "test"}},
+					TaskDependencies:   &[]cyclonedx.Dependency{{Ref: "ci-test"}},
+				},
+			},
+		},
+	}
+	return bom
+}
+
+func mergeSectionsTestSBOMs(t *testing.T) *cyclonedx.BOM {
+	testDir := t.TempDir()
+	sbom1Path := filepath.Join(testDir, "sbom1.json")
+	sbom2Path := filepath.Join(testDir, "sbom2.json")
+	outputPath := filepath.Join(testDir, "merged.json")
+
+	if err := WriteSBOMFile(sectionsTestSBOM("urn:uuid:serial-1"), sbom1Path); err != nil {
+		t.Fatalf("Failed to write test SBOM 1: %v", err)
+	}
+	if err := WriteSBOMFile(sectionsTestSBOM("urn:uuid:serial-2"), sbom2Path); err != nil {
+		t.Fatalf("Failed to write test SBOM 2: %v", err)
+	}
+
+	if err := MergeSBOMs([]string{sbom1Path, sbom2Path}, outputPath, "merged", ""); err != nil {
+		t.Fatalf("Failed to merge SBOMs: %v", err)
+	}
+
+	mergedBom, err := ReadSBOMFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	return mergedBom
+}
+
+func TestMergeSBOMsKeepsSections(t *testing.T) {
+	mergedBom := mergeSectionsTestSBOMs(t)
+
+	if mergedBom.Services == nil || len(*mergedBom.Services) != 2 {
+		t.Fatalf("Expected 2 services, got %+v", mergedBom.Services)
+	}
+	service := (*mergedBom.Services)[1]
+	if service.BOMRef != "urn:uuid:serial-2/api" || (*service.Services)[0].BOMRef != "urn:uuid:serial-2/api-auth" {
+		t.Errorf("Expected service refs to be prefixed, got %s and %s", service.BOMRef, (*service.Services)[0].BOMRef)
+	}
+
+	if mergedBom.Compositions == nil || len(*mergedBom.Compositions) != 2 {
+		t.Fatalf("Expected 2 compositions, got %+v", mergedBom.Compositions)
+	}
+	assemblies := *(*mergedBom.Compositions)[0].Assemblies
+	if assemblies[0] != "urn:uuid:serial-1/lodash" {
+		t.Errorf("Expected assembly ref to be prefixed, got %s", assemblies[0])
+	}
+	if assemblies[1] != "urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1#lodash" {
+		t.Errorf("Expected BOM-Link to be kept as it is, got %s", assemblies[1])
+	}
+
+	if mergedBom.Annotations == nil || len(*mergedBom.Annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, got %+v", mergedBom.Annotations)
+	}
+	if subject := (*(*mergedBom.Annotations)[1].Subjects)[0]; subject != "urn:uuid:serial-2/lodash" {
+		t.Errorf("Expected annotation subject to be prefixed, got %s", subject)
+	}
+
+	if mergedBom.ExternalReferences == nil || len(*mergedBom.ExternalReferences) != 1 {
+		t.Errorf("Expected identical external references to be merged, got %+v", mergedBom.ExternalReferences)
+	}
+
+	if mergedBom.Formulation == nil || len(*mergedBom.Formulation) != 2 {
+		t.Fatalf("Expected 2 formulas, got %+v", mergedBom.Formulation)
+	}
+	workflow := (*(*mergedBom.Formulation)[0].Workflows)[0]
+	if workflow.BOMRef != "urn:uuid:serial-1/ci" || (*workflow.ResourceReferences)[0].Ref != "urn:uuid:serial-1/lodash" {
+		t.Errorf("Expected workflow refs to be prefixed, got %+v", workflow)
+	}
+	if (*workflow.TaskDependencies)[0].Ref != "urn:uuid:serial-1/ci-test" {
+		t.Errorf("Expected task dependency to be prefixed, got %s", (*workflow.TaskDependencies)[0].Ref)
+	}
+}
+
+func TestMergeSBOMsMergesVulnerabilities(t *testing.T) {
+	mergedBom := mergeSectionsTestSBOMs(t)
+
+	if mergedBom.Vulnerabilities == nil || len(*mergedBom.Vulnerabilities) != 1 {
+		t.Fatalf("Expected the same CVE of both inputs to be merged, got %+v", mergedBom.Vulnerabilities)
+	}
+	vuln := (*mergedBom.Vulnerabilities)[0]
+	if vuln.Analysis == nil || vuln.Analysis.State != cyclonedx.IASNotAffected {
+		t.Errorf("Expected VEX analysis to survive the merge, got %+v", vuln.Analysis)
+	}
+	if vuln.Affects == nil || len(*vuln.Affects) != 2 {
+		t.Fatalf("Expected the vulnerability to affect both lodash components, got %+v", vuln.Affects)
+	}
+	for i, serial := range []string{"urn:uuid:serial-1", "urn:uuid:serial-2"} {
+		if ref := (*vuln.Affects)[i].Ref; ref != serial+"/lodash" {
+			t.Errorf("Expected affected ref %s/lodash, got %s", serial, ref)
+		}
+	}
+}
+
+func TestDeduplicateVulnerabilitiesKeepsDifferentAnalyses(t *testing.T) {
+	vulnerabilities := []cyclonedx.Vulnerability{
+		{ID: "CVE-2021-23337", Affects: &[]cyclonedx.Affects{{Ref: "a"}}, Analysis: &cyclonedx.VulnerabilityAnalysis{State: cyclonedx.IASNotAffected}},
+		{ID: "CVE-2021-23337", Affects: &[]cyclonedx.Affects{{Ref: "b"}}, Analysis: &cyclonedx.VulnerabilityAnalysis{State: cyclonedx.IASExploitable}},
+	}
+
+	unique := deduplicateVulnerabilities(&vulnerabilities)
+	if len(*unique) != 2 {
+		t.Errorf("Expected vulnerabilities with different analysis states to be kept apart, got %+v", *unique)
+	}
+}
+
+func TestMergeSectionsUsesRefMapper(t *testing.T) {
+	input := sectionsTestSBOM("urn:uuid:serial-1")
+	merged := cyclonedx.NewBOM()
+
+	mergeSections(merged, input, func(ref string) string {
+		if ref == "lodash" {
+			return "pkg:npm/lodash@4.17.20"
+		}
+		return ref
+	})
+
+	if ref := (*(*merged.Vulnerabilities)[0].Affects)[0].Ref; ref != "pkg:npm/lodash@4.17.20" {
+		t.Errorf("Expected affected ref to be rewritten by the ref mapper, got %s", ref)
+	}
+}
@@ -0,0 +1,953 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+)
+
+// SPDXDocument is an SPDX 2.3 document in its JSON encoding. Only the parts
+// with a CycloneDX counterpart are modeled in detail, the others are kept as
+// raw JSON, so they can be reported as lost.
+type SPDXDocument struct {
+	SPDXVersion                string                 `json:"spdxVersion"`
+	DataLicense                string                 `json:"dataLicense"`
+	SPDXID                     string                 `json:"SPDXID"`
+	Name                       string                 `json:"name"`
+	DocumentNamespace          string                 `json:"documentNamespace"`
+	Comment                    string                 `json:"comment,omitempty"`
+	CreationInfo               SPDXCreationInfo       `json:"creationInfo"`
+	DocumentDescribes          []string               `json:"documentDescribes,omitempty"`
+	Packages                   []SPDXPackage          `json:"packages,omitempty"`
+	Relationships              []SPDXRelationship     `json:"relationships,omitempty"`
+	HasExtractedLicensingInfos []SPDXExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+	ExternalDocumentRefs       []json.RawMessage      `json:"externalDocumentRefs,omitempty"`
+	Files                      []json.RawMessage      `json:"files,omitempty"`
+	Snippets                   []json.RawMessage      `json:"snippets,omitempty"`
+	Annotations                []json.RawMessage      `json:"annotations,omitempty"`
+}
+
+// SPDXCreationInfo describes who created an SPDX document and when
+type SPDXCreationInfo struct {
+	Created            string   `json:"created"`
+	Creators           []string `json:"creators"`
+	LicenseListVersion string   `json:"licenseListVersion,omitempty"`
+	Comment            string   `json:"comment,omitempty"`
+}
+
+// SPDXPackage is a package of an SPDX document
+type SPDXPackage struct {
+	SPDXID                  string            `json:"SPDXID"`
+	Name                    string            `json:"name"`
+	VersionInfo             string            `json:"versionInfo,omitempty"`
+	PackageFileName         string            `json:"packageFileName,omitempty"`
+	Supplier                string            `json:"supplier,omitempty"`
+	Originator              string            `json:"originator,omitempty"`
+	DownloadLocation        string            `json:"downloadLocation"`
+	FilesAnalyzed           *bool             `json:"filesAnalyzed,omitempty"`
+	PackageVerificationCode json.RawMessage   `json:"packageVerificationCode,omitempty"`
+	Checksums               []SPDXChecksum    `json:"checksums,omitempty"`
+	Homepage                string            `json:"homepage,omitempty"`
+	SourceInfo              string            `json:"sourceInfo,omitempty"`
+	LicenseConcluded        string            `json:"licenseConcluded,omitempty"`
+	LicenseInfoFromFiles    []string          `json:"licenseInfoFromFiles,omitempty"`
+	LicenseDeclared         string            `json:"licenseDeclared,omitempty"`
+	LicenseComments         string            `json:"licenseComments,omitempty"`
+	CopyrightText           string            `json:"copyrightText,omitempty"`
+	Summary                 string            `json:"summary,omitempty"`
+	Description             string            `json:"description,omitempty"`
+	Comment                 string            `json:"comment,omitempty"`
+	ExternalRefs            []SPDXExternalRef `json:"externalRefs,omitempty"`
+	AttributionTexts        []string          `json:"attributionTexts,omitempty"`
+	PrimaryPackagePurpose   string            `json:"primaryPackagePurpose,omitempty"`
+	ReleaseDate             string            `json:"releaseDate,omitempty"`
+	BuiltDate               string            `json:"builtDate,omitempty"`
+	ValidUntilDate          string            `json:"validUntilDate,omitempty"`
+	HasFiles                []string          `json:"hasFiles,omitempty"`
+	Annotations             []json.RawMessage `json:"annotations,omitempty"`
+}
+
+// SPDXChecksum is a checksum of a package
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// SPDXExternalRef is an external reference of a package, like its package URL
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+	Comment           string `json:"comment,omitempty"`
+}
+
+// SPDXRelationship is a relationship between two elements of a document
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+	Comment            string `json:"comment,omitempty"`
+}
+
+// SPDXExtractedLicense is a license that is not on the SPDX license list,
+// referenced by its LicenseRef- ID
+type SPDXExtractedLicense struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name,omitempty"`
+}
+
+const (
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxNoAssertion = "NOASSERTION"
+	spdxNone        = "NONE"
+)
+
+// spdxHashAlgorithms maps SPDX checksum algorithms to CycloneDX hash algorithms.
+// SPDX additionally knows SHA224, MD2, MD4, MD6 and ADLER32, which are lost.
+var spdxHashAlgorithms = []struct {
+	spdx      string
+	cyclonedx cyclonedx.HashAlgorithm
+}{
+	{"MD5", cyclonedx.HashAlgoMD5},
+	{"SHA1", cyclonedx.HashAlgoSHA1},
+	{"SHA256", cyclonedx.HashAlgoSHA256},
+	{"SHA384", cyclonedx.HashAlgoSHA384},
+	{"SHA512", cyclonedx.HashAlgoSHA512},
+	{"SHA3-256", cyclonedx.HashAlgoSHA3_256},
+	{"SHA3-384", cyclonedx.HashAlgoSHA3_384},
+	{"SHA3-512", cyclonedx.HashAlgoSHA3_512},
+	{"BLAKE2b-256", cyclonedx.HashAlgoBlake2b_256},
+	{"BLAKE2b-384", cyclonedx.HashAlgoBlake2b_384},
+	{"BLAKE2b-512", cyclonedx.HashAlgoBlake2b_512},
+	{"BLAKE3", cyclonedx.HashAlgoBlake3},
+}
+
+// spdxPackagePurposes maps SPDX primary package purposes to CycloneDX
+// component types. Purposes without an exact counterpart are reported as
+// lost when importing, types without one are exported as OTHER.
+var spdxPackagePurposes = []struct {
+	spdx      string
+	cyclonedx cyclonedx.ComponentType
+	exact     bool
+}{
+	{"APPLICATION", cyclonedx.ComponentTypeApplication, true},
+	{"FRAMEWORK", cyclonedx.ComponentTypeFramework, true},
+	{"LIBRARY", cyclonedx.ComponentTypeLibrary, true},
+	{"CONTAINER", cyclonedx.ComponentTypeContainer, true},
+	{"OPERATING-SYSTEM", cyclonedx.ComponentTypeOS, true},
+	{"DEVICE", cyclonedx.ComponentTypeDevice, true},
+	{"FIRMWARE", cyclonedx.ComponentTypeFirmware, true},
+	{"FILE", cyclonedx.ComponentTypeFile, true},
+	{"SOURCE", cyclonedx.ComponentTypeFile, false},
+	{"ARCHIVE", cyclonedx.ComponentTypeFile, false},
+	{"INSTALL", cyclonedx.ComponentTypeApplication, false},
+}
+
+// spdxDependencyOf are the relationship types stating that the element is a
+// dependency of the related element
+var spdxDependencyOf = []string{
+	"DEPENDENCY_OF",
+	"BUILD_DEPENDENCY_OF",
+	"DEV_DEPENDENCY_OF",
+	"OPTIONAL_DEPENDENCY_OF",
+	"PROVIDED_DEPENDENCY_OF",
+	"RUNTIME_DEPENDENCY_OF",
+	"TEST_DEPENDENCY_OF",
+}
+
+// DecodeSPDX decodes an SPDX 2.x JSON document into a CycloneDX BOM. It
+// returns the BOM and the fields of the document without a CycloneDX
+// counterpart.
+func DecodeSPDX(data []byte) (*cyclonedx.BOM, []ConversionLoss, error) {
+	var doc SPDXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode SPDX document: %w", err)
+	}
+	if !strings.HasPrefix(doc.SPDXVersion, "SPDX-2.") {
+		return nil, nil, fmt.Errorf("unsupported SPDX version %q, only SPDX 2.x is supported", doc.SPDXVersion)
+	}
+
+	counts := make(map[string]int)
+	bom := spdxToBOM(&doc, counts)
+	return bom, sortedLosses(counts), nil
+}
+
+// EncodeSPDX encodes a CycloneDX BOM as SPDX 2.3 JSON document. It returns
+// the encoded document and the fields of the BOM without an SPDX counterpart.
+func EncodeSPDX(bom *cyclonedx.BOM) ([]byte, []ConversionLoss, error) {
+	counts := make(map[string]int)
+	doc := bomToSPDX(bom, counts)
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode SPDX document: %w", err)
+	}
+	return append(data, '\n'), sortedLosses(counts), nil
+}
+
+// spdxToBOM converts an SPDX document to a CycloneDX BOM, counting the
+// fields that are lost
+func spdxToBOM(doc *SPDXDocument, counts map[string]int) *cyclonedx.BOM {
+	bom := cyclonedx.NewBOM()
+	if doc.DocumentNamespace != "" {
+		bom.SerialNumber = "urn:uuid:" + uuid.NewSHA1(reproducibleNamespace, []byte(doc.DocumentNamespace)).String()
+	}
+	bom.Metadata = &cyclonedx.Metadata{Timestamp: doc.CreationInfo.Created}
+
+	var tools []cyclonedx.Component
+	var authors []cyclonedx.OrganizationalContact
+	for _, creator := range doc.CreationInfo.Creators {
+		kind, name, email := parseSPDXActor(creator)
+		switch kind {
+		case "Tool":
+			tool := cyclonedx.Component{Type: cyclonedx.ComponentTypeApplication, Name: name}
+			// Tools are usually given as name-version
+			if i := strings.LastIndex(name, "-"); i > 0 && i+1 < len(name) && name[i+1] >= '0' && name[i+1] <= '9' {
+				tool.Name, tool.Version = name[:i], name[i+1:]
+			}
+			tools = append(tools, tool)
+		case "Person", "Organization":
+			authors = append(authors, cyclonedx.OrganizationalContact{Name: name, Email: email})
+		}
+	}
+	if len(tools) > 0 {
+		bom.Metadata.Tools = &cyclonedx.ToolsChoice{Components: &tools}
+	}
+	bom.Metadata.Authors = sliceOrNil(authors)
+
+	if doc.Comment != "" {
+		counts["comment"]++
+	}
+	if doc.CreationInfo.Comment != "" {
+		counts["creationInfo.comment"]++
+	}
+	for key, values := range map[string][]json.RawMessage{
+		"externalDocumentRefs": doc.ExternalDocumentRefs,
+		"files":                doc.Files,
+		"snippets":             doc.Snippets,
+		"annotations":          doc.Annotations,
+	} {
+		if len(values) > 0 {
+			counts[key] += len(values)
+		}
+	}
+
+	extractedLicenses := make(map[string]SPDXExtractedLicense)
+	for _, license := range doc.HasExtractedLicensingInfos {
+		extractedLicenses[license.LicenseID] = license
+	}
+
+	components := make(map[string]*cyclonedx.Component)
+	for _, pkg := range doc.Packages {
+		component := spdxPackageToComponent(pkg, extractedLicenses, counts)
+		components[pkg.SPDXID] = &component
+	}
+
+	// Collect the root packages, dependencies and containment
+	var described []string
+	for _, id := range doc.DocumentDescribes {
+		if !slices.Contains(described, id) {
+			described = append(described, id)
+		}
+	}
+	dependsOn := make(map[string][]string)
+	parents := make(map[string]string)
+	var containment [][2]string
+	for _, rel := range doc.Relationships {
+		from, to := rel.SPDXElementID, rel.RelatedSPDXElement
+		if to == spdxNone || to == spdxNoAssertion {
+			continue
+		}
+
+		switch {
+		case rel.RelationshipType == "DESCRIBES" && from == spdxDocumentID:
+			if !slices.Contains(described, to) {
+				described = append(described, to)
+			}
+			continue
+		case rel.RelationshipType == "DESCRIBED_BY" && to == spdxDocumentID:
+			if !slices.Contains(described, from) {
+				described = append(described, from)
+			}
+			continue
+		}
+
+		if components[from] == nil || components[to] == nil {
+			counts["relationships["+rel.RelationshipType+"]"]++
+			continue
+		}
+
+		switch {
+		case rel.RelationshipType == "DEPENDS_ON":
+			if !slices.Contains(dependsOn[from], to) {
+				dependsOn[from] = append(dependsOn[from], to)
+			}
+		case slices.Contains(spdxDependencyOf, rel.RelationshipType):
+			if !slices.Contains(dependsOn[to], from) {
+				dependsOn[to] = append(dependsOn[to], from)
+			}
+		case rel.RelationshipType == "CONTAINS":
+			containment = append(containment, [2]string{from, to})
+		case rel.RelationshipType == "CONTAINED_BY":
+			containment = append(containment, [2]string{to, from})
+		default:
+			counts["relationships["+rel.RelationshipType+"]"]++
+		}
+	}
+
+	// A single described package becomes the metadata component, its
+	// contained packages stay top-level components that it depends on
+	root := ""
+	if len(described) == 1 && components[described[0]] != nil {
+		root = described[0]
+	}
+	for _, edge := range containment {
+		parent, child := edge[0], edge[1]
+		if parent == root {
+			if !slices.Contains(dependsOn[root], child) {
+				dependsOn[root] = append(dependsOn[root], child)
+			}
+			continue
+		}
+		// The metadata component cannot be nested in another component
+		if child == root {
+			counts["relationships[CONTAINS]"]++
+			continue
+		}
+		if _, ok := parents[child]; ok || isSPDXAncestor(parents, child, parent) {
+			counts["relationships[CONTAINS]"]++
+			continue
+		}
+		parents[child] = parent
+	}
+
+	// Nest contained packages, deepest first, keeping the document's order
+	var topLevel []cyclonedx.Component
+	var nest func(id string) cyclonedx.Component
+	nest = func(id string) cyclonedx.Component {
+		component := *components[id]
+		var children []cyclonedx.Component
+		for _, pkg := range doc.Packages {
+			if parents[pkg.SPDXID] == id {
+				children = append(children, nest(pkg.SPDXID))
+			}
+		}
+		component.Components = sliceOrNil(children)
+		return component
+	}
+	for _, pkg := range doc.Packages {
+		if _, ok := parents[pkg.SPDXID]; ok {
+			continue
+		}
+		if pkg.SPDXID == root {
+			rootComponent := nest(root)
+			bom.Metadata.Component = &rootComponent
+			continue
+		}
+		topLevel = append(topLevel, nest(pkg.SPDXID))
+	}
+	bom.Components = sliceOrNil(topLevel)
+
+	var dependencies []cyclonedx.Dependency
+	for _, pkg := range doc.Packages {
+		dependency := cyclonedx.Dependency{Ref: pkg.SPDXID}
+		if refs := dependsOn[pkg.SPDXID]; len(refs) > 0 {
+			dependency.Dependencies = &refs
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	bom.Dependencies = sliceOrNil(dependencies)
+
+	return bom
+}
+
+// isSPDXAncestor reports whether ancestor is id itself or one of its parents
+func isSPDXAncestor(parents map[string]string, ancestor, id string) bool {
+	for {
+		if id == ancestor {
+			return true
+		}
+		parent, ok := parents[id]
+		if !ok {
+			return false
+		}
+		id = parent
+	}
+}
+
+// spdxPackageToComponent converts an SPDX package to a CycloneDX component,
+// counting the fields that are lost
+func spdxPackageToComponent(pkg SPDXPackage, extractedLicenses map[string]SPDXExtractedLicense, counts map[string]int) cyclonedx.Component {
+	component := cyclonedx.Component{
+		BOMRef:      pkg.SPDXID,
+		Type:        cyclonedx.ComponentTypeLibrary,
+		Name:        pkg.Name,
+		Version:     pkg.VersionInfo,
+		Description: pkg.Description,
+	}
+
+	if pkg.PrimaryPackagePurpose != "" {
+		found := false
+		for _, purpose := range spdxPackagePurposes {
+			if purpose.spdx == pkg.PrimaryPackagePurpose {
+				component.Type, found = purpose.cyclonedx, purpose.exact
+			}
+		}
+		if !found && pkg.PrimaryPackagePurpose != "OTHER" {
+			counts["packages[].primaryPackagePurpose"]++
+		}
+	}
+
+	if kind, name, email := parseSPDXActor(pkg.Supplier); kind != "" {
+		component.Supplier = &cyclonedx.OrganizationalEntity{Name: name}
+		if email != "" {
+			component.Supplier.Contact = &[]cyclonedx.OrganizationalContact{{Email: email}}
+		}
+	}
+	if kind, name, email := parseSPDXActor(pkg.Originator); kind != "" {
+		component.Authors = &[]cyclonedx.OrganizationalContact{{Name: name, Email: email}}
+	}
+
+	if pkg.CopyrightText != spdxNoAssertion && pkg.CopyrightText != spdxNone {
+		component.Copyright = pkg.CopyrightText
+	}
+
+	// The summary is a short form of the description
+	if component.Description == "" {
+		component.Description = pkg.Summary
+	} else if pkg.Summary != "" {
+		counts["packages[].summary"]++
+	}
+
+	var hashes []cyclonedx.Hash
+	for _, checksum := range pkg.Checksums {
+		algorithm := spdxToHashAlgorithm(checksum.Algorithm)
+		if algorithm == "" {
+			counts["packages[].checksums[]"]++
+			continue
+		}
+		hashes = append(hashes, cyclonedx.Hash{Algorithm: algorithm, Value: checksum.ChecksumValue})
+	}
+	component.Hashes = sliceOrNil(hashes)
+
+	// CycloneDX allows either a single expression or a list of licenses, the
+	// declared license wins if they differ
+	declared := spdxLicenseChoice(pkg.LicenseDeclared, cyclonedx.LicenseAcknowledgementDeclared, extractedLicenses)
+	concluded := spdxLicenseChoice(pkg.LicenseConcluded, cyclonedx.LicenseAcknowledgementConcluded, extractedLicenses)
+	switch {
+	case declared != nil:
+		component.Licenses = &cyclonedx.Licenses{*declared}
+		if concluded != nil && pkg.LicenseConcluded != pkg.LicenseDeclared {
+			counts["packages[].licenseConcluded"]++
+		}
+	case concluded != nil:
+		component.Licenses = &cyclonedx.Licenses{*concluded}
+	}
+
+	var externalReferences []cyclonedx.ExternalReference
+	if pkg.DownloadLocation != "" && pkg.DownloadLocation != spdxNoAssertion && pkg.DownloadLocation != spdxNone {
+		externalReferences = append(externalReferences, cyclonedx.ExternalReference{URL: pkg.DownloadLocation, Type: cyclonedx.ERTypeDistribution})
+	}
+	if pkg.Homepage != "" && pkg.Homepage != spdxNoAssertion && pkg.Homepage != spdxNone {
+		externalReferences = append(externalReferences, cyclonedx.ExternalReference{URL: pkg.Homepage, Type: cyclonedx.ERTypeWebsite})
+	}
+	var swhids, omniborIDs []string
+	for _, ref := range pkg.ExternalRefs {
+		switch category := strings.ReplaceAll(ref.ReferenceCategory, "_", "-"); {
+		case category == "PACKAGE-MANAGER" && ref.ReferenceType == "purl" && component.PackageURL == "":
+			component.PackageURL = ref.ReferenceLocator
+		case category == "SECURITY" && (ref.ReferenceType == "cpe23Type" || ref.ReferenceType == "cpe22Type") && component.CPE == "":
+			component.CPE = ref.ReferenceLocator
+		case category == "SECURITY" && ref.ReferenceType == "advisory":
+			externalReferences = append(externalReferences, cyclonedx.ExternalReference{URL: ref.ReferenceLocator, Type: cyclonedx.ERTypeAdvisories, Comment: ref.Comment})
+		case category == "SECURITY" && ref.ReferenceType == "swid" && component.SWID == nil:
+			component.SWID = &cyclonedx.SWID{TagID: ref.ReferenceLocator, Name: pkg.Name}
+		case category == "PERSISTENT-ID" && ref.ReferenceType == "swh":
+			swhids = append(swhids, ref.ReferenceLocator)
+		case category == "PERSISTENT-ID" && ref.ReferenceType == "gitoid":
+			omniborIDs = append(omniborIDs, ref.ReferenceLocator)
+		case category == "OTHER" || category == "SECURITY":
+			// Exported CycloneDX references keep their type as reference type
+			refType := cyclonedx.ExternalReferenceType(ref.ReferenceType)
+			if !isExternalReferenceType(refType) {
+				refType = cyclonedx.ERTypeOther
+			}
+			externalReferences = append(externalReferences, cyclonedx.ExternalReference{URL: ref.ReferenceLocator, Type: refType, Comment: ref.Comment})
+		default:
+			counts["packages[].externalRefs[]"]++
+		}
+	}
+	component.ExternalReferences = sliceOrNil(externalReferences)
+	component.SWHID = sliceOrNil(swhids)
+	component.OmniborID = sliceOrNil(omniborIDs)
+
+	for field, set := range map[string]bool{
+		"packages[].packageFileName":         pkg.PackageFileName != "",
+		"packages[].packageVerificationCode": len(pkg.PackageVerificationCode) > 0,
+		"packages[].sourceInfo":              pkg.SourceInfo != "",
+		"packages[].licenseInfoFromFiles":    len(pkg.LicenseInfoFromFiles) > 0,
+		"packages[].licenseComments":         pkg.LicenseComments != "",
+		"packages[].comment":                 pkg.Comment != "",
+		"packages[].attributionTexts":        len(pkg.AttributionTexts) > 0,
+		"packages[].releaseDate":             pkg.ReleaseDate != "",
+		"packages[].builtDate":               pkg.BuiltDate != "",
+		"packages[].validUntilDate":          pkg.ValidUntilDate != "",
+		"packages[].hasFiles":                len(pkg.HasFiles) > 0,
+		"packages[].annotations":             len(pkg.Annotations) > 0,
+	} {
+		if set {
+			counts[field]++
+		}
+	}
+
+	return component
+}
+
+// spdxLicenseChoice converts an SPDX license expression to a CycloneDX
+// license. Single licenses keep how they were acknowledged, LicenseRefs are
+// resolved to the name and text of the extracted license.
+func spdxLicenseChoice(expression string, acknowledgement cyclonedx.LicenseAcknowledgement, extractedLicenses map[string]SPDXExtractedLicense) *cyclonedx.LicenseChoice {
+	expression = strings.TrimSpace(expression)
+	switch {
+	case expression == "" || expression == spdxNoAssertion || expression == spdxNone:
+		return nil
+	case strings.ContainsAny(expression, " ()+"):
+		return &cyclonedx.LicenseChoice{Expression: expression}
+	case strings.HasPrefix(expression, "LicenseRef-") || strings.HasPrefix(expression, "DocumentRef-"):
+		license := &cyclonedx.License{Name: expression, Acknowledgement: acknowledgement}
+		if extracted, ok := extractedLicenses[expression]; ok {
+			if extracted.Name != "" && extracted.Name != spdxNoAssertion {
+				license.Name = extracted.Name
+			}
+			if extracted.ExtractedText != "" {
+				license.Text = &cyclonedx.AttachedText{Content: extracted.ExtractedText}
+			}
+		}
+		return &cyclonedx.LicenseChoice{License: license}
+	}
+	return &cyclonedx.LicenseChoice{License: &cyclonedx.License{ID: expression, Acknowledgement: acknowledgement}}
+}
+
+// parseSPDXActor splits an SPDX actor like "Organization: ACME (info@acme.example)"
+// into its kind, name and email. The kind is empty for NOASSERTION.
+func parseSPDXActor(actor string) (kind, name, email string) {
+	kind, name, ok := strings.Cut(actor, ":")
+	if !ok {
+		return "", "", ""
+	}
+	name = strings.TrimSpace(name)
+	if strings.HasSuffix(name, ")") {
+		if i := strings.LastIndex(name, "("); i >= 0 {
+			name, email = strings.TrimSpace(name[:i]), name[i+1:len(name)-1]
+		}
+	}
+	return strings.TrimSpace(kind), name, email
+}
+
+// spdxToHashAlgorithm returns the CycloneDX hash algorithm of an SPDX
+// checksum algorithm, or an empty string if there is none
+func spdxToHashAlgorithm(algorithm string) cyclonedx.HashAlgorithm {
+	for _, alg := range spdxHashAlgorithms {
+		if alg.spdx == algorithm {
+			return alg.cyclonedx
+		}
+	}
+	return ""
+}
+
+// isExternalReferenceType reports whether the type is one of the common
+// external reference types, which are the ones known to the protobuf format
+func isExternalReferenceType(refType cyclonedx.ExternalReferenceType) bool {
+	return slices.Contains(protoExternalReferenceTypes, refType)
+}
+
+// spdxIDPattern matches the characters that are not allowed in SPDX IDs
+var spdxIDPattern = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// bomToSPDX converts a CycloneDX BOM to an SPDX document, counting the
+// fields that are lost
+func bomToSPDX(bom *cyclonedx.BOM, counts map[string]int) *SPDXDocument {
+	doc := &SPDXDocument{
+		SPDXVersion: "SPDX-2.3",
+		DataLicense: "CC0-1.0",
+		SPDXID:      spdxDocumentID,
+		Name:        "sbom",
+	}
+
+	// Without serial number, the namespace is derived from the content, so
+	// it does not change between conversions of the same BOM
+	namespaceID, err := uuid.Parse(strings.TrimPrefix(bom.SerialNumber, "urn:uuid:"))
+	if err != nil {
+		content, _ := json.Marshal(bom)
+		namespaceID = uuid.NewSHA1(reproducibleNamespace, content)
+	}
+
+	// SPDX requires a creation time, reproducible merges set the timestamp
+	doc.CreationInfo.Created = time.Now().UTC().Format(time.RFC3339)
+	if bom.Metadata != nil {
+		metadata := bom.Metadata
+		if metadata.Timestamp != "" {
+			doc.CreationInfo.Created = metadata.Timestamp
+		}
+		if metadata.Component != nil {
+			doc.Name = metadata.Component.Name
+			if metadata.Component.Version != "" {
+				doc.Name += "-" + metadata.Component.Version
+			}
+		}
+		if metadata.Tools != nil {
+			if metadata.Tools.Tools != nil {
+				for _, tool := range *metadata.Tools.Tools {
+					doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, spdxTool(tool.Name, tool.Version))
+				}
+			}
+			if metadata.Tools.Components != nil {
+				for _, tool := range *metadata.Tools.Components {
+					doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, spdxTool(tool.Name, tool.Version))
+				}
+			}
+			if metadata.Tools.Services != nil {
+				counts["metadata.tools.services"] += len(*metadata.Tools.Services)
+			}
+		}
+		if metadata.Authors != nil {
+			for _, author := range *metadata.Authors {
+				doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, spdxActor("Person", author.Name, author.Email))
+			}
+		}
+		for field, set := range map[string]bool{
+			"metadata.lifecycles":   metadata.Lifecycles != nil,
+			"metadata.manufacturer": metadata.Manufacturer != nil || metadata.Manufacture != nil,
+			"metadata.supplier":     metadata.Supplier != nil,
+			"metadata.licenses":     metadata.Licenses != nil,
+			"metadata.properties":   metadata.Properties != nil,
+		} {
+			if set {
+				counts[field]++
+			}
+		}
+	}
+	// At least one creator is required
+	if len(doc.CreationInfo.Creators) == 0 {
+		doc.CreationInfo.Creators = []string{"Tool: sbomctl"}
+	}
+	doc.DocumentNamespace = "https://spdx.org/spdxdocs/" + spdxIDPattern.ReplaceAllString(doc.Name, "-") + "-" + namespaceID.String()
+
+	e := &spdxExporter{doc: doc, counts: counts, ids: make(map[string]string), usedIDs: make(map[string]bool), licenseRefs: make(map[string]bool)}
+
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		id := e.addPackage(*bom.Metadata.Component, "metadata.component")
+		doc.DocumentDescribes = append(doc.DocumentDescribes, id)
+	}
+	if bom.Components != nil {
+		for _, component := range *bom.Components {
+			id := e.addPackage(component, "components[]")
+			// Without a metadata component, the document describes all top-level components
+			if bom.Metadata == nil || bom.Metadata.Component == nil {
+				doc.DocumentDescribes = append(doc.DocumentDescribes, id)
+			}
+		}
+	}
+
+	for _, id := range doc.DocumentDescribes {
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: id})
+	}
+	doc.Relationships = append(doc.Relationships, e.containment...)
+	// documentDescribes is deprecated in favor of the DESCRIBES relationships
+	doc.DocumentDescribes = nil
+
+	if bom.Dependencies != nil {
+		for _, dependency := range *bom.Dependencies {
+			if dependency.Dependencies == nil {
+				continue
+			}
+			from, ok := e.ids[dependency.Ref]
+			for _, ref := range *dependency.Dependencies {
+				to, toOK := e.ids[ref]
+				if !ok || !toOK {
+					counts["dependencies[].dependsOn[]"]++
+					continue
+				}
+				doc.Relationships = append(doc.Relationships, SPDXRelationship{SPDXElementID: from, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: to})
+			}
+		}
+	}
+
+	for field, count := range map[string]int{
+		"services":           lenOf(bom.Services),
+		"externalReferences": lenOf(bom.ExternalReferences),
+		"properties":         lenOf(bom.Properties),
+		"vulnerabilities":    lenOf(bom.Vulnerabilities),
+		"compositions":       lenOf(bom.Compositions),
+		"annotations":        lenOf(bom.Annotations),
+		"formulation":        lenOf(bom.Formulation),
+	} {
+		if count > 0 {
+			counts[field] += count
+		}
+	}
+
+	return doc
+}
+
+// lenOf returns the length of an optional slice
+func lenOf[T any](s *[]T) int {
+	if s == nil {
+		return 0
+	}
+	return len(*s)
+}
+
+// spdxExporter holds the state of converting components to SPDX packages
+type spdxExporter struct {
+	doc         *SPDXDocument
+	counts      map[string]int
+	ids         map[string]string
+	usedIDs     map[string]bool
+	licenseRefs map[string]bool
+	containment []SPDXRelationship
+}
+
+// addPackage adds a component and its nested components as packages and
+// returns the SPDX ID of the component. Nested components are related to
+// their parent with CONTAINS.
+func (e *spdxExporter) addPackage(component cyclonedx.Component, path string) string {
+	id := e.spdxID(component)
+	if component.BOMRef != "" {
+		e.ids[component.BOMRef] = id
+	}
+
+	pkg := SPDXPackage{
+		SPDXID:           id,
+		Name:             component.Name,
+		VersionInfo:      component.Version,
+		DownloadLocation: spdxNoAssertion,
+		FilesAnalyzed:    new(bool),
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+		Description:      component.Description,
+	}
+	if component.Copyright != "" {
+		pkg.CopyrightText = component.Copyright
+	}
+
+	pkg.PrimaryPackagePurpose = "OTHER"
+	for _, purpose := range spdxPackagePurposes {
+		if purpose.exact && purpose.cyclonedx == component.Type {
+			pkg.PrimaryPackagePurpose = purpose.spdx
+		}
+	}
+	if pkg.PrimaryPackagePurpose == "OTHER" && component.Type != "" {
+		e.counts[path+".type"]++
+	}
+
+	if component.Supplier != nil {
+		email := ""
+		if component.Supplier.Contact != nil && len(*component.Supplier.Contact) > 0 {
+			email = (*component.Supplier.Contact)[0].Email
+		}
+		pkg.Supplier = spdxActor("Organization", component.Supplier.Name, email)
+	}
+	switch {
+	case component.Authors != nil && len(*component.Authors) > 0:
+		author := (*component.Authors)[0]
+		pkg.Originator = spdxActor("Person", author.Name, author.Email)
+		if len(*component.Authors) > 1 {
+			e.counts[path+".authors[]"] += len(*component.Authors) - 1
+		}
+	case component.Author != "":
+		pkg.Originator = spdxActor("Person", component.Author, "")
+	}
+
+	if component.Hashes != nil {
+		for _, hash := range *component.Hashes {
+			algorithm := ""
+			for _, alg := range spdxHashAlgorithms {
+				if alg.cyclonedx == hash.Algorithm {
+					algorithm = alg.spdx
+				}
+			}
+			if algorithm == "" {
+				e.counts[path+".hashes[]"]++
+				continue
+			}
+			pkg.Checksums = append(pkg.Checksums, SPDXChecksum{Algorithm: algorithm, ChecksumValue: hash.Value})
+		}
+	}
+
+	if component.Licenses != nil {
+		var declared, concluded []string
+		for _, license := range *component.Licenses {
+			expression := e.licenseExpression(license)
+			if expression == "" {
+				e.counts[path+".licenses[]"]++
+				continue
+			}
+			if license.License != nil && license.License.Acknowledgement == cyclonedx.LicenseAcknowledgementConcluded {
+				concluded = append(concluded, expression)
+			} else {
+				declared = append(declared, expression)
+			}
+		}
+		if len(declared) > 0 {
+			pkg.LicenseDeclared = joinSPDXExpressions(declared)
+		}
+		if len(concluded) > 0 {
+			pkg.LicenseConcluded = joinSPDXExpressions(concluded)
+		}
+	}
+
+	if component.PackageURL != "" {
+		pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: component.PackageURL})
+	}
+	if component.CPE != "" {
+		cpeType := "cpe22Type"
+		if strings.HasPrefix(component.CPE, "cpe:2.3:") {
+			cpeType = "cpe23Type"
+		}
+		pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{ReferenceCategory: "SECURITY", ReferenceType: cpeType, ReferenceLocator: component.CPE})
+	}
+	if component.SWID != nil {
+		pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{ReferenceCategory: "SECURITY", ReferenceType: "swid", ReferenceLocator: component.SWID.TagID})
+	}
+	if component.SWHID != nil {
+		for _, swhid := range *component.SWHID {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{ReferenceCategory: "PERSISTENT-ID", ReferenceType: "swh", ReferenceLocator: swhid})
+		}
+	}
+	if component.OmniborID != nil {
+		for _, gitoid := range *component.OmniborID {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{ReferenceCategory: "PERSISTENT-ID", ReferenceType: "gitoid", ReferenceLocator: gitoid})
+		}
+	}
+	if component.ExternalReferences != nil {
+		for _, ref := range *component.ExternalReferences {
+			switch {
+			case ref.Type == cyclonedx.ERTypeDistribution && pkg.DownloadLocation == spdxNoAssertion:
+				pkg.DownloadLocation = ref.URL
+			case ref.Type == cyclonedx.ERTypeWebsite && pkg.Homepage == "":
+				pkg.Homepage = ref.URL
+			case ref.Type == cyclonedx.ERTypeAdvisories:
+				pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{ReferenceCategory: "SECURITY", ReferenceType: "advisory", ReferenceLocator: ref.URL, Comment: ref.Comment})
+			default:
+				pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{ReferenceCategory: "OTHER", ReferenceType: string(ref.Type), ReferenceLocator: ref.URL, Comment: ref.Comment})
+			}
+		}
+	}
+
+	for field, set := range map[string]bool{
+		".group":            component.Group != "",
+		".scope":            component.Scope != "",
+		".mime-type":        component.MIMEType != "",
+		".publisher":        component.Publisher != "",
+		".manufacturer":     component.Manufacturer != nil,
+		".pedigree":         component.Pedigree != nil,
+		".evidence":         component.Evidence != nil,
+		".releaseNotes":     component.ReleaseNotes != nil,
+		".modelCard":        component.ModelCard != nil,
+		".data":             component.Data != nil,
+		".cryptoProperties": component.CryptoProperties != nil,
+		".properties":       component.Properties != nil,
+	} {
+		if set {
+			e.counts[path+field]++
+		}
+	}
+
+	e.doc.Packages = append(e.doc.Packages, pkg)
+
+	if component.Components != nil {
+		for _, child := range *component.Components {
+			childID := e.addPackage(child, path+".components[]")
+			e.containment = append(e.containment, SPDXRelationship{SPDXElementID: id, RelationshipType: "CONTAINS", RelatedSPDXElement: childID})
+		}
+	}
+	return id
+}
+
+// spdxID returns a unique SPDX ID for a component, derived from its bom-ref
+func (e *spdxExporter) spdxID(component cyclonedx.Component) string {
+	base := component.BOMRef
+	if base == "" {
+		base = component.Name
+	}
+	// bom-refs of imported SPDX documents are SPDX IDs already
+	base = strings.TrimPrefix(base, "SPDXRef-")
+	base = "SPDXRef-" + strings.Trim(spdxIDPattern.ReplaceAllString(base, "-"), "-")
+
+	id := base
+	for i := 2; e.usedIDs[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	e.usedIDs[id] = true
+	return id
+}
+
+// licenseExpression returns the SPDX expression of a CycloneDX license.
+// Licenses without SPDX ID become LicenseRefs with an extracted license.
+func (e *spdxExporter) licenseExpression(license cyclonedx.LicenseChoice) string {
+	switch {
+	case license.Expression != "":
+		return license.Expression
+	case license.License == nil:
+		return ""
+	case license.License.ID != "":
+		return license.License.ID
+	case license.License.Name == "":
+		return ""
+	}
+
+	name := license.License.Name
+	if strings.HasPrefix(name, "LicenseRef-") && !strings.ContainsAny(name, " ") {
+		return name
+	}
+	id := "LicenseRef-" + strings.Trim(spdxIDPattern.ReplaceAllString(name, "-"), "-")
+	if !e.licenseRefs[id] {
+		e.licenseRefs[id] = true
+		text := name
+		if license.License.Text != nil && license.License.Text.Content != "" {
+			text = license.License.Text.Content
+		}
+		e.doc.HasExtractedLicensingInfos = append(e.doc.HasExtractedLicensingInfos, SPDXExtractedLicense{LicenseID: id, Name: name, ExtractedText: text})
+	}
+	return id
+}
+
+// joinSPDXExpressions combines several license expressions with AND
+func joinSPDXExpressions(expressions []string) string {
+	if len(expressions) == 1 {
+		return expressions[0]
+	}
+	parts := make([]string, len(expressions))
+	for i, expression := range expressions {
+		parts[i] = expression
+		if strings.Contains(expression, " ") {
+			parts[i] = "(" + expression + ")"
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// spdxTool returns the SPDX creator of a tool
+func spdxTool(name, version string) string {
+	if version == "" {
+		return "Tool: " + name
+	}
+	return "Tool: " + name + "-" + version
+}
+
+// spdxActor returns an SPDX actor like "Person: Jane Doe (jane@example.com)"
+func spdxActor(kind, name, email string) string {
+	if email == "" {
+		return kind + ": " + name
+	}
+	return kind + ": " + name + " (" + email + ")"
+}
@@ -0,0 +1,265 @@
+package sbom
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func readSPDXTestdata(t *testing.T) (*cyclonedx.BOM, []ConversionLoss) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sbom4.spdx.json"))
+	if err != nil {
+		t.Fatalf("Failed to read SPDX document: %v", err)
+	}
+	bom, losses, err := DecodeSPDX(data)
+	if err != nil {
+		t.Fatalf("Failed to decode SPDX document: %v", err)
+	}
+	return bom, losses
+}
+
+func TestDecodeSPDX(t *testing.T) {
+	bom, _ := readSPDXTestdata(t)
+
+	if bom.Metadata.Timestamp != "2024-03-01T12:00:00Z" {
+		t.Errorf("Expected timestamp from creationInfo, got %q", bom.Metadata.Timestamp)
+	}
+	if tools := bom.Metadata.Tools; tools == nil || tools.Components == nil || (*tools.Components)[0].Name != "spdx-generator" || (*tools.Components)[0].Version != "1.2.0" {
+		t.Errorf("Expected tool creator to become a tool component, got %+v", tools)
+	}
+
+	root := bom.Metadata.Component
+	if root == nil || root.Name != "example-app" || root.Type != cyclonedx.ComponentTypeApplication {
+		t.Fatalf("Expected described package to become the metadata component, got %+v", root)
+	}
+	if root.Supplier == nil || root.Supplier.Name != "Example Supplier" {
+		t.Errorf("Expected supplier to be converted, got %+v", root.Supplier)
+	}
+
+	if bom.Components == nil || len(*bom.Components) != 2 {
+		t.Fatalf("Expected 2 components, got %+v", bom.Components)
+	}
+	lib3, lib5 := (*bom.Components)[0], (*bom.Components)[1]
+	if lib3.PackageURL != "pkg:npm/example-lib-3@3.4.5" || lib3.CPE == "" {
+		t.Errorf("Expected purl and CPE from external refs, got %q and %q", lib3.PackageURL, lib3.CPE)
+	}
+	if lib3.Hashes == nil || len(*lib3.Hashes) != 1 || (*lib3.Hashes)[0].Algorithm != cyclonedx.HashAlgoSHA256 {
+		t.Errorf("Expected the SHA256 checksum as hash, got %+v", lib3.Hashes)
+	}
+	if lib3.Licenses == nil || (*lib3.Licenses)[0].Expression != "MIT OR Apache-2.0" {
+		t.Errorf("Expected license expression, got %+v", lib3.Licenses)
+	}
+	if lib5.Licenses == nil || (*lib5.Licenses)[0].License == nil || (*lib5.Licenses)[0].License.Name != "Example Proprietary License" ||
+		(*lib5.Licenses)[0].License.Acknowledgement != cyclonedx.LicenseAcknowledgementConcluded {
+		t.Errorf("Expected concluded LicenseRef to be resolved, got %+v", lib5.Licenses)
+	}
+
+	dependsOn := make(map[string][]string)
+	for _, dep := range *bom.Dependencies {
+		if dep.Dependencies != nil {
+			dependsOn[dep.Ref] = *dep.Dependencies
+		}
+	}
+	appDeps := dependsOn["SPDXRef-Package-example-app"]
+	if len(appDeps) != 2 || appDeps[0] != "SPDXRef-Package-example-lib-3" || appDeps[1] != "SPDXRef-Package-example-lib-5" {
+		t.Errorf("Expected DEPENDS_ON and DEV_DEPENDENCY_OF to become dependencies, got %v", appDeps)
+	}
+}
+
+func TestDecodeSPDXLosses(t *testing.T) {
+	_, losses := readSPDXTestdata(t)
+
+	expected := []ConversionLoss{
+		{Path: "files", Count: 1},
+		{Path: "packages[].checksums[]", Count: 1},
+		{Path: "packages[].sourceInfo", Count: 1},
+		{Path: "relationships[CONTAINS]", Count: 1},
+	}
+	if len(losses) != len(expected) {
+		t.Fatalf("Expected losses %+v, got %+v", expected, losses)
+	}
+	for i := range expected {
+		if losses[i] != expected[i] {
+			t.Errorf("Expected loss %+v, got %+v", expected[i], losses[i])
+		}
+	}
+}
+
+func TestDecodeSPDXUnsupportedVersion(t *testing.T) {
+	if _, _, err := DecodeSPDX([]byte(`{"spdxVersion": "SPDX-3.0"}`)); err == nil {
+		t.Error("Expected error for an unsupported SPDX version")
+	}
+}
+
+func TestDecodeSPDXNestsContainedPackages(t *testing.T) {
+	doc := SPDXDocument{
+		SPDXVersion: "SPDX-2.3",
+		Packages:    []SPDXPackage{{SPDXID: "SPDXRef-a", Name: "a"}, {SPDXID: "SPDXRef-b", Name: "b"}},
+		Relationships: []SPDXRelationship{
+			{SPDXElementID: "SPDXRef-a", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-b"},
+			{SPDXElementID: "SPDXRef-b", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-a"},
+		},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to encode SPDX document: %v", err)
+	}
+
+	bom, losses, err := DecodeSPDX(data)
+	if err != nil {
+		t.Fatalf("Failed to decode SPDX document: %v", err)
+	}
+	if len(*bom.Components) != 1 || (*bom.Components)[0].Components == nil || (*(*bom.Components)[0].Components)[0].Name != "b" {
+		t.Errorf("Expected b to be nested in a, got %+v", *bom.Components)
+	}
+	if len(losses) != 1 || losses[0].Path != "relationships[CONTAINS]" {
+		t.Errorf("Expected the cyclic containment to be reported as lost, got %+v", losses)
+	}
+}
+
+func TestDecodeSPDXRootContainment(t *testing.T) {
+	doc := SPDXDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DocumentDescribes: []string{"SPDXRef-app"},
+		Packages:          []SPDXPackage{{SPDXID: "SPDXRef-app", Name: "app"}, {SPDXID: "SPDXRef-lib", Name: "lib"}, {SPDXID: "SPDXRef-image", Name: "image"}},
+		Relationships: []SPDXRelationship{
+			{SPDXElementID: "SPDXRef-app", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-lib"},
+			{SPDXElementID: "SPDXRef-app", RelationshipType: "CONTAINED_BY", RelatedSPDXElement: "SPDXRef-image"},
+		},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to encode SPDX document: %v", err)
+	}
+
+	bom, losses, err := DecodeSPDX(data)
+	if err != nil {
+		t.Fatalf("Failed to decode SPDX document: %v", err)
+	}
+	if bom.Metadata.Component == nil || bom.Metadata.Component.Name != "app" || len(*bom.Components) != 2 {
+		t.Fatalf("Expected app as metadata component and the others as components, got %+v", bom)
+	}
+	dependency := (*bom.Dependencies)[0]
+	if dependency.Ref != "SPDXRef-app" || dependency.Dependencies == nil || !slices.Equal(*dependency.Dependencies, []string{"SPDXRef-lib"}) {
+		t.Errorf("Expected the metadata component to depend on its contained package, got %+v", dependency)
+	}
+	if len(losses) != 1 || losses[0].Path != "relationships[CONTAINS]" {
+		t.Errorf("Expected the containment of the metadata component to be reported as lost, got %+v", losses)
+	}
+}
+
+func TestEncodeSPDX(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.SerialNumber = "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"
+	bom.Metadata = &cyclonedx.Metadata{
+		Timestamp: "2024-03-01T12:00:00Z",
+		Component: &cyclonedx.Component{BOMRef: "app", Type: cyclonedx.ComponentTypeApplication, Name: "app", Version: "1.0.0"},
+	}
+	bom.Components = &[]cyclonedx.Component{
+		{
+			BOMRef:     "pkg:npm/lib@1.0.0",
+			Type:       cyclonedx.ComponentTypeLibrary,
+			Group:      "example",
+			Name:       "lib",
+			Version:    "1.0.0",
+			PackageURL: "pkg:npm/lib@1.0.0",
+			Hashes:     &[]cyclonedx.Hash{{Algorithm: cyclonedx.HashAlgoSHA256, Value: "abc"}},
+			Licenses: &cyclonedx.Licenses{
+				{License: &cyclonedx.License{ID: "MIT"}},
+				{License: &cyclonedx.License{Name: "Custom License"}},
+			},
+		},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{{Ref: "app", Dependencies: &[]string{"pkg:npm/lib@1.0.0"}}}
+	bom.Services = &[]cyclonedx.Service{{Name: "api"}}
+
+	data, losses, err := EncodeSPDX(bom)
+	if err != nil {
+		t.Fatalf("Failed to encode SPDX document: %v", err)
+	}
+
+	var doc SPDXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to decode SPDX document: %v", err)
+	}
+	if doc.SPDXVersion != "SPDX-2.3" || doc.Name != "app-1.0.0" || doc.CreationInfo.Created != "2024-03-01T12:00:00Z" {
+		t.Errorf("Unexpected document header: %+v", doc)
+	}
+	if len(doc.Packages) != 2 {
+		t.Fatalf("Expected 2 packages, got %+v", doc.Packages)
+	}
+
+	lib := doc.Packages[1]
+	if lib.SPDXID != "SPDXRef-pkg-npm-lib-1.0.0" || lib.LicenseDeclared != "MIT AND LicenseRef-Custom-License" {
+		t.Errorf("Unexpected package: %+v", lib)
+	}
+	if len(lib.Checksums) != 1 || lib.Checksums[0].Algorithm != "SHA256" {
+		t.Errorf("Expected SHA256 checksum, got %+v", lib.Checksums)
+	}
+	if len(doc.HasExtractedLicensingInfos) != 1 || doc.HasExtractedLicensingInfos[0].Name != "Custom License" {
+		t.Errorf("Expected extracted license for the named license, got %+v", doc.HasExtractedLicensingInfos)
+	}
+
+	expectedRelationships := []SPDXRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-app"},
+		{SPDXElementID: "SPDXRef-app", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-pkg-npm-lib-1.0.0"},
+	}
+	if len(doc.Relationships) != len(expectedRelationships) {
+		t.Fatalf("Expected relationships %+v, got %+v", expectedRelationships, doc.Relationships)
+	}
+	for i := range expectedRelationships {
+		if doc.Relationships[i] != expectedRelationships[i] {
+			t.Errorf("Expected relationship %+v, got %+v", expectedRelationships[i], doc.Relationships[i])
+		}
+	}
+
+	if len(losses) != 2 || losses[0].Path != "components[].group" || losses[1].Path != "services" {
+		t.Errorf("Expected group and services to be reported as lost, got %+v", losses)
+	}
+}
+
+func TestEncodeSPDXCreationTime(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}}
+
+	before := time.Now().UTC().Truncate(time.Second)
+	data, _, err := EncodeSPDX(bom)
+	if err != nil {
+		t.Fatalf("Failed to encode SPDX document: %v", err)
+	}
+	var doc SPDXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to decode SPDX document: %v", err)
+	}
+	created, err := time.Parse(time.RFC3339, doc.CreationInfo.Created)
+	if err != nil || created.Before(before) {
+		t.Errorf("Expected the current time as creation time of a BOM without timestamp, got %q", doc.CreationInfo.Created)
+	}
+}
+
+func TestSPDXRoundTrip(t *testing.T) {
+	bom, _ := readSPDXTestdata(t)
+
+	data, _, err := EncodeSPDX(bom)
+	if err != nil {
+		t.Fatalf("Failed to encode SPDX document: %v", err)
+	}
+	roundTripped, losses, err := DecodeSPDX(data)
+	if err != nil {
+		t.Fatalf("Failed to decode SPDX document: %v", err)
+	}
+	if len(losses) != 0 {
+		t.Errorf("Expected no losses for a converted SPDX document, got %+v", losses)
+	}
+	if len(*roundTripped.Components) != 2 || roundTripped.Metadata.Component.Name != "example-app" {
+		t.Errorf("Expected components to survive the round trip, got %+v", roundTripped.Components)
+	}
+	if (*roundTripped.Components)[0].PackageURL != "pkg:npm/example-lib-3@3.4.5" {
+		t.Errorf("Expected package URL to survive the round trip, got %+v", (*roundTripped.Components)[0])
+	}
+}
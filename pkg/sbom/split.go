@@ -0,0 +1,304 @@
+package sbom
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// SplitMode decides how SplitBOM divides a merged SBOM
+type SplitMode string
+
+const (
+	// SplitBySerial reconstructs one SBOM per serial number the bom-refs were
+	// prefixed with by the prefix or hierarchical merge strategy
+	SplitBySerial SplitMode = "serial"
+	// SplitByComponent makes one SBOM per top-level component that no other
+	// component depends on
+	SplitByComponent SplitMode = "component"
+)
+
+// ParseSplitMode validates the name of a split mode
+func ParseSplitMode(name string) (SplitMode, error) {
+	switch m := SplitMode(name); m {
+	case SplitBySerial, SplitByComponent:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown split mode %q, must be one of: serial, component", name)
+}
+
+// SplitPart is one of the SBOMs SplitBOM reconstructed
+type SplitPart struct {
+	// Name is the name and version of the part's metadata component, or its
+	// serial number without "urn:uuid:" if it has none
+	Name string
+	BOM  *cyclonedx.BOM
+}
+
+// splitSpec selects the top-level components of a part
+type splitSpec struct {
+	// serial is the serial number prefix stripped from the part's bom-refs
+	serial string
+	// root is the index of the top-level component that becomes the part's
+	// metadata component, -1 if there is none
+	root    int
+	members map[int]bool
+}
+
+// SplitBOM splits a merged SBOM back into one SBOM per source. With
+// SplitBySerial the top-level components are grouped by the serial number
+// prefix of their bom-ref, and the component the merged metadata component
+// depends on becomes the part's metadata component. With SplitByComponent
+// every top-level component that no other component depends on becomes the
+// metadata component of a part. Nested components stay with their parent,
+// except those of the metadata component, which become the part's top-level
+// components again as before a hierarchical merge.
+//
+// Components without serial prefix, like the ones the purl strategy merged,
+// are copied into every part whose components depend on them. The serial
+// number prefix of the part is stripped from all bom-refs, and dependencies,
+// vulnerabilities and the other sections only keep what refers to the part.
+// SplitBySerial parts get back their original serial number. SplitBOM also
+// returns the top-level components that ended up in no part.
+func SplitBOM(bom *cyclonedx.BOM, mode SplitMode) ([]SplitPart, []string, error) {
+	var rootRef string
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		rootRef = bom.Metadata.Component.BOMRef
+	}
+	g := NewGraph(bom)
+
+	// owner maps the bom-refs of all components to their top-level component
+	var topLevel []*cyclonedx.Component
+	topIndex := make(map[*cyclonedx.Component]int)
+	owner := make(map[string]int)
+	if bom.Components != nil {
+		for i := range *bom.Components {
+			c := &(*bom.Components)[i]
+			topLevel = append(topLevel, c)
+			topIndex[c] = i
+			for _, ref := range splitRefs(c) {
+				owner[ref] = i
+			}
+		}
+	}
+
+	var specs []*splitSpec
+	switch mode {
+	case SplitBySerial:
+		bySerial := make(map[string]*splitSpec)
+		for i, c := range topLevel {
+			serial := SourceSerial(c.BOMRef)
+			if serial == "" {
+				continue
+			}
+			spec, ok := bySerial[serial]
+			if !ok {
+				spec = &splitSpec{serial: serial, root: -1, members: make(map[int]bool)}
+				bySerial[serial] = spec
+				specs = append(specs, spec)
+			}
+			spec.members[i] = true
+			if rootRef != "" && spec.root < 0 && slices.Contains(g.DependsOn(rootRef), c.BOMRef) {
+				spec.root = i
+			}
+		}
+		if len(specs) == 0 {
+			return nil, nil, fmt.Errorf("no bom-refs with serial number prefix, split by component instead")
+		}
+		// Only shared components are added, not those of other sources
+		for _, spec := range specs {
+			spec.addDependencies(g, topLevel, owner, func(i int) bool { return SourceSerial(topLevel[i].BOMRef) == "" })
+		}
+	case SplitByComponent:
+		dependedOn := make(map[int]bool)
+		for ref, i := range owner {
+			for _, target := range g.DependsOn(ref) {
+				if j, ok := owner[target]; ok && j != i {
+					dependedOn[j] = true
+				}
+			}
+		}
+		serials := make(map[string]int)
+		for i, c := range topLevel {
+			if dependedOn[i] {
+				continue
+			}
+			spec := &splitSpec{serial: SourceSerial(c.BOMRef), root: i, members: map[int]bool{i: true}}
+			spec.addDependencies(g, topLevel, owner, func(int) bool { return true })
+			serials[spec.serial]++
+			specs = append(specs, spec)
+		}
+		// The serial number only identifies the source if no other part has it
+		for _, spec := range specs {
+			if serials[spec.serial] > 1 {
+				spec.serial = ""
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown split mode %q", mode)
+	}
+
+	parts := make([]SplitPart, 0, len(specs))
+	assigned := make(map[int]bool)
+	for _, spec := range specs {
+		for i := range spec.members {
+			assigned[i] = true
+		}
+		parts = append(parts, splitPart(bom, spec, rootRef, topLevel, topIndex))
+	}
+
+	unassigned := []string{}
+	for i, c := range topLevel {
+		if !assigned[i] {
+			unassigned = append(unassigned, componentLabel(c))
+		}
+	}
+	return parts, unassigned, nil
+}
+
+// addDependencies adds the top-level components include returns true for
+// that the part's components depend on, directly or transitively
+func (s *splitSpec) addDependencies(g *Graph, topLevel []*cyclonedx.Component, owner map[string]int, include func(int) bool) {
+	var queue []string
+	for i := range s.members {
+		queue = append(queue, splitRefs(topLevel[i])...)
+	}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		for _, target := range g.DependsOn(ref) {
+			i, ok := owner[target]
+			if !ok || s.members[i] || !include(i) {
+				continue
+			}
+			s.members[i] = true
+			queue = append(queue, splitRefs(topLevel[i])...)
+		}
+	}
+}
+
+// splitPart builds the SBOM of a part
+func splitPart(bom *cyclonedx.BOM, spec *splitSpec, rootRef string, topLevel []*cyclonedx.Component, topIndex map[*cyclonedx.Component]int) SplitPart {
+	part, removed := subsetBOM(bom, func(c *cyclonedx.Component) bool {
+		if i, ok := topIndex[c]; ok {
+			return spec.members[i]
+		}
+		return true
+	}, false)
+
+	owned := func(ref string) bool {
+		serial := SourceSerial(ref)
+		return spec.serial == "" || serial == "" || serial == spec.serial
+	}
+	strip := func(ref string) string {
+		if spec.serial == "" {
+			return ref
+		}
+		return strings.TrimPrefix(ref, spec.serial+"/")
+	}
+
+	// Services of other sources are removed like their components
+	var services []cyclonedx.Service
+	if bom.Services != nil {
+		for _, s := range *bom.Services {
+			if owned(s.BOMRef) {
+				services = append(services, s)
+				continue
+			}
+			walkServices(&[]cyclonedx.Service{s}, func(s *cyclonedx.Service) { removed[s.BOMRef] = true })
+		}
+	}
+	if rootRef != "" {
+		removed[rootRef] = true
+	}
+
+	// The subset's components are copies of the members in their original order
+	metadata := cyclonedx.Metadata{}
+	if bom.Metadata != nil {
+		metadata = *bom.Metadata
+	}
+	metadata.Component = nil
+	var components []cyclonedx.Component
+	k := 0
+	for i := range topLevel {
+		if !spec.members[i] {
+			continue
+		}
+		c := copyComponentWithRefs((*part.Components)[k], strip)
+		k++
+		if i != spec.root {
+			components = append(components, c)
+			continue
+		}
+		if c.Components != nil {
+			components = append(components, *c.Components...)
+		}
+		c.Components = nil
+		metadata.Component = &c
+	}
+
+	sections := &cyclonedx.BOM{
+		Services:           sliceOrNil(services),
+		Vulnerabilities:    subsetVulnerabilities(part.Vulnerabilities, removed),
+		Compositions:       splitOwned(part.Compositions, func(c cyclonedx.Composition) bool { return owned(c.BOMRef) }),
+		Annotations:        splitOwned(part.Annotations, func(a cyclonedx.Annotation) bool { return owned(a.BOMRef) }),
+		ExternalReferences: part.ExternalReferences,
+		Formulation:        splitOwned(part.Formulation, func(f cyclonedx.Formula) bool { return owned(f.BOMRef) }),
+	}
+	if sections.Annotations != nil {
+		for i, a := range *sections.Annotations {
+			(*sections.Annotations)[i].Subjects = subsetBOMReferences(a.Subjects, removed)
+		}
+	}
+
+	result := *part
+	result.Version = 1
+	result.Metadata = &metadata
+	result.Components = sliceOrNil(components)
+	result.Dependencies = mapDependencies(subsetDependencies(part.Dependencies, removed), strip)
+	result.Services, result.Vulnerabilities, result.Compositions = nil, nil, nil
+	result.Annotations, result.ExternalReferences, result.Formulation = nil, nil, nil
+	mergeSections(&result, sections, strip)
+
+	name := strings.TrimPrefix(spec.serial, "urn:uuid:")
+	if spec.serial != "" {
+		result.SerialNumber = spec.serial
+	}
+	if c := metadata.Component; c != nil && c.Name != "" {
+		name = c.Name
+		if c.Version != "" {
+			name += "-" + c.Version
+		}
+	}
+	return SplitPart{Name: name, BOM: &result}
+}
+
+// splitRefs returns the bom-refs of a component and its nested components
+func splitRefs(c *cyclonedx.Component) []string {
+	var refs []string
+	if c.BOMRef != "" {
+		refs = append(refs, c.BOMRef)
+	}
+	walkComponents(c.Components, func(nested *cyclonedx.Component) {
+		if nested.BOMRef != "" {
+			refs = append(refs, nested.BOMRef)
+		}
+	})
+	return refs
+}
+
+// splitOwned returns a copy of the section with only the entries keep returns true for
+func splitOwned[T any](section *[]T, keep func(T) bool) *[]T {
+	if section == nil {
+		return nil
+	}
+	var owned []T
+	for _, entry := range *section {
+		if keep(entry) {
+			owned = append(owned, entry)
+		}
+	}
+	return sliceOrNil(owned)
+}
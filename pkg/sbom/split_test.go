@@ -0,0 +1,131 @@
+package sbom
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestSplitBOMBySerial(t *testing.T) {
+	inputs := []string{filepath.Join("..", "..", "testdata", "sbom4.spdx.json"), filepath.Join("..", "..", "testdata", "sbom2.json")}
+
+	for _, strategy := range []MergeStrategy{PrefixStrategy{}, HierarchicalStrategy{}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			mergedFile := filepath.Join(t.TempDir(), "merged.json")
+			if _, err := MergeSBOMsWithOptions(inputs, mergedFile, MergeOptions{Strategy: strategy}); err != nil {
+				t.Fatalf("MergeSBOMsWithOptions failed: %v", err)
+			}
+			merged, err := ReadSBOMFile(mergedFile)
+			if err != nil {
+				t.Fatalf("Failed to read merged SBOM: %v", err)
+			}
+
+			parts, unassigned, err := SplitBOM(merged, SplitBySerial)
+			if err != nil {
+				t.Fatalf("SplitBOM failed: %v", err)
+			}
+			if len(parts) != 2 || len(unassigned) != 0 {
+				t.Fatalf("Expected 2 parts and no unassigned components, got %d parts and %v", len(parts), unassigned)
+			}
+
+			for i, input := range inputs {
+				original, err := ReadSBOMFile(input)
+				if err != nil {
+					t.Fatalf("Failed to read input: %v", err)
+				}
+				part := parts[i].BOM
+				if part.SerialNumber != original.SerialNumber {
+					t.Errorf("Expected serial number %s, got %s", original.SerialNumber, part.SerialNumber)
+				}
+				if refs, expected := componentRefs(*part.Components), componentRefs(*original.Components); !slices.Equal(refs, expected) {
+					t.Errorf("Expected components %v, got %v", expected, refs)
+				}
+				if (original.Metadata.Component == nil) != (part.Metadata.Component == nil) ||
+					original.Metadata.Component != nil && part.Metadata.Component.BOMRef != original.Metadata.Component.BOMRef {
+					t.Errorf("Expected metadata component %+v, got %+v", original.Metadata.Component, part.Metadata.Component)
+				}
+				if len(*part.Dependencies) != len(*original.Dependencies) {
+					t.Errorf("Expected dependencies %+v, got %+v", *original.Dependencies, *part.Dependencies)
+				}
+				if errs := CheckRefIntegrity(part); len(errs) != 0 {
+					t.Errorf("Expected consistent refs, got %v", errs)
+				}
+			}
+			if parts[0].Name != "example-app-2.0.0" || parts[1].Name != "4f782798-486a-52e6-b40f-b59032a70b80" {
+				t.Errorf("Unexpected part names: %s, %s", parts[0].Name, parts[1].Name)
+			}
+		})
+	}
+}
+
+func TestSplitBOMSections(t *testing.T) {
+	a, b := "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", "urn:uuid:4f782798-486a-52e6-b40f-b59032a70b80"
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "merged", Name: "merged"}}
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: a + "/app", Name: "app"},
+		{BOMRef: b + "/api", Name: "api"},
+		{BOMRef: "pkg:npm/lodash@4.17.21", Name: "lodash"},
+		{BOMRef: "pkg:npm/left-pad@1.3.0", Name: "left-pad"},
+	}
+	bom.Services = &[]cyclonedx.Service{{BOMRef: a + "/auth", Name: "auth"}, {BOMRef: b + "/db", Name: "db"}}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "merged", Dependencies: &[]string{a + "/app", b + "/api"}},
+		{Ref: a + "/app", Dependencies: &[]string{"pkg:npm/lodash@4.17.21", a + "/auth"}},
+		{Ref: b + "/api", Dependencies: &[]string{"pkg:npm/lodash@4.17.21", b + "/db"}},
+	}
+	bom.Vulnerabilities = &[]cyclonedx.Vulnerability{
+		{ID: "CVE-2021-23337", Affects: &[]cyclonedx.Affects{{Ref: "pkg:npm/lodash@4.17.21"}, {Ref: b + "/api"}}},
+		{ID: "CVE-2022-0001", Affects: &[]cyclonedx.Affects{{Ref: b + "/api"}}},
+	}
+
+	parts, unassigned, err := SplitBOM(bom, SplitBySerial)
+	if err != nil {
+		t.Fatalf("SplitBOM failed: %v", err)
+	}
+	if !slices.Equal(unassigned, []string{"left-pad"}) {
+		t.Errorf("Expected left-pad to be unassigned, got %v", unassigned)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("Expected 2 parts, got %d", len(parts))
+	}
+
+	app := parts[0].BOM
+	if app.Metadata.Component.BOMRef != "app" || app.Metadata.Tools != nil {
+		t.Errorf("Unexpected metadata: %+v", app.Metadata)
+	}
+	if refs := componentRefs(*app.Components); !slices.Equal(refs, []string{"pkg:npm/lodash@4.17.21"}) {
+		t.Errorf("Expected the shared lodash to be copied, got %v", refs)
+	}
+	if len(*app.Services) != 1 || (*app.Services)[0].BOMRef != "auth" {
+		t.Errorf("Unexpected services: %+v", *app.Services)
+	}
+	if len(*app.Vulnerabilities) != 1 || len(*(*app.Vulnerabilities)[0].Affects) != 1 {
+		t.Errorf("Unexpected vulnerabilities: %+v", *app.Vulnerabilities)
+	}
+	if len(*app.Dependencies) != 1 || !slices.Equal(*(*app.Dependencies)[0].Dependencies, []string{"pkg:npm/lodash@4.17.21", "auth"}) {
+		t.Errorf("Unexpected dependencies: %+v", *app.Dependencies)
+	}
+	if len(*parts[1].BOM.Vulnerabilities) != 2 {
+		t.Errorf("Expected both vulnerabilities in the second part, got %+v", *parts[1].BOM.Vulnerabilities)
+	}
+	if errs := CheckRefIntegrity(app); len(errs) != 0 {
+		t.Errorf("Expected consistent refs, got %v", errs)
+	}
+
+	// Without serial prefixes, each independent top-level component is a part
+	parts, unassigned, err = SplitBOM(bom, SplitByComponent)
+	if err != nil {
+		t.Fatalf("SplitBOM failed: %v", err)
+	}
+	if len(parts) != 3 || len(unassigned) != 0 || parts[2].Name != "left-pad" {
+		t.Errorf("Expected app, api and left-pad parts, got %+v", parts)
+	}
+
+	bom.Components = &[]cyclonedx.Component{{BOMRef: "app", Name: "app"}}
+	if _, _, err := SplitBOM(bom, SplitBySerial); err == nil {
+		t.Error("Expected an error without serial number prefixes")
+	}
+}
@@ -0,0 +1,286 @@
+package sbom
+
+import (
+	"fmt"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+)
+
+// RefMapper rewrites a bom-ref of an input SBOM to its bom-ref in the merged SBOM
+type RefMapper func(ref string) string
+
+// MergeStrategy decides how the components of the input SBOMs end up in the
+// merged SBOM. The dependencies of the inputs are rewritten with the returned
+// RefMappers, so a strategy only has to take care of components.
+type MergeStrategy interface {
+	// Name returns the name used to select the strategy
+	Name() string
+	// MergeComponents adds the components of all inputs, including their
+	// metadata components, to the merged SBOM. It returns one RefMapper per
+	// input, in the order of the inputs.
+	MergeComponents(mc *MergeContext) ([]RefMapper, error)
+}
+
+// MergeContext holds the state of a single merge and is passed to the MergeStrategy
+type MergeContext struct {
+	// Merged is the SBOM being built
+	Merged *cyclonedx.BOM
+	// Inputs are the SBOMs being merged, in the order they were given
+	Inputs []*cyclonedx.BOM
+	// Options are the options the merge was started with
+	Options MergeOptions
+	// Report collects what happened during the merge
+	Report *MergeReport
+}
+
+// mergeStrategies are the built-in strategies, selectable by name
+var mergeStrategies = []MergeStrategy{
+	PrefixStrategy{},
+	PurlStrategy{},
+	HierarchicalStrategy{},
+}
+
+// MergeStrategyByName returns the built-in strategy with the given name
+func MergeStrategyByName(name string) (MergeStrategy, error) {
+	for _, strategy := range mergeStrategies {
+		if strategy.Name() == name {
+			return strategy, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown merge strategy %q, must be one of: %v", name, MergeStrategyNames())
+}
+
+// MergeStrategyNames returns the names of all built-in strategies
+func MergeStrategyNames() []string {
+	names := make([]string, 0, len(mergeStrategies))
+	for _, strategy := range mergeStrategies {
+		names = append(names, strategy.Name())
+	}
+	return names
+}
+
+// PrefixRefMapper returns a RefMapper that prefixes refs with the given serial
+// number, so refs of different inputs cannot collide. Refs are returned as
+// they are if there is no serial number, or if they are BOM-Links into
+// another BOM.
+func PrefixRefMapper(serial string) RefMapper {
+	return func(ref string) string {
+		if ref == "" || serial == "" || isBOMLink(ref) {
+			return ref
+		}
+		return serial + "/" + ref
+	}
+}
+
+// PrefixStrategy prefixes every bom-ref with the serial number of its input.
+// Components are only deduplicated if they end up with the same bom-ref.
+type PrefixStrategy struct{}
+
+// Name implements the MergeStrategy interface
+func (PrefixStrategy) Name() string {
+	return "prefix"
+}
+
+// MergeComponents implements the MergeStrategy interface
+func (PrefixStrategy) MergeComponents(mc *MergeContext) ([]RefMapper, error) {
+	refMappers := make([]RefMapper, 0, len(mc.Inputs))
+	for _, bom := range mc.Inputs {
+		mapRef := PrefixRefMapper(bom.SerialNumber)
+		refMappers = append(refMappers, mapRef)
+
+		// Check if the SBOM has a metadata.component
+		if bom.Metadata != nil && bom.Metadata.Component != nil {
+			comp := copyComponentWithRefs(*bom.Metadata.Component, mapRef)
+			*mc.Merged.Components = append(*mc.Merged.Components, comp)
+		}
+
+		// Merge components, prefixing bom-ref
+		if bom.Components != nil {
+			for _, c := range *bom.Components {
+				*mc.Merged.Components = append(*mc.Merged.Components, copyComponentWithRefs(c, mapRef))
+			}
+		}
+	}
+
+	// Remove duplicate components
+	mc.Merged.Components = deduplicateComponents(mc.Merged.Components, mc.CombineComponents)
+
+	return refMappers, nil
+}
+
+// PurlStrategy merges components with identical package URLs into a single
+// component whose bom-ref is the package URL. The fields of all occurrences
+// are combined with MergeContext.CombineComponents and all refs to them are
+// rewritten to the merged component. Components without package URL are
+// prefixed like in PrefixStrategy.
+type PurlStrategy struct{}
+
+// Name implements the MergeStrategy interface
+func (PurlStrategy) Name() string {
+	return "purl"
+}
+
+// MergeComponents implements the MergeStrategy interface
+func (PurlStrategy) MergeComponents(mc *MergeContext) ([]RefMapper, error) {
+	// First pass: find the canonical ref of every package URL and which refs
+	// of each input map to it
+	canonicalRefs := make(map[string]string)
+	occurrences := make(map[string][]cyclonedx.Component)
+	refMaps := make([]map[string]string, len(mc.Inputs))
+	for i, bom := range mc.Inputs {
+		refMaps[i] = make(map[string]string)
+		visit := func(c *cyclonedx.Component) {
+			key := purlKey(c.PackageURL)
+			if key == "" {
+				return
+			}
+			if _, ok := canonicalRefs[key]; !ok {
+				canonicalRefs[key] = c.PackageURL
+			}
+			// Conflicts are reported with the ref of the merged component
+			occurrence := *c
+			occurrence.BOMRef = canonicalRefs[key]
+			occurrence.Components = nil
+			occurrences[key] = append(occurrences[key], occurrence)
+			if c.BOMRef != "" {
+				refMaps[i][c.BOMRef] = canonicalRefs[key]
+			}
+		}
+		if bom.Metadata != nil && bom.Metadata.Component != nil {
+			visit(bom.Metadata.Component)
+			walkComponents(bom.Metadata.Component.Components, visit)
+		}
+		walkComponents(bom.Components, visit)
+	}
+
+	refMappers := make([]RefMapper, 0, len(mc.Inputs))
+	for i, bom := range mc.Inputs {
+		refMap := refMaps[i]
+		prefixRef := PrefixRefMapper(bom.SerialNumber)
+		refMappers = append(refMappers, func(ref string) string {
+			if canonical, ok := refMap[ref]; ok {
+				return canonical
+			}
+			return prefixRef(ref)
+		})
+	}
+
+	// Second pass: add every package URL only once, at the place it was first
+	// seen, combining the fields of all of its occurrences
+	seen := make(map[string]bool)
+	var mergeList func(components []cyclonedx.Component, mapRef RefMapper) []cyclonedx.Component
+	mergeList = func(components []cyclonedx.Component, mapRef RefMapper) []cyclonedx.Component {
+		var result []cyclonedx.Component
+		for _, c := range components {
+			var children []cyclonedx.Component
+			if c.Components != nil {
+				children = mergeList(*c.Components, mapRef)
+			}
+
+			if key := purlKey(c.PackageURL); key != "" {
+				if seen[key] {
+					// Keep the nested components of the duplicate
+					result = append(result, children...)
+					continue
+				}
+				seen[key] = true
+				c = mc.CombineComponents(occurrences[key]...)
+				c.BOMRef = canonicalRefs[key]
+			} else {
+				c.BOMRef = mapRef(c.BOMRef)
+			}
+			c.Components = nil
+			if len(children) > 0 {
+				c.Components = &children
+			}
+			result = append(result, c)
+		}
+		return result
+	}
+
+	for i, bom := range mc.Inputs {
+		if bom.Metadata != nil && bom.Metadata.Component != nil {
+			*mc.Merged.Components = append(*mc.Merged.Components, mergeList([]cyclonedx.Component{*bom.Metadata.Component}, refMappers[i])...)
+		}
+		if bom.Components != nil {
+			*mc.Merged.Components = append(*mc.Merged.Components, mergeList(*bom.Components, refMappers[i])...)
+		}
+	}
+
+	// Components without package URL can still be duplicates
+	mc.Merged.Components = deduplicateComponents(mc.Merged.Components, mc.CombineComponents)
+
+	return refMappers, nil
+}
+
+// HierarchicalStrategy nests the components of each input under the input's
+// metadata component as sub-components, so the merged SBOM keeps track of
+// where each component came from. Refs are prefixed like in PrefixStrategy.
+// Components of inputs without a metadata component stay at the top level.
+type HierarchicalStrategy struct{}
+
+// Name implements the MergeStrategy interface
+func (HierarchicalStrategy) Name() string {
+	return "hierarchical"
+}
+
+// MergeComponents implements the MergeStrategy interface
+func (HierarchicalStrategy) MergeComponents(mc *MergeContext) ([]RefMapper, error) {
+	refMappers := make([]RefMapper, 0, len(mc.Inputs))
+	for _, bom := range mc.Inputs {
+		mapRef := PrefixRefMapper(bom.SerialNumber)
+		refMappers = append(refMappers, mapRef)
+
+		var components []cyclonedx.Component
+		if bom.Components != nil {
+			for _, c := range *bom.Components {
+				components = append(components, copyComponentWithRefs(c, mapRef))
+			}
+		}
+
+		if bom.Metadata == nil || bom.Metadata.Component == nil {
+			*mc.Merged.Components = append(*mc.Merged.Components, components...)
+			continue
+		}
+
+		parent := copyComponentWithRefs(*bom.Metadata.Component, mapRef)
+		if len(components) > 0 {
+			if parent.Components == nil {
+				parent.Components = &[]cyclonedx.Component{}
+			}
+			*parent.Components = append(*parent.Components, components...)
+		}
+		*mc.Merged.Components = append(*mc.Merged.Components, parent)
+	}
+
+	return refMappers, nil
+}
+
+// copyComponentWithRefs returns a copy of the component in which the bom-refs
+// of the component and all of its nested components are rewritten
+func copyComponentWithRefs(c cyclonedx.Component, mapRef RefMapper) cyclonedx.Component {
+	c.BOMRef = mapRef(c.BOMRef)
+	if c.Components != nil {
+		children := make([]cyclonedx.Component, 0, len(*c.Components))
+		for _, child := range *c.Components {
+			children = append(children, copyComponentWithRefs(child, mapRef))
+		}
+		c.Components = &children
+	}
+	return c
+}
+
+// purlKey returns the normalized form of a package URL, used to compare
+// package URLs that are written differently. Invalid package URLs are used
+// as they are.
+func purlKey(purl string) string {
+	if purl == "" {
+		return ""
+	}
+	parsed, err := packageurl.FromString(purl)
+	if err != nil {
+		return purl
+	}
+	return parsed.ToString()
+}
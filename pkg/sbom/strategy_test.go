@@ -0,0 +1,194 @@
+package sbom
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// writeStrategyTestSBOMs writes two SBOMs of different services that both
+// depend on the same lodash version
+func writeStrategyTestSBOMs(t *testing.T, testDir string) []string {
+	sbom1Path := filepath.Join(testDir, "sbom1.json")
+	sbom2Path := filepath.Join(testDir, "sbom2.json")
+
+	sbom1 := cyclonedx.NewBOM()
+	sbom1.SerialNumber = "urn:uuid:serial-1"
+	sbom1.Metadata = &cyclonedx.Metadata{
+		Component: &cyclonedx.Component{BOMRef: "service-a", Name: "service-a", Type: cyclonedx.ComponentTypeApplication},
+	}
+	sbom1.Components = &[]cyclonedx.Component{
+		{BOMRef: "lodash-ref-1", Name: "lodash", Version: "4.17.21", PackageURL: "pkg:npm/lodash@4.17.21", Type: cyclonedx.ComponentTypeLibrary},
+	}
+	sbom1.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "service-a", Dependencies: &[]string{"lodash-ref-1"}},
+	}
+
+	sbom2 := cyclonedx.NewBOM()
+	sbom2.SerialNumber = "urn:uuid:serial-2"
+	sbom2.Metadata = &cyclonedx.Metadata{
+		Component: &cyclonedx.Component{BOMRef: "service-b", Name: "service-b", Type: cyclonedx.ComponentTypeApplication},
+	}
+	sbom2.Components = &[]cyclonedx.Component{
+		{BOMRef: "lodash-ref-2", Name: "lodash", Version: "4.17.21", PackageURL: "pkg:npm/lodash@4.17.21", Type: cyclonedx.ComponentTypeLibrary},
+		{BOMRef: "internal", Name: "internal", Version: "1.0.0", Type: cyclonedx.ComponentTypeLibrary},
+	}
+	sbom2.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "service-b", Dependencies: &[]string{"lodash-ref-2", "internal"}},
+	}
+
+	if err := WriteSBOMFile(sbom1, sbom1Path); err != nil {
+		t.Fatalf("Failed to write test SBOM 1: %v", err)
+	}
+	if err := WriteSBOMFile(sbom2, sbom2Path); err != nil {
+		t.Fatalf("Failed to write test SBOM 2: %v", err)
+	}
+	return []string{sbom1Path, sbom2Path}
+}
+
+func mergeWithStrategy(t *testing.T, strategy MergeStrategy) *cyclonedx.BOM {
+	testDir := t.TempDir()
+	inputFiles := writeStrategyTestSBOMs(t, testDir)
+	outputPath := filepath.Join(testDir, "merged.json")
+
+	report, err := MergeSBOMsWithOptions(inputFiles, outputPath, MergeOptions{
+		Strategy:  strategy,
+		Integrity: IntegrityModeStrict,
+	})
+	if err != nil {
+		t.Fatalf("Failed to merge SBOMs: %v", err)
+	}
+	if len(report.IntegrityErrors) != 0 {
+		t.Errorf("Expected no integrity errors, got %v", report.IntegrityErrors)
+	}
+
+	mergedBom, err := ReadSBOMFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read merged SBOM: %v", err)
+	}
+	return mergedBom
+}
+
+func findDependency(bom *cyclonedx.BOM, ref string) *cyclonedx.Dependency {
+	if bom.Dependencies == nil {
+		return nil
+	}
+	for _, dep := range *bom.Dependencies {
+		if dep.Ref == ref {
+			return &dep
+		}
+	}
+	return nil
+}
+
+func TestPrefixStrategyKeepsDuplicatePurls(t *testing.T) {
+	mergedBom := mergeWithStrategy(t, PrefixStrategy{})
+
+	lodashCount := 0
+	for _, c := range *mergedBom.Components {
+		if c.Name == "lodash" {
+			lodashCount++
+		}
+	}
+	if lodashCount != 2 {
+		t.Errorf("Expected 2 lodash components with the prefix strategy, got %d", lodashCount)
+	}
+}
+
+func TestPurlStrategyMergesIdenticalPurls(t *testing.T) {
+	mergedBom := mergeWithStrategy(t, PurlStrategy{})
+
+	// service-a, service-b, lodash and internal
+	if len(*mergedBom.Components) != 4 {
+		t.Fatalf("Expected 4 components, got %d: %+v", len(*mergedBom.Components), *mergedBom.Components)
+	}
+	for _, c := range *mergedBom.Components {
+		if c.Name == "lodash" && c.BOMRef != "pkg:npm/lodash@4.17.21" {
+			t.Errorf("Expected merged lodash to use its purl as bom-ref, got %s", c.BOMRef)
+		}
+		if c.Name == "internal" && c.BOMRef != "urn:uuid:serial-2/internal" {
+			t.Errorf("Expected component without purl to be prefixed, got %s", c.BOMRef)
+		}
+	}
+
+	// Both services must now depend on the single lodash component
+	for _, service := range []string{"urn:uuid:serial-1/service-a", "urn:uuid:serial-2/service-b"} {
+		dep := findDependency(mergedBom, service)
+		if dep == nil || dep.Dependencies == nil || (*dep.Dependencies)[0] != "pkg:npm/lodash@4.17.21" {
+			t.Errorf("Expected %s to depend on pkg:npm/lodash@4.17.21, got %+v", service, dep)
+		}
+	}
+}
+
+func TestHierarchicalStrategyNestsComponents(t *testing.T) {
+	mergedBom := mergeWithStrategy(t, HierarchicalStrategy{})
+
+	if len(*mergedBom.Components) != 2 {
+		t.Fatalf("Expected 2 top-level components, got %d", len(*mergedBom.Components))
+	}
+	serviceB := (*mergedBom.Components)[1]
+	if serviceB.Name != "service-b" {
+		t.Fatalf("Expected service-b as second component, got %s", serviceB.Name)
+	}
+	if serviceB.Components == nil || len(*serviceB.Components) != 2 {
+		t.Fatalf("Expected service-b to have 2 sub-components, got %+v", serviceB.Components)
+	}
+	if (*serviceB.Components)[0].BOMRef != "urn:uuid:serial-2/lodash-ref-2" {
+		t.Errorf("Expected sub-component refs to be prefixed, got %s", (*serviceB.Components)[0].BOMRef)
+	}
+
+	// The merged root must depend on both services
+	root := findDependency(mergedBom, mergedBom.Metadata.Component.BOMRef)
+	if root == nil || root.Dependencies == nil || len(*root.Dependencies) != 2 {
+		t.Errorf("Expected merged root to depend on both services, got %+v", root)
+	}
+}
+
+// dropAllStrategy is a custom strategy that does not add any components
+type dropAllStrategy struct{}
+
+func (dropAllStrategy) Name() string {
+	return "drop-all"
+}
+
+func (dropAllStrategy) MergeComponents(mc *MergeContext) ([]RefMapper, error) {
+	refMappers := make([]RefMapper, 0, len(mc.Inputs))
+	for range mc.Inputs {
+		refMappers = append(refMappers, func(ref string) string { return ref })
+	}
+	return refMappers, nil
+}
+
+func TestMergeSBOMsWithCustomStrategy(t *testing.T) {
+	testDir := t.TempDir()
+	inputFiles := writeStrategyTestSBOMs(t, testDir)
+	outputPath := filepath.Join(testDir, "merged.json")
+
+	report, err := MergeSBOMsWithOptions(inputFiles, outputPath, MergeOptions{Strategy: dropAllStrategy{}})
+	if err != nil {
+		t.Fatalf("Failed to merge SBOMs: %v", err)
+	}
+
+	// Without components all dependencies are dangling
+	if len(report.IntegrityErrors) == 0 {
+		t.Error("Expected integrity errors for a strategy that drops all components")
+	}
+}
+
+func TestMergeStrategyByName(t *testing.T) {
+	for _, name := range []string{"prefix", "purl", "hierarchical"} {
+		strategy, err := MergeStrategyByName(name)
+		if err != nil {
+			t.Errorf("Expected strategy %s to exist: %v", name, err)
+			continue
+		}
+		if strategy.Name() != name {
+			t.Errorf("Expected strategy named %s, got %s", name, strategy.Name())
+		}
+	}
+
+	if _, err := MergeStrategyByName("unknown"); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+}
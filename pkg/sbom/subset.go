@@ -0,0 +1,150 @@
+package sbom
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+)
+
+// SubsetBOM returns a copy of the BOM with only the components keep returns
+// true for. Kept components that were nested in a removed component take its
+// place. The metadata component is always kept. Refs to removed components
+// are dropped from the dependencies, vulnerabilities and compositions;
+// vulnerabilities that only affected removed components are dropped as well.
+// The subset is a new BOM, so it gets a serial number derived from the
+// original one and the kept components.
+func SubsetBOM(bom *cyclonedx.BOM, keep func(*cyclonedx.Component) bool) *cyclonedx.BOM {
+	subset, _ := subsetBOM(bom, keep, true)
+	return subset
+}
+
+// subsetBOM implements SubsetBOM. Unless hoist is set, the nested components
+// of removed components are removed as well. It also returns the bom-refs of
+// the removed components.
+func subsetBOM(bom *cyclonedx.BOM, keep func(*cyclonedx.Component) bool, hoist bool) (*cyclonedx.BOM, map[string]bool) {
+	subset := *bom
+	removed := make(map[string]bool)
+	subset.Components = sliceOrNil(subsetComponents(bom.Components, keep, hoist, removed))
+	subset.Dependencies = subsetDependencies(bom.Dependencies, removed)
+
+	subset.Vulnerabilities = subsetVulnerabilities(bom.Vulnerabilities, removed)
+
+	if bom.Compositions != nil {
+		compositions := make([]cyclonedx.Composition, 0, len(*bom.Compositions))
+		for _, c := range *bom.Compositions {
+			c.Assemblies = subsetBOMReferences(c.Assemblies, removed)
+			c.Dependencies = subsetBOMReferences(c.Dependencies, removed)
+			compositions = append(compositions, c)
+		}
+		subset.Compositions = &compositions
+	}
+
+	var kept []string
+	walkComponents(subset.Components, func(c *cyclonedx.Component) {
+		kept = append(kept, c.BOMRef)
+	})
+	sort.Strings(kept)
+	serial := uuid.NewSHA1(reproducibleNamespace, []byte(bom.SerialNumber+"\n"+strings.Join(kept, "\n")))
+	subset.SerialNumber = "urn:uuid:" + serial.String()
+
+	return &subset, removed
+}
+
+// subsetComponents returns copies of the components to keep, recording the
+// bom-refs of the removed ones
+func subsetComponents(components *[]cyclonedx.Component, keep func(*cyclonedx.Component) bool, hoist bool, removed map[string]bool) []cyclonedx.Component {
+	if components == nil {
+		return nil
+	}
+	var kept []cyclonedx.Component
+	for i := range *components {
+		// keep gets the original component, so callers can select by identity
+		c := (*components)[i]
+		if keep(&(*components)[i]) {
+			c.Components = sliceOrNil(subsetComponents(c.Components, keep, hoist, removed))
+			kept = append(kept, c)
+			continue
+		}
+		if c.BOMRef != "" {
+			removed[c.BOMRef] = true
+		}
+		if hoist {
+			kept = append(kept, subsetComponents(c.Components, keep, hoist, removed)...)
+		} else {
+			walkComponents(c.Components, func(nested *cyclonedx.Component) {
+				if nested.BOMRef != "" {
+					removed[nested.BOMRef] = true
+				}
+			})
+		}
+	}
+	return kept
+}
+
+// subsetDependencies returns a copy of the dependencies without the entries
+// of and edges to removed components
+func subsetDependencies(dependencies *[]cyclonedx.Dependency, removed map[string]bool) *[]cyclonedx.Dependency {
+	if dependencies == nil {
+		return nil
+	}
+	subset := make([]cyclonedx.Dependency, 0, len(*dependencies))
+	for _, d := range *dependencies {
+		if removed[d.Ref] {
+			continue
+		}
+		newDep := cyclonedx.Dependency{Ref: d.Ref}
+		if d.Dependencies != nil {
+			dependsOn := make([]string, 0, len(*d.Dependencies))
+			for _, target := range *d.Dependencies {
+				if !removed[target] {
+					dependsOn = append(dependsOn, target)
+				}
+			}
+			newDep.Dependencies = &dependsOn
+		}
+		subset = append(subset, newDep)
+	}
+	return &subset
+}
+
+// subsetVulnerabilities returns a copy of the vulnerabilities without the
+// affected removed components, dropping vulnerabilities that only affected
+// removed components
+func subsetVulnerabilities(vulnerabilities *[]cyclonedx.Vulnerability, removed map[string]bool) *[]cyclonedx.Vulnerability {
+	if vulnerabilities == nil {
+		return nil
+	}
+	var subset []cyclonedx.Vulnerability
+	for _, v := range *vulnerabilities {
+		if v.Affects != nil {
+			var affects []cyclonedx.Affects
+			for _, affected := range *v.Affects {
+				if !removed[affected.Ref] {
+					affects = append(affects, affected)
+				}
+			}
+			if len(affects) == 0 {
+				continue
+			}
+			v.Affects = &affects
+		}
+		subset = append(subset, v)
+	}
+	return sliceOrNil(subset)
+}
+
+// subsetBOMReferences returns a copy of the references without removed refs
+func subsetBOMReferences(refs *[]cyclonedx.BOMReference, removed map[string]bool) *[]cyclonedx.BOMReference {
+	if refs == nil {
+		return nil
+	}
+	subset := make([]cyclonedx.BOMReference, 0, len(*refs))
+	for _, ref := range *refs {
+		if !removed[string(ref)] {
+			subset = append(subset, ref)
+		}
+	}
+	return &subset
+}
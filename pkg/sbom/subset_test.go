@@ -0,0 +1,64 @@
+package sbom
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestSubsetBOM(t *testing.T) {
+	bom := queryTestBOM()
+	bom.SerialNumber = "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root", Name: "root"}}
+	*bom.Dependencies = append(*bom.Dependencies, cyclonedx.Dependency{Ref: "root", Dependencies: &[]string{"app", "testify"}})
+	bom.Vulnerabilities = &[]cyclonedx.Vulnerability{
+		{ID: "CVE-2021-0001", Affects: &[]cyclonedx.Affects{{Ref: "lodash"}, {Ref: "testify"}}},
+		{ID: "CVE-2021-0002", Affects: &[]cyclonedx.Affects{{Ref: "testify"}}},
+		{ID: "CVE-2021-0003"},
+	}
+	bom.Compositions = &[]cyclonedx.Composition{
+		{Aggregate: cyclonedx.CompositionAggregateComplete, Assemblies: &[]cyclonedx.BOMReference{"app", "testify"}},
+	}
+
+	// Removing app hoists its nested plugin
+	subset := SubsetBOM(bom, func(c *cyclonedx.Component) bool {
+		return c.BOMRef != "app" && c.Scope != cyclonedx.ScopeExcluded
+	})
+
+	if refs := componentRefs(*subset.Components); !slices.Equal(refs, []string{"plugin", "lodash", "uuid"}) {
+		t.Errorf("Unexpected components: %v", refs)
+	}
+	if len(*bom.Components) != 4 || (*bom.Components)[0].Components == nil {
+		t.Error("Expected the original BOM to be unchanged")
+	}
+
+	expectedDependencies := map[string][]string{
+		"uuid": {"lodash"},
+		"root": {},
+	}
+	if len(*subset.Dependencies) != len(expectedDependencies) {
+		t.Errorf("Unexpected dependencies: %+v", *subset.Dependencies)
+	}
+	for _, dep := range *subset.Dependencies {
+		if expected, ok := expectedDependencies[dep.Ref]; !ok || !slices.Equal(*dep.Dependencies, expected) {
+			t.Errorf("Unexpected dependencies of %s: %v", dep.Ref, *dep.Dependencies)
+		}
+	}
+
+	if len(*subset.Vulnerabilities) != 2 || len(*(*subset.Vulnerabilities)[0].Affects) != 1 || (*subset.Vulnerabilities)[1].ID != "CVE-2021-0003" {
+		t.Errorf("Unexpected vulnerabilities: %+v", *subset.Vulnerabilities)
+	}
+	if assemblies := *(*subset.Compositions)[0].Assemblies; len(assemblies) != 0 {
+		t.Errorf("Expected the assemblies to be removed, got %v", assemblies)
+	}
+
+	if subset.SerialNumber == bom.SerialNumber || subset.SerialNumber != SubsetBOM(bom, func(c *cyclonedx.Component) bool {
+		return c.BOMRef != "app" && c.Scope != cyclonedx.ScopeExcluded
+	}).SerialNumber {
+		t.Errorf("Expected a new, reproducible serial number, got %s", subset.SerialNumber)
+	}
+	if subset.Metadata.Component.BOMRef != "root" {
+		t.Error("Expected the metadata component to be kept")
+	}
+}
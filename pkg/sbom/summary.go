@@ -0,0 +1,249 @@
+package sbom
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// SummaryTopComponents is the number of components listed in a Summary
+const SummaryTopComponents = 10
+
+// Summary is an overview of an SBOM, as shown by the inspect command
+type Summary struct {
+	File         string            `json:"file" yaml:"file"`
+	BOMFormat    string            `json:"bomFormat" yaml:"bomFormat"`
+	SpecVersion  string            `json:"specVersion" yaml:"specVersion"`
+	SerialNumber string            `json:"serialNumber,omitempty" yaml:"serialNumber,omitempty"`
+	Version      int               `json:"version" yaml:"version"`
+	Metadata     *MetadataSummary  `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Components   ComponentSummary  `json:"components" yaml:"components"`
+	Dependencies DependencySummary `json:"dependencies" yaml:"dependencies"`
+	// Vulnerabilities summarizes the vulnerabilities section, e.g. as added by vuln scan
+	Vulnerabilities VulnerabilitySummary `json:"vulnerabilities" yaml:"vulnerabilities"`
+}
+
+// MetadataSummary is the part of the SBOM's metadata shown in a Summary
+type MetadataSummary struct {
+	Timestamp string        `json:"timestamp,omitempty" yaml:"timestamp,omitempty"`
+	Tools     []ToolSummary `json:"tools,omitempty" yaml:"tools,omitempty"`
+}
+
+// ToolSummary is a tool that created the SBOM, either from the legacy tools
+// list or from the tool components
+type ToolSummary struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Vendor  string `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+}
+
+// ComponentSummary counts the top-level components of an SBOM by type and
+// lists the first of them by name
+type ComponentSummary struct {
+	Total int            `json:"total" yaml:"total"`
+	Types map[string]int `json:"types" yaml:"types"`
+	Top   []TopComponent `json:"top" yaml:"top"`
+}
+
+// TopComponent is a component listed in a ComponentSummary
+type TopComponent struct {
+	Name       string `json:"name" yaml:"name"`
+	Version    string `json:"version,omitempty" yaml:"version,omitempty"`
+	Type       string `json:"type,omitempty" yaml:"type,omitempty"`
+	PackageURL string `json:"purl,omitempty" yaml:"purl,omitempty"`
+}
+
+// DependencySummary describes the dependency graph of an SBOM
+type DependencySummary struct {
+	Total         int `json:"total" yaml:"total"`
+	WithDependsOn int `json:"withDependsOn" yaml:"withDependsOn"`
+	MaxDependsOn  int `json:"maxDependsOn" yaml:"maxDependsOn"`
+}
+
+// VulnerabilitySummary counts the vulnerabilities of an SBOM by severity and
+// analysis state, and lists the most affected components and the
+// vulnerabilities with fixes
+type VulnerabilitySummary struct {
+	Total int `json:"total" yaml:"total"`
+	// Severities counts the vulnerabilities by the highest severity of their ratings
+	Severities map[string]int `json:"severities" yaml:"severities"`
+	// States counts the vulnerabilities by analysis state, "not_analyzed" if they have none
+	States      map[string]int      `json:"states" yaml:"states"`
+	TopAffected []AffectedComponent `json:"topAffected" yaml:"topAffected"`
+	Fixes       []VulnerabilityFix  `json:"fixes" yaml:"fixes"`
+}
+
+// AffectedComponent is a component listed in a VulnerabilitySummary with the
+// number of vulnerabilities affecting it
+type AffectedComponent struct {
+	Component string `json:"component" yaml:"component"`
+	// BOMRef tells apart the same package in several sources of a merged SBOM
+	BOMRef          string `json:"bomRef" yaml:"bomRef"`
+	Vulnerabilities int    `json:"vulnerabilities" yaml:"vulnerabilities"`
+	HighestSeverity string `json:"highestSeverity" yaml:"highestSeverity"`
+}
+
+// VulnerabilityFix is a vulnerability with a fix: versions that are not
+// affected, or a recommendation or analysis response to update or roll back
+type VulnerabilityFix struct {
+	ID             string   `json:"id" yaml:"id"`
+	Severity       string   `json:"severity" yaml:"severity"`
+	FixedVersions  []string `json:"fixedVersions,omitempty" yaml:"fixedVersions,omitempty"`
+	Recommendation string   `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+}
+
+// SummarizeSBOM returns the summary of an SBOM read from the given file
+func SummarizeSBOM(bom *cyclonedx.BOM, file string) *Summary {
+	summary := &Summary{
+		File:         file,
+		BOMFormat:    bom.BOMFormat,
+		SpecVersion:  bom.SpecVersion.String(),
+		SerialNumber: bom.SerialNumber,
+		Version:      bom.Version,
+		Components: ComponentSummary{
+			Types: map[string]int{},
+			Top:   []TopComponent{},
+		},
+	}
+
+	if bom.Metadata != nil {
+		summary.Metadata = &MetadataSummary{Timestamp: bom.Metadata.Timestamp}
+		if tools := bom.Metadata.Tools; tools != nil {
+			// Handle deprecated Tools field (for backward compatibility)
+			if tools.Tools != nil {
+				for _, tool := range *tools.Tools {
+					summary.Metadata.Tools = append(summary.Metadata.Tools, ToolSummary{Name: tool.Name, Version: tool.Version, Vendor: tool.Vendor})
+				}
+			}
+			if tools.Components != nil {
+				for _, component := range *tools.Components {
+					summary.Metadata.Tools = append(summary.Metadata.Tools, ToolSummary{Name: component.Name, Version: component.Version, Vendor: component.Publisher})
+				}
+			}
+		}
+	}
+
+	if bom.Components != nil {
+		components := slices.Clone(*bom.Components)
+		summary.Components.Total = len(components)
+		for _, component := range components {
+			summary.Components.Types[string(component.Type)]++
+		}
+
+		// Sort components by name for consistent output
+		sort.SliceStable(components, func(i, j int) bool {
+			return strings.ToLower(components[i].Name) < strings.ToLower(components[j].Name)
+		})
+		for i := 0; i < len(components) && i < SummaryTopComponents; i++ {
+			summary.Components.Top = append(summary.Components.Top, TopComponent{
+				Name:       components[i].Name,
+				Version:    components[i].Version,
+				Type:       string(components[i].Type),
+				PackageURL: components[i].PackageURL,
+			})
+		}
+	}
+
+	if bom.Dependencies != nil {
+		summary.Dependencies.Total = len(*bom.Dependencies)
+		for _, dep := range *bom.Dependencies {
+			if dep.Dependencies != nil && len(*dep.Dependencies) > 0 {
+				summary.Dependencies.WithDependsOn++
+				summary.Dependencies.MaxDependsOn = max(summary.Dependencies.MaxDependsOn, len(*dep.Dependencies))
+			}
+		}
+	}
+
+	summary.Vulnerabilities = summarizeVulnerabilities(bom)
+
+	return summary
+}
+
+// summarizeVulnerabilities returns the VulnerabilitySummary of an SBOM
+func summarizeVulnerabilities(bom *cyclonedx.BOM) VulnerabilitySummary {
+	summary := VulnerabilitySummary{
+		Severities:  map[string]int{},
+		States:      map[string]int{},
+		TopAffected: []AffectedComponent{},
+		Fixes:       []VulnerabilityFix{},
+	}
+	if bom.Vulnerabilities == nil {
+		return summary
+	}
+
+	labels := make(map[string]string)
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		labels[bom.Metadata.Component.BOMRef] = componentLabel(bom.Metadata.Component)
+		walkComponents(bom.Metadata.Component.Components, func(c *cyclonedx.Component) { labels[c.BOMRef] = componentLabel(c) })
+	}
+	walkComponents(bom.Components, func(c *cyclonedx.Component) { labels[c.BOMRef] = componentLabel(c) })
+
+	affected := make(map[string]*AffectedComponent)
+	var order []string
+	for _, v := range *bom.Vulnerabilities {
+		summary.Total++
+		severity := highestSeverity(v.Ratings)
+		summary.Severities[string(severity)]++
+		state := "not_analyzed"
+		if v.Analysis != nil && v.Analysis.State != "" {
+			state = string(v.Analysis.State)
+		}
+		summary.States[state]++
+
+		fix := VulnerabilityFix{ID: v.ID, Severity: string(severity), Recommendation: v.Recommendation}
+		hasFix := v.Recommendation != "" || v.Analysis != nil && v.Analysis.Response != nil && slices.ContainsFunc(*v.Analysis.Response, func(r cyclonedx.ImpactAnalysisResponse) bool {
+			return r == cyclonedx.IARUpdate || r == cyclonedx.IARRollback
+		})
+		if v.Affects != nil {
+			seen := make(map[string]bool)
+			for _, a := range *v.Affects {
+				if a.Range != nil {
+					for _, r := range *a.Range {
+						if r.Status == cyclonedx.VulnerabilityStatusNotAffected && r.Version != "" && !slices.Contains(fix.FixedVersions, r.Version) {
+							fix.FixedVersions = append(fix.FixedVersions, r.Version)
+						}
+					}
+				}
+
+				// Vulnerabilities are counted once per component
+				if seen[a.Ref] {
+					continue
+				}
+				seen[a.Ref] = true
+				c, ok := affected[a.Ref]
+				if !ok {
+					label := labels[a.Ref]
+					if label == "" {
+						label = a.Ref
+					}
+					c = &AffectedComponent{Component: label, BOMRef: a.Ref, HighestSeverity: string(cyclonedx.SeverityUnknown)}
+					affected[a.Ref] = c
+					order = append(order, a.Ref)
+				}
+				c.Vulnerabilities++
+				if slices.Index(severityOrder, severity) > slices.Index(severityOrder, cyclonedx.Severity(c.HighestSeverity)) {
+					c.HighestSeverity = string(severity)
+				}
+			}
+		}
+		if hasFix || len(fix.FixedVersions) > 0 {
+			summary.Fixes = append(summary.Fixes, fix)
+		}
+	}
+
+	// Most affected components first, then by severity
+	components := make([]AffectedComponent, 0, len(order))
+	for _, ref := range order {
+		components = append(components, *affected[ref])
+	}
+	sort.SliceStable(components, func(i, j int) bool {
+		if components[i].Vulnerabilities != components[j].Vulnerabilities {
+			return components[i].Vulnerabilities > components[j].Vulnerabilities
+		}
+		return slices.Index(severityOrder, cyclonedx.Severity(components[i].HighestSeverity)) > slices.Index(severityOrder, cyclonedx.Severity(components[j].HighestSeverity))
+	})
+	summary.TopAffected = components[:min(len(components), SummaryTopComponents)]
+	return summary
+}
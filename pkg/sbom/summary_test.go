@@ -0,0 +1,112 @@
+package sbom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestSummarizeSBOM(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.SpecVersion = cyclonedx.SpecVersion1_5
+	bom.Metadata = &cyclonedx.Metadata{
+		Timestamp: "2023-01-01T12:00:00Z",
+		Tools: &cyclonedx.ToolsChoice{
+			Components: &[]cyclonedx.Component{{Name: "Test Tool", Version: "1.0.0", Publisher: "Test Vendor"}},
+		},
+	}
+	var components []cyclonedx.Component
+	for i := 12; i > 0; i-- {
+		components = append(components, cyclonedx.Component{
+			BOMRef: fmt.Sprintf("c%02d", i),
+			Name:   fmt.Sprintf("component-%02d", i),
+			Type:   cyclonedx.ComponentTypeLibrary,
+		})
+	}
+	components[0].Type = cyclonedx.ComponentTypeFramework
+	bom.Components = &components
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "c01", Dependencies: &[]string{"c02", "c03"}},
+		{Ref: "c02", Dependencies: &[]string{"c03"}},
+		{Ref: "c03"},
+	}
+
+	summary := SummarizeSBOM(bom, "sbom.json")
+
+	if summary.File != "sbom.json" || summary.SpecVersion != "1.5" {
+		t.Errorf("Unexpected header: %+v", summary)
+	}
+	if len(summary.Metadata.Tools) != 1 || summary.Metadata.Tools[0].Vendor != "Test Vendor" {
+		t.Errorf("Expected tool component with publisher as vendor, got %+v", summary.Metadata.Tools)
+	}
+	if summary.Components.Total != 12 || summary.Components.Types["library"] != 11 || summary.Components.Types["framework"] != 1 {
+		t.Errorf("Unexpected component counts: %+v", summary.Components)
+	}
+	if len(summary.Components.Top) != SummaryTopComponents || summary.Components.Top[0].Name != "component-01" {
+		t.Errorf("Expected the first %d components by name, got %+v", SummaryTopComponents, summary.Components.Top)
+	}
+	if (*bom.Components)[0].Name != "component-12" {
+		t.Error("Expected the components of the BOM to keep their order")
+	}
+	if summary.Dependencies != (DependencySummary{Total: 3, WithDependsOn: 2, MaxDependsOn: 2}) {
+		t.Errorf("Unexpected dependency summary: %+v", summary.Dependencies)
+	}
+}
+
+func TestSummarizeVulnerabilities(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "lodash", Name: "lodash", Version: "4.17.20", PackageURL: "pkg:npm/lodash@4.17.20"},
+		{BOMRef: "minimist", Name: "minimist", Version: "1.2.5"},
+	}
+	score := 9.1
+	bom.Vulnerabilities = &[]cyclonedx.Vulnerability{
+		{
+			ID:      "CVE-2021-23337",
+			Ratings: &[]cyclonedx.VulnerabilityRating{{Severity: cyclonedx.SeverityMedium}, {Score: &score}},
+			Affects: &[]cyclonedx.Affects{{Ref: "lodash", Range: &[]cyclonedx.AffectedVersions{
+				{Version: "4.17.20", Status: cyclonedx.VulnerabilityStatusAffected},
+				{Version: "4.17.21", Status: cyclonedx.VulnerabilityStatusNotAffected},
+			}}},
+		},
+		{
+			ID:       "CVE-2020-28500",
+			Ratings:  &[]cyclonedx.VulnerabilityRating{{Severity: cyclonedx.SeverityMedium}},
+			Analysis: &cyclonedx.VulnerabilityAnalysis{State: cyclonedx.IASNotAffected},
+			Affects:  &[]cyclonedx.Affects{{Ref: "lodash"}, {Ref: "lodash"}},
+		},
+		{
+			ID:       "CVE-2021-44906",
+			Analysis: &cyclonedx.VulnerabilityAnalysis{State: cyclonedx.IASExploitable, Response: &[]cyclonedx.ImpactAnalysisResponse{cyclonedx.IARUpdate}},
+			Affects:  &[]cyclonedx.Affects{{Ref: "minimist"}},
+		},
+	}
+
+	summary := SummarizeSBOM(bom, "sbom.json").Vulnerabilities
+	if summary.Total != 3 {
+		t.Errorf("Expected 3 vulnerabilities, got %d", summary.Total)
+	}
+	if len(summary.Severities) != 3 || summary.Severities["critical"] != 1 || summary.Severities["medium"] != 1 || summary.Severities["unknown"] != 1 {
+		t.Errorf("Unexpected severities: %v", summary.Severities)
+	}
+	if len(summary.States) != 3 || summary.States["not_analyzed"] != 1 || summary.States["not_affected"] != 1 || summary.States["exploitable"] != 1 {
+		t.Errorf("Unexpected states: %v", summary.States)
+	}
+
+	expectedTop := []AffectedComponent{
+		{Component: "pkg:npm/lodash@4.17.20", BOMRef: "lodash", Vulnerabilities: 2, HighestSeverity: "critical"},
+		{Component: "minimist@1.2.5", BOMRef: "minimist", Vulnerabilities: 1, HighestSeverity: "unknown"},
+	}
+	if fmt.Sprint(summary.TopAffected) != fmt.Sprint(expectedTop) {
+		t.Errorf("Expected top affected %v, got %v", expectedTop, summary.TopAffected)
+	}
+
+	expectedFixes := []VulnerabilityFix{
+		{ID: "CVE-2021-23337", Severity: "critical", FixedVersions: []string{"4.17.21"}},
+		{ID: "CVE-2021-44906", Severity: "unknown"},
+	}
+	if fmt.Sprint(summary.Fixes) != fmt.Sprint(expectedFixes) {
+		t.Errorf("Expected fixes %v, got %v", expectedFixes, summary.Fixes)
+	}
+}
@@ -0,0 +1,282 @@
+package sbom
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaFS contains the CycloneDX JSON schemas, so validation works without network access
+//
+//go:embed schema/*.json
+var schemaFS embed.FS
+
+// schemaBaseURL is the URL the CycloneDX schemas reference each other by
+const schemaBaseURL = "http://cyclonedx.org/schema/"
+
+// jsonSchemaFiles maps the supported spec versions to their embedded schema
+var jsonSchemaFiles = map[string]string{
+	"1.2": "bom-1.2.schema.json",
+	"1.3": "bom-1.3.schema.json",
+	"1.4": "bom-1.4.schema.json",
+	"1.5": "bom-1.5.schema.json",
+	"1.6": "bom-1.6.schema.json",
+}
+
+// ValidationError is a single problem found while validating an SBOM
+type ValidationError struct {
+	// Pointer is a JSON pointer to the offending value
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	pointer := e.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + e.Message
+}
+
+// ValidateSBOMFile validates a CycloneDX JSON SBOM file against the schema of
+// its specVersion and checks the integrity of its bom-refs. XML and protobuf
+// files are decoded and only checked for the integrity of their bom-refs. The
+// returned error is only set if the validation could not be performed at all.
+func ValidateSBOMFile(filename string) ([]ValidationError, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	switch format := DetectFileFormat(data); format {
+	case FileFormatJSON:
+	case FileFormatXML, FileFormatProto:
+		bom, err := DecodeSBOM(data, format)
+		if err != nil {
+			return []ValidationError{{Message: fmt.Sprintf("failed to decode BOM: %v", err)}}, nil
+		}
+		return CheckRefIntegrity(bom), nil
+	default:
+		return nil, fmt.Errorf("validation is only supported for CycloneDX, not %s", format)
+	}
+
+	validationErrors, err := ValidateSchema(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Semantic checks need the decoded BOM. If it cannot be decoded, the schema
+	// errors already explain why.
+	bom := &cyclonedx.BOM{}
+	if err := cyclonedx.NewBOMDecoder(bytes.NewReader(data), cyclonedx.BOMFileFormatJSON).Decode(bom); err != nil {
+		if len(validationErrors) == 0 {
+			validationErrors = append(validationErrors, ValidationError{Message: fmt.Sprintf("failed to decode BOM: %v", err)})
+		}
+		return validationErrors, nil
+	}
+
+	return append(validationErrors, CheckRefIntegrity(bom)...), nil
+}
+
+// ValidateSchema validates a CycloneDX JSON document against the embedded
+// JSON schema matching its specVersion
+func ValidateSchema(data []byte) ([]ValidationError, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return []ValidationError{{Message: "document is not a JSON object"}}, nil
+	}
+	specVersion, _ := obj["specVersion"].(string)
+	if specVersion == "" {
+		return []ValidationError{{Pointer: "/specVersion", Message: "missing specVersion"}}, nil
+	}
+
+	schema, err := compileSchema(specVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	err = schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	schemaErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, fmt.Errorf("failed to validate: %w", err)
+	}
+
+	var validationErrors []ValidationError
+	collectSchemaErrors(schemaErr, &validationErrors)
+	return validationErrors, nil
+}
+
+// compiledSchemas caches the compiled schemas by spec version
+var (
+	compiledSchemas   = make(map[string]*jsonschema.Schema)
+	compiledSchemasMu sync.Mutex
+)
+
+// compileSchema compiles the embedded schema for the given spec version, once
+// per spec version
+func compileSchema(specVersion string) (*jsonschema.Schema, error) {
+	schemaFile, ok := jsonSchemaFiles[specVersion]
+	if !ok {
+		return nil, fmt.Errorf("no JSON schema available for spec version %s", specVersion)
+	}
+
+	compiledSchemasMu.Lock()
+	defer compiledSchemasMu.Unlock()
+	if schema, ok := compiledSchemas[specVersion]; ok {
+		return schema, nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	entries, err := schemaFS.ReadDir("schema")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded schemas: %w", err)
+	}
+	for _, entry := range entries {
+		data, err := schemaFS.ReadFile(path.Join("schema", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded schema %s: %w", entry.Name(), err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse embedded schema %s: %w", entry.Name(), err)
+		}
+		if err := compiler.AddResource(schemaBaseURL+entry.Name(), doc); err != nil {
+			return nil, fmt.Errorf("failed to add embedded schema %s: %w", entry.Name(), err)
+		}
+	}
+
+	schema, err := compiler.Compile(schemaBaseURL + schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema for spec version %s: %w", specVersion, err)
+	}
+	compiledSchemas[specVersion] = schema
+	return schema, nil
+}
+
+// collectSchemaErrors flattens the error tree of the schema validator into
+// its leaves, which carry the actual reasons
+func collectSchemaErrors(err *jsonschema.ValidationError, result *[]ValidationError) {
+	if len(err.Causes) == 0 {
+		message := ""
+		if output := err.BasicOutput(); output.Error != nil {
+			message = output.Error.String()
+		}
+		*result = append(*result, ValidationError{
+			Pointer: jsonPointer(err.InstanceLocation...),
+			Message: message,
+		})
+		return
+	}
+	for _, cause := range err.Causes {
+		collectSchemaErrors(cause, result)
+	}
+}
+
+// CheckRefIntegrity checks that all bom-refs in the BOM are unique, that every
+// dependencies entry belongs to a known bom-ref and that every dependsOn
+// target resolves
+func CheckRefIntegrity(bom *cyclonedx.BOM) []ValidationError {
+	var validationErrors []ValidationError
+
+	// Collect all bom-refs, remembering where they were first defined
+	refs := make(map[string]string)
+	addRef := func(ref, pointer string) {
+		if ref == "" {
+			return
+		}
+		if first, exists := refs[ref]; exists {
+			validationErrors = append(validationErrors, ValidationError{
+				Pointer: pointer + "/bom-ref",
+				Message: fmt.Sprintf("duplicate bom-ref %q, first defined at %s", ref, first),
+			})
+			return
+		}
+		refs[ref] = pointer
+	}
+
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		addRef(bom.Metadata.Component.BOMRef, "/metadata/component")
+		collectComponentRefs(bom.Metadata.Component.Components, "/metadata/component/components", addRef)
+	}
+	collectComponentRefs(bom.Components, "/components", addRef)
+	collectServiceRefs(bom.Services, "/services", addRef)
+	if bom.Vulnerabilities != nil {
+		for i, vuln := range *bom.Vulnerabilities {
+			addRef(vuln.BOMRef, jsonPointer("vulnerabilities", strconv.Itoa(i)))
+		}
+	}
+
+	// Check that the dependency graph only references known bom-refs
+	if bom.Dependencies != nil {
+		for i, dep := range *bom.Dependencies {
+			pointer := jsonPointer("dependencies", strconv.Itoa(i))
+			if _, ok := refs[dep.Ref]; !ok {
+				validationErrors = append(validationErrors, ValidationError{
+					Pointer: pointer + "/ref",
+					Message: fmt.Sprintf("dependency ref %q does not match any bom-ref", dep.Ref),
+				})
+			}
+			if dep.Dependencies == nil {
+				continue
+			}
+			for j, target := range *dep.Dependencies {
+				if _, ok := refs[target]; !ok {
+					validationErrors = append(validationErrors, ValidationError{
+						Pointer: pointer + jsonPointer("dependsOn", strconv.Itoa(j)),
+						Message: fmt.Sprintf("dependsOn target %q does not match any bom-ref", target),
+					})
+				}
+			}
+		}
+	}
+
+	return validationErrors
+}
+
+func collectComponentRefs(components *[]cyclonedx.Component, pointer string, addRef func(ref, pointer string)) {
+	if components == nil {
+		return
+	}
+	for i, c := range *components {
+		componentPointer := pointer + "/" + strconv.Itoa(i)
+		addRef(c.BOMRef, componentPointer)
+		collectComponentRefs(c.Components, componentPointer+"/components", addRef)
+	}
+}
+
+func collectServiceRefs(services *[]cyclonedx.Service, pointer string, addRef func(ref, pointer string)) {
+	if services == nil {
+		return
+	}
+	for i, s := range *services {
+		servicePointer := pointer + "/" + strconv.Itoa(i)
+		addRef(s.BOMRef, servicePointer)
+		collectServiceRefs(s.Services, servicePointer+"/services", addRef)
+	}
+}
+
+// jsonPointer builds a JSON pointer (RFC 6901) from the given reference tokens
+func jsonPointer(tokens ...string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		sb.WriteString("/" + token)
+	}
+	return sb.String()
+}
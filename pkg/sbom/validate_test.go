@@ -0,0 +1,129 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestValidateSBOMFileValid(t *testing.T) {
+	validationErrors, err := ValidateSBOMFile("../../testdata/merged.json")
+	if err != nil {
+		t.Fatalf("Failed to validate SBOM: %v", err)
+	}
+	if len(validationErrors) != 0 {
+		t.Errorf("Expected no validation errors, got %v", validationErrors)
+	}
+}
+
+func TestValidateSBOMFileSchemaErrors(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "invalid.json")
+	content := `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "version": 1,
+  "components": [
+    {"type": "library", "version": "1.0.0"}
+  ]
+}`
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test SBOM: %v", err)
+	}
+
+	validationErrors, err := ValidateSBOMFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to validate SBOM: %v", err)
+	}
+	if len(validationErrors) != 1 {
+		t.Fatalf("Expected 1 validation error, got %v", validationErrors)
+	}
+	if validationErrors[0].Pointer != "/components/0" || !strings.Contains(validationErrors[0].Message, "name") {
+		t.Errorf("Expected missing name error at /components/0, got %v", validationErrors[0])
+	}
+}
+
+func TestValidateSBOMFileXMLAndProto(t *testing.T) {
+	validationErrors, err := ValidateSBOMFile(filepath.Join("..", "..", "testdata", "sbom3.xml"))
+	if err != nil {
+		t.Fatalf("Failed to validate SBOM: %v", err)
+	}
+	if len(validationErrors) != 0 {
+		t.Errorf("Expected no validation errors for the XML SBOM, got %v", validationErrors)
+	}
+
+	bom := cyclonedx.NewBOM()
+	bom.Components = &[]cyclonedx.Component{{BOMRef: "lib", Type: cyclonedx.ComponentTypeLibrary, Name: "lib"}}
+	bom.Dependencies = &[]cyclonedx.Dependency{{Ref: "lib", Dependencies: &[]string{"missing"}}}
+	testFile := filepath.Join(t.TempDir(), "sbom.cdx.bin")
+	if err := WriteSBOMFileFormat(bom, testFile, FileFormatProto); err != nil {
+		t.Fatalf("Failed to write test SBOM: %v", err)
+	}
+	validationErrors, err = ValidateSBOMFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to validate SBOM: %v", err)
+	}
+	if len(validationErrors) != 1 || validationErrors[0].Pointer != "/dependencies/0/dependsOn/0" {
+		t.Errorf("Expected the dangling dependsOn target of the protobuf SBOM, got %v", validationErrors)
+	}
+}
+
+func TestCompileSchemaCached(t *testing.T) {
+	first, err := compileSchema("1.6")
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	second, err := compileSchema("1.6")
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	if first != second {
+		t.Error("Expected the schema to be compiled once")
+	}
+}
+
+func TestValidateSchemaUnsupportedVersion(t *testing.T) {
+	_, err := ValidateSchema([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.1"}`))
+	if err == nil {
+		t.Error("Expected error for unsupported spec version")
+	}
+}
+
+func TestCheckRefIntegrity(t *testing.T) {
+	bom := cyclonedx.NewBOM()
+	bom.Metadata = &cyclonedx.Metadata{
+		Component: &cyclonedx.Component{BOMRef: "app", Name: "app"},
+	}
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: "pkg:npm/foo@1.0.0", Name: "foo"},
+		{
+			BOMRef: "pkg:npm/bar@1.0.0",
+			Name:   "bar",
+			Components: &[]cyclonedx.Component{
+				{BOMRef: "pkg:npm/foo@1.0.0", Name: "foo"},
+			},
+		},
+	}
+	bom.Dependencies = &[]cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"pkg:npm/foo@1.0.0", "pkg:npm/missing@1.0.0"}},
+		{Ref: "pkg:npm/orphan@1.0.0"},
+	}
+
+	validationErrors := CheckRefIntegrity(bom)
+
+	expected := []string{
+		"/components/1/components/0/bom-ref",
+		"/dependencies/0/dependsOn/1",
+		"/dependencies/1/ref",
+	}
+	if len(validationErrors) != len(expected) {
+		t.Fatalf("Expected %d validation errors, got %v", len(expected), validationErrors)
+	}
+	for i, pointer := range expected {
+		if validationErrors[i].Pointer != pointer {
+			t.Errorf("Expected error %d at %s, got %v", i, pointer, validationErrors[i])
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+)
+
+// VEXStatement is a statement of a VEX document about the impact of a
+// vulnerability on products, normalized from OpenVEX, CSAF VEX or CycloneDX VEX
+type VEXStatement struct {
+	Vulnerability string   `json:"vulnerability"`
+	Aliases       []string `json:"aliases,omitempty"`
+	// Products are package URLs, bom-refs or BOM-Links of the components the
+	// statement is about
+	Products      []string                              `json:"products"`
+	State         cyclonedx.ImpactAnalysisState         `json:"state"`
+	Justification cyclonedx.ImpactAnalysisJustification `json:"justification,omitempty"`
+	Response      []cyclonedx.ImpactAnalysisResponse    `json:"response,omitempty"`
+	Detail        string                                `json:"detail,omitempty"`
+	Timestamp     string                                `json:"timestamp,omitempty"`
+}
+
+// VEXReport is the result of applying VEX statements to an SBOM
+type VEXReport struct {
+	// Applied is the number of statements that updated a vulnerability
+	Applied int `json:"applied"`
+	// Updated is the number of vulnerabilities whose analysis was updated
+	Updated   int                     `json:"updated"`
+	Unmatched []UnmatchedVEXStatement `json:"unmatched"`
+}
+
+// UnmatchedVEXStatement is a statement that did not apply to the SBOM
+type UnmatchedVEXStatement struct {
+	Vulnerability string   `json:"vulnerability"`
+	Products      []string `json:"products"`
+	Reason        string   `json:"reason"`
+}
+
+// vexJustifications map the justifications of OpenVEX and the CSAF VEX flags
+// to CycloneDX, as recommended by CISA's "Minimum Requirements for VEX"
+var vexJustifications = map[string]cyclonedx.ImpactAnalysisJustification{
+	"component_not_present":                             cyclonedx.IAJCodeNotPresent,
+	"vulnerable_code_not_present":                       cyclonedx.IAJCodeNotPresent,
+	"vulnerable_code_not_in_execute_path":               cyclonedx.IAJCodeNotReachable,
+	"vulnerable_code_cannot_be_controlled_by_adversary": cyclonedx.IAJRequiresEnvironment,
+	"inline_mitigations_already_exist":                  cyclonedx.IAJProtectedByMitigatingControl,
+}
+
+// ParseVEX reads the statements of an OpenVEX, CSAF VEX or CycloneDX VEX
+// document and returns them with the name of the document's format
+func ParseVEX(data []byte) ([]VEXStatement, string, error) {
+	var probe struct {
+		Context    any             `json:"@context"`
+		Statements json.RawMessage `json:"statements"`
+		Document   *struct {
+			CSAFVersion string `json:"csaf_version"`
+		} `json:"document"`
+		BOMFormat string `json:"bomFormat"`
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return nil, "", fmt.Errorf("failed to parse VEX document: %w", err)
+		}
+	}
+
+	switch {
+	case probe.Statements != nil || strings.Contains(fmt.Sprint(probe.Context), "openvex"):
+		statements, err := parseOpenVEX(data)
+		return statements, "OpenVEX", err
+	case probe.Document != nil && probe.Document.CSAFVersion != "":
+		statements, err := parseCSAFVEX(data)
+		return statements, "CSAF", err
+	case probe.BOMFormat == "CycloneDX" || DetectFileFormat(data) == FileFormatXML:
+		bom, err := DecodeSBOM(data, DetectFileFormat(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse VEX document: %w", err)
+		}
+		return cyclonedxVEXStatements(bom), "CycloneDX", nil
+	}
+	return nil, "", fmt.Errorf("unsupported VEX document, must be OpenVEX, CSAF VEX or CycloneDX VEX")
+}
+
+// cyclonedxVEXStatements returns a statement for every vulnerability with an
+// analysis. Affected refs to components of the VEX document with a package
+// URL are replaced by the package URL, as the refs of the VEX document need
+// not be the refs of the SBOM.
+func cyclonedxVEXStatements(bom *cyclonedx.BOM) []VEXStatement {
+	purls := make(map[string]string)
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		walkComponents(&[]cyclonedx.Component{*bom.Metadata.Component}, func(c *cyclonedx.Component) { purls[c.BOMRef] = c.PackageURL })
+	}
+	walkComponents(bom.Components, func(c *cyclonedx.Component) { purls[c.BOMRef] = c.PackageURL })
+
+	statements := []VEXStatement{}
+	if bom.Vulnerabilities == nil {
+		return statements
+	}
+	for _, v := range *bom.Vulnerabilities {
+		if v.Analysis == nil || v.Analysis.State == "" {
+			continue
+		}
+		statement := VEXStatement{
+			Vulnerability: v.ID,
+			Products:      []string{},
+			State:         v.Analysis.State,
+			Justification: v.Analysis.Justification,
+			Detail:        v.Analysis.Detail,
+			Timestamp:     v.Analysis.LastUpdated,
+		}
+		if v.Analysis.Response != nil {
+			statement.Response = *v.Analysis.Response
+		}
+		if v.References != nil {
+			for _, ref := range *v.References {
+				statement.Aliases = append(statement.Aliases, ref.ID)
+			}
+		}
+		if v.Affects != nil {
+			for _, affected := range *v.Affects {
+				product := affected.Ref
+				if purl := purls[affected.Ref]; purl != "" {
+					product = purl
+				}
+				statement.Products = append(statement.Products, product)
+			}
+		}
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// ApplyVEX updates the analysis of the SBOM's vulnerabilities with the VEX
+// statements, in order, so later statements override earlier ones. A
+// statement applies to the vulnerabilities with its ID or one of its aliases
+// as ID or reference, and to the affected components matching one of its
+// products by package URL, bom-ref or BOM-Link. bom-refs also match the
+// serial-prefixed refs of merged SBOMs. A product matching the metadata
+// component covers all components.
+//
+// If a statement covers only some of the components a vulnerability affects,
+// they are split off into a copy of the vulnerability with the new analysis,
+// because CycloneDX analyses apply to all affected components.
+func ApplyVEX(bom *cyclonedx.BOM, statements []VEXStatement) *VEXReport {
+	components := make(map[string]*cyclonedx.Component)
+	var root *cyclonedx.Component
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		root = bom.Metadata.Component
+		components[root.BOMRef] = root
+		walkComponents(root.Components, func(c *cyclonedx.Component) { components[c.BOMRef] = c })
+	}
+	walkComponents(bom.Components, func(c *cyclonedx.Component) { components[c.BOMRef] = c })
+
+	report := &VEXReport{Unmatched: []UnmatchedVEXStatement{}}
+	updated := make(map[int]bool)
+	for _, statement := range statements {
+		coversAll := root != nil && slices.ContainsFunc(statement.Products, func(product string) bool {
+			return productMatches(product, root.BOMRef, root, bom.SerialNumber)
+		})
+		matchesRef := func(ref string) bool {
+			return coversAll || slices.ContainsFunc(statement.Products, func(product string) bool {
+				return productMatches(product, ref, components[ref], bom.SerialNumber)
+			})
+		}
+
+		found, applied := false, false
+		var splits []cyclonedx.Vulnerability
+		if bom.Vulnerabilities != nil {
+			for i := range *bom.Vulnerabilities {
+				v := &(*bom.Vulnerabilities)[i]
+				if !statement.matchesVulnerability(v) {
+					continue
+				}
+				found = true
+				if v.Affects == nil {
+					continue
+				}
+
+				var matched, rest []cyclonedx.Affects
+				for _, affected := range *v.Affects {
+					if matchesRef(affected.Ref) {
+						matched = append(matched, affected)
+					} else {
+						rest = append(rest, affected)
+					}
+				}
+				if len(matched) == 0 {
+					continue
+				}
+				applied = true
+				if len(rest) == 0 {
+					v.Analysis = statement.analysis(v.Analysis)
+					updated[i] = true
+					continue
+				}
+				split := *v
+				split.BOMRef = ""
+				split.Affects = &matched
+				split.Analysis = statement.analysis(v.Analysis)
+				v.Affects = &rest
+				splits = append(splits, split)
+			}
+		}
+		for _, split := range splits {
+			updated[len(*bom.Vulnerabilities)] = true
+			*bom.Vulnerabilities = append(*bom.Vulnerabilities, split)
+		}
+
+		switch {
+		case applied:
+			report.Applied++
+		case found:
+			report.Unmatched = append(report.Unmatched, UnmatchedVEXStatement{Vulnerability: statement.Vulnerability, Products: statement.Products, Reason: "no affected component matches the products"})
+		default:
+			report.Unmatched = append(report.Unmatched, UnmatchedVEXStatement{Vulnerability: statement.Vulnerability, Products: statement.Products, Reason: "vulnerability not in SBOM"})
+		}
+	}
+	report.Updated = len(updated)
+	return report
+}
+
+// matchesVulnerability reports whether the statement is about the vulnerability
+func (s VEXStatement) matchesVulnerability(v *cyclonedx.Vulnerability) bool {
+	ids := []string{v.ID}
+	if v.References != nil {
+		for _, ref := range *v.References {
+			ids = append(ids, ref.ID)
+		}
+	}
+	return slices.ContainsFunc(ids, func(id string) bool {
+		return id != "" && (strings.EqualFold(id, s.Vulnerability) || slices.ContainsFunc(s.Aliases, func(alias string) bool { return strings.EqualFold(id, alias) }))
+	})
+}
+
+// analysis returns the analysis updated with the statement. The
+// justification is replaced, as it only applies to the state it was given
+// for; response and detail are kept unless the statement has them.
+func (s VEXStatement) analysis(existing *cyclonedx.VulnerabilityAnalysis) *cyclonedx.VulnerabilityAnalysis {
+	analysis := &cyclonedx.VulnerabilityAnalysis{}
+	if existing != nil {
+		*analysis = *existing
+	}
+	analysis.State = s.State
+	analysis.Justification = s.Justification
+	if len(s.Response) > 0 {
+		analysis.Response = &s.Response
+	}
+	if s.Detail != "" {
+		analysis.Detail = s.Detail
+	}
+	if s.Timestamp != "" {
+		analysis.LastUpdated = s.Timestamp
+	}
+	return analysis
+}
+
+// productMatches reports whether a product of a VEX statement matches an
+// affected ref and the component it refers to, if any
+func productMatches(product, ref string, c *cyclonedx.Component, serialNumber string) bool {
+	switch {
+	case strings.HasPrefix(product, "pkg:"):
+		return c != nil && purlMatches(product, c.PackageURL)
+	case strings.HasPrefix(product, "urn:cdx:"):
+		// BOM-Links are urn:cdx:<serial>/<version>#<url-encoded bom-ref>
+		bom, fragment, ok := strings.Cut(strings.TrimPrefix(product, "urn:cdx:"), "#")
+		if !ok {
+			return false
+		}
+		serial, _, _ := strings.Cut(bom, "/")
+		target, err := url.PathUnescape(fragment)
+		if err != nil {
+			return false
+		}
+		serial = "urn:uuid:" + serial
+		return ref == serial+"/"+target || serial == serialNumber && ref == target
+	}
+	return product == ref || product == stripSerialPrefix(ref)
+}
+
+// purlMatches reports whether a package URL matches a pattern package URL.
+// Versions only need to match if the pattern has one, and qualifiers only
+// those the pattern has.
+func purlMatches(pattern, purl string) bool {
+	if purl == "" {
+		return false
+	}
+	p, err := packageurl.FromString(pattern)
+	if err != nil {
+		return pattern == purl
+	}
+	c, err := packageurl.FromString(purl)
+	if err != nil {
+		return false
+	}
+	if p.Type != c.Type || p.Namespace != c.Namespace || p.Name != c.Name || p.Version != "" && p.Version != c.Version {
+		return false
+	}
+	qualifiers := c.Qualifiers.Map()
+	for key, value := range p.Qualifiers.Map() {
+		if qualifiers[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package sbom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+const vexTestSerial = "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"
+
+// vexTestBOM returns a merged SBOM in which two sources contain the same vulnerable component
+func vexTestBOM() *cyclonedx.BOM {
+	bom := cyclonedx.NewBOM()
+	bom.SerialNumber = "urn:uuid:11111111-2222-3333-4444-555555555555"
+	bom.Metadata = &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app", PackageURL: "pkg:generic/app@1.0.0"}}
+	bom.Components = &[]cyclonedx.Component{
+		{BOMRef: vexTestSerial + "/lodash", Name: "lodash", Version: "4.17.20", PackageURL: "pkg:npm/lodash@4.17.20"},
+		{BOMRef: "urn:uuid:4f782798-486a-52e6-b40f-b59032a70b80/lodash", Name: "lodash", Version: "4.17.20", PackageURL: "pkg:npm/lodash@4.17.20"},
+		{BOMRef: "minimist", Name: "minimist", Version: "1.2.5", PackageURL: "pkg:npm/minimist@1.2.5?arch=any"},
+	}
+	bom.Vulnerabilities = &[]cyclonedx.Vulnerability{
+		{
+			ID:         "GHSA-35jh-r3h4-6jhm",
+			References: &[]cyclonedx.VulnerabilityReference{{ID: "CVE-2021-23337"}},
+			Affects:    &[]cyclonedx.Affects{{Ref: vexTestSerial + "/lodash"}, {Ref: "urn:uuid:4f782798-486a-52e6-b40f-b59032a70b80/lodash"}},
+		},
+		{
+			ID:       "CVE-2021-44906",
+			Analysis: &cyclonedx.VulnerabilityAnalysis{State: cyclonedx.IASInTriage, Justification: cyclonedx.IAJRequiresConfiguration, Detail: "Looking into it"},
+			Affects:  &[]cyclonedx.Affects{{Ref: "minimist"}},
+		},
+	}
+	return bom
+}
+
+func TestApplyVEX(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement VEXStatement
+		// expected are the IDs, analysis states and affected refs of the vulnerabilities
+		expected  []string
+		unmatched string
+	}{
+		{
+			name:      "alias and package URL covering all affected components",
+			statement: VEXStatement{Vulnerability: "CVE-2021-23337", Products: []string{"pkg:npm/lodash@4.17.20"}, State: cyclonedx.IASNotAffected},
+			expected:  []string{"GHSA-35jh-r3h4-6jhm not_affected 2", "CVE-2021-44906 in_triage 1"},
+		},
+		{
+			name:      "BOM-Link splits the vulnerability",
+			statement: VEXStatement{Vulnerability: "GHSA-35jh-r3h4-6jhm", Products: []string{"urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1#lodash"}, State: cyclonedx.IASNotAffected},
+			expected:  []string{"GHSA-35jh-r3h4-6jhm  1", "CVE-2021-44906 in_triage 1", "GHSA-35jh-r3h4-6jhm not_affected 1"},
+		},
+		{
+			name:      "bom-ref without serial prefix",
+			statement: VEXStatement{Vulnerability: "GHSA-35jh-r3h4-6jhm", Products: []string{"lodash"}, State: cyclonedx.IASExploitable},
+			expected:  []string{"GHSA-35jh-r3h4-6jhm exploitable 2", "CVE-2021-44906 in_triage 1"},
+		},
+		{
+			name:      "package URL without version and qualifiers",
+			statement: VEXStatement{Vulnerability: "cve-2021-44906", Products: []string{"pkg:npm/minimist"}, State: cyclonedx.IASNotAffected, Justification: cyclonedx.IAJCodeNotReachable},
+			expected:  []string{"GHSA-35jh-r3h4-6jhm  2", "CVE-2021-44906 not_affected 1"},
+		},
+		{
+			name:      "metadata component covers all components",
+			statement: VEXStatement{Vulnerability: "CVE-2021-44906", Products: []string{"pkg:generic/app@1.0.0"}, State: cyclonedx.IASResolved},
+			expected:  []string{"GHSA-35jh-r3h4-6jhm  2", "CVE-2021-44906 resolved 1"},
+		},
+		{
+			name:      "unknown vulnerability",
+			statement: VEXStatement{Vulnerability: "CVE-2099-0001", Products: []string{"pkg:npm/lodash@4.17.20"}, State: cyclonedx.IASNotAffected},
+			expected:  []string{"GHSA-35jh-r3h4-6jhm  2", "CVE-2021-44906 in_triage 1"},
+			unmatched: "vulnerability not in SBOM",
+		},
+		{
+			name:      "other version",
+			statement: VEXStatement{Vulnerability: "CVE-2021-23337", Products: []string{"pkg:npm/lodash@4.17.21"}, State: cyclonedx.IASNotAffected},
+			expected:  []string{"GHSA-35jh-r3h4-6jhm  2", "CVE-2021-44906 in_triage 1"},
+			unmatched: "no affected component matches the products",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bom := vexTestBOM()
+			report := ApplyVEX(bom, []VEXStatement{tt.statement})
+
+			var got []string
+			for _, v := range *bom.Vulnerabilities {
+				state := ""
+				if v.Analysis != nil {
+					state = string(v.Analysis.State)
+				}
+				got = append(got, fmt.Sprintf("%s %s %d", v.ID, state, len(*v.Affects)))
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected vulnerabilities %v, got %v", tt.expected, got)
+			}
+
+			if tt.unmatched == "" && (report.Applied != 1 || len(report.Unmatched) != 0) {
+				t.Errorf("Expected statement to apply, got %+v", report)
+			}
+			if tt.unmatched != "" && (report.Applied != 0 || len(report.Unmatched) != 1 || report.Unmatched[0].Reason != tt.unmatched) {
+				t.Errorf("Expected statement not to match with %q, got %+v", tt.unmatched, report)
+			}
+			if errs := CheckRefIntegrity(bom); len(errs) != 0 {
+				t.Errorf("Expected consistent refs, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestApplyVEXReplacesJustification(t *testing.T) {
+	bom := vexTestBOM()
+	report := ApplyVEX(bom, []VEXStatement{
+		{Vulnerability: "CVE-2021-44906", Products: []string{"minimist"}, State: cyclonedx.IASNotAffected, Justification: cyclonedx.IAJCodeNotPresent},
+		{Vulnerability: "CVE-2021-44906", Products: []string{"minimist"}, State: cyclonedx.IASExploitable, Response: []cyclonedx.ImpactAnalysisResponse{cyclonedx.IARUpdate}},
+	})
+	if report.Applied != 2 || report.Updated != 1 {
+		t.Errorf("Expected 2 statements applied to 1 vulnerability, got %+v", report)
+	}
+
+	analysis := (*bom.Vulnerabilities)[1].Analysis
+	if analysis.State != cyclonedx.IASExploitable || analysis.Justification != "" || analysis.Detail != "Looking into it" || len(*analysis.Response) != 1 {
+		t.Errorf("Unexpected analysis: %+v", analysis)
+	}
+}
+
+func TestParseCycloneDXVEX(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "vex", "cyclonedx-vex.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, format, err := ParseVEX(data)
+	if err != nil {
+		t.Fatalf("Failed to parse VEX: %v", err)
+	}
+	if format != "CycloneDX" || len(statements) != 1 {
+		t.Fatalf("Expected 1 CycloneDX statement, got %s %+v", format, statements)
+	}
+	s := statements[0]
+	if s.Vulnerability != "GHSA-xxxx-lib2-0003" || s.State != cyclonedx.IASNotAffected || s.Justification != cyclonedx.IAJCodeNotPresent ||
+		!slices.Equal(s.Products, []string{"urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1#pkg%3Anpm%2Fexample-lib-2%402.3.4"}) {
+		t.Errorf("Unexpected statement: %+v", s)
+	}
+
+	if _, _, err := ParseVEX([]byte(`{"foo": "bar"}`)); err == nil {
+		t.Error("Expected error for unknown document")
+	}
+}